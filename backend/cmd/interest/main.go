@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"banking-app/shared/database"
+	"banking-app/shared/interest"
+	"banking-app/shared/repository"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: interest accrue [options]
+
+Court les intérêts des comptes épargne rémunérés, journée par journée (UTC), et verse
+les intérêts des périodes achevées. Les journées déjà courues sont ignorées : la commande
+peut être relancée, ou utilisée pour rattraper une plage de dates.
+
+Options:
+  -from AAAA-MM-JJ  première journée (par défaut : lendemain de la dernière journée courue)
+  -to AAAA-MM-JJ    dernière journée (par défaut et au plus tard : la veille)
+  -account ID       limite le calcul à un compte`
+
+func main() {
+	// Charger les variables d'environnement
+	if err := godotenv.Load("../.env"); err != nil {
+		log.Println("Aucun fichier .env trouvé")
+	}
+
+	if len(os.Args) < 2 || os.Args[1] != "accrue" {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("accrue", flag.ExitOnError)
+	flags.Usage = func() { fmt.Println(usage) }
+	from := flags.String("from", "", "première journée")
+	to := flags.String("to", "", "dernière journée")
+	accountID := flags.Uint("account", 0, "compte")
+	flags.Parse(os.Args[2:])
+
+	var options interest.Options
+	options.AccountID = *accountID
+	if *from != "" {
+		date, err := time.Parse("2006-01-02", *from)
+		if err != nil {
+			log.Fatal("Date de début invalide: ", *from)
+		}
+		options.From = date
+	}
+	if *to != "" {
+		date, err := time.Parse("2006-01-02", *to)
+		if err != nil {
+			log.Fatal("Date de fin invalide: ", *to)
+		}
+		options.To = date
+	}
+
+	// Connexion à la base de données
+	if err := database.ConnectDatabase(); err != nil {
+		log.Fatal("Erreur de connexion à la base de données:", err)
+	}
+	defer database.CloseDatabase()
+
+	result, err := interest.Run(repository.NewStore(database.GetDB()), options, time.Now())
+	fmt.Printf("%d compte(s), %d journée(s) courue(s), %d déjà courue(s), %d versement(s)\n",
+		result.Accounts, result.Accrued, result.Skipped, result.Payouts)
+	if err != nil {
+		log.Fatal("Erreur lors du calcul des intérêts:\n", err)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"banking-app/shared/database"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: migrate <commande> [argument]
+
+Les migrations SQL ne s'appliquent qu'à MySQL ; sous SQLite, le schéma est créé au
+démarrage des services.
+
+Commandes:
+  up            applique toutes les migrations en attente, puis les reprises de données
+                (montants en unités mineures, soldes d'ouverture, IBAN)
+  down [n]      annule les n dernières migrations (1 par défaut)
+  status        affiche l'état des migrations
+  to <version>  amène le schéma à la version indiquée (0 pour tout annuler)`
+
+func main() {
+	// Charger les variables d'environnement
+	if err := godotenv.Load("../.env"); err != nil {
+		log.Println("Aucun fichier .env trouvé")
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	// Connexion à la base de données
+	if err := database.ConnectDatabase(); err != nil {
+		log.Fatal("Erreur de connexion à la base de données:", err)
+	}
+	defer database.CloseDatabase()
+
+	if database.GetDB().Dialector.Name() == database.DriverSQLite {
+		log.Fatal("Les migrations SQL ne s'appliquent qu'à MySQL : sous SQLite, le schéma est créé au démarrage des services")
+	}
+
+	migrator, err := database.NewMigrator(database.GetDB())
+	if err != nil {
+		log.Fatal("Erreur de chargement des migrations:", err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		// Même chemin qu'au démarrage des services : migrations puis reprises de données
+		if err := database.MigrateDatabase(); err != nil {
+			log.Fatal(err)
+		}
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				log.Fatal("Nombre de migrations invalide: ", os.Args[2])
+			}
+		}
+		count, err := migrator.Down(steps)
+		if err != nil {
+			log.Fatal("Erreur de rollback:", err)
+		}
+		fmt.Printf("%d migration(s) annulée(s)\n", count)
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal("Erreur de lecture du statut:", err)
+		}
+		for _, status := range statuses {
+			state := "en attente"
+			if status.Applied {
+				state = "appliquée le " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.ChecksumMismatch {
+				state += " (MODIFIÉE depuis son application)"
+			}
+			fmt.Printf("%03d_%s\t%s\n", status.Version, status.Name, state)
+		}
+
+	case "to":
+		if len(os.Args) < 3 {
+			log.Fatal("Version cible requise")
+		}
+		version, err := strconv.ParseInt(os.Args[2], 10, 64)
+		if err != nil || version < 0 {
+			log.Fatal("Version invalide: ", os.Args[2])
+		}
+		count, err := migrator.To(version)
+		if err != nil {
+			log.Fatal("Erreur de migration:", err)
+		}
+		fmt.Printf("Schéma à la version %d (%d migration(s) exécutée(s))\n", version, count)
+
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
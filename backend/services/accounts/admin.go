@@ -0,0 +1,342 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchAccountsHandler liste les comptes de tous les clients, filtrables par user_id et status
+func (s *server) searchAccountsHandler(c *gin.Context) {
+	var userID uint64
+	if value := c.Query("user_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "ID d'utilisateur invalide",
+				Error:   "Bad Request",
+			})
+			return
+		}
+		userID = parsed
+	}
+
+	s.listAccounts(c, uint(userID))
+}
+
+// adminGetAccountHandler récupère un compte quelconque
+func (s *server) adminGetAccountHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de compte invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	account, err := s.store.Accounts.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Compte récupéré avec succès",
+		Data:    account,
+	})
+}
+
+// accountStatusChange applique un changement de statut sur un compte verrouillé,
+// ou retourne le code HTTP et le message du refus
+type accountStatusChange func(account *models.Account) (int, string)
+
+// changeAccountStatus verrouille le compte :id, applique change et enregistre le résultat
+func (s *server) changeAccountStatus(c *gin.Context, change accountStatusChange, success string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de compte invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	var account *models.Account
+	status, message := http.StatusOK, ""
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		var err error
+		// Verrou : un débit concurrent ne peut pas s'intercaler entre la vérification et le changement
+		account, err = tx.Accounts.LockByID(uint(id))
+		if err != nil {
+			return err
+		}
+		if status, message = change(account); status != http.StatusOK {
+			return nil
+		}
+		return tx.Accounts.Save(account)
+	})
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la mise à jour du compte",
+			Error:   "Internal Server Error",
+		})
+		return
+	case status != http.StatusOK:
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   http.StatusText(status),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: success,
+		Data:    account,
+	})
+}
+
+// freezeAccountHandler gèle un compte ; seul un membre de l'administration pourra le dégeler
+func (s *server) freezeAccountHandler(c *gin.Context) {
+	var request struct {
+		Reason string `json:"reason" binding:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Le motif du gel est requis",
+			Error:   err.Error(),
+		})
+		return
+	}
+	adminID := c.GetUint("user_id")
+
+	s.changeAccountStatus(c, func(account *models.Account) (int, string) {
+		if account.Status == "closed" {
+			return http.StatusConflict, "Le compte est clôturé"
+		}
+		account.Status = "frozen"
+		account.FrozenBy = &adminID
+		account.FreezeReason = request.Reason
+		return http.StatusOK, ""
+	}, "Compte gelé avec succès")
+}
+
+// unfreezeAccountHandler lève le gel d'un compte, qu'il ait été posé par le client ou l'administration
+func (s *server) unfreezeAccountHandler(c *gin.Context) {
+	s.changeAccountStatus(c, func(account *models.Account) (int, string) {
+		if account.Status != "frozen" {
+			return http.StatusConflict, "Le compte n'est pas gelé"
+		}
+		account.Status = "active"
+		account.FrozenBy = nil
+		account.FreezeReason = ""
+		return http.StatusOK, ""
+	}, "Compte dégelé avec succès")
+}
+
+// closeAccountHandler clôture définitivement un compte dont le solde est nul
+func (s *server) closeAccountHandler(c *gin.Context) {
+	s.changeAccountStatus(c, func(account *models.Account) (int, string) {
+		if account.Status == "closed" {
+			return http.StatusConflict, "Le compte est déjà clôturé"
+		}
+		if !account.Balance.IsZero() || !account.HeldAmount.IsZero() {
+			return http.StatusConflict, "Impossible de clôturer un compte avec un solde ou des fonds réservés non nuls"
+		}
+		account.Status = "closed"
+		account.FrozenBy = nil
+		account.FreezeReason = ""
+		return http.StatusOK, ""
+	}, "Compte clôturé avec succès")
+}
+
+// defaultOverdraftRateBps est le taux annuel des intérêts débiteurs appliqué à défaut (15 %)
+const defaultOverdraftRateBps = 1500
+
+// overdraftRateBps retourne le taux appliqué lorsqu'un découvert est accordé sans taux (OVERDRAFT_RATE_BPS)
+func overdraftRateBps() int {
+	if value, err := strconv.Atoi(os.Getenv("OVERDRAFT_RATE_BPS")); err == nil && value >= 0 {
+		return value
+	}
+	return defaultOverdraftRateBps
+}
+
+// setCreditLimitHandler modifie le découvert autorisé ou le plafond de crédit d'un compte
+// et son taux d'intérêts débiteurs ; chaque modification est tracée avec son auteur et son motif
+func (s *server) setCreditLimitHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de compte invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	var request struct {
+		CreditLimit      json.Number `json:"credit_limit" binding:"required"`
+		OverdraftRateBps *int        `json:"overdraft_rate_bps"`
+		Reason           string      `json:"reason" binding:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Le plafond et le motif de la modification sont requis",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if request.OverdraftRateBps != nil && (*request.OverdraftRateBps < 0 || *request.OverdraftRateBps > 10000) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Taux invalide : il doit être compris entre 0 et 10000 points de base",
+			Error:   "Bad Request",
+		})
+		return
+	}
+	adminID := c.GetUint("user_id")
+
+	var account *models.Account
+	status, message := http.StatusOK, ""
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		var err error
+		account, err = tx.Accounts.LockByID(uint(id))
+		if err != nil {
+			return err
+		}
+		switch {
+		case account.Status == "closed":
+			status, message = http.StatusConflict, "Le compte est clôturé"
+			return nil
+		case account.AccountType == "savings":
+			status, message = http.StatusConflict, "Un compte épargne ne peut pas être à découvert"
+			return nil
+		}
+
+		limit, err := models.ParseMoney(request.CreditLimit.String(), account.Currency)
+		if err != nil || limit.IsNegative() {
+			status, message = http.StatusBadRequest, "Plafond invalide"
+			return nil
+		}
+		rate := account.OverdraftRateBps
+		switch {
+		case request.OverdraftRateBps != nil:
+			rate = *request.OverdraftRateBps
+		case rate == 0 && limit.IsPositive():
+			rate = overdraftRateBps()
+		}
+
+		change := models.AccountLimitChange{
+			AccountID:       account.ID,
+			ChangedBy:       adminID,
+			PreviousLimit:   account.CreditLimit,
+			NewLimit:        limit,
+			PreviousRateBps: account.OverdraftRateBps,
+			NewRateBps:      rate,
+			Currency:        account.Currency,
+			Reason:          request.Reason,
+		}
+		// Un plafond inférieur au découvert en cours est accepté : seuls les nouveaux débits sont bloqués
+		account.CreditLimit = limit
+		account.OverdraftRateBps = rate
+		if err := tx.Accounts.Save(account); err != nil {
+			return err
+		}
+		return tx.Accounts.CreateLimitChange(&change)
+	})
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la mise à jour du plafond",
+			Error:   "Internal Server Error",
+		})
+		return
+	case status != http.StatusOK:
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   http.StatusText(status),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Plafond mis à jour avec succès",
+		Data:    account,
+	})
+}
+
+// limitHistoryHandler retourne l'historique des modifications de plafond d'un compte
+func (s *server) limitHistoryHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de compte invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	if _, err := s.store.Accounts.FindByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	changes, err := s.store.Accounts.ListLimitChanges(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération de l'historique",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Historique récupéré avec succès",
+		Data:    changes,
+	})
+}
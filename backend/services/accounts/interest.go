@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"banking-app/shared/interest"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accrualHistoryDays est le nombre de journées courues retournées avec la rémunération d'un compte
+const accrualHistoryDays = 31
+
+// checkInterestProduct vérifie que le produit peut rémunérer un compte épargne dans currency,
+// ou retourne le code HTTP et le message du refus
+func (s *server) checkInterestProduct(id uint, currency string) (int, string) {
+	product, err := s.store.Interest.FindProduct(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return http.StatusBadRequest, "Produit d'épargne inconnu"
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "Erreur lors de la récupération du produit d'épargne"
+	}
+	if !product.Active {
+		return http.StatusBadRequest, "Ce produit d'épargne n'est plus commercialisé"
+	}
+	if product.Currency != currency {
+		return http.StatusBadRequest, "La devise du produit d'épargne ne correspond pas à celle du compte"
+	}
+	return http.StatusOK, ""
+}
+
+// listInterestProductsHandler liste les produits d'épargne commercialisés
+func (s *server) listInterestProductsHandler(c *gin.Context) {
+	s.listInterestProducts(c, true)
+}
+
+// adminListInterestProductsHandler liste tous les produits d'épargne, y compris retirés
+func (s *server) adminListInterestProductsHandler(c *gin.Context) {
+	s.listInterestProducts(c, false)
+}
+
+func (s *server) listInterestProducts(c *gin.Context, activeOnly bool) {
+	products, err := s.store.Interest.ListProducts(activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des produits d'épargne",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Produits d'épargne récupérés avec succès",
+		Data:    products,
+	})
+}
+
+// createInterestProductHandler crée un produit d'épargne. Ses tranches, sa base de calcul et
+// sa capitalisation ne sont plus modifiables ensuite, afin que les intérêts déjà courus
+// restent reproductibles ; un produit peut seulement être retiré de la vente.
+func (s *server) createInterestProductHandler(c *gin.Context) {
+	var request struct {
+		Code            string `json:"code" binding:"required,max=50"`
+		Name            string `json:"name" binding:"required,max=100"`
+		Currency        string `json:"currency"`
+		DayCount        string `json:"day_count"`
+		Compounding     string `json:"compounding"`
+		PayoutFrequency string `json:"payout_frequency"`
+		Tiers           []struct {
+			MinBalance json.Number `json:"min_balance" binding:"required"`
+			RateBps    int         `json:"rate_bps"`
+		} `json:"tiers" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	product := models.InterestProduct{
+		Code:            request.Code,
+		Name:            request.Name,
+		Currency:        request.Currency,
+		DayCount:        request.DayCount,
+		Compounding:     request.Compounding,
+		PayoutFrequency: request.PayoutFrequency,
+		Active:          true,
+	}
+	if product.Currency == "" {
+		product.Currency = models.DefaultCurrency
+	}
+	if product.DayCount == "" {
+		product.DayCount = models.DayCountAct365
+	}
+	if product.Compounding == "" {
+		product.Compounding = models.CompoundingNone
+	}
+	if product.PayoutFrequency == "" {
+		product.PayoutFrequency = models.PayoutMonthly
+	}
+	if !models.IsSupportedCurrency(product.Currency) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Devise non supportée",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	for _, tier := range request.Tiers {
+		minBalance, err := models.ParseMoney(tier.MinBalance.String(), product.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Seuil de tranche invalide: " + err.Error(),
+				Error:   "Bad Request",
+			})
+			return
+		}
+		product.Tiers = append(product.Tiers, models.InterestTier{MinBalance: minBalance, RateBps: tier.RateBps})
+	}
+	sort.SliceStable(product.Tiers, func(i, j int) bool {
+		return product.Tiers[i].MinBalance.Amount < product.Tiers[j].MinBalance.Amount
+	})
+	if err := interest.Validate(&product); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	if _, err := s.store.Interest.FindProductByCode(product.Code); err == nil {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Un produit d'épargne porte déjà ce code",
+			Error:   "Conflict",
+		})
+		return
+	}
+	if err := s.store.Interest.CreateProduct(&product); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la création du produit d'épargne",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Produit d'épargne créé avec succès",
+		Data:    product,
+	})
+}
+
+// updateInterestProductHandler commercialise ou retire un produit d'épargne ; les comptes
+// déjà rattachés continuent d'être rémunérés
+func (s *server) updateInterestProductHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de produit invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	var request struct {
+		Name   string `json:"name" binding:"max=100"`
+		Active *bool  `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	product, err := s.store.Interest.FindProduct(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Produit d'épargne non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	if request.Name != "" {
+		product.Name = request.Name
+	}
+	if request.Active != nil {
+		product.Active = *request.Active
+	}
+	if err := s.store.Interest.SaveProduct(product); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la mise à jour du produit d'épargne",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Produit d'épargne mis à jour avec succès",
+		Data:    product,
+	})
+}
+
+// getAccountInterestHandler retourne la rémunération d'un compte épargne : son produit,
+// les intérêts courus non encore versés et les dernières journées courues
+func (s *server) getAccountInterestHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de compte invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	account, err := s.store.Accounts.FindForUser(uint(id), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+	if account.InterestProductID == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Ce compte n'est pas rémunéré",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	product, err := s.store.Interest.FindProduct(*account.InterestProductID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération du produit d'épargne",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+	accruals, err := s.store.Interest.ListAccruals(account.ID, accrualHistoryDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des intérêts courus",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	// Les journées courues sont toutes antérieures au jour courant
+	unpaidMicros, err := s.store.Interest.SumUnpaid(account.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des intérêts courus",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+	unpaid, err := interest.PayoutAmount(unpaidMicros)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors du calcul des intérêts courus",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Rémunération récupérée avec succès",
+		Data: gin.H{
+			"product":         product,
+			"accrued_unpaid":  models.NewMoney(unpaid, account.Currency),
+			"recent_accruals": accruals,
+		},
+	})
+}
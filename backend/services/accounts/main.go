@@ -9,6 +9,7 @@ import (
 	"banking-app/shared/database"
 	"banking-app/shared/middleware"
 	"banking-app/shared/models"
+	"banking-app/shared/repository"
 	"banking-app/shared/utils"
 
 	"github.com/gin-gonic/gin"
@@ -31,6 +32,30 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	r := newServer(repository.NewStore(database.GetDB())).setupRouter()
+
+	// Démarrage du serveur
+	port := os.Getenv("ACCOUNTS_SERVICE_PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	log.Printf("Service de gestion des comptes démarré sur le port %s", port)
+	log.Fatal(http.ListenAndServe(":"+port, r))
+}
+
+// server regroupe les dépendances des handlers du service de comptes
+type server struct {
+	store *repository.Store
+}
+
+// newServer crée le service à partir des dépôts de données
+func newServer(store *repository.Store) *server {
+	return &server{store: store}
+}
+
+// setupRouter configure les routes du service de comptes
+func (s *server) setupRouter() *gin.Engine {
 	r := gin.Default()
 
 	// Middleware
@@ -45,31 +70,48 @@ func main() {
 	})
 
 	// Protected routes
-	r.Use(middleware.AuthMiddleware())
+	r.Use(middleware.AuthMiddleware(s.store.Users, s.store.Sessions))
 
 	// Routes
 	accounts := r.Group("/api/accounts")
 	{
-		accounts.GET("/", getAccountsHandler)
-		accounts.POST("/", createAccountHandler)
-		accounts.GET("/:id", getAccountHandler)
-		accounts.PUT("/:id", updateAccountHandler)
-		accounts.DELETE("/:id", deleteAccountHandler)
-		accounts.GET("/:id/balance", getBalanceHandler)
+		accounts.GET("/", s.getAccountsHandler)
+		accounts.POST("/", s.createAccountHandler)
+		accounts.GET("/:id", s.getAccountHandler)
+		accounts.PUT("/:id", s.updateAccountHandler)
+		accounts.DELETE("/:id", s.deleteAccountHandler)
+		accounts.GET("/:id/balance", s.getBalanceHandler)
+		accounts.GET("/:id/interest", s.getAccountInterestHandler)
+		accounts.GET("/:id/statements", s.listStatementsHandler)
+		accounts.GET("/:id/statements/:period", s.getStatementHandler)
 	}
 
-	// Démarrage du serveur
-	port := os.Getenv("ACCOUNTS_SERVICE_PORT")
-	if port == "" {
-		port = "8080"
+	r.GET("/api/interest-products", s.listInterestProductsHandler)
+
+	// Routes d'administration
+	admin := r.Group("/api/admin/accounts")
+	{
+		admin.GET("/", middleware.RequirePermission(models.PermAccountsRead), s.searchAccountsHandler)
+		admin.GET("/:id", middleware.RequirePermission(models.PermAccountsRead), s.adminGetAccountHandler)
+		admin.POST("/:id/freeze", middleware.RequirePermission(models.PermAccountsFreeze), s.freezeAccountHandler)
+		admin.POST("/:id/unfreeze", middleware.RequirePermission(models.PermAccountsFreeze), s.unfreezeAccountHandler)
+		admin.POST("/:id/close", middleware.RequirePermission(models.PermAccountsClose), s.closeAccountHandler)
+		admin.PUT("/:id/limit", middleware.RequirePermission(models.PermAccountsLimits), s.setCreditLimitHandler)
+		admin.GET("/:id/limit/history", middleware.RequirePermission(models.PermAccountsLimits), s.limitHistoryHandler)
 	}
 
-	log.Printf("Service de gestion des comptes démarré sur le port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	products := r.Group("/api/admin/interest-products", middleware.RequirePermission(models.PermInterestManage))
+	{
+		products.GET("/", s.adminListInterestProductsHandler)
+		products.POST("/", s.createInterestProductHandler)
+		products.PUT("/:id", s.updateInterestProductHandler)
+	}
+
+	return r
 }
 
-// getAccountsHandler récupère tous les comptes de l'utilisateur connecté
-func getAccountsHandler(c *gin.Context) {
+// getAccountsHandler récupère une page des comptes de l'utilisateur connecté
+func (s *server) getAccountsHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -80,8 +122,33 @@ func getAccountsHandler(c *gin.Context) {
 		return
 	}
 
-	var accounts []models.Account
-	if err := database.GetDB().Where("user_id = ?", userID).Find(&accounts).Error; err != nil {
+	s.listAccounts(c, userID.(uint))
+}
+
+// listAccounts écrit une page de comptes, filtrés par utilisateur (0 pour tous) et par le paramètre status
+func (s *server) listAccounts(c *gin.Context, userID uint) {
+	page, err := utils.ParsePageRequest(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && !isValidAccountStatus(status) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Statut invalide. Statuts valides: active, frozen, closed",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	accounts, err := s.store.Accounts.Search(userID, status, page)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la récupération des comptes",
@@ -90,15 +157,30 @@ func getAccountsHandler(c *gin.Context) {
 		return
 	}
 
+	accounts, info := utils.NewPage(accounts, page, func(account models.Account) utils.Cursor {
+		return utils.Cursor{CreatedAt: account.CreatedAt, ID: account.ID}
+	})
 	c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Message: "Comptes récupérés avec succès",
-		Data:    accounts,
+		Success:    true,
+		Message:    "Comptes récupérés avec succès",
+		Data:       accounts,
+		Pagination: &info,
 	})
 }
 
+// isValidAccountStatus valide le statut de compte
+func isValidAccountStatus(status string) bool {
+	validStatuses := []string{"active", "frozen", "closed"}
+	for _, validStatus := range validStatuses {
+		if status == validStatus {
+			return true
+		}
+	}
+	return false
+}
+
 // createAccountHandler crée un nouveau compte
-func createAccountHandler(c *gin.Context) {
+func (s *server) createAccountHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -132,31 +214,50 @@ func createAccountHandler(c *gin.Context) {
 
 	// Définir la devise par défaut
 	if request.Currency == "" {
-		request.Currency = "EUR"
+		request.Currency = models.DefaultCurrency
 	}
 
-	// Générer un numéro de compte unique
-	accountNumber, err := utils.GenerateAccountNumber(request.AccountType)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+	// Valider la devise (ISO 4217)
+	if !models.IsSupportedCurrency(request.Currency) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Erreur lors de la génération du numéro de compte",
-			Error:   "Internal Server Error",
+			Message: "Devise non supportée",
+			Error:   "Bad Request",
 		})
 		return
 	}
 
-	// Créer le compte
+	// Seuls les comptes épargne sont rémunérés, par un produit commercialisé dans leur devise
+	if request.InterestProductID != nil {
+		if request.AccountType != "savings" {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Seul un compte épargne peut être rattaché à un produit d'épargne",
+				Error:   "Bad Request",
+			})
+			return
+		}
+		if status, message := s.checkInterestProduct(*request.InterestProductID, request.Currency); status != http.StatusOK {
+			c.JSON(status, models.APIResponse{
+				Success: false,
+				Message: message,
+				Error:   http.StatusText(status),
+			})
+			return
+		}
+	}
+
+	// Créer le compte avec un numéro IBAN unique
 	account := models.Account{
-		UserID:        userID.(uint),
-		AccountNumber: accountNumber,
-		AccountType:   request.AccountType,
-		Balance:       0.0,
-		Currency:      request.Currency,
-		Status:        "active",
+		UserID:            userID.(uint),
+		AccountType:       request.AccountType,
+		Balance:           models.NewMoney(0, request.Currency),
+		Currency:          request.Currency,
+		Status:            "active",
+		InterestProductID: request.InterestProductID,
 	}
 
-	if err := database.GetDB().Create(&account).Error; err != nil {
+	if err := s.store.Accounts.CreateWithNumber(&account, utils.GenerateAccountNumber); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la création du compte",
@@ -173,7 +274,7 @@ func createAccountHandler(c *gin.Context) {
 }
 
 // getAccountHandler récupère un compte spécifique
-func getAccountHandler(c *gin.Context) {
+func (s *server) getAccountHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -195,9 +296,8 @@ func getAccountHandler(c *gin.Context) {
 		return
 	}
 
-	var account models.Account
-	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).
-		Preload("Transactions").First(&account).Error; err != nil {
+	account, err := s.store.Accounts.FindForUserWithTransactions(uint(id), userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Compte non trouvé",
@@ -214,7 +314,7 @@ func getAccountHandler(c *gin.Context) {
 }
 
 // updateAccountHandler met à jour un compte
-func updateAccountHandler(c *gin.Context) {
+func (s *server) updateAccountHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -250,16 +350,7 @@ func updateAccountHandler(c *gin.Context) {
 	}
 
 	// Valider le statut
-	validStatuses := []string{"active", "frozen", "closed"}
-	isValidStatus := false
-	for _, status := range validStatuses {
-		if request.Status == status {
-			isValidStatus = true
-			break
-		}
-	}
-
-	if !isValidStatus {
+	if !isValidAccountStatus(request.Status) {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Message: "Statut invalide. Statuts valides: active, frozen, closed",
@@ -269,8 +360,8 @@ func updateAccountHandler(c *gin.Context) {
 	}
 
 	// Mettre à jour le compte
-	var account models.Account
-	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&account).Error; err != nil {
+	account, err := s.store.Accounts.FindForUser(uint(id), userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Compte non trouvé",
@@ -279,8 +370,18 @@ func updateAccountHandler(c *gin.Context) {
 		return
 	}
 
+	// Un gel administratif ou une clôture ne peuvent être levés que par l'administration
+	if account.Status != request.Status && (account.Status == "closed" || account.FrozenBy != nil) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Le statut de ce compte ne peut être modifié que par notre équipe",
+			Error:   "Forbidden",
+		})
+		return
+	}
+
 	account.Status = request.Status
-	if err := database.GetDB().Save(&account).Error; err != nil {
+	if err := s.store.Accounts.Save(account); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la mise à jour du compte",
@@ -297,7 +398,7 @@ func updateAccountHandler(c *gin.Context) {
 }
 
 // deleteAccountHandler supprime un compte
-func deleteAccountHandler(c *gin.Context) {
+func (s *server) deleteAccountHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -320,8 +421,8 @@ func deleteAccountHandler(c *gin.Context) {
 	}
 
 	// Vérifier que le compte existe et appartient à l'utilisateur
-	var account models.Account
-	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&account).Error; err != nil {
+	account, err := s.store.Accounts.FindForUser(uint(id), userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Compte non trouvé",
@@ -330,18 +431,18 @@ func deleteAccountHandler(c *gin.Context) {
 		return
 	}
 
-	// Vérifier que le solde est nul
-	if account.Balance != 0 {
+	// Vérifier que le solde est nul et qu'aucun paiement autorisé n'est en attente
+	if !account.Balance.IsZero() || !account.HeldAmount.IsZero() {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Impossible de supprimer un compte avec un solde non nul",
+			Message: "Impossible de supprimer un compte avec un solde ou des fonds réservés non nuls",
 			Error:   "Bad Request",
 		})
 		return
 	}
 
 	// Supprimer le compte (soft delete)
-	if err := database.GetDB().Delete(&account).Error; err != nil {
+	if err := s.store.Accounts.Delete(account); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la suppression du compte",
@@ -356,8 +457,8 @@ func deleteAccountHandler(c *gin.Context) {
 	})
 }
 
-// getBalanceHandler récupère le solde d'un compte
-func getBalanceHandler(c *gin.Context) {
+// getBalanceHandler récupère le solde comptable et le solde disponible d'un compte
+func (s *server) getBalanceHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -379,9 +480,8 @@ func getBalanceHandler(c *gin.Context) {
 		return
 	}
 
-	var account models.Account
-	if err := database.GetDB().Select("id, balance, currency").
-		Where("id = ? AND user_id = ?", id, userID).First(&account).Error; err != nil {
+	account, err := s.store.Accounts.FindForUser(uint(id), userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Compte non trouvé",
@@ -390,10 +490,26 @@ func getBalanceHandler(c *gin.Context) {
 		return
 	}
 
+	available, err := account.AvailableBalance()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors du calcul du solde disponible",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	// balance reste le solde comptable ; le solde disponible déduit les paiements autorisés
+	// et ajoute le découvert autorisé
 	balanceInfo := map[string]interface{}{
-		"account_id": account.ID,
-		"balance":    account.Balance,
-		"currency":   account.Currency,
+		"account_id":        account.ID,
+		"balance":           account.Balance,
+		"booked_balance":    account.Balance,
+		"available_balance": available,
+		"held_amount":       account.HeldAmount,
+		"credit_limit":      account.CreditLimit,
+		"currency":          account.Currency,
 	}
 
 	c.JSON(http.StatusOK, models.APIResponse{
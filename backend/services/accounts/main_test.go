@@ -0,0 +1,380 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/statement"
+	"banking-app/shared/testutil"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTestRouter crée une base SQLite de test et retourne le routeur du service
+func setupTestRouter(t *testing.T) (*gin.Engine, *repository.Store) {
+	t.Helper()
+
+	store := testutil.NewStore(t)
+	return newServer(store).setupRouter(), store
+}
+
+func TestCreateAndListAccounts(t *testing.T) {
+	router, store := setupTestRouter(t)
+	_, token := testutil.CreateUser(t, store)
+
+	rec := testutil.Request(router, http.MethodPost, "/api/accounts/", token, map[string]string{
+		"account_type": "savings",
+		"currency":     "USD",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	var created models.Account
+	testutil.DecodeData(t, rec, &created)
+	if created.Currency != "USD" || !created.Balance.IsZero() || created.Status != "active" {
+		t.Errorf("compte créé = %+v", created)
+	}
+	if err := utils.ValidateIBAN(created.AccountNumber); err != nil || created.AccountNumber[:2] != "FR" {
+		t.Errorf("numéro de compte %q: %v", created.AccountNumber, err)
+	}
+
+	rec = testutil.Request(router, http.MethodGet, "/api/accounts/", token, nil)
+	var accounts []models.Account
+	testutil.DecodeData(t, rec, &accounts)
+	if len(accounts) != 1 || accounts[0].ID != created.ID {
+		t.Errorf("comptes = %+v, attendu uniquement le compte %d", accounts, created.ID)
+	}
+}
+
+func TestCreateAccountRejectsInvalidInput(t *testing.T) {
+	router, store := setupTestRouter(t)
+	_, token := testutil.CreateUser(t, store)
+
+	tests := map[string]map[string]string{
+		"type invalide":   {"account_type": "crypto"},
+		"devise inconnue": {"account_type": "checking", "currency": "XYZ"},
+		"type manquant":   {"currency": "EUR"},
+	}
+	for name, payload := range tests {
+		rec := testutil.Request(router, http.MethodPost, "/api/accounts/", token, payload)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: statut = %d, attendu 400", name, rec.Code)
+		}
+	}
+}
+
+func TestAccountsRequireAuthentication(t *testing.T) {
+	router, _ := setupTestRouter(t)
+
+	rec := testutil.Request(router, http.MethodGet, "/api/accounts/", "", nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("statut = %d, attendu 401", rec.Code)
+	}
+}
+
+func TestAccountIsScopedToOwner(t *testing.T) {
+	router, store := setupTestRouter(t)
+	_, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, other.ID, 0)
+
+	for _, path := range []string{"/api/accounts/%d", "/api/accounts/%d/balance"} {
+		rec := testutil.Request(router, http.MethodGet, fmt.Sprintf(path, account.ID), token, nil)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: statut = %d, attendu 404", path, rec.Code)
+		}
+	}
+}
+
+func TestBalanceAndDelete(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	funded := testutil.CreateAccount(t, store, user.ID, 4250)
+	empty := testutil.CreateAccount(t, store, user.ID, 0)
+
+	rec := testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/accounts/%d/balance", funded.ID), token, nil)
+	var balance struct {
+		Balance  float64 `json:"balance"`
+		Currency string  `json:"currency"`
+	}
+	testutil.DecodeData(t, rec, &balance)
+	if balance.Balance != 42.5 || balance.Currency != "EUR" {
+		t.Errorf("solde = %+v, attendu 42.5 EUR", balance)
+	}
+
+	// Une réservation réduit le solde disponible sans toucher au solde comptable
+	if err := store.Accounts.Reserve(funded.ID, models.NewMoney(1250, "EUR")); err != nil {
+		t.Fatal(err)
+	}
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/accounts/%d/balance", funded.ID), token, nil)
+	var balances struct {
+		Booked    float64 `json:"booked_balance"`
+		Available float64 `json:"available_balance"`
+		Held      float64 `json:"held_amount"`
+	}
+	testutil.DecodeData(t, rec, &balances)
+	if balances.Booked != 42.5 || balances.Available != 30 || balances.Held != 12.5 {
+		t.Errorf("soldes = %+v, attendu 42.5 comptable, 30 disponible, 12.5 réservé", balances)
+	}
+	if err := store.Accounts.Release(funded.ID, models.NewMoney(1250, "EUR")); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = testutil.Request(router, http.MethodDelete, fmt.Sprintf("/api/accounts/%d", funded.ID), token, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("suppression d'un compte approvisionné: statut = %d, attendu 400", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodDelete, fmt.Sprintf("/api/accounts/%d", empty.ID), token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("suppression: statut = %d, attendu 200", rec.Code)
+	}
+	if _, err := store.Accounts.FindByID(empty.ID); err != repository.ErrNotFound {
+		t.Errorf("compte supprimé toujours visible: %v", err)
+	}
+}
+
+// TestAdminFreezeOverridesCustomer vérifie qu'un gel administratif ne peut pas être levé par le client
+func TestAdminFreezeOverridesCustomer(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	_, supportToken := testutil.CreateUserWithRole(t, store, models.RoleSupport)
+	account := testutil.CreateAccount(t, store, user.ID, 1000)
+	freezePath := fmt.Sprintf("/api/admin/accounts/%d/freeze", account.ID)
+
+	if rec := testutil.Request(router, http.MethodPost, freezePath, token, map[string]string{"reason": "test"}); rec.Code != http.StatusForbidden {
+		t.Errorf("gel par un client: statut = %d, attendu 403", rec.Code)
+	}
+
+	rec := testutil.Request(router, http.MethodPost, freezePath, supportToken, map[string]string{"reason": "Suspicion de fraude"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("gel: statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = testutil.Request(router, http.MethodPut, fmt.Sprintf("/api/accounts/%d", account.ID), token, map[string]string{"status": "active"})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("dégel par le client: statut = %d, attendu 403", rec.Code)
+	}
+
+	// Le support peut geler mais pas clôturer
+	closePath := fmt.Sprintf("/api/admin/accounts/%d/close", account.ID)
+	if rec := testutil.Request(router, http.MethodPost, closePath, supportToken, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("clôture par le support: statut = %d, attendu 403", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/admin/accounts/%d/unfreeze", account.ID), supportToken, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("dégel: statut = %d, attendu 200", rec.Code)
+	}
+	unfrozen, err := store.Accounts.FindByID(account.ID)
+	if err != nil || unfrozen.Status != "active" || unfrozen.FrozenBy != nil {
+		t.Errorf("compte après dégel = %+v, %v", unfrozen, err)
+	}
+}
+
+// TestAdminCloseRequiresZeroBalance vérifie la clôture administrative et la recherche de comptes
+func TestAdminCloseRequiresZeroBalance(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	funded := testutil.CreateAccount(t, store, user.ID, 1000)
+	empty := testutil.CreateAccount(t, store, user.ID, 0)
+
+	rec := testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/admin/accounts/%d/close", funded.ID), adminToken, nil)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("clôture d'un compte approvisionné: statut = %d, attendu 409", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/admin/accounts/%d/close", empty.ID), adminToken, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("clôture: statut = %d, attendu 200", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodPut, fmt.Sprintf("/api/accounts/%d", empty.ID), token, map[string]string{"status": "active"})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("réouverture par le client: statut = %d, attendu 403", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/admin/accounts/?user_id=%d&status=closed", user.ID), adminToken, nil)
+	var accounts []models.Account
+	testutil.DecodeData(t, rec, &accounts)
+	if len(accounts) != 1 || accounts[0].ID != empty.ID {
+		t.Errorf("comptes clôturés = %+v, attendu le compte %d", accounts, empty.ID)
+	}
+}
+
+func TestAdminCreditLimitIsAudited(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	admin, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	_, supportToken := testutil.CreateUserWithRole(t, store, models.RoleSupport)
+	account := testutil.CreateAccount(t, store, user.ID, 10000)
+	path := fmt.Sprintf("/api/admin/accounts/%d/limit", account.ID)
+	payload := map[string]interface{}{"credit_limit": "500.00", "reason": "Découvert accordé"}
+
+	if rec := testutil.Request(router, http.MethodPut, path, supportToken, payload); rec.Code != http.StatusForbidden {
+		t.Errorf("modification par le support: statut = %d, attendu 403", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodPut, path, adminToken, map[string]interface{}{"credit_limit": "500.00"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("modification sans motif: statut = %d, attendu 400", rec.Code)
+	}
+
+	rec := testutil.Request(router, http.MethodPut, path, adminToken, payload)
+	var updated models.Account
+	testutil.DecodeData(t, rec, &updated)
+	if updated.CreditLimit.Amount != 50000 || updated.OverdraftRateBps != defaultOverdraftRateBps {
+		t.Errorf("compte = %+v, attendu un découvert de 50000 centimes au taux par défaut", updated)
+	}
+
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/accounts/%d/balance", account.ID), token, nil)
+	var balance struct {
+		Available models.Money `json:"available_balance"`
+	}
+	testutil.DecodeData(t, rec, &balance)
+	if balance.Available.Amount != 60000 {
+		t.Errorf("solde disponible = %d centimes, attendu 60000", balance.Available.Amount)
+	}
+
+	payload = map[string]interface{}{"credit_limit": "0", "overdraft_rate_bps": 900, "reason": "Révision"}
+	if rec := testutil.Request(router, http.MethodPut, path, adminToken, payload); rec.Code != http.StatusOK {
+		t.Fatalf("seconde modification: statut = %d, attendu 200", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodGet, path+"/history", adminToken, nil)
+	var changes []models.AccountLimitChange
+	testutil.DecodeData(t, rec, &changes)
+	if len(changes) != 2 {
+		t.Fatalf("historique = %+v, attendu 2 modifications", changes)
+	}
+	latest := changes[0]
+	if latest.ChangedBy != admin.ID || latest.PreviousLimit.Amount != 50000 || latest.NewLimit.Amount != 0 ||
+		latest.NewRateBps != 900 || latest.Reason != "Révision" {
+		t.Errorf("dernière modification = %+v", latest)
+	}
+
+	savings := models.Account{UserID: user.ID, AccountType: "savings", Currency: models.DefaultCurrency, Status: "active"}
+	if err := store.Accounts.CreateWithNumber(&savings, utils.GenerateAccountNumber); err != nil {
+		t.Fatal(err)
+	}
+	rec = testutil.Request(router, http.MethodPut, fmt.Sprintf("/api/admin/accounts/%d/limit", savings.ID), adminToken, map[string]interface{}{"credit_limit": "100.00", "reason": "Test"})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("découvert sur un compte épargne: statut = %d, attendu 409", rec.Code)
+	}
+}
+
+func TestSavingsAccountInterestProduct(t *testing.T) {
+	router, store := setupTestRouter(t)
+	_, token := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+
+	product := map[string]interface{}{
+		"code":             "LIVRET",
+		"name":             "Livret à paliers",
+		"compounding":      "monthly",
+		"payout_frequency": "quarterly",
+		"tiers": []map[string]interface{}{
+			{"min_balance": "10000.00", "rate_bps": 150},
+			{"min_balance": "0", "rate_bps": 300},
+		},
+	}
+	if rec := testutil.Request(router, http.MethodPost, "/api/admin/interest-products/", token, product); rec.Code != http.StatusForbidden {
+		t.Errorf("création par un client: statut = %d, attendu 403", rec.Code)
+	}
+	rec := testutil.Request(router, http.MethodPost, "/api/admin/interest-products/", adminToken, product)
+	var created models.InterestProduct
+	testutil.DecodeData(t, rec, &created)
+	if created.DayCount != models.DayCountAct365 || len(created.Tiers) != 2 || created.Tiers[0].RateBps != 300 {
+		t.Errorf("produit = %+v, attendu act/365 et tranches triées par seuil", created)
+	}
+	if rec := testutil.Request(router, http.MethodPost, "/api/admin/interest-products/", adminToken, product); rec.Code != http.StatusConflict {
+		t.Errorf("code en double: statut = %d, attendu 409", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/accounts/", token, map[string]interface{}{"account_type": "checking", "interest_product_id": created.ID})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("compte courant rémunéré: statut = %d, attendu 400", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodPost, "/api/accounts/", token, map[string]interface{}{"account_type": "savings", "interest_product_id": created.ID})
+	var savings models.Account
+	testutil.DecodeData(t, rec, &savings)
+	if savings.InterestProductID == nil || *savings.InterestProductID != created.ID {
+		t.Fatalf("compte épargne = %+v, attendu rattaché au produit %d", savings, created.ID)
+	}
+
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/accounts/%d/interest", savings.ID), token, nil)
+	var info struct {
+		Product       models.InterestProduct `json:"product"`
+		AccruedUnpaid models.Money           `json:"accrued_unpaid"`
+	}
+	testutil.DecodeData(t, rec, &info)
+	if info.Product.ID != created.ID || !info.AccruedUnpaid.IsZero() {
+		t.Errorf("rémunération = %+v", info)
+	}
+
+	// Un produit retiré n'est plus proposé, ni à la liste ni à l'ouverture
+	rec = testutil.Request(router, http.MethodPut, fmt.Sprintf("/api/admin/interest-products/%d", created.ID), adminToken, map[string]interface{}{"active": false})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("retrait: statut = %d, attendu 200", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodGet, "/api/interest-products", token, nil)
+	var products []models.InterestProduct
+	testutil.DecodeData(t, rec, &products)
+	if len(products) != 0 {
+		t.Errorf("produits commercialisés = %+v, attendu aucun", products)
+	}
+	rec = testutil.Request(router, http.MethodPost, "/api/accounts/", token, map[string]interface{}{"account_type": "savings", "interest_product_id": created.ID})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("ouverture sur un produit retiré: statut = %d, attendu 400", rec.Code)
+	}
+}
+
+func TestAccountStatements(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	_, otherToken := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 5000)
+	opened := time.Now().UTC().AddDate(0, -2, 0)
+	store.DB.Model(&models.Account{}).Where("id = ?", account.ID).Update("created_at", opened)
+	store.DB.Model(&models.Posting{}).Where("account_id = ?", account.ID).Update("created_at", opened)
+
+	period := statement.PreviousPeriod(time.Now())
+	path := fmt.Sprintf("/api/accounts/%d/statements/%s", account.ID, period)
+
+	if rec := testutil.Request(router, http.MethodGet, path, otherToken, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("relevé d'un autre client: statut = %d, attendu 404", rec.Code)
+	}
+
+	rec := testutil.Request(router, http.MethodGet, path+"?format=csv", token, nil)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("relevé CSV: statut = %d, type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "Solde de clôture,,50.00,EUR") {
+		t.Errorf("relevé CSV = %q", rec.Body.String())
+	}
+
+	rec = testutil.Request(router, http.MethodGet, path+"?format=pdf", token, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(rec.Body.String(), "%PDF-") {
+		t.Errorf("relevé PDF: statut = %d, type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/accounts/%d/statements", account.ID), token, nil)
+	var statements []models.Statement
+	testutil.DecodeData(t, rec, &statements)
+	if len(statements) != 1 || statements[0].Period != period {
+		t.Errorf("relevés = %+v, attendu celui de %s", statements, period)
+	}
+
+	current := time.Now().UTC().Format(models.StatementPeriodLayout)
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/accounts/%d/statements/%s", account.ID, current), token, nil)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("relevé du mois en cours: statut = %d, attendu 409", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodGet, path+"?format=xml", token, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("format inconnu: statut = %d, attendu 400", rec.Code)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/statement"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statementAccount retourne le compte :id de l'utilisateur connecté, ou écrit la réponse d'erreur
+func (s *server) statementAccount(c *gin.Context) (*models.Account, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de compte invalide",
+			Error:   "Bad Request",
+		})
+		return nil, false
+	}
+
+	account, err := s.store.Accounts.FindForUser(uint(id), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
+		})
+		return nil, false
+	}
+	return account, true
+}
+
+// listStatementsHandler liste les relevés établis d'un compte, sans leurs opérations
+func (s *server) listStatementsHandler(c *gin.Context) {
+	account, ok := s.statementAccount(c)
+	if !ok {
+		return
+	}
+
+	statements, err := s.store.Statements.ListForAccount(account.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des relevés",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Relevés récupérés avec succès",
+		Data:    statements,
+	})
+}
+
+// getStatementHandler retourne le relevé d'un mois clos (:period au format AAAA-MM), en JSON
+// par défaut ou en fichier avec format=csv ou format=pdf. Un relevé non encore établi par
+// la tâche mensuelle l'est à la demande ; il est ensuite figé.
+func (s *server) getStatementHandler(c *gin.Context) {
+	account, ok := s.statementAccount(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Format invalide. Formats valides: json, csv, pdf",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	result, err := statement.Get(s.store, account.ID, c.Param("period"), time.Now())
+	switch {
+	case errors.Is(err, statement.ErrInvalidPeriod):
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Période invalide, format attendu AAAA-MM",
+			Error:   "Bad Request",
+		})
+		return
+	case errors.Is(err, statement.ErrPeriodNotClosed):
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Le relevé n'est disponible qu'une fois le mois écoulé",
+			Error:   "Conflict",
+		})
+		return
+	case errors.Is(err, statement.ErrBeforeOpening), errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Aucun relevé pour cette période",
+			Error:   "Not Found",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de l'établissement du relevé",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: "Relevé récupéré avec succès",
+			Data:    result,
+		})
+		return
+	}
+
+	var body bytes.Buffer
+	contentType := "text/csv; charset=utf-8"
+	if format == "csv" {
+		err = statement.WriteCSV(&body, result)
+	} else {
+		contentType = "application/pdf"
+		err = statement.WritePDF(&body, result)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la mise en forme du relevé",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+statement.FileName(result, format)+`"`)
+	c.Data(http.StatusOK, contentType, body.Bytes())
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/notify"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// targetUser charge l'utilisateur désigné par le paramètre :id, ou écrit l'erreur et retourne nil
+func (s *server) targetUser(c *gin.Context) *models.User {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID d'utilisateur invalide",
+			Error:   "Bad Request",
+		})
+		return nil
+	}
+
+	user, err := s.store.Users.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non trouvé",
+			Error:   "Not Found",
+		})
+		return nil
+	}
+	return user
+}
+
+// rejectSelf refuse qu'un administrateur modifie son propre statut ou rôle, pour ne pas s'exclure lui-même
+func rejectSelf(c *gin.Context, target *models.User) bool {
+	if userID, _ := c.Get("user_id"); userID == target.ID {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Impossible de modifier son propre compte depuis l'administration",
+			Error:   "Bad Request",
+		})
+		return true
+	}
+	return false
+}
+
+// searchUsersHandler recherche des utilisateurs par email, prénom ou nom, par pages
+func (s *server) searchUsersHandler(c *gin.Context) {
+	page, err := utils.ParsePageRequest(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	users, err := s.store.Users.Search(strings.TrimSpace(c.Query("q")), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la recherche des utilisateurs",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	users, info := utils.NewPage(users, page, func(user models.User) utils.Cursor {
+		return utils.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	})
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success:    true,
+		Message:    "Utilisateurs récupérés avec succès",
+		Data:       users,
+		Pagination: &info,
+	})
+}
+
+// getUserHandler récupère un utilisateur quelconque
+func (s *server) getUserHandler(c *gin.Context) {
+	user := s.targetUser(c)
+	if user == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Utilisateur récupéré avec succès",
+		Data:    user,
+	})
+}
+
+// deactivateUserHandler désactive un utilisateur et révoque toutes ses sessions
+func (s *server) deactivateUserHandler(c *gin.Context) {
+	s.setUserActive(c, false)
+}
+
+// activateUserHandler réactive un utilisateur désactivé
+func (s *server) activateUserHandler(c *gin.Context) {
+	s.setUserActive(c, true)
+}
+
+func (s *server) setUserActive(c *gin.Context, active bool) {
+	user := s.targetUser(c)
+	if user == nil || rejectSelf(c, user) {
+		return
+	}
+
+	user.IsActive = active
+	err := s.store.Transaction(func(tx *repository.Store) error {
+		if err := tx.Users.Save(user); err != nil {
+			return err
+		}
+		if active {
+			return nil
+		}
+		_, err := tx.Sessions.RevokeAllForUser(user.ID, time.Now())
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la mise à jour de l'utilisateur",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	message := "Utilisateur réactivé avec succès"
+	if !active {
+		message = "Utilisateur désactivé avec succès"
+		s.notifier.Notify(user.ID, notify.ChannelEmail, "Compte désactivé",
+			"Votre compte a été désactivé par notre équipe. Contactez le support pour plus d'informations.")
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: message,
+		Data:    user,
+	})
+}
+
+// updateUserRoleHandler change le rôle d'un utilisateur ; ses sessions sont révoquées
+// pour que ses nouvelles permissions s'appliquent à la prochaine connexion
+func (s *server) updateUserRoleHandler(c *gin.Context) {
+	var request struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if !models.IsValidRole(request.Role) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Rôle invalide. Rôles valides: customer, support, admin",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	user := s.targetUser(c)
+	if user == nil || rejectSelf(c, user) {
+		return
+	}
+
+	user.Role = request.Role
+	err := s.store.Transaction(func(tx *repository.Store) error {
+		if err := tx.Users.Save(user); err != nil {
+			return err
+		}
+		_, err := tx.Sessions.RevokeAllForUser(user.ID, time.Now())
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la mise à jour du rôle",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Rôle mis à jour avec succès",
+		Data:    user,
+	})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Seuils de ralentissement : au-delà des échecs tolérés, chaque nouvel échec double le délai imposé
+const (
+	emailFreeAttempts = 2  // par email
+	ipFreeAttempts    = 10 // par adresse IP, plus tolérant (plusieurs utilisateurs derrière un NAT)
+	maxBackoff        = 15 * time.Minute
+)
+
+// maxLoginAttempts est le nombre d'échecs qui verrouille le compte (MAX_LOGIN_ATTEMPTS)
+func maxLoginAttempts() int {
+	if value, err := strconv.Atoi(os.Getenv("MAX_LOGIN_ATTEMPTS")); err == nil && value > 0 {
+		return value
+	}
+	return 5
+}
+
+// lockoutDuration est la durée du verrouillage et de la fenêtre de comptage (ACCOUNT_LOCKOUT_DURATION)
+func lockoutDuration() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("ACCOUNT_LOCKOUT_DURATION")); err == nil && value > 0 {
+		return value
+	}
+	return 15 * time.Minute
+}
+
+func emailAttemptKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// backoff retourne le délai imposé après failures échecs, dont free sont tolérés sans délai
+func backoff(failures, free int) time.Duration {
+	if failures <= free {
+		return 0
+	}
+	exponent := failures - free - 1
+	if exponent >= 20 {
+		return maxBackoff
+	}
+	return min(time.Second<<exponent, maxBackoff)
+}
+
+// retryAfter retourne le temps restant avant une nouvelle tentative pour key
+func (s *server) retryAfter(key string, free int, now time.Time) time.Duration {
+	attempt, err := s.store.LoginAttempts.Get(key)
+	if err != nil || now.Sub(attempt.LastFailureAt) > lockoutDuration() {
+		return 0
+	}
+	return max(attempt.LastFailureAt.Add(backoff(attempt.Failures, free)).Sub(now), 0)
+}
+
+// setRetryAfter positionne l'en-tête Retry-After en secondes
+func setRetryAfter(c *gin.Context, wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	return seconds
+}
+
+// checkLoginThrottle refuse la tentative (429) tant que l'email ou l'adresse IP est ralenti.
+// Le contrôle a lieu avant bcrypt pour que les tentatives refusées ne coûtent rien au serveur.
+func (s *server) checkLoginThrottle(c *gin.Context, email string) bool {
+	now := time.Now()
+	wait := max(
+		s.retryAfter(ipAttemptKey(c.ClientIP()), ipFreeAttempts, now),
+		s.retryAfter(emailAttemptKey(email), emailFreeAttempts, now),
+	)
+	if wait <= 0 {
+		return true
+	}
+
+	seconds := setRetryAfter(c, wait)
+	c.JSON(http.StatusTooManyRequests, models.APIResponse{
+		Success: false,
+		Message: fmt.Sprintf("Trop de tentatives de connexion, réessayez dans %d secondes", seconds),
+		Error:   "Too Many Requests",
+	})
+	return false
+}
+
+// checkAccountLock refuse la connexion (423) d'un compte verrouillé
+func checkAccountLock(c *gin.Context, user *models.User) bool {
+	now := time.Now()
+	if !user.IsLocked(now) {
+		return true
+	}
+
+	setRetryAfter(c, user.LockedUntil.Sub(now))
+	c.JSON(http.StatusLocked, models.APIResponse{
+		Success: false,
+		Message: "Compte temporairement verrouillé suite à de trop nombreuses tentatives de connexion",
+		Error:   "Locked",
+	})
+	return false
+}
+
+// recordLoginFailure comptabilise un échec pour l'email et l'adresse IP, et verrouille le
+// compte user (nil si l'email est inconnu) au-delà de maxLoginAttempts. Retourne true si
+// le compte vient d'être verrouillé.
+func (s *server) recordLoginFailure(c *gin.Context, email string, user *models.User) bool {
+	now := time.Now()
+	window := lockoutDuration()
+
+	if _, err := s.store.LoginAttempts.RecordFailure(ipAttemptKey(c.ClientIP()), now, window); err != nil {
+		log.Printf("Erreur lors de l'enregistrement d'un échec de connexion: %v", err)
+	}
+	attempt, err := s.store.LoginAttempts.RecordFailure(emailAttemptKey(email), now, window)
+	if err != nil {
+		log.Printf("Erreur lors de l'enregistrement d'un échec de connexion: %v", err)
+		return false
+	}
+	if user == nil || attempt.Failures < maxLoginAttempts() {
+		return false
+	}
+
+	lockedUntil := now.Add(window)
+	user.LockedUntil = &lockedUntil
+	if err := s.store.Users.Save(user); err != nil {
+		log.Printf("Erreur lors du verrouillage de l'utilisateur %d: %v", user.ID, err)
+		return false
+	}
+	// Le verrouillage prend le relais du compteur
+	s.resetLoginFailures(email)
+
+	message := fmt.Sprintf("Suite à %d tentatives de connexion échouées, votre compte est verrouillé jusqu'à %s. "+
+		"Si vous n'êtes pas à l'origine de ces tentatives, réinitialisez votre mot de passe.",
+		attempt.Failures, lockedUntil.Format("02/01/2006 15:04"))
+	if err := s.notifier.Notify(user.ID, notify.ChannelEmail, "Compte temporairement verrouillé", message); err != nil {
+		log.Printf("Erreur lors de la notification de verrouillage à l'utilisateur %d: %v", user.ID, err)
+	}
+	return true
+}
+
+// resetLoginFailures remet à zéro le compteur d'échecs de l'email
+func (s *server) resetLoginFailures(email string) {
+	if err := s.store.LoginAttempts.Reset(emailAttemptKey(email)); err != nil {
+		log.Printf("Erreur lors de la remise à zéro des échecs de connexion: %v", err)
+	}
+}
+
+// respondLoginFailure écrit la réponse d'un échec d'authentification, 423 si le compte vient d'être verrouillé
+func respondLoginFailure(c *gin.Context, locked bool, message string) {
+	if locked {
+		c.JSON(http.StatusLocked, models.APIResponse{
+			Success: false,
+			Message: "Compte temporairement verrouillé suite à de trop nombreuses tentatives de connexion",
+			Error:   "Locked",
+		})
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, models.APIResponse{
+		Success: false,
+		Message: message,
+		Error:   "Unauthorized",
+	})
+}
+
+// unlockUserHandler lève le verrouillage d'un compte (administrateurs)
+func (s *server) unlockUserHandler(c *gin.Context) {
+	user := s.targetUser(c)
+	if user == nil {
+		return
+	}
+
+	user.LockedUntil = nil
+	if err := s.store.Users.Save(user); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors du déverrouillage",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+	s.resetLoginFailures(user.Email)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Compte déverrouillé avec succès",
+		Data:    user,
+	})
+}
@@ -4,10 +4,13 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"banking-app/shared/database"
 	"banking-app/shared/middleware"
 	"banking-app/shared/models"
+	"banking-app/shared/notify"
+	"banking-app/shared/repository"
 	"banking-app/shared/utils"
 
 	"github.com/gin-gonic/gin"
@@ -35,6 +38,31 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	r := newServer(repository.NewStore(database.GetDB())).setupRouter()
+
+	// Démarrage du serveur
+	port := os.Getenv("AUTH_SERVICE_PORT")
+	if port == "" {
+		port = "8082"
+	}
+
+	log.Printf("Service d'authentification démarré sur le port %s", port)
+	log.Fatal(http.ListenAndServe(":"+port, r))
+}
+
+// server regroupe les dépendances des handlers du service d'authentification
+type server struct {
+	store    *repository.Store
+	notifier notify.Notifier
+}
+
+// newServer crée le service à partir des dépôts de données
+func newServer(store *repository.Store) *server {
+	return &server{store: store, notifier: notify.NewStoreNotifier(store)}
+}
+
+// setupRouter configure les routes du service d'authentification
+func (s *server) setupRouter() *gin.Engine {
 	r := gin.Default()
 
 	// Middleware
@@ -51,31 +79,49 @@ func main() {
 	// Routes publiques
 	auth := r.Group("/api/auth")
 	{
-		auth.POST("/register", registerHandler)
-		auth.POST("/login", loginHandler)
+		auth.POST("/register", s.registerHandler)
+		auth.POST("/login", s.loginHandler)
+		auth.POST("/refresh", s.refreshHandler)
+		auth.POST("/2fa/verify", s.verifyTwoFactorHandler)
+		auth.POST("/forgot-password", s.forgotPasswordHandler)
+		auth.POST("/reset-password", s.resetPasswordHandler)
+		auth.POST("/verify-email", s.verifyEmailHandler)
 	}
 
 	// Routes protégées
 	protected := r.Group("/api/auth")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(s.store.Users, s.store.Sessions))
 	{
-		protected.GET("/profile", getProfileHandler)
-		protected.PUT("/profile", updateProfileHandler)
-		protected.POST("/change-password", changePasswordHandler)
-	}
-
-	// Démarrage du serveur
-	port := os.Getenv("AUTH_SERVICE_PORT")
-	if port == "" {
-		port = "8082"
+		protected.GET("/profile", s.getProfileHandler)
+		protected.PUT("/profile", s.updateProfileHandler)
+		protected.POST("/change-password", s.changePasswordHandler)
+		protected.POST("/logout", s.logoutHandler)
+		protected.GET("/sessions", s.getSessionsHandler)
+		protected.DELETE("/sessions/:id", s.revokeSessionHandler)
+		protected.POST("/2fa/setup", s.setupTwoFactorHandler)
+		protected.POST("/2fa/confirm", s.confirmTwoFactorHandler)
+		protected.POST("/2fa/disable", s.disableTwoFactorHandler)
+		protected.POST("/2fa/recovery-codes", s.regenerateRecoveryCodesHandler)
+		protected.POST("/resend-verification", s.resendVerificationHandler)
+	}
+
+	// Routes d'administration
+	admin := r.Group("/api/admin")
+	admin.Use(middleware.AuthMiddleware(s.store.Users, s.store.Sessions))
+	{
+		admin.GET("/users", middleware.RequirePermission(models.PermUsersRead), s.searchUsersHandler)
+		admin.GET("/users/:id", middleware.RequirePermission(models.PermUsersRead), s.getUserHandler)
+		admin.POST("/users/:id/unlock", middleware.RequirePermission(models.PermUsersManage), s.unlockUserHandler)
+		admin.POST("/users/:id/deactivate", middleware.RequirePermission(models.PermUsersManage), s.deactivateUserHandler)
+		admin.POST("/users/:id/activate", middleware.RequirePermission(models.PermUsersManage), s.activateUserHandler)
+		admin.PUT("/users/:id/role", middleware.RequirePermission(models.PermUsersManage), s.updateUserRoleHandler)
 	}
 
-	log.Printf("Service d'authentification démarré sur le port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	return r
 }
 
 // registerHandler gère l'inscription d'un nouvel utilisateur
-func registerHandler(c *gin.Context) {
+func (s *server) registerHandler(c *gin.Context) {
 	var request struct {
 		Email     string `json:"email" binding:"required,email"`
 		Password  string `json:"password" binding:"required,min=6"`
@@ -94,8 +140,7 @@ func registerHandler(c *gin.Context) {
 	}
 
 	// Vérifier si l'utilisateur existe déjà
-	var existingUser models.User
-	if err := database.GetDB().Where("email = ?", request.Email).First(&existingUser).Error; err == nil {
+	if _, err := s.store.Users.FindByEmail(request.Email); err == nil {
 		c.JSON(http.StatusConflict, models.APIResponse{
 			Success: false,
 			Message: "Un utilisateur avec cet email existe déjà",
@@ -125,7 +170,7 @@ func registerHandler(c *gin.Context) {
 		IsActive:  true,
 	}
 
-	if err := database.GetDB().Create(&user).Error; err != nil {
+	if err := s.store.Users.Create(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la création de l'utilisateur",
@@ -134,8 +179,13 @@ func registerHandler(c *gin.Context) {
 		return
 	}
 
-	// Générer le token JWT
-	token, err := utils.GenerateJWT(user.ID)
+	// Envoyer le lien de vérification ; l'inscription reste valide en cas d'échec (renvoi possible)
+	if err := s.sendEmailVerification(&user); err != nil {
+		log.Printf("Erreur lors de l'envoi du lien de vérification à l'utilisateur %d: %v", user.ID, err)
+	}
+
+	// Ouvrir une session et générer les tokens
+	response, err := s.issueSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -148,15 +198,12 @@ func registerHandler(c *gin.Context) {
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "Utilisateur créé avec succès",
-		Data: models.LoginResponse{
-			Token: token,
-			User:  user,
-		},
+		Data:    response,
 	})
 }
 
 // loginHandler gère la connexion d'un utilisateur
-func loginHandler(c *gin.Context) {
+func (s *server) loginHandler(c *gin.Context) {
 	var request models.LoginRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -168,24 +215,29 @@ func loginHandler(c *gin.Context) {
 		return
 	}
 
+	// Ralentir les tentatives répétées avant tout calcul bcrypt
+	if !s.checkLoginThrottle(c, request.Email) {
+		return
+	}
+
 	// Rechercher l'utilisateur
-	var user models.User
-	if err := database.GetDB().Where("email = ?", request.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, models.APIResponse{
-			Success: false,
-			Message: "Email ou mot de passe incorrect",
-			Error:   "Unauthorized",
-		})
+	user, err := s.store.Users.FindByEmail(request.Email)
+	if err != nil {
+		// Le temps de réponse ne doit pas révéler l'existence du compte
+		utils.SimulatePasswordCheck(request.Password)
+		s.recordLoginFailure(c, request.Email, nil)
+		respondLoginFailure(c, false, "Email ou mot de passe incorrect")
+		return
+	}
+
+	if !checkAccountLock(c, user) {
 		return
 	}
 
 	// Vérifier le mot de passe
 	if !utils.CheckPasswordHash(request.Password, user.Password) {
-		c.JSON(http.StatusUnauthorized, models.APIResponse{
-			Success: false,
-			Message: "Email ou mot de passe incorrect",
-			Error:   "Unauthorized",
-		})
+		locked := s.recordLoginFailure(c, request.Email, user)
+		respondLoginFailure(c, locked, "Email ou mot de passe incorrect")
 		return
 	}
 
@@ -199,8 +251,35 @@ func loginHandler(c *gin.Context) {
 		return
 	}
 
-	// Générer le token JWT
-	token, err := utils.GenerateJWT(user.ID)
+	// Double authentification : la session n'est ouverte qu'après vérification du code, et
+	// le compteur d'échecs n'est remis à zéro qu'à ce moment
+	if user.TOTPEnabled {
+		challenge, err := utils.GenerateChallengeToken(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Erreur lors de la génération du token",
+				Error:   "Internal Server Error",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: "Code de double authentification requis",
+			Data: models.TwoFactorChallengeResponse{
+				TwoFactorRequired: true,
+				ChallengeToken:    challenge,
+				ExpiresIn:         int64(utils.ChallengeTokenTTL.Seconds()),
+			},
+		})
+		return
+	}
+
+	s.resetLoginFailures(request.Email)
+
+	// Ouvrir une session et générer les tokens
+	response, err := s.issueSession(c, *user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -213,15 +292,12 @@ func loginHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "Connexion réussie",
-		Data: models.LoginResponse{
-			Token: token,
-			User:  user,
-		},
+		Data:    response,
 	})
 }
 
 // getProfileHandler récupère le profil de l'utilisateur connecté
-func getProfileHandler(c *gin.Context) {
+func (s *server) getProfileHandler(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -240,7 +316,7 @@ func getProfileHandler(c *gin.Context) {
 }
 
 // updateProfileHandler met à jour le profil de l'utilisateur
-func updateProfileHandler(c *gin.Context) {
+func (s *server) updateProfileHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -267,8 +343,8 @@ func updateProfileHandler(c *gin.Context) {
 	}
 
 	// Mettre à jour l'utilisateur
-	var user models.User
-	if err := database.GetDB().First(&user, userID).Error; err != nil {
+	user, err := s.store.Users.FindByID(userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Utilisateur non trouvé",
@@ -281,7 +357,7 @@ func updateProfileHandler(c *gin.Context) {
 	user.LastName = request.LastName
 	user.Phone = request.Phone
 
-	if err := database.GetDB().Save(&user).Error; err != nil {
+	if err := s.store.Users.Save(user); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la mise à jour",
@@ -298,7 +374,7 @@ func updateProfileHandler(c *gin.Context) {
 }
 
 // changePasswordHandler change le mot de passe de l'utilisateur
-func changePasswordHandler(c *gin.Context) {
+func (s *server) changePasswordHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -324,8 +400,8 @@ func changePasswordHandler(c *gin.Context) {
 	}
 
 	// Récupérer l'utilisateur
-	var user models.User
-	if err := database.GetDB().First(&user, userID).Error; err != nil {
+	user, err := s.store.Users.FindByID(userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Utilisateur non trouvé",
@@ -355,9 +431,16 @@ func changePasswordHandler(c *gin.Context) {
 		return
 	}
 
-	// Mettre à jour le mot de passe
+	// Mettre à jour le mot de passe et révoquer toutes les sessions existantes
 	user.Password = hashedPassword
-	if err := database.GetDB().Save(&user).Error; err != nil {
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		if err := tx.Users.Save(user); err != nil {
+			return err
+		}
+		_, err := tx.Sessions.RevokeAllForUser(user.ID, time.Now())
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la mise à jour du mot de passe",
@@ -366,8 +449,20 @@ func changePasswordHandler(c *gin.Context) {
 		return
 	}
 
+	// Le client courant reçoit une nouvelle session
+	response, err := s.issueSession(c, *user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la génération du token",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "Mot de passe modifié avec succès",
+		Message: "Mot de passe modifié avec succès, les autres sessions ont été déconnectées",
+		Data:    response,
 	})
 }
@@ -0,0 +1,733 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/testutil"
+	"banking-app/shared/totp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTestRouter crée une base SQLite de test et retourne le routeur du service
+func setupTestRouter(t *testing.T) (*gin.Engine, *repository.Store) {
+	t.Helper()
+
+	store := testutil.NewStore(t)
+	return newServer(store).setupRouter(), store
+}
+
+// register inscrit un utilisateur et retourne la réponse de connexion
+func register(t *testing.T, router *gin.Engine, email, password string) models.LoginResponse {
+	t.Helper()
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":      email,
+		"password":   password,
+		"first_name": "Jean",
+		"last_name":  "Dupont",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("inscription: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	var response models.LoginResponse
+	testutil.DecodeData(t, rec, &response)
+	return response
+}
+
+func TestRegisterLoginAndProfile(t *testing.T) {
+	router, _ := setupTestRouter(t)
+
+	registered := register(t, router, "jean@example.com", "secret123")
+	if registered.Token == "" || registered.User.Email != "jean@example.com" {
+		t.Fatalf("inscription = %+v", registered)
+	}
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "jean@example.com",
+		"password": "secret123",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("connexion: statut = %d, attendu 200", rec.Code)
+	}
+	var login models.LoginResponse
+	testutil.DecodeData(t, rec, &login)
+
+	rec = testutil.Request(router, http.MethodGet, "/api/auth/profile", login.Token, nil)
+	var profile models.User
+	testutil.DecodeData(t, rec, &profile)
+	if profile.ID != registered.User.ID {
+		t.Errorf("profil = %d, attendu %d", profile.ID, registered.User.ID)
+	}
+}
+
+func TestRegisterRejectsDuplicateEmail(t *testing.T) {
+	router, _ := setupTestRouter(t)
+	register(t, router, "jean@example.com", "secret123")
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":      "jean@example.com",
+		"password":   "autre-secret",
+		"first_name": "Jean",
+		"last_name":  "Dupont",
+	})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("statut = %d, attendu 409", rec.Code)
+	}
+}
+
+func TestLoginRejectsInvalidCredentials(t *testing.T) {
+	router, store := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "jean@example.com",
+		"password": "mauvais",
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("mauvais mot de passe: statut = %d, attendu 401", rec.Code)
+	}
+
+	user, err := store.Users.FindByID(registered.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.IsActive = false
+	if err := store.Users.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "jean@example.com",
+		"password": "secret123",
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("compte désactivé: statut = %d, attendu 401", rec.Code)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	router, _ := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/change-password", registered.Token, map[string]string{
+		"current_password": "secret123",
+		"new_password":     "nouveau456",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("changement: statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+
+	var renewed models.LoginResponse
+	testutil.DecodeData(t, rec, &renewed)
+
+	// Les sessions ouvertes avant le changement sont révoquées
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", registered.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("ancien access token: statut = %d, attendu 401", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/refresh", "", map[string]string{
+		"refresh_token": registered.RefreshToken,
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("ancien refresh token: statut = %d, attendu 401", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", renewed.Token, nil); rec.Code != http.StatusOK {
+		t.Errorf("nouvelle session: statut = %d, attendu 200", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "jean@example.com",
+		"password": "nouveau456",
+	})
+	if rec.Code != http.StatusOK {
+		t.Errorf("connexion avec le nouveau mot de passe: statut = %d, attendu 200", rec.Code)
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	router, _ := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/refresh", "", map[string]string{
+		"refresh_token": registered.RefreshToken,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("renouvellement: statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+	var refreshed models.LoginResponse
+	testutil.DecodeData(t, rec, &refreshed)
+	if refreshed.RefreshToken == registered.RefreshToken || refreshed.ExpiresIn <= 0 {
+		t.Fatalf("renouvellement = %+v, attendu un nouveau refresh token", refreshed)
+	}
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", refreshed.Token, nil); rec.Code != http.StatusOK {
+		t.Errorf("nouvel access token: statut = %d, attendu 200", rec.Code)
+	}
+
+	// Rejouer l'ancien refresh token révoque la session entière
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/refresh", "", map[string]string{
+		"refresh_token": registered.RefreshToken,
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("réutilisation: statut = %d, attendu 401", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", refreshed.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("session après réutilisation: statut = %d, attendu 401", rec.Code)
+	}
+}
+
+func TestLogoutAndSessions(t *testing.T) {
+	router, _ := setupTestRouter(t)
+	first := register(t, router, "jean@example.com", "secret123")
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "jean@example.com",
+		"password": "secret123",
+	})
+	var second models.LoginResponse
+	testutil.DecodeData(t, rec, &second)
+
+	rec = testutil.Request(router, http.MethodGet, "/api/auth/sessions", second.Token, nil)
+	var sessions []struct {
+		ID      string `json:"id"`
+		Current bool   `json:"current"`
+	}
+	testutil.DecodeData(t, rec, &sessions)
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %+v, attendu 2", sessions)
+	}
+
+	// Révoquer à distance la première session
+	var firstID string
+	for _, session := range sessions {
+		if !session.Current {
+			firstID = session.ID
+		}
+	}
+	rec = testutil.Request(router, http.MethodDelete, "/api/auth/sessions/"+firstID, second.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("révocation: statut = %d, attendu 200", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", first.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("session révoquée: statut = %d, attendu 401", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/logout", second.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("déconnexion: statut = %d, attendu 200", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", second.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("après déconnexion: statut = %d, attendu 401", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/refresh", "", map[string]string{
+		"refresh_token": second.RefreshToken,
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh après déconnexion: statut = %d, attendu 401", rec.Code)
+	}
+}
+
+// currentCode calcule le code TOTP courant pour secret
+func currentCode(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTwoFactorEnrollmentAndLogin(t *testing.T) {
+	router, _ := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/2fa/setup", registered.Token, nil)
+	var setup struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}
+	testutil.DecodeData(t, rec, &setup)
+	if setup.Secret == "" || setup.OtpauthURI == "" {
+		t.Fatalf("enrôlement = %+v", setup)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/2fa/confirm", registered.Token, map[string]string{"code": "000000"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("confirmation avec un mauvais code: statut = %d, attendu 400", rec.Code)
+	}
+
+	// Le code du pas précédent confirme l'enrôlement ; celui du pas courant reste utilisable
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/2fa/confirm", registered.Token, map[string]string{
+		"code": currentCode(t, setup.Secret, -1),
+	})
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	testutil.DecodeData(t, rec, &confirmed)
+	if len(confirmed.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("codes de secours = %v", confirmed.RecoveryCodes)
+	}
+
+	login := func() string {
+		rec := testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+			"email":    "jean@example.com",
+			"password": "secret123",
+		})
+		var challenge models.TwoFactorChallengeResponse
+		testutil.DecodeData(t, rec, &challenge)
+		if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+			t.Fatalf("connexion = %s, attendu un challenge", rec.Body.String())
+		}
+		return challenge.ChallengeToken
+	}
+
+	challenge := login()
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", challenge, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("token de challenge utilisé comme access token: statut = %d, attendu 401", rec.Code)
+	}
+
+	code := currentCode(t, setup.Secret, 0)
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/2fa/verify", "", map[string]string{
+		"challenge_token": challenge,
+		"code":            code,
+	})
+	var session models.LoginResponse
+	testutil.DecodeData(t, rec, &session)
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", session.Token, nil); rec.Code != http.StatusOK {
+		t.Errorf("session après 2FA: statut = %d, attendu 200", rec.Code)
+	}
+
+	// Un code TOTP ne peut servir qu'une fois
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/2fa/verify", "", map[string]string{
+		"challenge_token": login(),
+		"code":            code,
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("rejeu du code: statut = %d, attendu 401", rec.Code)
+	}
+
+	// Un code de secours est accepté une seule fois, quel que soit son format
+	recovery := strings.ToUpper(strings.ReplaceAll(confirmed.RecoveryCodes[0], "-", ""))
+	for i, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		rec = testutil.Request(router, http.MethodPost, "/api/auth/2fa/verify", "", map[string]string{
+			"challenge_token": login(),
+			"recovery_code":   recovery,
+		})
+		if rec.Code != want {
+			t.Errorf("code de secours, essai %d: statut = %d, attendu %d", i+1, rec.Code, want)
+		}
+	}
+}
+
+func TestDisableTwoFactorRequiresPassword(t *testing.T) {
+	router, store := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+
+	user, err := store.Users.FindByID(registered.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.TOTPSecret, _ = totp.GenerateSecret()
+	user.TOTPEnabled = true
+	if err := store.Users.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/2fa/disable", registered.Token, map[string]string{"password": "mauvais"})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("mauvais mot de passe: statut = %d, attendu 401", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/2fa/recovery-codes", registered.Token, map[string]string{"password": "secret123"})
+	var regenerated struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	testutil.DecodeData(t, rec, &regenerated)
+	if len(regenerated.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("codes régénérés = %v", regenerated.RecoveryCodes)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/2fa/disable", registered.Token, map[string]string{"password": "secret123"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("désactivation: statut = %d, attendu 200", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "jean@example.com",
+		"password": "secret123",
+	})
+	var login models.LoginResponse
+	testutil.DecodeData(t, rec, &login)
+	if login.Token == "" {
+		t.Errorf("connexion sans 2FA = %s, attendu une session", rec.Body.String())
+	}
+}
+
+// tokenPattern extrait le token des liens envoyés par email
+var tokenPattern = regexp.MustCompile(`token=([A-Za-z0-9._-]+)`)
+
+// lastEmailToken retourne le token du dernier email dont le sujet est subject, et vérifie
+// qu'aucune notification consultable par l'utilisateur ne le contient
+func lastEmailToken(t *testing.T, store *repository.Store, userID uint, subject string) string {
+	t.Helper()
+
+	emails, err := store.OutboundEmails.ListByUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifications, err := store.Notifications.ListByUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, email := range emails {
+		if email.Subject != subject {
+			continue
+		}
+		if email.Status != "pending" {
+			t.Errorf("email = %+v, attendu en attente d'envoi", email)
+		}
+		matches := tokenPattern.FindStringSubmatch(email.Body)
+		if matches == nil {
+			continue
+		}
+		for _, notification := range notifications {
+			if strings.Contains(notification.Message, matches[1]) {
+				t.Errorf("notification %d expose le token de l'email %q", notification.ID, subject)
+			}
+		}
+		return matches[1]
+	}
+	t.Fatalf("aucun email %q pour l'utilisateur %d", subject, userID)
+	return ""
+}
+
+func TestEmailVerification(t *testing.T) {
+	router, store := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+	if registered.User.EmailVerifiedAt != nil {
+		t.Fatal("un nouvel utilisateur ne doit pas être vérifié")
+	}
+
+	// Un nouveau lien invalide le précédent
+	first := lastEmailToken(t, store, registered.User.ID, "Vérifiez votre adresse email")
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/resend-verification", registered.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("renvoi: statut = %d, attendu 200", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodPost, "/api/auth/verify-email", "", map[string]string{"token": first}); rec.Code != http.StatusBadRequest {
+		t.Errorf("ancien lien: statut = %d, attendu 400", rec.Code)
+	}
+
+	token := lastEmailToken(t, store, registered.User.ID, "Vérifiez votre adresse email")
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/verify-email", "", map[string]string{"token": token})
+	var verified models.User
+	testutil.DecodeData(t, rec, &verified)
+	if verified.EmailVerifiedAt == nil {
+		t.Error("l'email devrait être vérifié")
+	}
+
+	if rec := testutil.Request(router, http.MethodPost, "/api/auth/verify-email", "", map[string]string{"token": token}); rec.Code != http.StatusBadRequest {
+		t.Errorf("lien réutilisé: statut = %d, attendu 400", rec.Code)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	router, store := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+
+	// Réponse identique pour un email inconnu
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "inconnu@example.com"})
+	if rec.Code != http.StatusOK {
+		t.Errorf("email inconnu: statut = %d, attendu 200", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "jean@example.com"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("demande: statut = %d, attendu 200", rec.Code)
+	}
+	token := lastEmailToken(t, store, registered.User.ID, "Réinitialisation de votre mot de passe")
+
+	// Un token de vérification d'email n'est pas accepté pour réinitialiser le mot de passe
+	verification := lastEmailToken(t, store, registered.User.ID, "Vérifiez votre adresse email")
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/reset-password", "", map[string]string{
+		"token":        verification,
+		"new_password": "nouveau456",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("token d'un autre usage: statut = %d, attendu 400", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/reset-password", "", map[string]string{
+		"token":        token,
+		"new_password": "nouveau456",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("réinitialisation: statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", registered.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("session antérieure: statut = %d, attendu 401", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/reset-password", "", map[string]string{
+		"token":        token,
+		"new_password": "encore789",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("lien réutilisé: statut = %d, attendu 400", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "jean@example.com",
+		"password": "nouveau456",
+	})
+	var login models.LoginResponse
+	testutil.DecodeData(t, rec, &login)
+	if login.User.EmailVerifiedAt == nil {
+		t.Error("la réinitialisation par email devrait vérifier l'adresse")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{6, 8 * time.Second},
+		{40, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.failures, 2); got != tt.want {
+			t.Errorf("backoff(%d) = %s, attendu %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// elapseBackoff recule la date des derniers échecs pour simuler l'écoulement du délai imposé
+func elapseBackoff(t *testing.T, store *repository.Store) {
+	t.Helper()
+
+	err := store.DB.Model(&models.LoginAttempt{}).Where("1 = 1").
+		Update("last_failure_at", time.Now().Add(-time.Minute)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoginBackoffAndLockout(t *testing.T) {
+	router, store := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+
+	attempt := func(password string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+			"email":    "jean@example.com",
+			"password": password,
+		})
+	}
+
+	for i := 1; i <= 3; i++ {
+		if rec := attempt("mauvais"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("échec %d: statut = %d, attendu 401", i, rec.Code)
+		}
+	}
+
+	// Au-delà des échecs tolérés, les tentatives sont ralenties, même avec le bon mot de passe
+	rec := attempt("secret123")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("tentative ralentie: statut = %d, attendu 429 avec Retry-After", rec.Code)
+	}
+
+	elapseBackoff(t, store)
+	if rec := attempt("mauvais"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("échec 4: statut = %d, attendu 401", rec.Code)
+	}
+	elapseBackoff(t, store)
+	if rec := attempt("mauvais"); rec.Code != http.StatusLocked {
+		t.Fatalf("échec 5: statut = %d, attendu 423", rec.Code)
+	}
+
+	user, err := store.Users.FindByID(registered.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.IsLocked(time.Now()) {
+		t.Fatal("le compte devrait être verrouillé")
+	}
+	notifications, err := store.Notifications.ListByUser(user.ID)
+	if err != nil || len(notifications) == 0 || notifications[0].Title != "Compte temporairement verrouillé" {
+		t.Errorf("notifications = %+v, attendu une notification de verrouillage", notifications)
+	}
+
+	if rec := attempt("secret123"); rec.Code != http.StatusLocked {
+		t.Errorf("bon mot de passe sur compte verrouillé: statut = %d, attendu 423", rec.Code)
+	}
+
+	// Seul un administrateur peut déverrouiller le compte
+	_, customerToken := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	unlockPath := fmt.Sprintf("/api/admin/users/%d/unlock", user.ID)
+	if rec := testutil.Request(router, http.MethodPost, unlockPath, customerToken, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("déverrouillage par un client: statut = %d, attendu 403", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodPost, unlockPath, adminToken, nil); rec.Code != http.StatusOK {
+		t.Fatalf("déverrouillage: statut = %d, attendu 200", rec.Code)
+	}
+	if rec := attempt("secret123"); rec.Code != http.StatusOK {
+		t.Errorf("connexion après déverrouillage: statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestSecondFactorFailuresAreNotReset vérifie que le bon mot de passe ne remet pas à zéro les
+// échecs du second facteur : le compte est verrouillé après maxLoginAttempts codes erronés
+func TestSecondFactorFailuresAreNotReset(t *testing.T) {
+	router, store := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+
+	user, err := store.Users.FindByID(registered.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.TOTPSecret, _ = totp.GenerateSecret()
+	user.TOTPEnabled = true
+	if err := store.Users.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		elapseBackoff(t, store)
+		rec := testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+			"email":    "jean@example.com",
+			"password": "secret123",
+		})
+		var challenge models.TwoFactorChallengeResponse
+		testutil.DecodeData(t, rec, &challenge)
+
+		want := http.StatusUnauthorized
+		if i == 5 {
+			want = http.StatusLocked
+		}
+		rec = testutil.Request(router, http.MethodPost, "/api/auth/2fa/verify", "", map[string]string{
+			"challenge_token": challenge.ChallengeToken,
+			"code":            "000000",
+		})
+		if rec.Code != want {
+			t.Fatalf("code erroné %d: statut = %d, attendu %d", i, rec.Code, want)
+		}
+	}
+}
+
+// TestReauthenticationLockout vérifie que la ressaisie du mot de passe est ralentie puis
+// verrouillée comme la connexion
+func TestReauthenticationLockout(t *testing.T) {
+	router, store := setupTestRouter(t)
+	registered := register(t, router, "jean@example.com", "secret123")
+
+	regenerate := func(password string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/auth/2fa/recovery-codes", registered.Token,
+			map[string]string{"password": password})
+	}
+
+	for i := 1; i <= 3; i++ {
+		if rec := regenerate("mauvais"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("échec %d: statut = %d, attendu 401", i, rec.Code)
+		}
+	}
+	if rec := regenerate("secret123"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("tentative ralentie: statut = %d, attendu 429", rec.Code)
+	}
+	for i := 4; i <= 5; i++ {
+		elapseBackoff(t, store)
+		want := http.StatusUnauthorized
+		if i == 5 {
+			want = http.StatusLocked
+		}
+		if rec := regenerate("mauvais"); rec.Code != want {
+			t.Fatalf("échec %d: statut = %d, attendu %d", i, rec.Code, want)
+		}
+	}
+
+	elapseBackoff(t, store)
+	if rec := regenerate("secret123"); rec.Code != http.StatusLocked {
+		t.Errorf("bon mot de passe sur compte verrouillé: statut = %d, attendu 423", rec.Code)
+	}
+}
+
+// TestAdminDeactivateUser vérifie la recherche et la désactivation d'un utilisateur par un administrateur
+func TestAdminDeactivateUser(t *testing.T) {
+	router, store := setupTestRouter(t)
+	registered := register(t, router, "marie@example.com", "secret123")
+	admin, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	_, supportToken := testutil.CreateUserWithRole(t, store, models.RoleSupport)
+
+	rec := testutil.Request(router, http.MethodGet, "/api/admin/users?q=MARIE", supportToken, nil)
+	var users []models.User
+	testutil.DecodeData(t, rec, &users)
+	if len(users) != 1 || users[0].ID != registered.User.ID {
+		t.Fatalf("recherche = %+v, attendu l'utilisateur %d", users, registered.User.ID)
+	}
+
+	deactivatePath := fmt.Sprintf("/api/admin/users/%d/deactivate", registered.User.ID)
+	if rec := testutil.Request(router, http.MethodPost, deactivatePath, supportToken, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("désactivation par le support: statut = %d, attendu 403", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/admin/users/%d/deactivate", admin.ID), adminToken, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("auto-désactivation: statut = %d, attendu 400", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodPost, deactivatePath, adminToken, nil); rec.Code != http.StatusOK {
+		t.Fatalf("désactivation: statut = %d, attendu 200", rec.Code)
+	}
+
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", registered.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("profil après désactivation: statut = %d, attendu 401", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "marie@example.com",
+		"password": "secret123",
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("connexion après désactivation: statut = %d, attendu 401", rec.Code)
+	}
+}
+
+// TestRoleChangeInvalidatesTokens vérifie que les permissions du token suivent le rôle de l'utilisateur
+func TestRoleChangeInvalidatesTokens(t *testing.T) {
+	router, store := setupTestRouter(t)
+	registered := register(t, router, "paul@example.com", "secret123")
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+
+	if rec := testutil.Request(router, http.MethodGet, "/api/admin/users", registered.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("recherche par un client: statut = %d, attendu 403", rec.Code)
+	}
+
+	rolePath := fmt.Sprintf("/api/admin/users/%d/role", registered.User.ID)
+	if rec := testutil.Request(router, http.MethodPut, rolePath, adminToken, map[string]string{"role": "root"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("rôle inconnu: statut = %d, attendu 400", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodPut, rolePath, adminToken, map[string]string{"role": models.RoleSupport}); rec.Code != http.StatusOK {
+		t.Fatalf("changement de rôle: statut = %d, attendu 200", rec.Code)
+	}
+
+	if rec := testutil.Request(router, http.MethodGet, "/api/auth/profile", registered.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("ancien token: statut = %d, attendu 401", rec.Code)
+	}
+
+	rec := testutil.Request(router, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "paul@example.com",
+		"password": "secret123",
+	})
+	var login models.LoginResponse
+	testutil.DecodeData(t, rec, &login)
+	if rec := testutil.Request(router, http.MethodGet, "/api/admin/users", login.Token, nil); rec.Code != http.StatusOK {
+		t.Errorf("recherche par le support: statut = %d, attendu 200", rec.Code)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/notify"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Durées de validité des liens envoyés par email
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+)
+
+// frontendURL retourne l'URL de l'application web utilisée dans les liens (FRONTEND_URL)
+func frontendURL() string {
+	if value := os.Getenv("FRONTEND_URL"); value != "" {
+		return value
+	}
+	return "http://localhost:3000"
+}
+
+// issueUserToken enregistre un token à usage unique et retourne sa forme signée
+func (s *server) issueUserToken(userID uint, purpose string, ttl time.Duration) (string, error) {
+	tokenID, err := utils.GenerateRandomID()
+	if err != nil {
+		return "", err
+	}
+
+	record := models.UserToken{
+		ID:        tokenID,
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.store.UserTokens.Create(&record); err != nil {
+		return "", err
+	}
+	return utils.GeneratePurposeToken(userID, purpose, tokenID, ttl)
+}
+
+// consumeUserToken vérifie la signature d'un token puis le consomme ; retourne l'utilisateur concerné
+func (s *server) consumeUserToken(token, purpose string) (*models.User, error) {
+	userID, tokenID, err := utils.ParsePurposeToken(token, purpose)
+	if err != nil || tokenID == "" {
+		return nil, utils.ErrInvalidToken
+	}
+
+	if err := s.store.UserTokens.Consume(tokenID, userID, purpose, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, utils.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	user, err := s.store.Users.FindByID(userID)
+	if err != nil {
+		return nil, utils.ErrInvalidToken
+	}
+	return user, nil
+}
+
+// sendEmailVerification envoie le lien de vérification d'email par la file d'envoi des emails
+func (s *server) sendEmailVerification(user *models.User) error {
+	token, err := s.issueUserToken(user.ID, models.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := frontendURL() + "/verify-email?token=" + url.QueryEscape(token)
+	return s.notifier.Mail(user.ID, "Vérifiez votre adresse email",
+		fmt.Sprintf("Bonjour %s,\n\nConfirmez votre adresse email en ouvrant ce lien, valable %d heures :\n%s",
+			user.FirstName, int(emailVerificationTTL.Hours()), link))
+}
+
+// forgotPasswordHandler envoie un lien de réinitialisation. La réponse est identique
+// que l'email existe ou non, pour ne pas révéler les comptes enregistrés.
+func (s *server) forgotPasswordHandler(c *gin.Context) {
+	var request struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if user, err := s.store.Users.FindByEmail(request.Email); err == nil && user.IsActive {
+		if err := s.sendPasswordReset(user); err != nil {
+			log.Printf("Erreur lors de l'envoi du lien de réinitialisation à l'utilisateur %d: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Si un compte existe pour cet email, un lien de réinitialisation a été envoyé",
+	})
+}
+
+// sendPasswordReset invalide les liens précédents et envoie un nouveau lien de réinitialisation
+func (s *server) sendPasswordReset(user *models.User) error {
+	if err := s.store.UserTokens.InvalidateForUser(user.ID, models.TokenPurposePasswordReset, time.Now()); err != nil {
+		return err
+	}
+	token, err := s.issueUserToken(user.ID, models.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := frontendURL() + "/reset-password?token=" + url.QueryEscape(token)
+	return s.notifier.Mail(user.ID, "Réinitialisation de votre mot de passe",
+		fmt.Sprintf("Bonjour %s,\n\nPour choisir un nouveau mot de passe, ouvrez ce lien valable %d minutes :\n%s\n\n"+
+			"Si vous n'êtes pas à l'origine de cette demande, ignorez ce message.",
+			user.FirstName, int(passwordResetTTL.Minutes()), link))
+}
+
+// resetPasswordHandler remplace le mot de passe à l'aide d'un lien de réinitialisation
+// et ferme toutes les sessions ouvertes
+func (s *server) resetPasswordHandler(c *gin.Context) {
+	var request struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=6"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, err := s.consumeUserToken(request.Token, models.TokenPurposePasswordReset)
+	if errors.Is(err, utils.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Lien de réinitialisation invalide, expiré ou déjà utilisé",
+			Error:   "Bad Request",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la vérification du lien",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(request.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors du hachage du mot de passe",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	now := time.Now()
+	user.Password = hashedPassword
+	// Le lien prouve la possession de l'email : le verrouillage éventuel est levé
+	user.LockedUntil = nil
+	// Recevoir le lien prouve aussi la possession de l'adresse email
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		if err := tx.Users.Save(user); err != nil {
+			return err
+		}
+		if err := tx.UserTokens.InvalidateForUser(user.ID, models.TokenPurposePasswordReset, now); err != nil {
+			return err
+		}
+		_, err := tx.Sessions.RevokeAllForUser(user.ID, now)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la mise à jour du mot de passe",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	s.resetLoginFailures(user.Email)
+
+	if err := s.notifier.Notify(user.ID, notify.ChannelEmail, "Mot de passe modifié",
+		"Votre mot de passe a été réinitialisé et toutes vos sessions ont été fermées."); err != nil {
+		log.Printf("Erreur lors de la notification de réinitialisation à l'utilisateur %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Mot de passe réinitialisé avec succès, veuillez vous reconnecter",
+	})
+}
+
+// verifyEmailHandler confirme l'adresse email à l'aide du lien de vérification
+func (s *server) verifyEmailHandler(c *gin.Context) {
+	var request struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, err := s.consumeUserToken(request.Token, models.TokenPurposeEmailVerification)
+	if errors.Is(err, utils.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Lien de vérification invalide, expiré ou déjà utilisé",
+			Error:   "Bad Request",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la vérification du lien",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := s.store.Users.Save(user); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Erreur lors de la vérification de l'email",
+				Error:   "Internal Server Error",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Adresse email vérifiée avec succès",
+		Data:    user,
+	})
+}
+
+// resendVerificationHandler renvoie le lien de vérification à l'utilisateur connecté
+func (s *server) resendVerificationHandler(c *gin.Context) {
+	user := s.currentUser(c)
+	if user == nil {
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Adresse email déjà vérifiée",
+			Error:   "Conflict",
+		})
+		return
+	}
+
+	err := s.store.UserTokens.InvalidateForUser(user.ID, models.TokenPurposeEmailVerification, time.Now())
+	if err == nil {
+		err = s.sendEmailVerification(user)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de l'envoi du lien de vérification",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Lien de vérification envoyé",
+	})
+}
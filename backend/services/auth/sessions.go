@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionResponse décrit une session active ; Current marque celle du token utilisé
+type sessionResponse struct {
+	models.AuthSession
+	Current bool `json:"current"`
+}
+
+// issueSession ouvre une session pour user et retourne la paire access/refresh token
+func (s *server) issueSession(c *gin.Context, user models.User) (models.LoginResponse, error) {
+	sessionID, err := utils.GenerateRandomID()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	session := models.AuthSession{
+		ID:               sessionID,
+		UserID:           user.ID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		UserAgent:        truncate(c.Request.UserAgent(), 255),
+		IPAddress:        c.ClientIP(),
+		ExpiresAt:        time.Now().Add(utils.RefreshTokenTTL()),
+	}
+	if err := s.store.Sessions.Create(&session); err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return tokenResponse(user, sessionID, refreshToken)
+}
+
+// tokenResponse génère l'access token de la session et construit la réponse
+func tokenResponse(user models.User, sessionID, refreshToken string) (models.LoginResponse, error) {
+	token, err := utils.GenerateAccessToken(user.ID, sessionID, user.Role, models.PermissionsForRole(user.Role))
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	return models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
+		User:         user,
+	}, nil
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
+
+// refreshHandler échange un refresh token contre une nouvelle paire de tokens.
+// Le refresh token présenté est invalidé ; le rejouer révoque toute la session.
+func (s *server) refreshHandler(c *gin.Context) {
+	var request struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	hash := utils.HashToken(request.RefreshToken)
+
+	session, err := s.store.Sessions.FindByRefreshHash(hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Un token déjà renouvelé qui réapparaît indique un vol : la session est révoquée
+		if reused, err := s.store.Sessions.FindByPreviousHash(hash); err == nil {
+			s.store.Sessions.Revoke(reused.ID, reused.UserID, now)
+		}
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Refresh token invalide",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la vérification de la session",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	if !session.IsActive(now) {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Session expirée ou révoquée",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	user, err := s.store.Users.FindByID(session.UserID)
+	if err != nil || !user.IsActive {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Compte désactivé",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err == nil {
+		err = s.store.Sessions.Rotate(session.ID, hash, utils.HashToken(refreshToken), now)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		// Renouvellement concurrent avec le même token
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Refresh token invalide",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors du renouvellement de la session",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	response, err := tokenResponse(*user, session.ID, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la génération du token",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Session renouvelée",
+		Data:    response,
+	})
+}
+
+// logoutHandler révoque la session du token utilisé
+func (s *server) logoutHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	err := s.store.Sessions.Revoke(c.GetString("session_id"), userID.(uint), time.Now())
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la déconnexion",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Déconnexion réussie",
+	})
+}
+
+// getSessionsHandler liste les sessions actives de l'utilisateur
+func (s *server) getSessionsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	sessions, err := s.store.Sessions.ListActiveByUser(userID.(uint), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des sessions",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	currentID := c.GetString("session_id")
+	response := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, sessionResponse{AuthSession: session, Current: session.ID == currentID})
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Sessions récupérées avec succès",
+		Data:    response,
+	})
+}
+
+// revokeSessionHandler révoque une session de l'utilisateur (déconnexion à distance)
+func (s *server) revokeSessionHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	err := s.store.Sessions.Revoke(c.Param("id"), userID.(uint), time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Session non trouvée",
+			Error:   "Not Found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la révocation de la session",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Session révoquée avec succès",
+	})
+}
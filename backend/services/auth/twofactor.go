@@ -0,0 +1,394 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/totp"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recoveryCodeCount est le nombre de codes de secours générés à chaque enrôlement
+const recoveryCodeCount = 10
+
+// totpIssuer est le nom affiché dans l'application d'authentification (TOTP_ISSUER)
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Banking App"
+}
+
+// generateRecoveryCodes génère des codes de secours au format xxxxx-xxxxx et leurs empreintes
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		bytes := make([]byte, 7)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(bytes))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalise un code de secours (casse, tirets, espaces) puis le hache
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return utils.HashToken(normalized)
+}
+
+// verifySecondFactor vérifie un code TOTP ou, à défaut, consomme un code de secours
+func (s *server) verifySecondFactor(user *models.User, code, recoveryCode string) (bool, error) {
+	now := time.Now()
+
+	if code != "" {
+		step, ok := totp.Validate(user.TOTPSecret, code, now)
+		if !ok {
+			return false, nil
+		}
+		// Un code déjà utilisé (même pas de temps) est refusé
+		err := s.store.Users.AdvanceTOTPStep(user.ID, step)
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	if recoveryCode != "" {
+		err := s.store.RecoveryCodes.Consume(user.ID, hashRecoveryCode(recoveryCode), now)
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return false, nil
+}
+
+// currentUser charge l'utilisateur authentifié ; écrit la réponse d'erreur et retourne nil en cas d'échec
+func (s *server) currentUser(c *gin.Context) *models.User {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return nil
+	}
+
+	user, err := s.store.Users.FindByID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non trouvé",
+			Error:   "Not Found",
+		})
+		return nil
+	}
+	return user
+}
+
+// setupTwoFactorHandler génère un nouveau secret TOTP, actif seulement après confirmation
+func (s *server) setupTwoFactorHandler(c *gin.Context) {
+	user := s.currentUser(c)
+	if user == nil {
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "La double authentification est déjà activée",
+			Error:   "Conflict",
+		})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la génération du secret",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	user.TOTPSecret = secret
+	if err := s.store.Users.Save(user); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de l'enregistrement du secret",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Scannez le QR code puis confirmez avec un code",
+		Data: gin.H{
+			"secret":      secret,
+			"otpauth_uri": totp.URI(totpIssuer(), user.Email, secret),
+		},
+	})
+}
+
+// confirmTwoFactorHandler active la double authentification et retourne les codes de secours
+func (s *server) confirmTwoFactorHandler(c *gin.Context) {
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user := s.currentUser(c)
+	if user == nil {
+		return
+	}
+
+	if user.TOTPEnabled || user.TOTPSecret == "" {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Aucun enrôlement de double authentification en attente",
+			Error:   "Conflict",
+		})
+		return
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, request.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Code de vérification invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err == nil {
+		user.TOTPEnabled = true
+		user.TOTPLastStep = step
+		err = s.store.Transaction(func(tx *repository.Store) error {
+			if err := tx.Users.Save(user); err != nil {
+				return err
+			}
+			return tx.RecoveryCodes.Replace(user.ID, hashes)
+		})
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de l'activation de la double authentification",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Double authentification activée. Conservez ces codes de secours en lieu sûr",
+		Data:    gin.H{"recovery_codes": codes},
+	})
+}
+
+// verifyTwoFactorHandler échange un token de challenge et un code contre une session
+func (s *server) verifyTwoFactorHandler(c *gin.Context) {
+	var request struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil || (request.Code == "" && request.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides : code ou recovery_code requis",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	userID, err := utils.ParseChallengeToken(request.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Token de challenge invalide ou expiré",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	user, err := s.store.Users.FindByID(userID)
+	if err != nil || !user.IsActive || !user.TOTPEnabled {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Token de challenge invalide ou expiré",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	// Les codes erronés comptent comme des échecs de connexion
+	if !s.checkLoginThrottle(c, user.Email) || !checkAccountLock(c, user) {
+		return
+	}
+
+	ok, err := s.verifySecondFactor(user, request.Code, request.RecoveryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la vérification du code",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+	if !ok {
+		locked := s.recordLoginFailure(c, user.Email, user)
+		respondLoginFailure(c, locked, "Code de vérification invalide")
+		return
+	}
+	s.resetLoginFailures(user.Email)
+
+	response, err := s.issueSession(c, *user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la génération du token",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Connexion réussie",
+		Data:    response,
+	})
+}
+
+// passwordRequest est le corps des opérations sensibles qui exigent de ressaisir le mot de passe
+type passwordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// reauthenticate vérifie le mot de passe ressaisi, avec le même ralentissement et le même
+// verrouillage que la connexion ; écrit la réponse d'erreur et retourne nil en cas d'échec
+func (s *server) reauthenticate(c *gin.Context) *models.User {
+	var request passwordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return nil
+	}
+
+	user := s.currentUser(c)
+	if user == nil {
+		return nil
+	}
+
+	// Les mots de passe erronés comptent comme des échecs de connexion
+	if !s.checkLoginThrottle(c, user.Email) || !checkAccountLock(c, user) {
+		return nil
+	}
+	if !utils.CheckPasswordHash(request.Password, user.Password) {
+		locked := s.recordLoginFailure(c, user.Email, user)
+		respondLoginFailure(c, locked, "Mot de passe incorrect")
+		return nil
+	}
+	s.resetLoginFailures(user.Email)
+	return user
+}
+
+// disableTwoFactorHandler désactive la double authentification après ressaisie du mot de passe
+func (s *server) disableTwoFactorHandler(c *gin.Context) {
+	user := s.reauthenticate(c)
+	if user == nil {
+		return
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	err := s.store.Transaction(func(tx *repository.Store) error {
+		if err := tx.Users.Save(user); err != nil {
+			return err
+		}
+		return tx.RecoveryCodes.DeleteForUser(user.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la désactivation de la double authentification",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Double authentification désactivée",
+	})
+}
+
+// regenerateRecoveryCodesHandler remplace les codes de secours après ressaisie du mot de passe
+func (s *server) regenerateRecoveryCodesHandler(c *gin.Context) {
+	user := s.reauthenticate(c)
+	if user == nil {
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "La double authentification n'est pas activée",
+			Error:   "Conflict",
+		})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err == nil {
+		err = s.store.RecoveryCodes.Replace(user.ID, hashes)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la génération des codes de secours",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Nouveaux codes de secours générés, les précédents ne sont plus valides",
+		Data:    gin.H{"recovery_codes": codes},
+	})
+}
@@ -9,6 +9,8 @@ import (
 	"banking-app/shared/database"
 	"banking-app/shared/middleware"
 	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -30,6 +32,30 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	r := newServer(repository.NewStore(database.GetDB())).setupRouter()
+
+	// Démarrage du serveur
+	port := os.Getenv("NOTIFICATIONS_SERVICE_PORT")
+	if port == "" {
+		port = "8083"
+	}
+
+	log.Printf("Service de notifications démarré sur le port %s", port)
+	log.Fatal(http.ListenAndServe(":"+port, r))
+}
+
+// server regroupe les dépendances des handlers du service de notifications
+type server struct {
+	store *repository.Store
+}
+
+// newServer crée le service à partir des dépôts de données
+func newServer(store *repository.Store) *server {
+	return &server{store: store}
+}
+
+// setupRouter configure les routes du service de notifications
+func (s *server) setupRouter() *gin.Engine {
 	r := gin.Default()
 
 	// Middleware
@@ -44,30 +70,23 @@ func main() {
 	})
 
 	// Protected routes
-	r.Use(middleware.AuthMiddleware())
+	r.Use(middleware.AuthMiddleware(s.store.Users, s.store.Sessions))
 
 	// Routes
 	notifications := r.Group("/api/notifications")
 	{
-		notifications.GET("/", getNotificationsHandler)
-		notifications.POST("/", createNotificationHandler)
-		notifications.GET("/:id", getNotificationHandler)
-		notifications.PUT("/:id/read", markAsReadHandler)
-		notifications.DELETE("/:id", deleteNotificationHandler)
+		notifications.GET("/", s.getNotificationsHandler)
+		notifications.POST("/", s.createNotificationHandler)
+		notifications.GET("/:id", s.getNotificationHandler)
+		notifications.PUT("/:id/read", s.markAsReadHandler)
+		notifications.DELETE("/:id", s.deleteNotificationHandler)
 	}
 
-	// Démarrage du serveur
-	port := os.Getenv("NOTIFICATIONS_SERVICE_PORT")
-	if port == "" {
-		port = "8083"
-	}
-
-	log.Printf("Service de notifications démarré sur le port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	return r
 }
 
-// getNotificationsHandler récupère toutes les notifications de l'utilisateur
-func getNotificationsHandler(c *gin.Context) {
+// getNotificationsHandler récupère une page des notifications de l'utilisateur
+func (s *server) getNotificationsHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -78,10 +97,18 @@ func getNotificationsHandler(c *gin.Context) {
 		return
 	}
 
-	var notifications []models.Notification
-	if err := database.GetDB().Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Find(&notifications).Error; err != nil {
+	page, err := utils.ParsePageRequest(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	notifications, err := s.store.Notifications.ListPage(userID.(uint), page)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la récupération des notifications",
@@ -90,15 +117,19 @@ func getNotificationsHandler(c *gin.Context) {
 		return
 	}
 
+	notifications, info := utils.NewPage(notifications, page, func(notification models.Notification) utils.Cursor {
+		return utils.Cursor{CreatedAt: notification.CreatedAt, ID: notification.ID}
+	})
 	c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Message: "Notifications récupérées avec succès",
-		Data:    notifications,
+		Success:    true,
+		Message:    "Notifications récupérées avec succès",
+		Data:       notifications,
+		Pagination: &info,
 	})
 }
 
 // createNotificationHandler crée une nouvelle notification
-func createNotificationHandler(c *gin.Context) {
+func (s *server) createNotificationHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -152,7 +183,7 @@ func createNotificationHandler(c *gin.Context) {
 		Status:  "pending",
 	}
 
-	if err := database.GetDB().Create(&notification).Error; err != nil {
+	if err := s.store.Notifications.Create(&notification); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la création de la notification",
@@ -184,7 +215,7 @@ func createNotificationHandler(c *gin.Context) {
 }
 
 // getNotificationHandler récupère une notification spécifique
-func getNotificationHandler(c *gin.Context) {
+func (s *server) getNotificationHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -206,9 +237,8 @@ func getNotificationHandler(c *gin.Context) {
 		return
 	}
 
-	var notification models.Notification
-	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).
-		First(&notification).Error; err != nil {
+	notification, err := s.store.Notifications.FindForUser(uint(id), userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Notification non trouvée",
@@ -225,7 +255,7 @@ func getNotificationHandler(c *gin.Context) {
 }
 
 // markAsReadHandler marque une notification comme lue
-func markAsReadHandler(c *gin.Context) {
+func (s *server) markAsReadHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -248,9 +278,8 @@ func markAsReadHandler(c *gin.Context) {
 	}
 
 	// Vérifier que la notification existe et appartient à l'utilisateur
-	var notification models.Notification
-	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).
-		First(&notification).Error; err != nil {
+	notification, err := s.store.Notifications.FindForUser(uint(id), userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Notification non trouvée",
@@ -261,7 +290,7 @@ func markAsReadHandler(c *gin.Context) {
 
 	// Marquer comme lue (pour cet exemple, on change le statut)
 	notification.Status = "read"
-	if err := database.GetDB().Save(&notification).Error; err != nil {
+	if err := s.store.Notifications.Save(notification); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la mise à jour de la notification",
@@ -278,7 +307,7 @@ func markAsReadHandler(c *gin.Context) {
 }
 
 // deleteNotificationHandler supprime une notification
-func deleteNotificationHandler(c *gin.Context) {
+func (s *server) deleteNotificationHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -301,9 +330,8 @@ func deleteNotificationHandler(c *gin.Context) {
 	}
 
 	// Vérifier que la notification existe et appartient à l'utilisateur
-	var notification models.Notification
-	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).
-		First(&notification).Error; err != nil {
+	notification, err := s.store.Notifications.FindForUser(uint(id), userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Notification non trouvée",
@@ -313,7 +341,7 @@ func deleteNotificationHandler(c *gin.Context) {
 	}
 
 	// Supprimer la notification
-	if err := database.GetDB().Delete(&notification).Error; err != nil {
+	if err := s.store.Notifications.Delete(notification); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Erreur lors de la suppression de la notification",
@@ -328,8 +356,8 @@ func deleteNotificationHandler(c *gin.Context) {
 	})
 }
 
-// Helper function pour créer des notifications système
-func CreateSystemNotification(userID uint, title, message string) error {
+// createSystemNotification crée une notification système déjà envoyée
+func (s *server) createSystemNotification(userID uint, title, message string) error {
 	notification := models.Notification{
 		UserID:  userID,
 		Type:    "system",
@@ -338,5 +366,5 @@ func CreateSystemNotification(userID uint, title, message string) error {
 		Status:  "sent",
 	}
 
-	return database.GetDB().Create(&notification).Error
+	return s.store.Notifications.Create(&notification)
 }
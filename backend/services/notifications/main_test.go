@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"banking-app/shared/models"
+	"banking-app/shared/notify"
+	"banking-app/shared/repository"
+	"banking-app/shared/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTestRouter crée une base SQLite de test et retourne le routeur du service
+func setupTestRouter(t *testing.T) (*gin.Engine, *repository.Store) {
+	t.Helper()
+
+	store := testutil.NewStore(t)
+	return newServer(store).setupRouter(), store
+}
+
+func TestCreateListAndReadNotification(t *testing.T) {
+	router, store := setupTestRouter(t)
+	_, token := testutil.CreateUser(t, store)
+
+	rec := testutil.Request(router, http.MethodPost, "/api/notifications/", token, map[string]string{
+		"type":    "push",
+		"title":   "Virement reçu",
+		"message": "Vous avez reçu 10,00 EUR",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	var created models.Notification
+	testutil.DecodeData(t, rec, &created)
+
+	rec = testutil.Request(router, http.MethodGet, "/api/notifications/", token, nil)
+	var notifications []models.Notification
+	testutil.DecodeData(t, rec, &notifications)
+	if len(notifications) != 1 || notifications[0].ID != created.ID {
+		t.Fatalf("notifications = %+v", notifications)
+	}
+
+	rec = testutil.Request(router, http.MethodPut, fmt.Sprintf("/api/notifications/%d/read", created.ID), token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("lecture: statut = %d, attendu 200", rec.Code)
+	}
+	notification, err := store.Notifications.FindForUser(created.ID, created.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notification.Status != "read" {
+		t.Errorf("statut = %q, attendu read", notification.Status)
+	}
+}
+
+func TestCreateNotificationRejectsInvalidType(t *testing.T) {
+	router, store := setupTestRouter(t)
+	_, token := testutil.CreateUser(t, store)
+
+	rec := testutil.Request(router, http.MethodPost, "/api/notifications/", token, map[string]string{
+		"type":    "pigeon",
+		"title":   "Titre",
+		"message": "Message",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("statut = %d, attendu 400", rec.Code)
+	}
+}
+
+func TestNotificationsAreScopedToOwner(t *testing.T) {
+	router, store := setupTestRouter(t)
+	_, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+
+	notification := models.Notification{UserID: other.ID, Type: "system", Title: "Privé", Message: "Privé"}
+	if err := store.Notifications.Create(&notification); err != nil {
+		t.Fatal(err)
+	}
+
+	path := fmt.Sprintf("/api/notifications/%d", notification.ID)
+	if rec := testutil.Request(router, http.MethodGet, path, token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("GET: statut = %d, attendu 404", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodDelete, path, token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE: statut = %d, attendu 404", rec.Code)
+	}
+}
+
+func TestSecretEmailsAreNotListed(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+
+	notifier := notify.NewStoreNotifier(store)
+	if err := notifier.Mail(user.ID, "Réinitialisation de votre mot de passe",
+		"Ouvrez ce lien : https://app.example.com/reset-password?token=jeton-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(user.ID, notify.ChannelEmail, "Mot de passe modifié", "Votre mot de passe a été modifié"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := testutil.Request(router, http.MethodGet, "/api/notifications/", token, nil)
+	if strings.Contains(rec.Body.String(), "jeton-secret") {
+		t.Fatalf("GET /api/notifications expose le token: %s", rec.Body.String())
+	}
+	var notifications []models.Notification
+	testutil.DecodeData(t, rec, &notifications)
+	if len(notifications) != 1 || notifications[0].Title != "Mot de passe modifié" {
+		t.Errorf("notifications = %+v, attendu la seule notification sans secret", notifications)
+	}
+
+	emails, err := store.OutboundEmails.ListByUser(user.ID)
+	if err != nil || len(emails) != 1 || !strings.Contains(emails[0].Body, "jeton-secret") {
+		t.Errorf("emails = %+v, attendu le lien dans la file d'envoi", emails)
+	}
+}
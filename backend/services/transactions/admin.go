@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"banking-app/shared/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminGetTransactionHandler récupère une transaction quelconque
+func (s *server) adminGetTransactionHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de transaction invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	transaction, err := s.store.Transactions.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Transaction non trouvée",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Transaction récupérée avec succès",
+		Data:    transaction,
+	})
+}
+
+// adminGetAccountTransactionsHandler récupère les transactions d'un compte quelconque
+func (s *server) adminGetAccountTransactionsHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("accountId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de compte invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	account, err := s.store.Accounts.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	filter, page, err := parseListRequest(c, account.Currency)
+	if err != nil {
+		respondWithError(c, err, "Paramètres de recherche invalides")
+		return
+	}
+	filter.AccountID = account.ID
+
+	s.listTransactions(c, filter, page, "Transactions du compte récupérées avec succès")
+}
+
+// adminReverseTransactionHandler annule tout ou partie d'une transaction quelconque
+func (s *server) adminReverseTransactionHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	s.handleReversal(c, userID.(uint), func(*models.Transaction) error {
+		return nil
+	}, "Transaction annulée avec succès")
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// handlerError est une erreur métier levée dans une transaction de base de données
+// et convertie en réponse HTTP par respondWithError ; cause conserve l'erreur d'origine
+type handlerError struct {
+	status  int
+	message string
+	cause   error
+	code    string // code d'erreur métier, à défaut le libellé du statut HTTP
+}
+
+func (e *handlerError) Error() string {
+	return e.message
+}
+
+func (e *handlerError) Unwrap() error {
+	return e.cause
+}
+
+// newHandlerError crée une erreur métier associée à un code HTTP
+func newHandlerError(status int, message string) error {
+	return &handlerError{status: status, message: message}
+}
+
+// respondWithError écrit la réponse correspondant à err, ou une erreur 500 avec le message par défaut
+func respondWithError(c *gin.Context, err error, defaultMessage string) {
+	var herr *handlerError
+	if errors.As(err, &herr) {
+		code := herr.code
+		if code == "" {
+			code = http.StatusText(herr.status)
+		}
+		c.JSON(herr.status, models.APIResponse{
+			Success: false,
+			Message: herr.message,
+			Error:   code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Message: defaultMessage,
+		Error:   "Internal Server Error",
+	})
+}
+
+// parseAmount convertit le montant de la requête dans la devise du compte et vérifie qu'il est positif
+func parseAmount(value json.Number, currency string) (models.Money, error) {
+	amount, err := models.ParseMoney(value.String(), currency)
+	if err != nil {
+		return models.Money{}, newHandlerError(http.StatusBadRequest, "Montant invalide: "+err.Error())
+	}
+	if !amount.IsPositive() {
+		return models.Money{}, newHandlerError(http.StatusBadRequest, "Le montant doit être strictement positif")
+	}
+	return amount, nil
+}
+
+// postEntry enregistre une écriture au grand livre et convertit les erreurs métier en réponses HTTP
+func postEntry(tx *gorm.DB, entry *models.JournalEntry) error {
+	err := ledger.Post(tx, entry)
+	switch {
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return &handlerError{status: http.StatusBadRequest, message: "Solde insuffisant", cause: err}
+	case errors.Is(err, ledger.ErrAccountCurrency):
+		return &handlerError{status: http.StatusBadRequest, message: "La devise ne correspond pas à celle du compte", cause: err}
+	}
+	return err
+}
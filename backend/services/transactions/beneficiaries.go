@@ -0,0 +1,389 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"banking-app/shared/models"
+	"banking-app/shared/notify"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxNicknameLength borne la longueur du surnom d'un bénéficiaire
+const maxNicknameLength = 50
+
+// beneficiaryCoolingOff est le délai de carence d'un nouveau bénéficiaire
+// (BENEFICIARY_COOLING_OFF, 24 h par défaut, 0 pour le désactiver)
+func beneficiaryCoolingOff() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("BENEFICIARY_COOLING_OFF")); err == nil && value >= 0 {
+		return value
+	}
+	return 24 * time.Hour
+}
+
+// coolingOffLimit est le montant maximal d'un virement vers un bénéficiaire en délai de
+// carence, dans la devise du compte débité (BENEFICIARY_COOLING_OFF_LIMIT, 1000 par défaut)
+func coolingOffLimit(currency string) models.Money {
+	if limit, err := models.ParseMoney(os.Getenv("BENEFICIARY_COOLING_OFF_LIMIT"), currency); err == nil && !limit.IsNegative() {
+		return limit
+	}
+	limit, _ := models.ParseMoney("1000", currency)
+	return limit
+}
+
+// checkCoolingOff refuse un virement de plus de coolingOffLimit vers un bénéficiaire dont le
+// délai de carence court encore ; beneficiary vaut nil pour un virement sans bénéficiaire
+func checkCoolingOff(beneficiary *models.Beneficiary, amount models.Money, now time.Time) error {
+	if beneficiary == nil || !now.Before(beneficiary.TrustedAt) {
+		return nil
+	}
+	limit := coolingOffLimit(amount.Currency)
+	if cmp, err := amount.Cmp(limit); err != nil || cmp <= 0 {
+		return err
+	}
+	return newHandlerError(http.StatusForbidden, fmt.Sprintf(
+		"Bénéficiaire ajouté récemment : les virements de plus de %s vers ce bénéficiaire seront possibles à partir du %s",
+		limit, beneficiary.TrustedAt.UTC().Format("02/01/2006 15:04 UTC")))
+}
+
+// payeeBeneficiary retourne le bénéficiaire désigné par le virement ou, à défaut, celui que le
+// client a enregistré pour le compte destination : un virement par IBAN, programmé ou groupé
+// vers un bénéficiaire récent reste soumis à son délai de carence. nil si le compte n'est
+// pas un bénéficiaire du client.
+func payeeBeneficiary(store *repository.Store, userID uint, toAccount *models.Account, designated *models.Beneficiary) (*models.Beneficiary, error) {
+	if designated != nil {
+		return designated, nil
+	}
+	beneficiary, err := store.Beneficiaries.FindByIBAN(userID, toAccount.AccountNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return beneficiary, err
+}
+
+// resolveTransferDestination retourne le compte destination d'un virement, désigné par un
+// bénéficiaire de l'utilisateur, par son identifiant ou par son IBAN
+func (s *server) resolveTransferDestination(userID, beneficiaryID, toAccountID uint, toIBAN string) (uint, *models.Beneficiary, error) {
+	if beneficiaryID == 0 {
+		toAccountID, err := s.resolveDestination(toAccountID, toIBAN)
+		return toAccountID, nil, err
+	}
+	if toAccountID != 0 || toIBAN != "" {
+		return 0, nil, newHandlerError(http.StatusBadRequest, "Indiquez le compte destination par beneficiary_id, to_account_id ou to_iban")
+	}
+	beneficiary, err := s.store.Beneficiaries.FindForUser(beneficiaryID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, nil, newHandlerError(http.StatusNotFound, "Bénéficiaire non trouvé")
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return beneficiary.AccountID, beneficiary, nil
+}
+
+// beneficiaryCursor retourne la position d'un bénéficiaire dans une liste paginée
+func beneficiaryCursor(beneficiary models.Beneficiary) utils.Cursor {
+	return utils.Cursor{CreatedAt: beneficiary.CreatedAt, ID: beneficiary.ID}
+}
+
+// beneficiaryID lit l'identifiant :id ; écrit la réponse d'erreur et retourne false s'il est invalide
+func beneficiaryID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de bénéficiaire invalide",
+			Error:   "Bad Request",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseNickname valide le surnom d'un bénéficiaire
+func parseNickname(nickname string) (string, error) {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" || utf8.RuneCountInString(nickname) > maxNicknameLength {
+		return "", newHandlerError(http.StatusBadRequest, fmt.Sprintf("Le surnom doit comporter de 1 à %d caractères", maxNicknameLength))
+	}
+	return nickname, nil
+}
+
+// newBeneficiary vérifie le compte désigné par iban, qui doit être un compte actif de la
+// banque que l'utilisateur n'a pas déjà enregistré. Le délai de carence ne s'applique pas
+// aux comptes de l'utilisateur lui-même.
+func (s *server) newBeneficiary(userID uint, nickname, iban string, now time.Time) (*models.Beneficiary, error) {
+	nickname, err := parseNickname(nickname)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateIBAN(iban); err != nil {
+		return nil, newHandlerError(http.StatusBadRequest, err.Error())
+	}
+	iban = utils.NormalizeIBAN(iban)
+
+	account, err := s.store.Accounts.FindByNumber(iban)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, newHandlerError(http.StatusNotFound, "Aucun compte de la banque ne correspond à cet IBAN")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if account.Status != "active" {
+		return nil, newHandlerError(http.StatusBadRequest, "Le compte du bénéficiaire n'est pas actif")
+	}
+	if _, err := s.store.Beneficiaries.FindByIBAN(userID, iban); err == nil {
+		return nil, newHandlerError(http.StatusConflict, "Ce bénéficiaire est déjà enregistré")
+	}
+
+	trustedAt := now.Add(beneficiaryCoolingOff())
+	if account.UserID == userID {
+		trustedAt = now
+	}
+	return &models.Beneficiary{
+		UserID:    userID,
+		Nickname:  nickname,
+		IBAN:      iban,
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		TrustedAt: trustedAt,
+	}, nil
+}
+
+// listBeneficiariesHandler récupère une page des bénéficiaires de l'utilisateur
+func (s *server) listBeneficiariesHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	page, err := utils.ParsePageRequest(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	beneficiaries, err := s.store.Beneficiaries.ListPage(userID.(uint), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des bénéficiaires",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	beneficiaries, info := utils.NewPage(beneficiaries, page, beneficiaryCursor)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success:    true,
+		Message:    "Bénéficiaires récupérés avec succès",
+		Data:       beneficiaries,
+		Pagination: &info,
+	})
+}
+
+// createBeneficiaryHandler enregistre un bénéficiaire désigné par son IBAN. L'utilisateur
+// est averti par email de tout nouvel ajout.
+func (s *server) createBeneficiaryHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	var request struct {
+		Nickname string `json:"nickname" binding:"required"`
+		IBAN     string `json:"iban" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	beneficiary, err := s.newBeneficiary(userID.(uint), request.Nickname, request.IBAN, time.Now())
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de l'enregistrement du bénéficiaire")
+		return
+	}
+	if err := s.store.Beneficiaries.Create(beneficiary); err != nil {
+		// Un ajout concurrent du même IBAN se heurte à l'index unique
+		if _, findErr := s.store.Beneficiaries.FindByIBAN(beneficiary.UserID, beneficiary.IBAN); findErr == nil {
+			err = newHandlerError(http.StatusConflict, "Ce bénéficiaire est déjà enregistré")
+		}
+		respondWithError(c, err, "Erreur lors de l'enregistrement du bénéficiaire")
+		return
+	}
+	s.notifyBeneficiary(beneficiary)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Bénéficiaire enregistré avec succès",
+		Data:    beneficiary,
+	})
+}
+
+// notifyBeneficiary avertit l'utilisateur de l'ajout d'un bénéficiaire
+func (s *server) notifyBeneficiary(beneficiary *models.Beneficiary) {
+	message := fmt.Sprintf("Le bénéficiaire « %s » (IBAN %s) a été ajouté à votre compte le %s. "+
+		"Si vous n'êtes pas à l'origine de cet ajout, supprimez-le et changez votre mot de passe.",
+		beneficiary.Nickname, beneficiary.IBAN, beneficiary.CreatedAt.UTC().Format("02/01/2006 à 15:04 UTC"))
+	if err := s.notifier.Notify(beneficiary.UserID, notify.ChannelEmail, "Nouveau bénéficiaire", message); err != nil {
+		log.Printf("Erreur lors de la notification du bénéficiaire %d: %v", beneficiary.ID, err)
+	}
+}
+
+// getBeneficiaryHandler récupère un bénéficiaire de l'utilisateur
+func (s *server) getBeneficiaryHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	id, ok := beneficiaryID(c)
+	if !ok {
+		return
+	}
+	beneficiary, err := s.store.Beneficiaries.FindForUser(id, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Bénéficiaire non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Bénéficiaire récupéré avec succès",
+		Data:    beneficiary,
+	})
+}
+
+// updateBeneficiaryHandler renomme un bénéficiaire. L'IBAN ne se modifie pas : un autre
+// compte est un nouveau bénéficiaire, soumis à son propre délai de carence.
+func (s *server) updateBeneficiaryHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	id, ok := beneficiaryID(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Nickname string `json:"nickname" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	beneficiary, err := s.store.Beneficiaries.FindForUser(id, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Bénéficiaire non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+	if beneficiary.Nickname, err = parseNickname(request.Nickname); err == nil {
+		err = s.store.Beneficiaries.Save(beneficiary)
+	}
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la mise à jour du bénéficiaire")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Bénéficiaire mis à jour avec succès",
+		Data:    beneficiary,
+	})
+}
+
+// deleteBeneficiaryHandler supprime un bénéficiaire ; les virements passés sont conservés
+func (s *server) deleteBeneficiaryHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	id, ok := beneficiaryID(c)
+	if !ok {
+		return
+	}
+	beneficiary, err := s.store.Beneficiaries.FindForUser(id, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Bénéficiaire non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+	if err := s.store.Beneficiaries.Delete(beneficiary); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la suppression du bénéficiaire",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Bénéficiaire supprimé avec succès",
+	})
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/limits"
+	"banking-app/shared/models"
+	"banking-app/shared/pain"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBulkFileSize borne la taille d'un fichier de virements
+const maxBulkFileSize = 5 << 20
+
+// errBatchRejected annule un lot atomique dont un virement a été refusé
+var errBatchRejected = errors.New("lot refusé")
+
+// bulkInstruction est un virement validé, prêt à être exécuté
+type bulkInstruction struct {
+	item          *models.PaymentBatchItem
+	fromAccountID uint
+	toAccountID   uint
+}
+
+// readBulkFile lit le fichier remis, en pièce jointe (champ file) ou en corps de requête
+func readBulkFile(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBulkFileSize)
+	if c.ContentType() == "multipart/form-data" {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+	return io.ReadAll(c.Request.Body)
+}
+
+// parseBulkFile lit un message pain.001 ou la variante CSV. Un CSV n'ayant pas d'identifiant
+// de message, il est identifié par message_id ou, à défaut, par l'empreinte de son contenu.
+func parseBulkFile(content []byte, messageID string) (*pain.Batch, error) {
+	if pain.IsXML(content) {
+		return pain.ParsePain001(bytes.NewReader(content))
+	}
+	if messageID == "" {
+		sum := sha256.Sum256(content)
+		messageID = "CSV-" + hex.EncodeToString(sum[:])[:31]
+	}
+	return pain.ParseCSV(bytes.NewReader(content), messageID)
+}
+
+// validateInstruction contrôle un virement du fichier avant toute exécution : comptes
+// débité (du client) et crédité (de la banque) actifs, devise, montant et date d'exécution.
+// Le virement est retourné refusé, avec son motif ISO 20022, ou prêt à être exécuté.
+func (s *server) validateInstruction(userID uint, instruction pain.Instruction, position int, today time.Time, seen map[string]bool) bulkInstruction {
+	item := &models.PaymentBatchItem{
+		Position:      position,
+		PaymentInfoID: instruction.PaymentInfoID,
+		InstructionID: instruction.InstructionID,
+		EndToEndID:    instruction.EndToEndID,
+		DebtorIBAN:    utils.NormalizeIBAN(instruction.DebtorIBAN),
+		CreditorIBAN:  utils.NormalizeIBAN(instruction.CreditorIBAN),
+		CreditorName:  instruction.CreditorName,
+		Currency:      instruction.Currency,
+		Description:   instruction.Remittance,
+		Status:        models.PaymentStatusPending,
+	}
+	result := bulkInstruction{item: item}
+	reject := func(code, info string) bulkInstruction {
+		item.Status, item.ReasonCode, item.ReasonInfo = models.PaymentStatusRejected, code, info
+		return result
+	}
+
+	if seen[instruction.EndToEndID] {
+		return reject(pain.ReasonDuplicate, "Référence de bout en bout en double dans le fichier")
+	}
+	seen[instruction.EndToEndID] = true
+
+	if utils.ValidateIBAN(item.DebtorIBAN) != nil {
+		return reject(pain.ReasonIncorrectAccount, "IBAN du compte débité invalide")
+	}
+	from, err := s.store.Accounts.FindByNumber(item.DebtorIBAN)
+	if err != nil || from.UserID != userID {
+		return reject(pain.ReasonIncorrectAccount, "Compte débité inconnu")
+	}
+	if code, info := accountRejection(from, "débité"); code != "" {
+		return reject(code, info)
+	}
+	if item.Currency == "" {
+		item.Currency = from.Currency
+	}
+	if item.Currency != from.Currency {
+		return reject(pain.ReasonCurrency, "La devise du virement doit être celle du compte débité ("+from.Currency+")")
+	}
+	amount, err := models.ParseMoney(instruction.Amount, item.Currency)
+	if err != nil || !amount.IsPositive() {
+		return reject(pain.ReasonInvalidAmount, "Montant invalide: "+instruction.Amount)
+	}
+	item.Amount = amount
+
+	if utils.ValidateIBAN(item.CreditorIBAN) != nil {
+		return reject(pain.ReasonIncorrectAccount, "IBAN du bénéficiaire invalide")
+	}
+	to, err := s.store.Accounts.FindByNumber(item.CreditorIBAN)
+	if err != nil {
+		return reject(pain.ReasonIncorrectAccount, "Aucun compte de la banque ne correspond à l'IBAN du bénéficiaire")
+	}
+	if code, info := accountRejection(to, "crédité"); code != "" {
+		return reject(code, info)
+	}
+	if to.ID == from.ID {
+		return reject(pain.ReasonNarrative, "Impossible de transférer vers le même compte")
+	}
+
+	if instruction.RequestedDate != "" {
+		requested, err := pain.ParseDate(instruction.RequestedDate)
+		if err != nil {
+			return reject(pain.ReasonInvalidDate, "Date d'exécution invalide: "+instruction.RequestedDate)
+		}
+		if requested.After(today) {
+			return reject(pain.ReasonInvalidDate, "Exécution différée non prise en charge, utilisez un virement programmé")
+		}
+	}
+
+	result.fromAccountID, result.toAccountID = from.ID, to.ID
+	return result
+}
+
+// accountRejection retourne le motif de refus d'un compte qui n'est pas actif
+func accountRejection(account *models.Account, role string) (string, string) {
+	switch account.Status {
+	case "active":
+		return "", ""
+	case "closed":
+		return pain.ReasonClosedAccount, "Le compte " + role + " est clôturé"
+	}
+	return pain.ReasonBlockedAccount, "Le compte " + role + " n'est pas actif"
+}
+
+// executeInstruction soumet au contrôle anti-fraude puis exécute un virement validé dans la
+// transaction tx
+func (s *server) executeInstruction(tx *repository.Store, userID uint, instruction bulkInstruction) error {
+	item := instruction.item
+	reference, err := utils.GenerateTransactionReference()
+	if err != nil {
+		return err
+	}
+	description := item.Description
+	if description == "" && item.CreditorName != "" {
+		description = "Virement à " + item.CreditorName
+	}
+
+	result, err := s.screenedTransfer(tx, transferOrder{
+		UserID:        userID,
+		FromAccountID: instruction.fromAccountID,
+		ToAccountID:   instruction.toAccountID,
+		Amount:        json.Number(item.Amount.Decimal()),
+		Description:   description,
+		Reference:     reference,
+		Batch:         fmt.Sprintf("LOT-%d", item.BatchID),
+	})
+	if err != nil {
+		return err
+	}
+	item.Status = models.PaymentStatusCompleted
+	item.Reference = reference
+	item.DebitTransactionID = &result.Debit.ID
+	return nil
+}
+
+// rejectionReason convertit l'échec de l'exécution d'un virement en motif ISO 20022
+func rejectionReason(err error) (string, string) {
+	var exceeded *limits.ExceededError
+	var held *heldTransferError
+	var herr *handlerError
+	switch {
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return pain.ReasonInsufficientFunds, "Solde insuffisant"
+	case errors.As(err, &exceeded):
+		return pain.ReasonNotAllowedAmount, exceeded.Error()
+	case errors.As(err, &held):
+		return pain.ReasonTransactionDenied, held.Error()
+	case errors.As(err, &herr):
+		return pain.ReasonNarrative, herr.message
+	}
+	return pain.ReasonNarrative, "Erreur technique lors de l'exécution du virement"
+}
+
+// createBulkPaymentHandler importe un fichier de virements : message pain.001 ou variante
+// CSV (colonnes debtor_iban, creditor_iban, amount, et optionnellement creditor_name, currency,
+// end_to_end_id, remittance_info, execution_date). Tous les virements sont contrôlés avant
+// toute exécution. En mode atomic (par défaut), le lot est exécuté en une seule transaction et
+// le moindre refus l'annule entièrement ; en mode per_item, chaque virement valide est exécuté
+// isolément. La réponse est le compte rendu du lot, en JSON ou, avec report=pain002, au format
+// pain.002. Un même identifiant de message ne peut être remis qu'une fois.
+func (s *server) createBulkPaymentHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	mode := c.DefaultQuery("mode", models.BatchModeAtomic)
+	if mode != models.BatchModeAtomic && mode != models.BatchModePerItem {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Mode invalide. Modes valides: atomic, per_item",
+			Error:   "Bad Request",
+		})
+		return
+	}
+	reportFormat := c.DefaultQuery("report", "json")
+	if reportFormat != "json" && reportFormat != "pain002" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Format de compte rendu invalide. Formats valides: json, pain002",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	content, err := readBulkFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Fichier illisible ou trop volumineux (5 Mo au plus)",
+			Error:   "Bad Request",
+		})
+		return
+	}
+	file, err := parseBulkFile(content, c.Query("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   "Bad Request",
+		})
+		return
+	}
+	if len(file.MessageID) > 35 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "L'identifiant du message dépasse 35 caractères",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	if _, err := s.store.PaymentBatches.FindByMessageID(userID.(uint), file.MessageID); err == nil {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Ce fichier a déjà été remis (identifiant de message " + file.MessageID + ")",
+			Error:   "Conflict",
+		})
+		return
+	}
+
+	// Tous les virements sont contrôlés avant la moindre exécution
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	seen := map[string]bool{}
+	instructions := make([]bulkInstruction, 0, len(file.Instructions))
+	batch := &models.PaymentBatch{
+		UserID:      userID.(uint),
+		MessageID:   file.MessageID,
+		MessageName: file.MessageName,
+		Mode:        mode,
+		Status:      models.PaymentStatusPending,
+		NbOfTxs:     file.NbOfTxs,
+		ControlSum:  file.ControlSum,
+	}
+	for i, instruction := range file.Instructions {
+		validated := s.validateInstruction(userID.(uint), instruction, i+1, today, seen)
+		instructions = append(instructions, validated)
+		batch.Items = append(batch.Items, *validated.item)
+	}
+
+	// L'enregistrement du lot réserve son identifiant de message avant toute exécution
+	if err := s.store.PaymentBatches.Create(batch); err != nil {
+		if _, findErr := s.store.PaymentBatches.FindByMessageID(userID.(uint), file.MessageID); findErr == nil {
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Message: "Ce fichier a déjà été remis (identifiant de message " + file.MessageID + ")",
+				Error:   "Conflict",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de l'enregistrement du lot",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+	for i := range instructions {
+		instructions[i].item = &batch.Items[i]
+	}
+
+	if mode == models.BatchModeAtomic {
+		err = s.executeAtomicBatch(userID.(uint), batch, instructions)
+	} else {
+		err = s.executePerItemBatch(userID.(uint), batch, instructions)
+	}
+	if err != nil {
+		log.Printf("Erreur lors de l'exécution du lot %d: %v", batch.ID, err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de l'exécution du lot",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	status, message := http.StatusCreated, "Lot de virements exécuté"
+	switch batch.Status {
+	case models.PaymentStatusPartial:
+		message = "Lot de virements partiellement exécuté"
+	case models.PaymentStatusRejected:
+		status, message = http.StatusUnprocessableEntity, "Lot de virements refusé"
+	}
+	s.respondWithBatch(c, status, message, batch, reportFormat)
+}
+
+// executeAtomicBatch exécute tous les virements du lot dans une seule transaction, ou aucun :
+// un virement refusé au contrôle ou à l'exécution fait refuser le lot entier. Un virement
+// retenu par le contrôle anti-fraude ne peut alors plus être libéré : sa décision est
+// enregistrée rejetée.
+func (s *server) executeAtomicBatch(userID uint, batch *models.PaymentBatch, instructions []bulkInstruction) error {
+	var failed *models.PaymentBatchItem
+	var held *heldTransferError
+	for _, instruction := range instructions {
+		if instruction.item.Status == models.PaymentStatusRejected {
+			failed = instruction.item
+			break
+		}
+	}
+
+	if failed == nil {
+		err := s.store.Transaction(func(tx *repository.Store) error {
+			for _, instruction := range instructions {
+				if err := s.executeInstruction(tx, userID, instruction); err != nil {
+					failed = instruction.item
+					failed.Status = models.PaymentStatusRejected
+					failed.ReasonCode, failed.ReasonInfo = rejectionReason(err)
+					errors.As(err, &held)
+					return errBatchRejected
+				}
+			}
+			return finishBatch(tx, batch)
+		})
+		if !errors.Is(err, errBatchRejected) {
+			return err
+		}
+	}
+
+	// Lot refusé : aucun virement n'a été exécuté
+	for i := range batch.Items {
+		item := &batch.Items[i]
+		if item != failed && item.Status != models.PaymentStatusRejected {
+			item.ReasonCode = pain.ReasonNarrative
+			item.ReasonInfo = "Lot refusé : le virement " + failed.EndToEndID + " n'a pas pu être exécuté"
+		}
+		item.Status = models.PaymentStatusRejected
+		item.Reference, item.DebitTransactionID = "", nil
+	}
+	return s.store.Transaction(func(tx *repository.Store) error {
+		if held != nil {
+			held.decision.Status = models.FraudStatusRejected
+			held.decision.ReviewNote = "Lot refusé : virement non exécutable isolément"
+			if err := tx.Fraud.Create(held.decision); err != nil {
+				return err
+			}
+		}
+		return finishBatch(tx, batch)
+	})
+}
+
+// executePerItemBatch exécute chaque virement valide dans sa propre transaction. Un virement
+// retenu par le contrôle anti-fraude est refusé dans le lot ; mis en attente de vérification,
+// il sera exécuté isolément s'il est libéré.
+func (s *server) executePerItemBatch(userID uint, batch *models.PaymentBatch, instructions []bulkInstruction) error {
+	for _, instruction := range instructions {
+		item := instruction.item
+		if item.Status == models.PaymentStatusRejected {
+			continue
+		}
+		err := s.store.Transaction(func(tx *repository.Store) error {
+			if err := s.executeInstruction(tx, userID, instruction); err != nil {
+				return err
+			}
+			return tx.PaymentBatches.SaveItem(item)
+		})
+		var held *heldTransferError
+		if errors.As(err, &held) {
+			if err := s.store.Fraud.Create(held.decision); err != nil {
+				return err
+			}
+			s.notifyHeldTransfer(held.decision)
+		}
+		if err != nil {
+			item.Status = models.PaymentStatusRejected
+			item.ReasonCode, item.ReasonInfo = rejectionReason(err)
+			item.Reference, item.DebitTransactionID = "", nil
+		}
+	}
+	return s.store.Transaction(func(tx *repository.Store) error {
+		return finishBatch(tx, batch)
+	})
+}
+
+// finishBatch enregistre le statut final du lot et de ses virements
+func finishBatch(tx *repository.Store, batch *models.PaymentBatch) error {
+	batch.Accepted, batch.Rejected = 0, 0
+	for i := range batch.Items {
+		if err := tx.PaymentBatches.SaveItem(&batch.Items[i]); err != nil {
+			return err
+		}
+		if batch.Items[i].Status == models.PaymentStatusCompleted {
+			batch.Accepted++
+		} else {
+			batch.Rejected++
+		}
+	}
+	batch.Status = pain.GroupStatus(batch.Accepted, batch.Rejected)
+	return tx.PaymentBatches.Save(batch)
+}
+
+// respondWithBatch écrit le compte rendu du lot en JSON ou au format pain.002
+func (s *server) respondWithBatch(c *gin.Context, status int, message string, batch *models.PaymentBatch, format string) {
+	if format == "pain002" {
+		var body bytes.Buffer
+		if err := pain.WriteReport(&body, batch); err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Erreur lors de la mise en forme du compte rendu",
+				Error:   "Internal Server Error",
+			})
+			return
+		}
+		c.Data(status, "application/xml; charset=utf-8", body.Bytes())
+		return
+	}
+
+	c.JSON(status, models.APIResponse{
+		Success: status < http.StatusBadRequest,
+		Message: message,
+		Data:    batch,
+	})
+}
+
+// listBulkPaymentsHandler liste les lots de virements du client
+func (s *server) listBulkPaymentsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	batches, err := s.store.PaymentBatches.ListForUser(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des lots de virements",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Lots de virements récupérés avec succès",
+		Data:    batches,
+	})
+}
+
+// getBulkPaymentHandler retourne le compte rendu d'un lot, en JSON ou avec report=pain002
+func (s *server) getBulkPaymentHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de lot invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	batch, err := s.store.PaymentBatches.FindForUser(uint(id), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Lot de virements non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	format := "json"
+	if c.Query("report") == "pain002" {
+		format = "pain002"
+	}
+	s.respondWithBatch(c, http.StatusOK, "Lot de virements récupéré avec succès", batch, format)
+}
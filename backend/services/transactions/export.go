@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"banking-app/shared/export"
+	"banking-app/shared/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportAccountTransactionsHandler exporte les opérations d'un compte sur une plage de dates
+// (from et to au format AAAA-MM-JJ, UTC, bornes incluses) pour les logiciels comptables :
+// format=ofx (OFX 2.2), qif ou camt053 (ISO 20022 camt.053.001.02)
+func (s *server) exportAccountTransactionsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("accountId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de compte invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	account, err := s.store.Accounts.FindForUser(uint(id), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	format := c.Query("format")
+	if format != export.FormatOFX && format != export.FormatQIF && format != export.FormatCAMT053 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Format invalide. Formats valides: ofx, qif, camt053",
+			Error:   "Bad Request",
+		})
+		return
+	}
+	from, to, err := export.ParseRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Plage de dates invalide: from et to sont requis au format 2006-01-02, sur " + strconv.Itoa(export.MaxDays) + " jours au plus",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	holder, err := s.store.Users.FindByID(account.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération du titulaire",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+	document, err := export.Build(s.store, account, strings.TrimSpace(holder.FirstName+" "+holder.LastName), from, to, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des opérations",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	var body bytes.Buffer
+	if err := export.Write(&body, format, document); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la mise en forme de l'export",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+export.FileName(document, format)+`"`)
+	c.Data(http.StatusOK, export.ContentType(format)+"; charset=utf-8", body.Bytes())
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dateLayout est le format des bornes de date sans heure (from=2024-01-31)
+const dateLayout = "2006-01-02"
+
+// parseListRequest lit la pagination et les filtres d'une liste de transactions :
+// type, status, currency, q (description), min_amount, max_amount, from et to.
+// Les montants sont exprimés dans currency, ou à défaut dans amountCurrency.
+func parseListRequest(c *gin.Context, amountCurrency string) (repository.TransactionFilter, utils.PageRequest, error) {
+	var filter repository.TransactionFilter
+
+	page, err := utils.ParsePageRequest(c.Request.URL.Query())
+	if err != nil {
+		return filter, page, newHandlerError(http.StatusBadRequest, err.Error())
+	}
+
+	filter.Type = c.Query("type")
+	if filter.Type != "" && !utils.ValidateTransactionType(filter.Type) {
+		return filter, page, newHandlerError(http.StatusBadRequest, "Type de transaction invalide. Types valides: debit, credit, transfer, reversal")
+	}
+	filter.Status = c.Query("status")
+	if filter.Status != "" && !utils.ValidateTransactionStatus(filter.Status) {
+		return filter, page, newHandlerError(http.StatusBadRequest, "Statut invalide. Statuts valides: pending, completed, failed, cancelled")
+	}
+	filter.Currency = c.Query("currency")
+	if filter.Currency != "" {
+		if !models.IsSupportedCurrency(filter.Currency) {
+			return filter, page, newHandlerError(http.StatusBadRequest, "Devise non supportée")
+		}
+		amountCurrency = filter.Currency
+	}
+	filter.Description = c.Query("q")
+
+	if filter.MinAmount, err = parseAmountBound(c.Query("min_amount"), amountCurrency); err != nil {
+		return filter, page, err
+	}
+	if filter.MaxAmount, err = parseAmountBound(c.Query("max_amount"), amountCurrency); err != nil {
+		return filter, page, err
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return filter, page, newHandlerError(http.StatusBadRequest, "min_amount doit être inférieur ou égal à max_amount")
+	}
+
+	if filter.From, err = parseDateBound(c.Query("from"), false); err != nil {
+		return filter, page, err
+	}
+	if filter.To, err = parseDateBound(c.Query("to"), true); err != nil {
+		return filter, page, err
+	}
+	return filter, page, nil
+}
+
+// parseAmountBound convertit une borne de montant en unités mineures
+func parseAmountBound(value, currency string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := models.ParseMoney(value, currency)
+	if err != nil || amount.IsNegative() {
+		return nil, newHandlerError(http.StatusBadRequest, "Borne de montant invalide: "+value)
+	}
+	return &amount.Amount, nil
+}
+
+// parseDateBound accepte une date (2024-01-31) ou un horodatage RFC 3339. Une date
+// utilisée comme borne de fin inclut toute la journée.
+func parseDateBound(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return nil, newHandlerError(http.StatusBadRequest, "Date invalide: "+value+" (formats acceptés: 2006-01-02, RFC 3339)")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// transactionCursor retourne la position d'une transaction dans une liste paginée
+func transactionCursor(transaction models.Transaction) utils.Cursor {
+	return utils.Cursor{CreatedAt: transaction.CreatedAt, ID: transaction.ID}
+}
+
+// listTransactions exécute la recherche et écrit la page de résultats
+func (s *server) listTransactions(c *gin.Context, filter repository.TransactionFilter, page utils.PageRequest, message string) {
+	transactions, err := s.store.Transactions.List(filter, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des transactions",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	transactions, info := utils.NewPage(transactions, page, transactionCursor)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success:    true,
+		Message:    message,
+		Data:       transactions,
+		Pagination: &info,
+	})
+}
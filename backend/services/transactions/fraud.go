@@ -0,0 +1,465 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"banking-app/shared/fraud"
+	"banking-app/shared/models"
+	"banking-app/shared/notify"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fraudEngine construit le moteur du contrôle anti-fraude : scores de mise en attente
+// (FRAUD_REVIEW_SCORE, 50 par défaut) et de refus (FRAUD_BLOCK_SCORE, 100 par défaut), montant
+// élevé vers un nouveau destinataire (FRAUD_LARGE_AMOUNT, 1000 par défaut) et plage horaire
+// inhabituelle (FRAUD_NIGHT_HOURS, 0-5 UTC par défaut, 0-0 pour la désactiver)
+func fraudEngine() *fraud.Engine {
+	nightFrom, nightTo := fraudNightHours()
+	largeAmount := os.Getenv("FRAUD_LARGE_AMOUNT")
+	if largeAmount == "" {
+		largeAmount = "1000"
+	}
+	return &fraud.Engine{
+		Rules:       fraud.DefaultRules(largeAmount, nightFrom, nightTo),
+		ReviewScore: envScore("FRAUD_REVIEW_SCORE", 50),
+		BlockScore:  envScore("FRAUD_BLOCK_SCORE", 100),
+		Lookback:    90 * 24 * time.Hour,
+	}
+}
+
+// envScore lit un score strictement positif dans la variable name
+func envScore(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// fraudNightHours lit la plage horaire inhabituelle FRAUD_NIGHT_HOURS (« de-à », heures UTC)
+func fraudNightHours() (int, int) {
+	from, to, found := strings.Cut(os.Getenv("FRAUD_NIGHT_HOURS"), "-")
+	start, startErr := strconv.Atoi(strings.TrimSpace(from))
+	end, endErr := strconv.Atoi(strings.TrimSpace(to))
+	if !found || startErr != nil || endErr != nil || start < 0 || start > 23 || end < 0 || end > 23 {
+		return 0, 5
+	}
+	return start, end
+}
+
+// fraudStatus est le statut initial d'une décision selon son issue
+func fraudStatus(outcome string) string {
+	switch outcome {
+	case models.FraudOutcomeReview:
+		return models.FraudStatusPending
+	case models.FraudOutcomeBlock:
+		return models.FraudStatusBlocked
+	default:
+		return models.FraudStatusAllowed
+	}
+}
+
+// screenTransfer soumet un virement vers un autre client au contrôle anti-fraude et retourne
+// la décision, sans l'enregistrer ; elle vaut nil pour un virement entre les comptes du client,
+// qui n'est pas contrôlé. Un virement refusé d'emblée (délai de carence) n'est pas soumis au
+// contrôle.
+func screenTransfer(store *repository.Store, order transferOrder) (*models.FraudDecision, error) {
+	fromAccount, err := store.Accounts.FindForUser(order.FromAccountID, order.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, newHandlerError(http.StatusNotFound, "Compte source non trouvé")
+	}
+	if err != nil {
+		return nil, err
+	}
+	toAccount, err := store.Accounts.FindByID(order.ToAccountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, newHandlerError(http.StatusNotFound, "Compte destination non trouvé")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if toAccount.UserID == fromAccount.UserID {
+		return nil, nil
+	}
+
+	amount, err := parseAmount(order.Amount, fromAccount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	beneficiary, err := payeeBeneficiary(store, order.UserID, toAccount, order.Beneficiary)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := checkCoolingOff(beneficiary, amount, now); err != nil {
+		return nil, err
+	}
+
+	result, err := fraudEngine().Screen(store, fraud.Payment{
+		UserID:      order.UserID,
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Amount:      amount,
+		Beneficiary: beneficiary,
+		At:          now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &models.FraudDecision{
+		UserID:        order.UserID,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		Currency:      amount.Currency,
+		Description:   order.Description,
+		Reference:     order.Reference,
+		Score:         result.Score,
+		Outcome:       result.Outcome,
+		Status:        fraudStatus(result.Outcome),
+		Hits:          result.Hits,
+	}
+	if beneficiary != nil {
+		decision.BeneficiaryID = &beneficiary.ID
+	}
+	return decision, nil
+}
+
+// heldTransferError signale un virement que le contrôle anti-fraude n'a pas laissé passer :
+// refusé ou mis en attente de vérification. Le virement n'est pas exécuté ; sa décision n'est
+// pas encore enregistrée, c'est à l'appelant de le faire hors de la transaction annulée.
+type heldTransferError struct {
+	decision *models.FraudDecision
+}
+
+func (e *heldTransferError) Error() string {
+	if e.decision.Outcome == models.FraudOutcomeBlock {
+		return "Virement refusé par le contrôle de sécurité"
+	}
+	return "Virement en attente de vérification par le contrôle de sécurité"
+}
+
+// screenedTransfer soumet un virement au contrôle anti-fraude puis l'exécute dans la
+// transaction tx. La décision d'un virement exécuté est enregistrée dans tx : elle est annulée
+// avec le virement s'il échoue. Un virement retenu par le contrôle retourne un
+// *heldTransferError.
+func (s *server) screenedTransfer(tx *repository.Store, order transferOrder) (transferResult, error) {
+	decision, err := screenTransfer(tx, order)
+	if err != nil {
+		return transferResult{}, err
+	}
+	if decision != nil && decision.Outcome != models.FraudOutcomeAllow {
+		return transferResult{}, &heldTransferError{decision: decision}
+	}
+
+	result, err := s.executeTransfer(tx, order)
+	if err != nil || decision == nil {
+		return result, err
+	}
+	decision.TransactionID = &result.Debit.ID
+	return result, tx.Fraud.Create(decision)
+}
+
+// respondToHeldTransfer répond à un virement que le contrôle anti-fraude n'a pas laissé
+// passer : refusé (403) ou mis en attente de vérification (202). Le client ne connaît pas
+// les règles déclenchées.
+func (s *server) respondToHeldTransfer(c *gin.Context, decision *models.FraudDecision) {
+	if decision.Outcome == models.FraudOutcomeBlock {
+		respondWithError(c, &handlerError{
+			status:  http.StatusForbidden,
+			message: "Virement refusé par le contrôle de sécurité. Contactez votre conseiller.",
+			code:    "FRAUD_BLOCKED",
+		}, "Erreur lors du transfert")
+		return
+	}
+
+	s.notifyHeldTransfer(decision)
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Virement en attente de vérification",
+		Data: map[string]interface{}{
+			"review_id":          decision.ID,
+			"status":             decision.Status,
+			"transfer_reference": decision.Reference,
+			"amount":             decision.Amount,
+			"from_account_id":    decision.FromAccountID,
+			"to_account_id":      decision.ToAccountID,
+		},
+	})
+}
+
+// notifyHeldTransfer avertit par email le client d'un virement mis en attente de vérification
+func (s *server) notifyHeldTransfer(decision *models.FraudDecision) {
+	if decision.Status != models.FraudStatusPending {
+		return
+	}
+	s.notifyFraudReview(decision, "Virement en cours de vérification", fmt.Sprintf(
+		"Votre virement de %s (référence %s) est en cours de vérification par nos services. "+
+			"Il sera exécuté dès sa validation ; vous serez averti de la décision.",
+		decision.Amount, decision.Reference))
+}
+
+// notifyFraudReview avertit par email le client d'un virement mis en attente ou vérifié
+func (s *server) notifyFraudReview(decision *models.FraudDecision, subject, message string) {
+	if err := s.notifier.Notify(decision.UserID, notify.ChannelEmail, subject, message); err != nil {
+		log.Printf("Erreur lors de la notification de la décision anti-fraude %d: %v", decision.ID, err)
+	}
+}
+
+// fraudDecisionCursor retourne la position d'une décision dans une liste paginée
+func fraudDecisionCursor(decision models.FraudDecision) utils.Cursor {
+	return utils.Cursor{CreatedAt: decision.CreatedAt, ID: decision.ID}
+}
+
+// fraudDecisionID lit l'identifiant :id ; écrit la réponse d'erreur et retourne false s'il est invalide
+func fraudDecisionID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de décision invalide",
+			Error:   "Bad Request",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// adminListFraudDecisionsHandler récupère une page des décisions anti-fraude avec leurs règles
+// déclenchées, filtrées par statuts séparés par des virgules (status=pending,blocked pour la
+// file de vérification) et par client
+func (s *server) adminListFraudDecisionsHandler(c *gin.Context) {
+	var filter repository.FraudFilter
+	if value := c.Query("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			switch status {
+			case models.FraudStatusAllowed, models.FraudStatusPending, models.FraudStatusReleased,
+				models.FraudStatusRejected, models.FraudStatusBlocked:
+				filter.Statuses = append(filter.Statuses, status)
+			default:
+				c.JSON(http.StatusBadRequest, models.APIResponse{
+					Success: false,
+					Message: "Statut invalide. Statuts valides: allowed, pending, released, rejected, blocked",
+					Error:   "Bad Request",
+				})
+				return
+			}
+		}
+	}
+	if value := c.Query("user_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "ID d'utilisateur invalide",
+				Error:   "Bad Request",
+			})
+			return
+		}
+		filter.UserID = uint(id)
+	}
+
+	page, err := utils.ParsePageRequest(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	decisions, err := s.store.Fraud.ListPage(filter, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des décisions",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	decisions, info := utils.NewPage(decisions, page, fraudDecisionCursor)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success:    true,
+		Message:    "Décisions récupérées avec succès",
+		Data:       decisions,
+		Pagination: &info,
+	})
+}
+
+// adminGetFraudDecisionHandler récupère une décision anti-fraude et ses règles déclenchées
+func (s *server) adminGetFraudDecisionHandler(c *gin.Context) {
+	id, ok := fraudDecisionID(c)
+	if !ok {
+		return
+	}
+	decision, err := s.store.Fraud.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Décision non trouvée",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Décision récupérée avec succès",
+		Data:    decision,
+	})
+}
+
+// reviewFraudDecision verrouille une décision à vérifier, en attente ou refusée par le
+// contrôle, et applique review dans la même transaction, puis enregistre la vérification
+func (s *server) reviewFraudDecision(id, reviewerID uint, status, note string, review func(tx *repository.Store, decision *models.FraudDecision) error) (*models.FraudDecision, error) {
+	var decision *models.FraudDecision
+	err := s.store.Transaction(func(tx *repository.Store) error {
+		var err error
+		decision, err = tx.Fraud.LockByID(id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return newHandlerError(http.StatusNotFound, "Décision non trouvée")
+		}
+		if err != nil {
+			return err
+		}
+		if decision.Status != models.FraudStatusPending && decision.Status != models.FraudStatusBlocked {
+			return newHandlerError(http.StatusConflict, "Ce virement n'est pas en attente de vérification")
+		}
+		if err := review(tx, decision); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		decision.Status = status
+		decision.ReviewedBy = &reviewerID
+		decision.ReviewedAt = &now
+		decision.ReviewNote = note
+		return tx.Fraud.Save(decision)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Fraud.FindByID(id)
+}
+
+// adminReleaseFraudDecisionHandler libère un virement en attente de vérification ou refusé
+// par le contrôle : il est exécuté, sous réserve du solde et des plafonds du compte à cet
+// instant. Un virement entre devises est converti au cours courant.
+func (s *server) adminReleaseFraudDecisionHandler(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+	id, ok := fraudDecisionID(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Note string `json:"note" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	decision, err := s.reviewFraudDecision(id, adminID.(uint), models.FraudStatusReleased, request.Note,
+		func(tx *repository.Store, decision *models.FraudDecision) error {
+			result, err := s.executeTransfer(tx, transferOrder{
+				UserID:            decision.UserID,
+				FromAccountID:     decision.FromAccountID,
+				ToAccountID:       decision.ToAccountID,
+				Amount:            json.Number(decision.Amount.Decimal()),
+				Description:       decision.Description,
+				Reference:         decision.Reference,
+				CoolingOffChecked: true,
+			})
+			if err != nil {
+				return err
+			}
+			decision.TransactionID = &result.Debit.ID
+			return nil
+		})
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la libération du virement")
+		return
+	}
+	s.notifyFraudReview(decision, "Virement validé", fmt.Sprintf(
+		"Votre virement de %s (référence %s) a été vérifié et exécuté.", decision.Amount, decision.Reference))
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Virement libéré et exécuté avec succès",
+		Data:    decision,
+	})
+}
+
+// adminRejectFraudDecisionHandler rejette un virement en attente de vérification ou confirme
+// le refus du contrôle ; le motif est obligatoire
+func (s *server) adminRejectFraudDecisionHandler(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+	id, ok := fraudDecisionID(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason" binding:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	decision, err := s.reviewFraudDecision(id, adminID.(uint), models.FraudStatusRejected, request.Reason,
+		func(*repository.Store, *models.FraudDecision) error { return nil })
+	if err != nil {
+		respondWithError(c, err, "Erreur lors du rejet du virement")
+		return
+	}
+	s.notifyFraudReview(decision, "Virement refusé", fmt.Sprintf(
+		"Votre virement de %s (référence %s) a été refusé à l'issue de sa vérification. "+
+			"Aucun montant n'a été débité ; contactez votre conseiller pour plus d'informations.",
+		decision.Amount, decision.Reference))
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Virement rejeté",
+		Data:    decision,
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"banking-app/shared/fx"
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fxError convertit les erreurs de change en erreurs métier
+func fxError(err error) error {
+	switch {
+	case errors.Is(err, fx.ErrRateUnavailable):
+		return newHandlerError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, fx.ErrAmountTooSmall):
+		return newHandlerError(http.StatusBadRequest, "Montant trop faible pour être converti")
+	}
+	return err
+}
+
+// convert convertit amount au cours courant, avec la marge configurée
+func (s *server) convert(amount models.Money, toCurrency string) (fx.Conversion, error) {
+	rate, err := s.rates.Rate(amount.Currency, toCurrency)
+	if err != nil {
+		return fx.Conversion{}, fxError(err)
+	}
+	conversion, err := fx.Convert(amount, rate, fx.SpreadBps())
+	if err != nil {
+		return fx.Conversion{}, fxError(err)
+	}
+	return conversion, nil
+}
+
+// useQuote consomme la cotation quoteID pour convertir amount vers toCurrency ;
+// la cotation doit porter exactement sur ce montant et cette paire de devises
+func useQuote(tx *repository.Store, quoteID string, userID uint, amount models.Money, toCurrency string) (fx.Conversion, error) {
+	quote, err := tx.FXQuotes.FindForUser(quoteID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fx.Conversion{}, newHandlerError(http.StatusNotFound, "Cotation non trouvée")
+	}
+	if err != nil {
+		return fx.Conversion{}, err
+	}
+	if quote.FromCurrency != amount.Currency || quote.ToCurrency != toCurrency || quote.SourceAmount.Amount != amount.Amount {
+		return fx.Conversion{}, newHandlerError(http.StatusBadRequest, "La cotation ne correspond pas au montant ou aux devises du virement")
+	}
+
+	if err := tx.FXQuotes.Consume(quote.ID, userID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fx.Conversion{}, newHandlerError(http.StatusConflict, "Cotation expirée ou déjà utilisée")
+		}
+		return fx.Conversion{}, err
+	}
+
+	value, err := fx.ParseRate(quote.Rate)
+	if err != nil {
+		return fx.Conversion{}, err
+	}
+	return fx.Conversion{
+		Source:    quote.SourceAmount,
+		Mid:       quote.MidAmount,
+		Target:    quote.TargetAmount,
+		Rate:      fx.Rate{From: quote.FromCurrency, To: quote.ToCurrency, Value: value, AsOf: quote.RateDate},
+		SpreadBps: quote.SpreadBps,
+	}, nil
+}
+
+// conversionPostings construit les lignes d'un virement entre devises : chaque devise
+// s'équilibre contre la position de change, la marge étant portée en produit
+func conversionPostings(fromAccountID, toAccountID uint, conversion fx.Conversion) []models.Posting {
+	postings := []models.Posting{
+		ledger.CustomerPosting(fromAccountID, conversion.Source.Neg()),
+		ledger.SystemPosting(ledger.FXAccount, conversion.Source),
+		ledger.SystemPosting(ledger.FXAccount, conversion.Mid.Neg()),
+		ledger.CustomerPosting(toAccountID, conversion.Target),
+	}
+	if spread := conversion.Spread(); !spread.IsZero() {
+		postings = append(postings, ledger.SystemPosting(ledger.FXRevenueAccount, spread))
+	}
+	return postings
+}
+
+// recordConversion reporte le change appliqué sur une jambe du virement
+func recordConversion(transaction *models.Transaction, conversion fx.Conversion, quoteID string) {
+	rate := conversion.Rate.String()
+	spread := conversion.SpreadBps
+	transaction.ExchangeRate = &rate
+	transaction.ExchangeSpreadBps = &spread
+	if quoteID != "" {
+		transaction.FXQuoteID = &quoteID
+	}
+}
+
+// createQuoteHandler cote la conversion d'un montant ; la cotation est garantie
+// pendant FX_QUOTE_TTL et peut être utilisée une fois via quote_id lors d'un virement
+func (s *server) createQuoteHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	var request struct {
+		FromCurrency string      `json:"from_currency" binding:"required"`
+		ToCurrency   string      `json:"to_currency" binding:"required"`
+		Amount       json.Number `json:"amount" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if request.FromCurrency == request.ToCurrency {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Les devises source et cible doivent être différentes",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	amount, err := parseAmount(request.Amount, request.FromCurrency)
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la cotation")
+		return
+	}
+	conversion, err := s.convert(amount, request.ToCurrency)
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la cotation")
+		return
+	}
+
+	id, err := utils.GenerateRandomID()
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la cotation")
+		return
+	}
+	quote := models.FXQuote{
+		ID:           id,
+		UserID:       userID.(uint),
+		FromCurrency: request.FromCurrency,
+		ToCurrency:   request.ToCurrency,
+		SourceAmount: conversion.Source,
+		MidAmount:    conversion.Mid,
+		TargetAmount: conversion.Target,
+		Rate:         conversion.Rate.String(),
+		SpreadBps:    conversion.SpreadBps,
+		RateDate:     conversion.Rate.AsOf,
+		ExpiresAt:    time.Now().Add(fx.QuoteTTL()),
+	}
+	if err := s.store.FXQuotes.Create(&quote); err != nil {
+		respondWithError(c, err, "Erreur lors de la cotation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Cotation créée avec succès",
+		Data:    quote,
+	})
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/limits"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// holdBatchSize borne le nombre de réservations expirées traitées par passage
+const holdBatchSize = 100
+
+// holdExpiry est la durée de validité d'une réservation non capturée (HOLD_EXPIRY)
+func holdExpiry() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("HOLD_EXPIRY")); err == nil && value > 0 {
+		return value
+	}
+	return 7 * 24 * time.Hour
+}
+
+// captureToleranceBps est le dépassement autorisé à la capture, en points de base du
+// montant réservé (HOLD_CAPTURE_TOLERANCE_BPS, 15 % par défaut : pourboires, carburant)
+func captureToleranceBps() int64 {
+	if value, err := strconv.ParseInt(os.Getenv("HOLD_CAPTURE_TOLERANCE_BPS"), 10, 64); err == nil && value >= 0 {
+		return value
+	}
+	return 1500
+}
+
+// maxCapture retourne le montant maximal capturable pour une réservation de amount
+func maxCapture(amount models.Money) (models.Money, error) {
+	limit := new(big.Int).Mul(big.NewInt(amount.Amount), big.NewInt(10000+captureToleranceBps()))
+	limit.Quo(limit, big.NewInt(10000))
+	if !limit.IsInt64() {
+		return models.Money{}, models.ErrAmountOverflow
+	}
+	return models.NewMoney(limit.Int64(), amount.Currency), nil
+}
+
+// holdCursor retourne la position d'une réservation dans une liste paginée
+func holdCursor(hold models.Hold) utils.Cursor {
+	return utils.Cursor{CreatedAt: hold.CreatedAt, ID: hold.ID}
+}
+
+// createHoldHandler autorise un paiement : le montant est réservé sur le compte, sans être débité
+func (s *server) createHoldHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	var request struct {
+		AccountID   uint        `json:"account_id" binding:"required"`
+		Amount      json.Number `json:"amount" binding:"required"`
+		Description string      `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	reference, err := utils.GenerateTransactionReference()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la génération de la référence",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	var hold models.Hold
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		account, err := tx.Accounts.LockForUser(request.AccountID, userID.(uint))
+		if errors.Is(err, repository.ErrNotFound) {
+			return newHandlerError(http.StatusNotFound, "Compte non trouvé")
+		}
+		if err != nil {
+			return err
+		}
+		if account.Status != "active" {
+			return newHandlerError(http.StatusBadRequest, "Le compte n'est pas actif")
+		}
+
+		amount, err := parseAmount(request.Amount, account.Currency)
+		if err != nil {
+			return err
+		}
+		// Les plafonds ne sont imputés qu'à la capture, lorsque les fonds sortent du compte
+		if err := verifyOutflow(tx, account, limits.Outflow{Amount: amount, Reference: reference}); err != nil {
+			return err
+		}
+		if err := tx.Accounts.Reserve(account.ID, amount); err != nil {
+			if errors.Is(err, ledger.ErrInsufficientFunds) {
+				return newHandlerError(http.StatusBadRequest, "Solde disponible insuffisant")
+			}
+			return err
+		}
+
+		hold = models.Hold{
+			AccountID:   account.ID,
+			Amount:      amount,
+			Currency:    account.Currency,
+			Description: request.Description,
+			Reference:   reference,
+			Status:      models.HoldAuthorized,
+			ExpiresAt:   time.Now().Add(holdExpiry()),
+		}
+		return tx.Holds.Create(&hold)
+	})
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de l'autorisation du paiement")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Paiement autorisé avec succès",
+		Data:    hold,
+	})
+}
+
+// listHoldsHandler récupère une page des réservations de l'utilisateur (filtres account_id, status)
+func (s *server) listHoldsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	page, err := utils.ParsePageRequest(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	filter := repository.HoldFilter{UserID: userID.(uint), Status: c.Query("status")}
+	if accountID := c.Query("account_id"); accountID != "" {
+		id, err := strconv.ParseUint(accountID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "ID de compte invalide",
+				Error:   "Bad Request",
+			})
+			return
+		}
+		filter.AccountID = uint(id)
+	}
+
+	holds, err := s.store.Holds.List(filter, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des réservations",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	holds, info := utils.NewPage(holds, page, holdCursor)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success:    true,
+		Message:    "Réservations récupérées avec succès",
+		Data:       holds,
+		Pagination: &info,
+	})
+}
+
+// getHoldHandler récupère une réservation de l'utilisateur
+func (s *server) getHoldHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de réservation invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	hold, err := s.store.Holds.FindForUser(uint(id), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Réservation non trouvée",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Réservation récupérée avec succès",
+		Data:    hold,
+	})
+}
+
+// settleHold dénoue la réservation :id de l'utilisateur : les fonds réservés sont libérés
+// puis settle capture ou annule la réservation, le tout sous verrou et dans une même transaction
+func (s *server) settleHold(c *gin.Context, settle func(tx *repository.Store, hold *models.Hold, account *models.Account) error, success string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de réservation invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+	if _, err := s.store.Holds.FindForUser(uint(id), userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Réservation non trouvée",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	var hold *models.Hold
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		var err error
+		if hold, err = tx.Holds.LockByID(uint(id)); err != nil {
+			return err
+		}
+		if hold.Status != models.HoldAuthorized {
+			return newHandlerError(http.StatusConflict, "La réservation n'est plus en cours ("+hold.Status+")")
+		}
+		if !hold.ExpiresAt.After(time.Now()) {
+			return newHandlerError(http.StatusConflict, "La réservation a expiré")
+		}
+
+		account, err := tx.Accounts.LockByID(hold.AccountID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Accounts.Release(account.ID, hold.Amount); err != nil {
+			return err
+		}
+		if err := settle(tx, hold, account); err != nil {
+			return err
+		}
+		return tx.Holds.Save(hold)
+	})
+	if err != nil {
+		respondWithError(c, err, "Erreur lors du traitement de la réservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: success,
+		Data:    hold,
+	})
+}
+
+// captureHoldHandler débite tout ou partie d'une réservation (amount, le montant réservé si
+// absent), ou davantage dans la limite de HOLD_CAPTURE_TOLERANCE_BPS ; le reliquat est libéré
+func (s *server) captureHoldHandler(c *gin.Context) {
+	var request struct {
+		Amount *json.Number `json:"amount"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	s.settleHold(c, func(tx *repository.Store, hold *models.Hold, account *models.Account) error {
+		if account.Status != "active" {
+			return newHandlerError(http.StatusBadRequest, "Le compte n'est pas actif")
+		}
+
+		amount := hold.Amount
+		if request.Amount != nil {
+			var err error
+			if amount, err = parseAmount(*request.Amount, hold.Currency); err != nil {
+				return err
+			}
+			limit, err := maxCapture(hold.Amount)
+			if err != nil {
+				return err
+			}
+			if amount.Amount > limit.Amount {
+				return newHandlerError(http.StatusBadRequest, "Le montant capturé dépasse le maximum autorisé ("+limit.String()+")")
+			}
+		}
+
+		reference, err := utils.GenerateTransactionReference()
+		if err != nil {
+			return err
+		}
+		if err := authorizeOutflow(tx, account, limits.Outflow{Amount: amount, Reference: reference}); err != nil {
+			return err
+		}
+		entry := models.JournalEntry{
+			Reference:   reference,
+			Description: hold.Description,
+			Postings: []models.Posting{
+				ledger.CustomerPosting(account.ID, amount.Neg()),
+				ledger.SystemPosting(ledger.ExternalAccount, amount),
+			},
+		}
+		if err := postEntry(tx.DB, &entry); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		transaction := models.Transaction{
+			AccountID:      account.ID,
+			Type:           "debit",
+			Amount:         amount,
+			Currency:       account.Currency,
+			Description:    hold.Description,
+			Reference:      reference,
+			Status:         "completed",
+			ProcessedAt:    &now,
+			JournalEntryID: &entry.ID,
+		}
+		if err := tx.Transactions.Create(&transaction); err != nil {
+			return err
+		}
+
+		hold.Status = models.HoldCaptured
+		hold.CapturedAmount = amount
+		hold.CapturedAt = &now
+		hold.TransactionID = &transaction.ID
+		return nil
+	}, "Paiement capturé avec succès")
+}
+
+// voidHoldHandler annule une réservation et libère les fonds
+func (s *server) voidHoldHandler(c *gin.Context) {
+	s.settleHold(c, func(_ *repository.Store, hold *models.Hold, _ *models.Account) error {
+		now := time.Now()
+		hold.Status = models.HoldVoided
+		hold.ReleasedAt = &now
+		return nil
+	}, "Réservation annulée avec succès")
+}
+
+// expireHolds libère les réservations échues à now et retourne leur nombre
+func (s *server) expireHolds(now time.Time) (int, error) {
+	ids, err := s.store.Holds.ListExpired(now, holdBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		err := s.store.Transaction(func(tx *repository.Store) error {
+			hold, err := tx.Holds.LockByID(id)
+			if err != nil {
+				return err
+			}
+			// Capturée, annulée ou expirée entre-temps par une autre instance
+			if hold.Status != models.HoldAuthorized || hold.ExpiresAt.After(now) {
+				return repository.ErrNotFound
+			}
+			if err := tx.Accounts.Release(hold.AccountID, hold.Amount); err != nil {
+				return err
+			}
+			hold.Status = models.HoldExpired
+			hold.ReleasedAt = &now
+			return tx.Holds.Save(hold)
+		})
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+		case err != nil:
+			log.Printf("Erreur lors de l'expiration de la réservation %d: %v", id, err)
+		default:
+			expired++
+		}
+	}
+	return expired, nil
+}
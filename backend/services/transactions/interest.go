@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+)
+
+// interestBatchSize borne le nombre de comptes facturés par passage
+const interestBatchSize = 100
+
+// daysPerYear est la base de calcul des intérêts débiteurs (exact/365)
+const daysPerYear = 365
+
+// interestPeriod retourne le mois civil (UTC) précédant now : [from, to)
+func interestPeriod(now time.Time) (from, to time.Time) {
+	now = now.UTC()
+	to = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return to.AddDate(0, -1, 0), to
+}
+
+// overdraftInterest calcule les intérêts débiteurs dus pour une suite de soldes de clôture
+// quotidiens au taux annuel rateBps : somme des découverts journaliers × taux / 365,
+// arrondie au plus proche une seule fois pour la période
+func overdraftInterest(balances []int64, rateBps int) (int64, error) {
+	overdrawn := new(big.Int)
+	for _, balance := range balances {
+		if balance < 0 {
+			overdrawn.Add(overdrawn, big.NewInt(-balance))
+		}
+	}
+
+	interest := new(big.Rat).SetFrac(
+		overdrawn.Mul(overdrawn, big.NewInt(int64(rateBps))),
+		big.NewInt(10000*daysPerYear),
+	)
+	interest.Add(interest, big.NewRat(1, 2))
+	rounded := new(big.Int).Quo(interest.Num(), interest.Denom())
+	if !rounded.IsInt64() {
+		return 0, models.ErrAmountOverflow
+	}
+	return rounded.Int64(), nil
+}
+
+// chargeOverdraftInterest facture les intérêts débiteurs du mois précédant now aux comptes
+// qui ne l'ont pas encore été et retourne le nombre de comptes traités
+func (s *server) chargeOverdraftInterest(now time.Time) (int, error) {
+	from, to := interestPeriod(now)
+	ids, err := s.store.Accounts.ListInterestDue(to, interestBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	charged := 0
+	for _, id := range ids {
+		err := s.chargeAccountInterest(id, from, to)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+		case err != nil:
+			log.Printf("Erreur lors de la facturation des intérêts du compte %d: %v", id, err)
+		default:
+			charged++
+		}
+	}
+	return charged, nil
+}
+
+// chargeAccountInterest facture au compte id les intérêts de la période [from, to), au taux
+// en vigueur à la facturation. Le compte est verrouillé et sa date de dernière facturation
+// avancée dans la même transaction : la période n'est facturée qu'une fois, même si
+// plusieurs instances exécutent le planificateur.
+func (s *server) chargeAccountInterest(id uint, from, to time.Time) error {
+	return s.store.Transaction(func(tx *repository.Store) error {
+		account, err := tx.Accounts.LockByID(id)
+		if err != nil {
+			return err
+		}
+		// Facturé entre-temps par une autre instance
+		if account.OverdraftRateBps <= 0 || (account.InterestChargedThrough != nil && !account.InterestChargedThrough.Before(to)) {
+			return repository.ErrNotFound
+		}
+
+		balances, err := ledger.DailyBalances(tx.DB, account.ID, account.Currency, from, to)
+		if err != nil {
+			return err
+		}
+		amount, err := overdraftInterest(balances, account.OverdraftRateBps)
+		if err != nil {
+			return err
+		}
+
+		if amount > 0 {
+			interest := models.NewMoney(amount, account.Currency)
+			reference := fmt.Sprintf("INT-%d-%s", account.ID, from.Format("200601"))
+			description := "Intérêts débiteurs " + from.Format("01/2006")
+			entry := models.JournalEntry{
+				Reference:   reference,
+				Description: description,
+				Postings: []models.Posting{
+					ledger.CustomerPosting(account.ID, interest.Neg()),
+					ledger.SystemPosting(ledger.InterestIncomeAccount, interest),
+				},
+			}
+			if err := ledger.PostCharge(tx.DB, &entry); err != nil {
+				return err
+			}
+
+			now := time.Now()
+			transaction := models.Transaction{
+				AccountID:      account.ID,
+				Type:           "debit",
+				Amount:         interest,
+				Currency:       account.Currency,
+				Description:    description,
+				Reference:      reference,
+				Status:         "completed",
+				ProcessedAt:    &now,
+				JournalEntryID: &entry.ID,
+			}
+			if err := tx.Transactions.Create(&transaction); err != nil {
+				return err
+			}
+		}
+
+		account.InterestChargedThrough = &to
+		return tx.Accounts.Save(account)
+	})
+}
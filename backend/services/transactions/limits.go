@@ -0,0 +1,352 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"banking-app/shared/limits"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authorizeOutflow impute une sortie de fonds sur les plafonds du compte. Un dépassement est
+// refusé avec le code du plafond : 403, ou 429 pour le nombre d'opérations par heure.
+func authorizeOutflow(tx *repository.Store, account *models.Account, outflow limits.Outflow) error {
+	return limitError(limits.Authorize(tx, account, outflow, time.Now()))
+}
+
+// verifyOutflow vérifie, sans l'imputer, qu'une sortie de fonds respecterait les plafonds du
+// compte ; un dépassement est refusé comme par authorizeOutflow
+func verifyOutflow(tx *repository.Store, account *models.Account, outflow limits.Outflow) error {
+	return limitError(limits.Verify(tx, account, outflow, time.Now()))
+}
+
+// limitError convertit le dépassement d'un plafond, ou son absence, en handlerError
+func limitError(err error) error {
+	var exceeded *limits.ExceededError
+	if errors.As(err, &exceeded) {
+		status := http.StatusForbidden
+		if exceeded.Kind == limits.KindHourlyCount {
+			status = http.StatusTooManyRequests
+		}
+		return &handlerError{status: status, message: exceeded.Error(), cause: err, code: exceeded.Code()}
+	}
+	if errors.Is(err, limits.ErrNoBankLimit) {
+		return &handlerError{status: http.StatusForbidden, cause: err, code: "TRANSFER_LIMIT_UNDEFINED",
+			message: "Aucun plafond n'est fixé pour ce type de compte et cette devise : sortie de fonds refusée"}
+	}
+	return err
+}
+
+// limitsRequest décrit les plafonds des comptes d'un type et d'une devise ; un plafond absent
+// ou null n'est pas limité
+type limitsRequest struct {
+	AccountType    string       `json:"account_type" binding:"required"`
+	Currency       string       `json:"currency"` // EUR par défaut
+	PerTransaction *json.Number `json:"per_transaction"`
+	Daily          *json.Number `json:"daily"`
+	Monthly        *json.Number `json:"monthly"`
+	HourlyCount    *int         `json:"hourly_count"`
+}
+
+// parse valide le type de compte, la devise et les plafonds de la requête
+func (r *limitsRequest) parse() (limits.Limits, error) {
+	if !utils.ValidateAccountType(r.AccountType) {
+		return limits.Limits{}, newHandlerError(http.StatusBadRequest, "Type de compte invalide. Types valides: checking, savings, credit")
+	}
+	r.Currency = strings.ToUpper(r.Currency)
+	if r.Currency == "" {
+		r.Currency = models.DefaultCurrency
+	}
+	if !models.IsSupportedCurrency(r.Currency) {
+		return limits.Limits{}, newHandlerError(http.StatusBadRequest, "Devise non supportée: "+r.Currency)
+	}
+
+	parseLimit := func(field string, value *json.Number) (*models.Money, error) {
+		if value == nil {
+			return nil, nil
+		}
+		amount, err := models.ParseMoney(value.String(), r.Currency)
+		if err != nil || amount.IsNegative() {
+			return nil, newHandlerError(http.StatusBadRequest, field+" invalide: "+value.String())
+		}
+		return &amount, nil
+	}
+	var result limits.Limits
+	var err error
+	if result.PerTransaction, err = parseLimit("per_transaction", r.PerTransaction); err != nil {
+		return limits.Limits{}, err
+	}
+	if result.Daily, err = parseLimit("daily", r.Daily); err != nil {
+		return limits.Limits{}, err
+	}
+	if result.Monthly, err = parseLimit("monthly", r.Monthly); err != nil {
+		return limits.Limits{}, err
+	}
+	if r.HourlyCount != nil && *r.HourlyCount < 0 {
+		return limits.Limits{}, newHandlerError(http.StatusBadRequest, "hourly_count doit être positif")
+	}
+	result.HourlyCount = r.HourlyCount
+	return result, nil
+}
+
+// saveLimits enregistre les plafonds d'une origine, ou les retire s'ils sont tous vides
+func saveLimits(store *repository.Store, userID *uint, request limitsRequest, source string, values limits.Limits, updatedBy uint) error {
+	limit, err := store.TransferLimits.Find(userID, request.AccountType, request.Currency, source)
+	if errors.Is(err, repository.ErrNotFound) {
+		if values.IsEmpty() {
+			return nil
+		}
+		limit = &models.TransferLimit{UserID: userID, AccountType: request.AccountType, Currency: request.Currency, Source: source}
+	} else if err != nil {
+		return err
+	}
+	if values.IsEmpty() {
+		return store.TransferLimits.Delete(limit)
+	}
+	values.Apply(limit)
+	limit.UpdatedBy = &updatedBy
+	return store.TransferLimits.Save(limit)
+}
+
+// getLimitsHandler récupère, pour chaque compte de l'utilisateur, les plafonds appliqués, leur
+// consommation et le disponible
+func (s *server) getLimitsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	accounts, err := s.store.Accounts.ListByUser(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des comptes",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	now := time.Now()
+	statuses := []limits.Status{}
+	for i := range accounts {
+		if accounts[i].Status == "closed" {
+			continue
+		}
+		status, err := limits.AccountStatus(s.store, &accounts[i], now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Erreur lors de la récupération des plafonds",
+				Error:   "Internal Server Error",
+			})
+			return
+		}
+		statuses = append(statuses, status)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Plafonds récupérés avec succès",
+		Data:    statuses,
+	})
+}
+
+// updateLimitsHandler fixe les plafonds choisis par l'utilisateur pour ses comptes d'un type
+// et d'une devise. Ils ne peuvent qu'abaisser ceux de la banque ; un plafond absent reprend
+// celui de la banque.
+func (s *server) updateLimitsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	var request limitsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	customer, err := request.parse()
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la mise à jour des plafonds")
+		return
+	}
+
+	owner := userID.(uint)
+	var set limits.Set
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		current, err := limits.Resolve(tx, owner, request.AccountType, request.Currency)
+		if err != nil {
+			return err
+		}
+		if err := limits.CheckCustomer(current.Bank, customer); err != nil {
+			return newHandlerError(http.StatusBadRequest, "Plafonds refusés : "+err.Error())
+		}
+		if err := saveLimits(tx, &owner, request, models.LimitSourceCustomer, customer, owner); err != nil {
+			return err
+		}
+		set, err = limits.Resolve(tx, owner, request.AccountType, request.Currency)
+		return err
+	})
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la mise à jour des plafonds")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Plafonds mis à jour avec succès",
+		Data:    set,
+	})
+}
+
+// adminListTransferLimitsHandler récupère les plafonds par défaut de la banque ou, avec
+// user_id, ceux propres à un client
+func (s *server) adminListTransferLimitsHandler(c *gin.Context) {
+	var userID *uint
+	if value := c.Query("user_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "ID d'utilisateur invalide",
+				Error:   "Bad Request",
+			})
+			return
+		}
+		owner := uint(id)
+		userID = &owner
+	}
+
+	transferLimits, err := s.store.TransferLimits.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des plafonds",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Plafonds récupérés avec succès",
+		Data:    transferLimits,
+	})
+}
+
+// adminSetTransferLimitHandler fixe les plafonds de la banque pour un type de compte et une
+// devise, par défaut ou, avec user_id, pour un client : un plafond fixé pour un client
+// remplace le plafond par défaut correspondant. Des plafonds tous vides sont retirés.
+func (s *server) adminSetTransferLimitHandler(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	var request struct {
+		limitsRequest
+		UserID *uint `json:"user_id"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	values, err := request.parse()
+	if err == nil && request.UserID != nil {
+		if _, findErr := s.store.Users.FindByID(*request.UserID); findErr != nil {
+			err = newHandlerError(http.StatusNotFound, "Utilisateur non trouvé")
+		}
+	}
+	if err == nil {
+		err = saveLimits(s.store, request.UserID, request.limitsRequest, models.LimitSourceBank, values, adminID.(uint))
+	}
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la mise à jour des plafonds")
+		return
+	}
+
+	transferLimits, err := s.store.TransferLimits.List(request.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des plafonds",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Plafonds mis à jour avec succès",
+		Data:    transferLimits,
+	})
+}
+
+// adminDeleteTransferLimitHandler retire des plafonds, de la banque ou choisis par un client
+func (s *server) adminDeleteTransferLimitHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de plafond invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	limit, err := s.store.TransferLimits.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Plafond non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+	if err := s.store.TransferLimits.Delete(limit); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la suppression des plafonds",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Plafonds supprimés avec succès",
+	})
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -8,8 +10,13 @@ import (
 	"time"
 
 	"banking-app/shared/database"
+	"banking-app/shared/fx"
+	"banking-app/shared/ledger"
+	"banking-app/shared/limits"
 	"banking-app/shared/middleware"
 	"banking-app/shared/models"
+	"banking-app/shared/notify"
+	"banking-app/shared/repository"
 	"banking-app/shared/utils"
 
 	"github.com/gin-gonic/gin"
@@ -32,6 +39,43 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	srv := newServer(repository.NewStore(database.GetDB()))
+	r := srv.setupRouter()
+
+	// Exécution des virements programmés et expiration des réservations échus
+	if schedulerEnabled() {
+		go srv.runScheduler(schedulerInterval())
+	}
+
+	// Démarrage du serveur
+	port := os.Getenv("TRANSACTIONS_SERVICE_PORT")
+	if port == "" {
+		port = "8081"
+	}
+
+	log.Printf("Service de transactions démarré sur le port %s", port)
+	log.Fatal(http.ListenAndServe(":"+port, r))
+}
+
+// server regroupe les dépendances des handlers du service de transactions
+type server struct {
+	store    *repository.Store
+	rates    fx.Provider
+	notifier notify.Notifier
+}
+
+// newServer crée le service à partir des dépôts de données ; les cours de change
+// sont lus dans le fichier FX_RATES_FILE
+func newServer(store *repository.Store) *server {
+	return &server{
+		store:    store,
+		rates:    fx.NewFileProvider(os.Getenv("FX_RATES_FILE"), fx.MaxRateAge()),
+		notifier: notify.NewStoreNotifier(store),
+	}
+}
+
+// setupRouter configure les routes du service de transactions
+func (s *server) setupRouter() *gin.Engine {
 	r := gin.Default()
 
 	// Middleware
@@ -46,30 +90,69 @@ func main() {
 	})
 
 	// Protected routes
-	r.Use(middleware.AuthMiddleware())
+	r.Use(middleware.AuthMiddleware(s.store.Users, s.store.Sessions))
+
+	// Les opérations qui déplacent des fonds acceptent l'en-tête Idempotency-Key
+	idempotency := middleware.IdempotencyMiddleware(s.store.DB)
 
 	// Routes
 	transactions := r.Group("/api/transactions")
 	{
-		transactions.GET("/", getTransactionsHandler)
-		transactions.POST("/", createTransactionHandler)
-		transactions.GET("/:id", getTransactionHandler)
-		transactions.GET("/account/:accountId", getAccountTransactionsHandler)
-		transactions.POST("/transfer", transferHandler)
-	}
-
-	// Démarrage du serveur
-	port := os.Getenv("TRANSACTIONS_SERVICE_PORT")
-	if port == "" {
-		port = "8081"
-	}
-
-	log.Printf("Service de transactions démarré sur le port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+		transactions.GET("/", s.getTransactionsHandler)
+		transactions.POST("/", middleware.RequireVerifiedEmail(), idempotency, s.createTransactionHandler)
+		transactions.GET("/:id", s.getTransactionHandler)
+		transactions.GET("/account/:accountId", s.getAccountTransactionsHandler)
+		transactions.POST("/transfer", middleware.RequireVerifiedEmail(), idempotency, s.transferHandler)
+		transactions.POST("/:id/refund", middleware.RequireVerifiedEmail(), idempotency, s.refundTransactionHandler)
+		transactions.GET("/account/:accountId/postings", s.getAccountPostingsHandler)
+		transactions.GET("/account/:accountId/reconciliation", s.reconcileAccountHandler)
+		transactions.GET("/account/:accountId/export", s.exportAccountTransactionsHandler)
+		transactions.POST("/fx/quotes", s.createQuoteHandler)
+		// Un lot est identifié par l'identifiant de son message, unique par client : il joue
+		// le rôle de clé d'idempotence
+		transactions.GET("/bulk", s.listBulkPaymentsHandler)
+		transactions.POST("/bulk", middleware.RequireVerifiedEmail(), s.createBulkPaymentHandler)
+		transactions.GET("/bulk/:id", s.getBulkPaymentHandler)
+		transactions.GET("/beneficiaries", s.listBeneficiariesHandler)
+		transactions.POST("/beneficiaries", middleware.RequireVerifiedEmail(), s.createBeneficiaryHandler)
+		transactions.GET("/beneficiaries/:id", s.getBeneficiaryHandler)
+		transactions.PUT("/beneficiaries/:id", s.updateBeneficiaryHandler)
+		transactions.DELETE("/beneficiaries/:id", s.deleteBeneficiaryHandler)
+		transactions.GET("/limits", s.getLimitsHandler)
+		transactions.PUT("/limits", s.updateLimitsHandler)
+		transactions.GET("/holds", s.listHoldsHandler)
+		transactions.POST("/holds", middleware.RequireVerifiedEmail(), idempotency, s.createHoldHandler)
+		transactions.GET("/holds/:id", s.getHoldHandler)
+		transactions.POST("/holds/:id/capture", idempotency, s.captureHoldHandler)
+		transactions.POST("/holds/:id/void", s.voidHoldHandler)
+		transactions.GET("/scheduled", s.listStandingOrdersHandler)
+		transactions.POST("/scheduled", middleware.RequireVerifiedEmail(), s.createStandingOrderHandler)
+		transactions.GET("/scheduled/:id", s.getStandingOrderHandler)
+		transactions.PUT("/scheduled/:id", s.updateStandingOrderHandler)
+		transactions.DELETE("/scheduled/:id", s.cancelStandingOrderHandler)
+	}
+
+	// Routes d'administration
+	admin := r.Group("/api/admin/transactions")
+	admin.Use(middleware.RequirePermission(models.PermTransactionsRead))
+	{
+		admin.GET("/:id", s.adminGetTransactionHandler)
+		admin.GET("/account/:accountId", s.adminGetAccountTransactionsHandler)
+		admin.POST("/:id/reverse", middleware.RequirePermission(models.PermTransactionsReverse), idempotency, s.adminReverseTransactionHandler)
+		admin.GET("/limits", middleware.RequirePermission(models.PermAccountsLimits), s.adminListTransferLimitsHandler)
+		admin.PUT("/limits", middleware.RequirePermission(models.PermAccountsLimits), s.adminSetTransferLimitHandler)
+		admin.DELETE("/limits/:id", middleware.RequirePermission(models.PermAccountsLimits), s.adminDeleteTransferLimitHandler)
+		admin.GET("/fraud/decisions", s.adminListFraudDecisionsHandler)
+		admin.GET("/fraud/decisions/:id", s.adminGetFraudDecisionHandler)
+		admin.POST("/fraud/decisions/:id/release", middleware.RequirePermission(models.PermFraudReview), s.adminReleaseFraudDecisionHandler)
+		admin.POST("/fraud/decisions/:id/reject", middleware.RequirePermission(models.PermFraudReview), s.adminRejectFraudDecisionHandler)
+	}
+
+	return r
 }
 
-// getTransactionsHandler récupère toutes les transactions de l'utilisateur
-func getTransactionsHandler(c *gin.Context) {
+// getTransactionsHandler récupère une page des transactions de l'utilisateur
+func (s *server) getTransactionsHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -80,29 +163,18 @@ func getTransactionsHandler(c *gin.Context) {
 		return
 	}
 
-	var transactions []models.Transaction
-	if err := database.GetDB().
-		Joins("JOIN accounts ON transactions.account_id = accounts.id").
-		Where("accounts.user_id = ?", userID).
-		Preload("Account").
-		Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Erreur lors de la récupération des transactions",
-			Error:   "Internal Server Error",
-		})
+	filter, page, err := parseListRequest(c, models.DefaultCurrency)
+	if err != nil {
+		respondWithError(c, err, "Paramètres de recherche invalides")
 		return
 	}
+	filter.UserID = userID.(uint)
 
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Message: "Transactions récupérées avec succès",
-		Data:    transactions,
-	})
+	s.listTransactions(c, filter, page, "Transactions récupérées avec succès")
 }
 
 // createTransactionHandler crée une nouvelle transaction
-func createTransactionHandler(c *gin.Context) {
+func (s *server) createTransactionHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -114,10 +186,10 @@ func createTransactionHandler(c *gin.Context) {
 	}
 
 	var request struct {
-		AccountID   uint    `json:"account_id" binding:"required"`
-		Type        string  `json:"type" binding:"required"`
-		Amount      float64 `json:"amount" binding:"required,gt=0"`
-		Description string  `json:"description"`
+		AccountID   uint        `json:"account_id" binding:"required"`
+		Type        string      `json:"type" binding:"required"`
+		Amount      json.Number `json:"amount" binding:"required"`
+		Description string      `json:"description"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -129,8 +201,8 @@ func createTransactionHandler(c *gin.Context) {
 		return
 	}
 
-	// Valider le type de transaction
-	if !utils.ValidateTransactionType(request.Type) {
+	// Valider le type de transaction (les annulations passent par leur propre route)
+	if !utils.ValidateTransactionType(request.Type) || request.Type == "reversal" {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Message: "Type de transaction invalide. Types valides: debit, credit",
@@ -139,38 +211,6 @@ func createTransactionHandler(c *gin.Context) {
 		return
 	}
 
-	// Vérifier que le compte appartient à l'utilisateur
-	var account models.Account
-	if err := database.GetDB().Where("id = ? AND user_id = ?", request.AccountID, userID).
-		First(&account).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.APIResponse{
-			Success: false,
-			Message: "Compte non trouvé",
-			Error:   "Not Found",
-		})
-		return
-	}
-
-	// Vérifier que le compte est actif
-	if account.Status != "active" {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
-			Success: false,
-			Message: "Le compte n'est pas actif",
-			Error:   "Bad Request",
-		})
-		return
-	}
-
-	// Pour les débits, vérifier que le solde est suffisant
-	if request.Type == "debit" && account.Balance < request.Amount {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
-			Success: false,
-			Message: "Solde insuffisant",
-			Error:   "Bad Request",
-		})
-		return
-	}
-
 	// Générer une référence unique
 	reference, err := utils.GenerateTransactionReference()
 	if err != nil {
@@ -182,65 +222,73 @@ func createTransactionHandler(c *gin.Context) {
 		return
 	}
 
-	// Démarrer une transaction de base de données
-	tx := database.GetDB().Begin()
-
-	// Créer la transaction
-	transaction := models.Transaction{
-		AccountID:   request.AccountID,
-		Type:        request.Type,
-		Amount:      request.Amount,
-		Currency:    account.Currency,
-		Description: request.Description,
-		Reference:   reference,
-		Status:      "completed",
-	}
-
-	if err := tx.Create(&transaction).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Erreur lors de la création de la transaction",
-			Error:   "Internal Server Error",
-		})
-		return
-	}
-
-	// Mettre à jour le solde du compte
-	var newBalance float64
-	if request.Type == "credit" {
-		newBalance = account.Balance + request.Amount
-	} else {
-		newBalance = account.Balance - request.Amount
-	}
-
-	if err := tx.Model(&account).Update("balance", newBalance).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Erreur lors de la mise à jour du solde",
-			Error:   "Internal Server Error",
-		})
-		return
-	}
-
-	// Marquer la transaction comme traitée
-	now := time.Now()
-	transaction.ProcessedAt = &now
+	var transaction models.Transaction
 
-	if err := tx.Save(&transaction).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Erreur lors de la finalisation de la transaction",
-			Error:   "Internal Server Error",
-		})
+	// Le contrôle du solde et sa mise à jour se font sous verrou dans la même transaction
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		// Vérifier que le compte appartient à l'utilisateur
+		account, err := tx.Accounts.LockForUser(request.AccountID, userID.(uint))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return newHandlerError(http.StatusNotFound, "Compte non trouvé")
+			}
+			return err
+		}
+
+		// Vérifier que le compte est actif
+		if account.Status != "active" {
+			return newHandlerError(http.StatusBadRequest, "Le compte n'est pas actif")
+		}
+
+		// Le montant est exprimé dans la devise du compte
+		amount, err := parseAmount(request.Amount, account.Currency)
+		if err != nil {
+			return err
+		}
+		if request.Type != "credit" {
+			if err := authorizeOutflow(tx, account, limits.Outflow{Amount: amount, Reference: reference}); err != nil {
+				return err
+			}
+		}
+
+		// Écriture comptable : le compte client contre la contrepartie externe
+		// (les débits échouent si le solde est insuffisant)
+		movement := amount
+		if request.Type != "credit" {
+			movement = amount.Neg()
+		}
+		entry := models.JournalEntry{
+			Reference:   reference,
+			Description: request.Description,
+			Postings: []models.Posting{
+				ledger.CustomerPosting(account.ID, movement),
+				ledger.SystemPosting(ledger.ExternalAccount, movement.Neg()),
+			},
+		}
+		if err := postEntry(tx.DB, &entry); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		transaction = models.Transaction{
+			AccountID:      request.AccountID,
+			Type:           request.Type,
+			Amount:         amount,
+			Currency:       account.Currency,
+			Description:    request.Description,
+			Reference:      reference,
+			Status:         "completed",
+			ProcessedAt:    &now,
+			JournalEntryID: &entry.ID,
+		}
+
+		return tx.Transactions.Create(&transaction)
+	})
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la création de la transaction")
 		return
 	}
 
-	// Confirmer la transaction
-	tx.Commit()
-
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "Transaction créée avec succès",
@@ -249,7 +297,7 @@ func createTransactionHandler(c *gin.Context) {
 }
 
 // getTransactionHandler récupère une transaction spécifique
-func getTransactionHandler(c *gin.Context) {
+func (s *server) getTransactionHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -271,12 +319,8 @@ func getTransactionHandler(c *gin.Context) {
 		return
 	}
 
-	var transaction models.Transaction
-	if err := database.GetDB().
-		Joins("JOIN accounts ON transactions.account_id = accounts.id").
-		Where("transactions.id = ? AND accounts.user_id = ?", id, userID).
-		Preload("Account").
-		First(&transaction).Error; err != nil {
+	transaction, err := s.store.Transactions.FindForUser(uint(id), userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Transaction non trouvée",
@@ -293,7 +337,7 @@ func getTransactionHandler(c *gin.Context) {
 }
 
 // getAccountTransactionsHandler récupère les transactions d'un compte
-func getAccountTransactionsHandler(c *gin.Context) {
+func (s *server) getAccountTransactionsHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -316,9 +360,8 @@ func getAccountTransactionsHandler(c *gin.Context) {
 	}
 
 	// Vérifier que le compte appartient à l'utilisateur
-	var account models.Account
-	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).
-		First(&account).Error; err != nil {
+	account, err := s.store.Accounts.FindForUser(uint(id), userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Compte non trouvé",
@@ -327,27 +370,18 @@ func getAccountTransactionsHandler(c *gin.Context) {
 		return
 	}
 
-	var transactions []models.Transaction
-	if err := database.GetDB().Where("account_id = ?", id).
-		Order("created_at DESC").
-		Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Erreur lors de la récupération des transactions",
-			Error:   "Internal Server Error",
-		})
+	filter, page, err := parseListRequest(c, account.Currency)
+	if err != nil {
+		respondWithError(c, err, "Paramètres de recherche invalides")
 		return
 	}
+	filter.AccountID = account.ID
 
-	c.JSON(http.StatusOK, models.APIResponse{
-		Success: true,
-		Message: "Transactions du compte récupérées avec succès",
-		Data:    transactions,
-	})
+	s.listTransactions(c, filter, page, "Transactions du compte récupérées avec succès")
 }
 
 // transferHandler gère les transferts entre comptes
-func transferHandler(c *gin.Context) {
+func (s *server) transferHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
@@ -359,10 +393,13 @@ func transferHandler(c *gin.Context) {
 	}
 
 	var request struct {
-		FromAccountID uint    `json:"from_account_id" binding:"required"`
-		ToAccountID   uint    `json:"to_account_id" binding:"required"`
-		Amount        float64 `json:"amount" binding:"required,gt=0"`
-		Description   string  `json:"description"`
+		FromAccountID uint        `json:"from_account_id" binding:"required"`
+		ToAccountID   uint        `json:"to_account_id"`
+		ToIBAN        string      `json:"to_iban"`        // alternative à to_account_id
+		BeneficiaryID uint        `json:"beneficiary_id"` // bénéficiaire enregistré, alternative à to_account_id
+		Amount        json.Number `json:"amount" binding:"required"`
+		Description   string      `json:"description"`
+		QuoteID       string      `json:"quote_id"` // cotation de change, virements entre devises
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -374,6 +411,14 @@ func transferHandler(c *gin.Context) {
 		return
 	}
 
+	// Le compte destination est désigné par un bénéficiaire, son identifiant ou son IBAN
+	toAccountID, beneficiary, err := s.resolveTransferDestination(userID.(uint), request.BeneficiaryID, request.ToAccountID, request.ToIBAN)
+	if err != nil {
+		respondWithError(c, err, "Erreur lors du transfert")
+		return
+	}
+	request.ToAccountID = toAccountID
+
 	// Vérifier que les comptes sont différents
 	if request.FromAccountID == request.ToAccountID {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
@@ -384,155 +429,165 @@ func transferHandler(c *gin.Context) {
 		return
 	}
 
-	// Vérifier que le compte source appartient à l'utilisateur
-	var fromAccount models.Account
-	if err := database.GetDB().Where("id = ? AND user_id = ?", request.FromAccountID, userID).
-		First(&fromAccount).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.APIResponse{
+	// Générer une référence unique
+	reference, err := utils.GenerateTransactionReference()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
-			Message: "Compte source non trouvé",
-			Error:   "Not Found",
+			Message: "Erreur lors de la génération de la référence",
+			Error:   "Internal Server Error",
 		})
 		return
 	}
 
-	// Vérifier que le compte destination existe
-	var toAccount models.Account
-	if err := database.GetDB().Where("id = ?", request.ToAccountID).
-		First(&toAccount).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.APIResponse{
-			Success: false,
-			Message: "Compte destination non trouvé",
-			Error:   "Not Found",
-		})
+	order := transferOrder{
+		UserID:        userID.(uint),
+		FromAccountID: request.FromAccountID,
+		ToAccountID:   request.ToAccountID,
+		Amount:        request.Amount,
+		Description:   request.Description,
+		QuoteID:       request.QuoteID,
+		Reference:     reference,
+		Beneficiary:   beneficiary,
+	}
+
+	// Le contrôle anti-fraude peut refuser le virement ou le mettre en attente de vérification
+	var result transferResult
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		var err error
+		result, err = s.screenedTransfer(tx, order)
+		return err
+	})
+	var held *heldTransferError
+	if errors.As(err, &held) {
+		if err := s.store.Fraud.Create(held.decision); err != nil {
+			respondWithError(c, err, "Erreur lors du transfert")
+			return
+		}
+		s.respondToHeldTransfer(c, held.decision)
+		return
+	}
+	if err != nil {
+		respondWithError(c, err, "Erreur lors du transfert")
 		return
 	}
 
-	// Vérifications
-	if fromAccount.Status != "active" {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+	transferResult := map[string]interface{}{
+		"transfer_reference": reference,
+		"debit_transaction":  result.Debit,
+		"credit_transaction": result.Credit,
+		"amount":             result.Debit.Amount,
+		"credited_amount":    result.Credit.Amount,
+		"from_account_id":    request.FromAccountID,
+		"to_account_id":      request.ToAccountID,
+	}
+	if beneficiary != nil {
+		transferResult["beneficiary_id"] = beneficiary.ID
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Transfert effectué avec succès",
+		Data:    transferResult,
+	})
+}
+
+// getAccountPostingsHandler récupère les lignes d'écriture d'un compte (piste d'audit du solde)
+func (s *server) getAccountPostingsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
-			Message: "Le compte source n'est pas actif",
-			Error:   "Bad Request",
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
 		})
 		return
 	}
 
-	if toAccount.Status != "active" {
+	accountID := c.Param("accountId")
+	id, err := strconv.ParseUint(accountID, 10, 32)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Le compte destination n'est pas actif",
+			Message: "ID de compte invalide",
 			Error:   "Bad Request",
 		})
 		return
 	}
 
-	if fromAccount.Balance < request.Amount {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+	// Vérifier que le compte appartient à l'utilisateur
+	if _, err := s.store.Accounts.FindForUser(uint(id), userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
-			Message: "Solde insuffisant",
-			Error:   "Bad Request",
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
 		})
 		return
 	}
 
-	// Générer une référence unique
-	reference, err := utils.GenerateTransactionReference()
+	postings, err := s.store.Transactions.ListPostings(uint(id))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
-			Message: "Erreur lors de la génération de la référence",
+			Message: "Erreur lors de la récupération des écritures",
 			Error:   "Internal Server Error",
 		})
 		return
 	}
 
-	// Démarrer une transaction de base de données
-	tx := database.GetDB().Begin()
-
-	now := time.Now()
-
-	// Créer la transaction de débit
-	debitTransaction := models.Transaction{
-		AccountID:   request.FromAccountID,
-		Type:        "transfer",
-		Amount:      request.Amount,
-		Currency:    fromAccount.Currency,
-		Description: request.Description,
-		Reference:   reference + "-OUT",
-		Status:      "completed",
-		ToAccountID: &request.ToAccountID,
-		ProcessedAt: &now,
-	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Écritures du compte récupérées avec succès",
+		Data:    postings,
+	})
+}
 
-	if err := tx.Create(&debitTransaction).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+// reconcileAccountHandler compare le solde d'un compte à la somme de ses écritures
+func (s *server) reconcileAccountHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
-			Message: "Erreur lors de la création de la transaction de débit",
-			Error:   "Internal Server Error",
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
 		})
 		return
 	}
 
-	// Créer la transaction de crédit
-	creditTransaction := models.Transaction{
-		AccountID:   request.ToAccountID,
-		Type:        "transfer",
-		Amount:      request.Amount,
-		Currency:    toAccount.Currency,
-		Description: request.Description,
-		Reference:   reference + "-IN",
-		Status:      "completed",
-		ProcessedAt: &now,
-	}
-
-	if err := tx.Create(&creditTransaction).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+	accountID := c.Param("accountId")
+	id, err := strconv.ParseUint(accountID, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Erreur lors de la création de la transaction de crédit",
-			Error:   "Internal Server Error",
+			Message: "ID de compte invalide",
+			Error:   "Bad Request",
 		})
 		return
 	}
 
-	// Mettre à jour les soldes
-	if err := tx.Model(&fromAccount).Update("balance", fromAccount.Balance-request.Amount).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+	account, err := s.store.Accounts.FindForUser(uint(id), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
-			Message: "Erreur lors de la mise à jour du solde source",
-			Error:   "Internal Server Error",
+			Message: "Compte non trouvé",
+			Error:   "Not Found",
 		})
 		return
 	}
 
-	if err := tx.Model(&toAccount).Update("balance", toAccount.Balance+request.Amount).Error; err != nil {
-		tx.Rollback()
+	reconciliation, err := ledger.Reconcile(s.store.DB, *account)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
-			Message: "Erreur lors de la mise à jour du solde destination",
+			Message: "Erreur lors du rapprochement du compte",
 			Error:   "Internal Server Error",
 		})
 		return
 	}
 
-	// Confirmer la transaction
-	tx.Commit()
-
-	transferResult := map[string]interface{}{
-		"transfer_reference": reference,
-		"debit_transaction":  debitTransaction,
-		"credit_transaction": creditTransaction,
-		"amount":             request.Amount,
-		"from_account_id":    request.FromAccountID,
-		"to_account_id":      request.ToAccountID,
-	}
-
-	c.JSON(http.StatusCreated, models.APIResponse{
+	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "Transfert effectué avec succès",
-		Data:    transferResult,
+		Message: "Rapprochement effectué avec succès",
+		Data:    reconciliation,
 	})
 }
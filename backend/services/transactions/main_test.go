@@ -0,0 +1,1894 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-app/shared/fx"
+	"banking-app/shared/ledger"
+	"banking-app/shared/limits"
+	"banking-app/shared/middleware"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/testutil"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTestRouter crée une base SQLite de test et retourne le routeur du service. La règle
+// anti-fraude de plage horaire est désactivée pour ne pas dépendre de l'heure des tests.
+func setupTestRouter(t *testing.T) (*gin.Engine, *repository.Store) {
+	t.Helper()
+	t.Setenv("FRAUD_NIGHT_HOURS", "0-0")
+
+	store := testutil.NewStore(t)
+	return newServer(store).setupRouter(), store
+}
+
+func accountBalance(t *testing.T, store *repository.Store, id uint) int64 {
+	t.Helper()
+
+	account, err := store.Accounts.FindByID(id)
+	if err != nil {
+		t.Fatalf("lecture du compte %d: %v", id, err)
+	}
+	return account.Balance.Amount
+}
+
+// TestConcurrentDebitsNoLostUpdates vérifie que des débits simultanés ne dépassent jamais le solde
+func TestConcurrentDebitsNoLostUpdates(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 10000)
+
+	const workers = 25
+	var wg sync.WaitGroup
+	statuses := make(chan int, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := testutil.Request(router, http.MethodPost, "/api/transactions/", token, map[string]interface{}{
+				"account_id": account.ID,
+				"type":       "debit",
+				"amount":     "10.00",
+			})
+			statuses <- rec.Code
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	succeeded := 0
+	for status := range statuses {
+		switch status {
+		case http.StatusCreated:
+			succeeded++
+		case http.StatusBadRequest:
+		default:
+			t.Errorf("statut inattendu: %d", status)
+		}
+	}
+
+	if succeeded != 10 {
+		t.Errorf("débits réussis = %d, attendu 10", succeeded)
+	}
+	if balance := accountBalance(t, store, account.ID); balance != 0 {
+		t.Errorf("solde final = %d centimes, attendu 0", balance)
+	}
+}
+
+// TestConcurrentCrossTransfers vérifie l'absence d'interblocage et la conservation des fonds
+func TestConcurrentCrossTransfers(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	first := testutil.CreateAccount(t, store, user.ID, 10000)
+	second := testutil.CreateAccount(t, store, user.ID, 10000)
+
+	const transfersPerSide = 20
+	var wg sync.WaitGroup
+	failures := make(chan string, 2*transfersPerSide)
+
+	transfer := func(from, to uint) {
+		defer wg.Done()
+		rec := testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+			"from_account_id": from,
+			"to_account_id":   to,
+			"amount":          5,
+		})
+		if rec.Code != http.StatusCreated {
+			failures <- rec.Body.String()
+		}
+	}
+
+	for i := 0; i < transfersPerSide; i++ {
+		wg.Add(2)
+		go transfer(first.ID, second.ID)
+		go transfer(second.ID, first.ID)
+	}
+	wg.Wait()
+	close(failures)
+
+	for failure := range failures {
+		t.Errorf("transfert en échec: %s", failure)
+	}
+
+	firstBalance := accountBalance(t, store, first.ID)
+	secondBalance := accountBalance(t, store, second.ID)
+	if firstBalance != 10000 || secondBalance != 10000 {
+		t.Errorf("soldes finaux = %d / %d centimes, attendu 10000 / 10000", firstBalance, secondBalance)
+	}
+}
+
+// TestTransferPostsBalancedEntry vérifie qu'un transfert met à jour les deux soldes et reste réconcilié
+func TestTransferPostsBalancedEntry(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": from.ID,
+		"to_account_id":   to.ID,
+		"amount":          "12.34",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+
+	if balance := accountBalance(t, store, from.ID); balance != 8766 {
+		t.Errorf("solde source = %d centimes, attendu 8766", balance)
+	}
+	if balance := accountBalance(t, store, to.ID); balance != 1234 {
+		t.Errorf("solde destination = %d centimes, attendu 1234", balance)
+	}
+
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/transactions/account/%d/reconciliation", from.ID), token, nil)
+	var reconciliation struct {
+		Balanced bool `json:"balanced"`
+	}
+	testutil.DecodeData(t, rec, &reconciliation)
+	if !reconciliation.Balanced {
+		t.Errorf("compte non réconcilié: %s", rec.Body.String())
+	}
+}
+
+// TestTransactionsAreScopedToOwner vérifie qu'un utilisateur ne peut pas débiter le compte d'un autre
+func TestTransactionsAreScopedToOwner(t *testing.T) {
+	router, store := setupTestRouter(t)
+	_, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, other.ID, 10000)
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/", token, map[string]interface{}{
+		"account_id": account.ID,
+		"type":       "debit",
+		"amount":     "1.00",
+	})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("statut = %d, attendu 404", rec.Code)
+	}
+	if balance := accountBalance(t, store, account.ID); balance != 10000 {
+		t.Errorf("solde = %d centimes, attendu 10000", balance)
+	}
+}
+
+// TestIdempotentTransactionReplay vérifie qu'une requête rejouée ne crée qu'une transaction
+func TestIdempotentTransactionReplay(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 10000)
+
+	send := func(amount string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{
+			"account_id": account.ID,
+			"type":       "debit",
+			"amount":     amount,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(middleware.IdempotencyHeader, "debit-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("25.00")
+	second := send("25.00")
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("statuts = %d / %d, attendu 201 / 201", first.Code, second.Code)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("la seconde réponse devrait être rejouée")
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Error("la réponse rejouée diffère de l'originale")
+	}
+	if balance := accountBalance(t, store, account.ID); balance != 7500 {
+		t.Errorf("solde = %d centimes, attendu 7500", balance)
+	}
+
+	if rec := send("30.00"); rec.Code != http.StatusConflict {
+		t.Errorf("statut = %d, attendu 409 pour un corps différent", rec.Code)
+	}
+}
+
+// TestTransferRequiresVerifiedEmail vérifie qu'un utilisateur non vérifié ne peut pas initier de virement
+func TestTransferRequiresVerifiedEmail(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, user.ID, 0)
+
+	user.EmailVerifiedAt = nil
+	if err := store.Users.Save(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": from.ID,
+		"to_account_id":   to.ID,
+		"amount":          "1.00",
+	})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("statut = %d, attendu 403", rec.Code)
+	}
+	if balance := accountBalance(t, store, from.ID); balance != 10000 {
+		t.Errorf("solde = %d centimes, attendu 10000", balance)
+	}
+}
+
+// TestDebitRequiresVerifiedEmail vérifie qu'un utilisateur non vérifié ne peut pas débiter son compte
+func TestDebitRequiresVerifiedEmail(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 10000)
+
+	user.EmailVerifiedAt = nil
+	if err := store.Users.Save(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/", token, map[string]interface{}{
+		"account_id": account.ID,
+		"type":       "debit",
+		"amount":     "1.00",
+	})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("statut = %d, attendu 403", rec.Code)
+	}
+	if balance := accountBalance(t, store, account.ID); balance != 10000 {
+		t.Errorf("solde = %d centimes, attendu 10000", balance)
+	}
+}
+
+// TestAdminCanViewAnyTransaction vérifie l'accès du support aux transactions de tous les clients
+func TestAdminCanViewAnyTransaction(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	_, supportToken := testutil.CreateUserWithRole(t, store, models.RoleSupport)
+	account := testutil.CreateAccount(t, store, user.ID, 10000)
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/", token, map[string]interface{}{
+		"account_id": account.ID,
+		"type":       "debit",
+		"amount":     "5.00",
+	})
+	var created models.Transaction
+	testutil.DecodeData(t, rec, &created)
+
+	path := fmt.Sprintf("/api/admin/transactions/%d", created.ID)
+	if rec := testutil.Request(router, http.MethodGet, path, token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("accès client à l'administration: statut = %d, attendu 403", rec.Code)
+	}
+	if rec := testutil.Request(router, http.MethodGet, path, supportToken, nil); rec.Code != http.StatusOK {
+		t.Errorf("transaction: statut = %d, attendu 200", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/admin/transactions/account/%d", account.ID), supportToken, nil)
+	var transactions []models.Transaction
+	testutil.DecodeData(t, rec, &transactions)
+	if len(transactions) != 1 {
+		t.Errorf("transactions du compte = %d, attendu 1", len(transactions))
+	}
+}
+
+// TestTransactionListPaginationAndFilters vérifie le parcours par curseur et les filtres de recherche
+func TestTransactionListPaginationAndFilters(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 100000)
+
+	for _, amount := range []string{"1.00", "2.00", "3.00", "4.00", "5.00"} {
+		rec := testutil.Request(router, http.MethodPost, "/api/transactions/", token, map[string]interface{}{
+			"account_id":  account.ID,
+			"type":        "debit",
+			"amount":      amount,
+			"description": "Achat " + amount,
+		})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("création: statut = %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	type page struct {
+		Data       []models.Transaction `json:"data"`
+		Pagination struct {
+			HasMore    bool   `json:"has_more"`
+			NextCursor string `json:"next_cursor"`
+		} `json:"pagination"`
+	}
+	fetch := func(query string) page {
+		t.Helper()
+		rec := testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/transactions/account/%d?%s", account.ID, query), token, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET ?%s: statut = %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var result page
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// Les pages successives couvrent toutes les transactions, sans doublon, de la plus récente à la plus ancienne
+	var seen []uint
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination sans fin")
+		}
+		result := fetch("type=debit&limit=2&cursor=" + cursor)
+		for _, transaction := range result.Data {
+			seen = append(seen, transaction.ID)
+		}
+		if !result.Pagination.HasMore {
+			break
+		}
+		cursor = result.Pagination.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Fatalf("transactions parcourues = %v, attendu 5", seen)
+	}
+	for i := 1; i < len(seen); i++ {
+		if seen[i] >= seen[i-1] {
+			t.Errorf("ordre décroissant non respecté: %v", seen)
+		}
+	}
+
+	result := fetch("min_amount=2&max_amount=4.00&order=asc")
+	if len(result.Data) != 3 || result.Data[0].Amount.Amount != 200 || result.Pagination.HasMore {
+		t.Errorf("filtre par montant = %+v, attendu 2.00, 3.00, 4.00", result.Data)
+	}
+	if result := fetch("q=achat%205"); len(result.Data) != 1 {
+		t.Errorf("filtre par description = %d résultats, attendu 1", len(result.Data))
+	}
+	if result := fetch("type=credit"); len(result.Data) != 0 {
+		t.Errorf("filtre par type = %d résultats, attendu 0", len(result.Data))
+	}
+
+	for _, query := range []string{"limit=0", "cursor=invalide", "order=up", "type=autre", "from=hier", "min_amount=5&max_amount=1"} {
+		rec := testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/transactions/account/%d?%s", account.ID, query), token, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET ?%s: statut = %d, attendu 400", query, rec.Code)
+		}
+	}
+}
+
+// TestTransferByIBAN vérifie qu'un virement peut désigner le compte destination par son IBAN
+func TestTransferByIBAN(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+
+	transfer := func(iban string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+			"from_account_id": from.ID,
+			"to_iban":         iban,
+			"amount":          "10.00",
+		})
+	}
+
+	if rec := transfer(utils.FormatIBAN(to.AccountNumber)); rec.Code != http.StatusCreated {
+		t.Fatalf("virement par IBAN: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	if balance := accountBalance(t, store, to.ID); balance != 1000 {
+		t.Errorf("solde destination = %d centimes, attendu 1000", balance)
+	}
+
+	if rec := transfer("FR7630006000011234567890188"); rec.Code != http.StatusBadRequest {
+		t.Errorf("IBAN invalide: statut = %d, attendu 400", rec.Code)
+	}
+	if rec := transfer("FR7630006000011234567890189"); rec.Code != http.StatusNotFound {
+		t.Errorf("IBAN externe: statut = %d, attendu 404", rec.Code)
+	}
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": from.ID,
+		"to_account_id":   to.ID,
+		"to_iban":         to.AccountNumber,
+		"amount":          "10.00",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("destination ambiguë: statut = %d, attendu 400", rec.Code)
+	}
+}
+
+// setupFXRouter crée le routeur du service avec des cours de change fixes contre l'euro
+func setupFXRouter(t *testing.T, rates map[string]string) (*gin.Engine, *repository.Store) {
+	t.Helper()
+
+	store := testutil.NewStore(t)
+	srv := newServer(store)
+	table, err := fx.NewTable(time.Now(), rates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.rates = table
+	return srv.setupRouter(), store
+}
+
+// TestCrossCurrencyTransfer vérifie la conversion au cours courant et l'équilibre du grand livre par devise
+func TestCrossCurrencyTransfer(t *testing.T) {
+	t.Setenv("FX_SPREAD_BPS", "100")
+	router, store := setupFXRouter(t, map[string]string{"USD": "1.25"})
+	user, token := testutil.CreateUser(t, store)
+	usd := testutil.CreateAccountInCurrency(t, store, user.ID, 10000, "USD")
+	eur := testutil.CreateAccount(t, store, user.ID, 0)
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": usd.ID,
+		"to_account_id":   eur.ID,
+		"amount":          "50.00",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		Credit models.Transaction `json:"credit_transaction"`
+		Debit  models.Transaction `json:"debit_transaction"`
+	}
+	testutil.DecodeData(t, rec, &result)
+
+	// 50.00 USD / 1.25 = 40.00 EUR, moins 1 % = 39.60 EUR
+	if balance := accountBalance(t, store, usd.ID); balance != 5000 {
+		t.Errorf("solde USD = %d, attendu 5000", balance)
+	}
+	if balance := accountBalance(t, store, eur.ID); balance != 3960 {
+		t.Errorf("solde EUR = %d, attendu 3960", balance)
+	}
+	for _, leg := range []models.Transaction{result.Debit, result.Credit} {
+		if leg.ExchangeRate == nil || *leg.ExchangeRate != "0.8" || leg.ExchangeSpreadBps == nil || *leg.ExchangeSpreadBps != 100 {
+			t.Errorf("change non enregistré sur la jambe %+v", leg)
+		}
+	}
+	if result.Credit.Currency != "EUR" || result.Credit.Amount.Amount != 3960 {
+		t.Errorf("jambe créditrice = %+v, attendu 39.60 EUR", result.Credit.Amount)
+	}
+
+	for _, account := range []models.Account{usd, eur} {
+		rec := testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/transactions/account/%d/reconciliation", account.ID), token, nil)
+		var reconciliation struct {
+			Balanced bool `json:"balanced"`
+		}
+		testutil.DecodeData(t, rec, &reconciliation)
+		if !reconciliation.Balanced {
+			t.Errorf("compte %d non réconcilié: %s", account.ID, rec.Body.String())
+		}
+	}
+
+	// Sans cours disponible, le virement est refusé
+	gbp := testutil.CreateAccountInCurrency(t, store, user.ID, 0, "GBP")
+	rec = testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": usd.ID,
+		"to_account_id":   gbp.ID,
+		"amount":          "10.00",
+	})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("sans cours: statut = %d, attendu 422", rec.Code)
+	}
+	if balance := accountBalance(t, store, usd.ID); balance != 5000 {
+		t.Errorf("solde USD = %d, attendu 5000", balance)
+	}
+}
+
+// TestTransferWithQuote vérifie qu'une cotation fixe le montant crédité et ne sert qu'une fois
+func TestTransferWithQuote(t *testing.T) {
+	t.Setenv("FX_SPREAD_BPS", "0")
+	router, store := setupFXRouter(t, map[string]string{"USD": "1.10"})
+	user, token := testutil.CreateUser(t, store)
+	eur := testutil.CreateAccount(t, store, user.ID, 10000)
+	usd := testutil.CreateAccountInCurrency(t, store, user.ID, 0, "USD")
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/fx/quotes", token, map[string]interface{}{
+		"from_currency": "EUR",
+		"to_currency":   "USD",
+		"amount":        "20.00",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("cotation: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	var quote models.FXQuote
+	testutil.DecodeData(t, rec, &quote)
+	if quote.ID == "" || quote.TargetAmount.Amount != 2200 || !quote.ExpiresAt.After(time.Now()) {
+		t.Fatalf("cotation = %+v", quote)
+	}
+
+	transfer := func(amount string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+			"from_account_id": eur.ID,
+			"to_account_id":   usd.ID,
+			"amount":          amount,
+			"quote_id":        quote.ID,
+		})
+	}
+
+	if rec := transfer("25.00"); rec.Code != http.StatusBadRequest {
+		t.Errorf("montant différent de la cotation: statut = %d, attendu 400", rec.Code)
+	}
+	if rec := transfer("20.00"); rec.Code != http.StatusCreated {
+		t.Fatalf("virement coté: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	if balance := accountBalance(t, store, usd.ID); balance != 2200 {
+		t.Errorf("solde USD = %d, attendu 2200", balance)
+	}
+	if rec := transfer("20.00"); rec.Code != http.StatusConflict {
+		t.Errorf("cotation réutilisée: statut = %d, attendu 409", rec.Code)
+	}
+}
+
+// TestStandingOrderOccurrences vérifie le calcul des échéances mensuelles et de fin de mois
+func TestStandingOrderOccurrences(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		frequency string
+		n         int
+		want      string
+	}{
+		{models.FrequencyDaily, 1, "2024-02-01"},
+		{models.FrequencyWeekly, 2, "2024-02-14"},
+		{models.FrequencyMonthly, 1, "2024-02-29"},
+		{models.FrequencyMonthly, 2, "2024-03-31"},
+		{models.FrequencyMonthly, 3, "2024-04-30"},
+		{models.FrequencyEndOfMonth, 0, "2024-01-31"},
+		{models.FrequencyEndOfMonth, 1, "2024-02-29"},
+		{models.FrequencyEndOfMonth, 13, "2025-02-28"},
+	}
+	for _, tt := range tests {
+		if got := occurrence(start, tt.frequency, tt.n).Format(dateLayout); got != tt.want {
+			t.Errorf("occurrence(%s, %d) = %s, attendu %s", tt.frequency, tt.n, got, tt.want)
+		}
+	}
+
+	// La prochaine échéance se calcule directement, même des années après le départ
+	nextTests := []struct {
+		frequency string
+		after     string
+		want      string
+	}{
+		{models.FrequencyDaily, "2024-01-30", "2024-01-31"},
+		{models.FrequencyDaily, "2031-05-09", "2031-05-10"},
+		{models.FrequencyWeekly, "2031-05-09", "2031-05-14"},
+		{models.FrequencyMonthly, "2030-06-15", "2030-06-30"},
+		{models.FrequencyMonthly, "2030-06-30", "2030-07-31"},
+		{models.FrequencyEndOfMonth, "2032-02-28", "2032-02-29"},
+	}
+	for _, tt := range nextTests {
+		after, _ := time.Parse(dateLayout, tt.after)
+		order := &models.StandingOrder{StartDate: start, Frequency: tt.frequency}
+		next, ok := nextOccurrence(order, after)
+		if !ok || next.Format(dateLayout) != tt.want {
+			t.Errorf("nextOccurrence(%s, %s) = %s, attendu %s", tt.frequency, tt.after, next.Format(dateLayout), tt.want)
+		}
+	}
+}
+
+// createStandingOrder programme un virement quotidien démarrant aujourd'hui
+func createStandingOrder(t *testing.T, router *gin.Engine, token string, from, to uint, maxRuns int) models.StandingOrder {
+	t.Helper()
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/scheduled", token, map[string]interface{}{
+		"from_account_id": from,
+		"to_account_id":   to,
+		"amount":          "30.00",
+		"frequency":       models.FrequencyDaily,
+		"start_date":      time.Now().UTC().Format(dateLayout),
+		"max_runs":        maxRuns,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	var order models.StandingOrder
+	testutil.DecodeData(t, rec, &order)
+	return order
+}
+
+// TestStandingOrderExecutesEachOccurrenceOnce vérifie qu'une échéance n'est exécutée qu'une
+// fois malgré des passages concurrents, puis que l'ordre se termine après max_runs échéances
+func TestStandingOrderExecutesEachOccurrenceOnce(t *testing.T) {
+	store := testutil.NewStore(t)
+	srv := newServer(store)
+	router := srv.setupRouter()
+	user, token := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, user.ID, 0)
+	order := createStandingOrder(t, router, token, from.ID, to.ID, 2)
+
+	// Plusieurs instances de l'ordonnanceur passent en même temps
+	now := time.Now()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	processed := 0
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := srv.runDueOrders(now)
+			if err != nil {
+				t.Errorf("exécution des ordres: %v", err)
+			}
+			mu.Lock()
+			processed += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if processed != 1 {
+		t.Errorf("échéances exécutées = %d, attendu 1", processed)
+	}
+	if balance := accountBalance(t, store, to.ID); balance != 3000 {
+		t.Errorf("solde destination = %d centimes, attendu 3000", balance)
+	}
+
+	// L'échéance du lendemain termine l'ordre
+	if n, err := srv.runDueOrders(now.AddDate(0, 0, 1)); err != nil || n != 1 {
+		t.Fatalf("second passage: %d échéance(s), erreur %v", n, err)
+	}
+	if n, _ := srv.runDueOrders(now.AddDate(0, 0, 5)); n != 0 {
+		t.Errorf("ordre terminé exécuté %d fois", n)
+	}
+
+	rec := testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/transactions/scheduled/%d", order.ID), token, nil)
+	var detail struct {
+		StandingOrder models.StandingOrder      `json:"standing_order"`
+		Runs          []models.StandingOrderRun `json:"runs"`
+	}
+	testutil.DecodeData(t, rec, &detail)
+	if detail.StandingOrder.Status != models.StandingOrderCompleted || detail.StandingOrder.NextRunAt != nil {
+		t.Errorf("ordre = %s (prochaine échéance %v), attendu completed", detail.StandingOrder.Status, detail.StandingOrder.NextRunAt)
+	}
+	if len(detail.Runs) != 2 || detail.Runs[0].Status != runSucceeded {
+		t.Errorf("exécutions = %+v, attendu 2 réussies", detail.Runs)
+	}
+	if balance := accountBalance(t, store, from.ID); balance != 4000 {
+		t.Errorf("solde source = %d centimes, attendu 4000", balance)
+	}
+}
+
+// TestStandingOrderCatchUpAfterOutage vérifie qu'après une interruption seule la dernière
+// échéance manquée est exécutée, les précédentes étant sautées et tracées
+func TestStandingOrderCatchUpAfterOutage(t *testing.T) {
+	store := testutil.NewStore(t)
+	srv := newServer(store)
+	router := srv.setupRouter()
+	user, token := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, user.ID, 0)
+	order := createStandingOrder(t, router, token, from.ID, to.ID, 10)
+	capped := createStandingOrder(t, router, token, from.ID, to.ID, 3)
+
+	// Six échéances quotidiennes manquées : une seule est exécutée par ordre
+	now := time.Now().AddDate(0, 0, 5)
+	if n, err := srv.runDueOrders(now); err != nil || n != 2 {
+		t.Fatalf("passage: %d échéance(s), erreur %v, attendu 2", n, err)
+	}
+	if n, _ := srv.runDueOrders(now); n != 0 {
+		t.Errorf("échéances sautées exécutées au passage suivant: %d", n)
+	}
+	if balance := accountBalance(t, store, to.ID); balance != 6000 {
+		t.Errorf("solde destination = %d centimes, attendu 6000", balance)
+	}
+
+	saved, err := store.StandingOrders.FindForUser(order.ID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantNext := startOfDay(now).AddDate(0, 0, 1)
+	if saved.RunCount != 6 || saved.NextRunAt == nil || !saved.NextRunAt.Equal(wantNext) {
+		t.Errorf("ordre = %d échéances, prochaine %v, attendu 6 et %s", saved.RunCount, saved.NextRunAt, wantNext)
+	}
+	runs, err := store.StandingOrders.ListRuns(order.ID, 10)
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("exécutions = %+v, erreur %v, attendu 2", runs, err)
+	}
+	statuses := map[string]string{}
+	for _, run := range runs {
+		statuses[run.Status] = run.Error
+	}
+	if !strings.HasPrefix(statuses[runSkipped], "5 échéance(s)") {
+		t.Errorf("échéances sautées = %q, attendu 5", statuses[runSkipped])
+	}
+	if _, ok := statuses[runSucceeded]; !ok {
+		t.Errorf("exécutions = %+v, attendu une échéance réussie", runs)
+	}
+
+	// max_runs compte les échéances sautées, sans sauter la dernière échéance permise
+	saved, err = store.StandingOrders.FindForUser(capped.ID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.RunCount != 3 || saved.Status != models.StandingOrderCompleted {
+		t.Errorf("ordre plafonné = %d échéances, statut %s, attendu 3 et completed", saved.RunCount, saved.Status)
+	}
+}
+
+// TestStandingOrderRecordsInsufficientFunds vérifie qu'un solde insuffisant est tracé, notifié
+// par email, et que l'ordre passe à l'échéance suivante
+func TestStandingOrderRecordsInsufficientFunds(t *testing.T) {
+	store := testutil.NewStore(t)
+	srv := newServer(store)
+	router := srv.setupRouter()
+	user, token := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 1000)
+	to := testutil.CreateAccount(t, store, user.ID, 0)
+	order := createStandingOrder(t, router, token, from.ID, to.ID, 0)
+
+	if n, err := srv.runDueOrders(time.Now()); err != nil || n != 1 {
+		t.Fatalf("passage: %d échéance(s), erreur %v", n, err)
+	}
+	if balance := accountBalance(t, store, from.ID); balance != 1000 {
+		t.Errorf("solde source = %d centimes, attendu 1000", balance)
+	}
+
+	updated, err := store.StandingOrders.FindForUser(order.ID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.FailureCount != 1 || updated.Status != models.StandingOrderActive || !updated.NextRunAt.After(*order.NextRunAt) {
+		t.Errorf("ordre après échec = %+v", updated)
+	}
+	runs, err := store.StandingOrders.ListRuns(order.ID, 10)
+	if err != nil || len(runs) != 1 || runs[0].Status != runFailed || runs[0].Error != "Solde insuffisant" {
+		t.Errorf("exécutions = %+v (%v), attendu un échec pour solde insuffisant", runs, err)
+	}
+
+	notifications, err := store.Notifications.ListByUser(user.ID)
+	if err != nil || len(notifications) != 1 || notifications[0].Type != "email" {
+		t.Errorf("notifications = %+v (%v), attendu un email", notifications, err)
+	}
+}
+
+// TestStandingOrderHeldForFraudReview vérifie qu'une échéance mise en attente par le contrôle
+// anti-fraude n'est pas un échec, qu'elle est notifiée comme telle et exécutée à sa libération
+func TestStandingOrderHeldForFraudReview(t *testing.T) {
+	t.Setenv("FRAUD_LARGE_AMOUNT", "10")
+	t.Setenv("FRAUD_NIGHT_HOURS", "0-0")
+	store := testutil.NewStore(t)
+	srv := newServer(store)
+	router := srv.setupRouter()
+	user, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+	order := createStandingOrder(t, router, token, from.ID, to.ID, 0)
+
+	if n, err := srv.runDueOrders(time.Now()); err != nil || n != 1 {
+		t.Fatalf("passage: %d échéance(s), erreur %v", n, err)
+	}
+	updated, err := store.StandingOrders.FindForUser(order.ID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs, err := store.StandingOrders.ListRuns(order.ID, 10)
+	if err != nil || len(runs) != 1 || runs[0].Status != runHeld || runs[0].Reference == "" || updated.FailureCount != 0 {
+		t.Fatalf("exécutions = %+v (%v), ordre = %+v, attendu une échéance en attente", runs, err, updated)
+	}
+	notifications, err := store.Notifications.ListByUser(user.ID)
+	if err != nil || len(notifications) != 1 || notifications[0].Title != "Virement programmé en cours de vérification" {
+		t.Errorf("notifications = %+v (%v), attendu un email de mise en attente", notifications, err)
+	}
+
+	var queue []models.FraudDecision
+	testutil.DecodeData(t, testutil.Request(router, http.MethodGet, "/api/admin/transactions/fraud/decisions?status=pending", adminToken, nil), &queue)
+	if len(queue) != 1 || queue[0].Reference != runs[0].Reference {
+		t.Fatalf("file de vérification = %+v", queue)
+	}
+	rec := testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/admin/transactions/fraud/decisions/%d/release", queue[0].ID), adminToken, nil)
+	if rec.Code != http.StatusOK || accountBalance(t, store, to.ID) != 3000 {
+		t.Errorf("libération: statut = %d, solde destination %d", rec.Code, accountBalance(t, store, to.ID))
+	}
+}
+
+// TestStandingOrderValidationAndCancel vérifie la validation de l'échéancier, la suspension et l'annulation
+func TestStandingOrderValidationAndCancel(t *testing.T) {
+	store := testutil.NewStore(t)
+	srv := newServer(store)
+	router := srv.setupRouter()
+	user, token := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, user.ID, 0)
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(dateLayout)
+	for _, payload := range []map[string]interface{}{
+		{"frequency": "yearly", "start_date": time.Now().UTC().Format(dateLayout)},
+		{"frequency": models.FrequencyDaily, "start_date": yesterday},
+		{"frequency": models.FrequencyDaily, "start_date": "31/01/2024"},
+	} {
+		payload["from_account_id"], payload["to_account_id"], payload["amount"] = from.ID, to.ID, "1.00"
+		if rec := testutil.Request(router, http.MethodPost, "/api/transactions/scheduled", token, payload); rec.Code != http.StatusBadRequest {
+			t.Errorf("%v: statut = %d, attendu 400", payload, rec.Code)
+		}
+	}
+
+	order := createStandingOrder(t, router, token, from.ID, to.ID, 0)
+	path := fmt.Sprintf("/api/transactions/scheduled/%d", order.ID)
+
+	// Un ordre suspendu n'est pas exécuté
+	rec := testutil.Request(router, http.MethodPut, path, token, map[string]interface{}{"status": models.StandingOrderPaused})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+	if n, _ := srv.runDueOrders(time.Now()); n != 0 {
+		t.Errorf("ordre suspendu exécuté %d fois", n)
+	}
+
+	if rec := testutil.Request(router, http.MethodDelete, path, token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+	if rec := testutil.Request(router, http.MethodPut, path, token, map[string]interface{}{"status": models.StandingOrderActive}); rec.Code != http.StatusConflict {
+		t.Errorf("reprise d'un ordre annulé: statut = %d, attendu 409", rec.Code)
+	}
+
+	_, otherToken := testutil.CreateUser(t, store)
+	if rec := testutil.Request(router, http.MethodGet, path, otherToken, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("ordre d'un autre utilisateur: statut = %d, attendu 404", rec.Code)
+	}
+}
+
+// transferFunds effectue un virement et retourne ses deux jambes
+func transferFunds(t *testing.T, router *gin.Engine, token string, from, to uint, amount string) (models.Transaction, models.Transaction) {
+	t.Helper()
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": from,
+		"to_account_id":   to,
+		"amount":          amount,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("virement: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		Debit  models.Transaction `json:"debit_transaction"`
+		Credit models.Transaction `json:"credit_transaction"`
+	}
+	testutil.DecodeData(t, rec, &result)
+	return result.Debit, result.Credit
+}
+
+// TestTransferReversal vérifie les annulations partielles puis totale d'un virement,
+// le plafond du montant annulable et la chaîne d'annulations exposée par GET /:id
+func TestTransferReversal(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+
+	debit, _ := transferFunds(t, router, token, from.ID, to.ID, "50.00")
+	path := fmt.Sprintf("/api/admin/transactions/%d/reverse", debit.ID)
+
+	if rec := testutil.Request(router, http.MethodPost, path, token, map[string]interface{}{"reason": "erreur"}); rec.Code != http.StatusForbidden {
+		t.Errorf("annulation par un client: statut = %d, attendu 403", rec.Code)
+	}
+
+	rec := testutil.Request(router, http.MethodPost, path, adminToken, map[string]interface{}{"amount": "20.00", "reason": "litige"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	if from, to := accountBalance(t, store, from.ID), accountBalance(t, store, to.ID); from != 7000 || to != 3000 {
+		t.Errorf("soldes après annulation partielle = %d / %d centimes, attendu 7000 / 3000", from, to)
+	}
+
+	if rec := testutil.Request(router, http.MethodPost, path, adminToken, map[string]interface{}{"amount": "30.01", "reason": "litige"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("dépassement du montant: statut = %d, attendu 400", rec.Code)
+	}
+
+	// Sans montant, le restant est annulé
+	if rec := testutil.Request(router, http.MethodPost, path, adminToken, map[string]interface{}{"reason": "litige"}); rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	if from, to := accountBalance(t, store, from.ID), accountBalance(t, store, to.ID); from != 10000 || to != 0 {
+		t.Errorf("soldes après annulation totale = %d / %d centimes, attendu 10000 / 0", from, to)
+	}
+	if rec := testutil.Request(router, http.MethodPost, path, adminToken, map[string]interface{}{"reason": "litige"}); rec.Code != http.StatusConflict {
+		t.Errorf("seconde annulation totale: statut = %d, attendu 409", rec.Code)
+	}
+
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/transactions/%d", debit.ID), token, nil)
+	var detail models.Transaction
+	testutil.DecodeData(t, rec, &detail)
+	if detail.Status != "cancelled" || len(detail.Reversals) != 2 || *detail.Reversals[0].ReversalOf != debit.ID {
+		t.Errorf("transaction = %s avec %d annulation(s), attendu cancelled avec 2", detail.Status, len(detail.Reversals))
+	}
+
+	rec = testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/transactions/account/%d/reconciliation", from.ID), token, nil)
+	var reconciliation struct {
+		Balanced bool `json:"balanced"`
+	}
+	testutil.DecodeData(t, rec, &reconciliation)
+	if !reconciliation.Balanced {
+		t.Errorf("compte non réconcilié: %s", rec.Body.String())
+	}
+}
+
+// TestRefundIncomingTransfer vérifie qu'un bénéficiaire peut rembourser un virement reçu, et lui seul
+func TestRefundIncomingTransfer(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	other, otherToken := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+
+	debit, credit := transferFunds(t, router, token, from.ID, to.ID, "40.00")
+
+	if rec := testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/transactions/%d/refund", debit.ID), token, map[string]interface{}{"reason": "annulation"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("remboursement d'un virement émis: statut = %d, attendu 400", rec.Code)
+	}
+	creditPath := fmt.Sprintf("/api/transactions/%d/refund", credit.ID)
+	if rec := testutil.Request(router, http.MethodPost, creditPath, token, map[string]interface{}{"reason": "annulation"}); rec.Code != http.StatusNotFound {
+		t.Errorf("remboursement par l'émetteur: statut = %d, attendu 404", rec.Code)
+	}
+
+	rec := testutil.Request(router, http.MethodPost, creditPath, otherToken, map[string]interface{}{"amount": "15.00", "reason": "trop perçu"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	if from, to := accountBalance(t, store, from.ID), accountBalance(t, store, to.ID); from != 7500 || to != 2500 {
+		t.Errorf("soldes = %d / %d centimes, attendu 7500 / 2500", from, to)
+	}
+
+	notifications, err := store.Notifications.ListByUser(user.ID)
+	if err != nil || len(notifications) != 1 {
+		t.Errorf("notifications de l'émetteur = %+v (%v), attendu 1", notifications, err)
+	}
+}
+
+// TestHoldCaptureVoidAndExpiry vérifie la réservation des fonds, la capture majorée dans la
+// tolérance, l'annulation et l'expiration automatique des autorisations
+func TestHoldCaptureVoidAndExpiry(t *testing.T) {
+	store := testutil.NewStore(t)
+	srv := newServer(store)
+	router := srv.setupRouter()
+	user, token := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 10000)
+
+	authorize := func(amount string) models.Hold {
+		t.Helper()
+		rec := testutil.Request(router, http.MethodPost, "/api/transactions/holds", token, map[string]interface{}{
+			"account_id": account.ID,
+			"amount":     amount,
+		})
+		var hold models.Hold
+		testutil.DecodeData(t, rec, &hold)
+		return hold
+	}
+	heldAmount := func() int64 {
+		t.Helper()
+		account, err := store.Accounts.FindByID(account.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return account.HeldAmount.Amount
+	}
+
+	hold := authorize("60.00")
+	if held := heldAmount(); held != 6000 {
+		t.Errorf("fonds réservés = %d centimes, attendu 6000", held)
+	}
+
+	// Les fonds réservés ne sont plus disponibles pour un débit
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/", token, map[string]interface{}{
+		"account_id": account.ID,
+		"type":       "debit",
+		"amount":     "50.00",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("débit au-delà du disponible: statut = %d, attendu 400", rec.Code)
+	}
+
+	capturePath := fmt.Sprintf("/api/transactions/holds/%d/capture", hold.ID)
+	if rec := testutil.Request(router, http.MethodPost, capturePath, token, map[string]interface{}{"amount": "69.01"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("capture au-delà de la tolérance: statut = %d, attendu 400", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodPost, capturePath, token, map[string]interface{}{"amount": "66.00"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("capture: statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+	if balance, held := accountBalance(t, store, account.ID), heldAmount(); balance != 3400 || held != 0 {
+		t.Errorf("après capture: solde %d, réservé %d centimes, attendu 3400 et 0", balance, held)
+	}
+	if rec := testutil.Request(router, http.MethodPost, capturePath, token, nil); rec.Code != http.StatusConflict {
+		t.Errorf("seconde capture: statut = %d, attendu 409", rec.Code)
+	}
+
+	voided := authorize("20.00")
+	rec = testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/transactions/holds/%d/void", voided.ID), token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("annulation: statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+
+	expiring := authorize("10.00")
+	if n, err := srv.expireHolds(time.Now()); err != nil || n != 0 {
+		t.Errorf("expiration anticipée: %d réservation(s), erreur %v", n, err)
+	}
+	if n, err := srv.expireHolds(time.Now().Add(holdExpiry() + time.Minute)); err != nil || n != 1 {
+		t.Errorf("expiration: %d réservation(s), erreur %v, attendu 1", n, err)
+	}
+	if held := heldAmount(); held != 0 {
+		t.Errorf("fonds réservés après expiration = %d centimes, attendu 0", held)
+	}
+	expired, err := store.Holds.FindForUser(expiring.ID, user.ID)
+	if err != nil || expired.Status != models.HoldExpired {
+		t.Errorf("réservation = %+v (%v), attendu expired", expired, err)
+	}
+	if balance := accountBalance(t, store, account.ID); balance != 3400 {
+		t.Errorf("solde final = %d centimes, attendu 3400", balance)
+	}
+}
+
+func TestOverdraftWithinCreditLimit(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 10000)
+	if err := store.DB.Model(&models.Account{}).Where("id = ?", account.ID).Update("credit_limit_minor", 50000).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	debit := func(amount string) int {
+		return testutil.Request(router, http.MethodPost, "/api/transactions/", token, map[string]interface{}{
+			"account_id": account.ID,
+			"type":       "debit",
+			"amount":     amount,
+		}).Code
+	}
+
+	// Solde 100,00 + découvert 500,00 : un débit de 600,00 est accepté, pas un centime de plus
+	if code := debit("600.00"); code != http.StatusCreated {
+		t.Fatalf("débit dans le découvert: statut = %d, attendu 201", code)
+	}
+	if balance := accountBalance(t, store, account.ID); balance != -50000 {
+		t.Errorf("solde = %d centimes, attendu -50000", balance)
+	}
+	if code := debit("0.01"); code != http.StatusBadRequest {
+		t.Errorf("débit au-delà du découvert: statut = %d, attendu 400", code)
+	}
+}
+
+func TestOverdraftInterest(t *testing.T) {
+	store := testutil.NewStore(t)
+	srv := newServer(store)
+	user, _ := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, -100000)
+	// 36,50 % par an : 0,1 % par jour, soit 1,00 par jour pour 1 000,00 de découvert
+	err := store.DB.Model(&models.Account{}).Where("id = ?", account.ID).
+		Updates(map[string]interface{}{"credit_limit_minor": 100000, "overdraft_rate_bps": 3650}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	from, to := interestPeriod(now)
+	if !from.Equal(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("période = [%s, %s), attendu février 2024", from, to)
+	}
+	// Découvert ouvert le 15 février : 15 jours facturés sur les 29 du mois
+	opened := time.Date(2024, time.February, 15, 9, 0, 0, 0, time.UTC)
+	if err := store.DB.Model(&models.Posting{}).Where("account_id = ?", account.ID).Update("created_at", opened).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := srv.chargeOverdraftInterest(now); err != nil || n != 1 {
+		t.Fatalf("facturation: %d compte(s), erreur %v, attendu 1", n, err)
+	}
+	if balance := accountBalance(t, store, account.ID); balance != -101500 {
+		t.Errorf("solde après intérêts = %d centimes, attendu -101500", balance)
+	}
+	if n, err := srv.chargeOverdraftInterest(now.Add(time.Hour)); err != nil || n != 0 {
+		t.Errorf("seconde facturation du mois: %d compte(s), erreur %v, attendu 0", n, err)
+	}
+
+	var charges []models.Transaction
+	store.DB.Where("account_id = ? AND reference = ?", account.ID, fmt.Sprintf("INT-%d-202402", account.ID)).Find(&charges)
+	if len(charges) != 1 || charges[0].Amount.Amount != 1500 || charges[0].Type != "debit" {
+		t.Errorf("transactions d'intérêts = %+v, attendu un débit de 1500 centimes", charges)
+	}
+
+	charged, err := store.Accounts.FindByID(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reconciliation, err := ledger.Reconcile(store.DB, *charged); err != nil || !reconciliation.Balanced {
+		t.Errorf("réconciliation = %+v (%v), attendu équilibrée", reconciliation, err)
+	}
+}
+
+func TestOverdraftInterestRounding(t *testing.T) {
+	tests := []struct {
+		balances []int64
+		rateBps  int
+		want     int64
+	}{
+		{[]int64{1000, 0, 500}, 1500, 0},
+		{[]int64{-100000, -100000}, 3650, 200},
+		// 10 000 × 1500 / 3 650 000 = 4,11 : arrondi une seule fois sur la période
+		{[]int64{-5000, -5000}, 1500, 4},
+		{[]int64{-50, -50, -50}, 1500, 0},
+	}
+	for _, tt := range tests {
+		got, err := overdraftInterest(tt.balances, tt.rateBps)
+		if err != nil || got != tt.want {
+			t.Errorf("overdraftInterest(%v, %d) = %d (%v), attendu %d", tt.balances, tt.rateBps, got, err, tt.want)
+		}
+	}
+}
+
+// TestExportAccountTransactions vérifie les exports OFX, QIF et camt.053 d'un compte :
+// montants signés, annulation et contrôle d'accès
+func TestExportAccountTransactions(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	other, otherToken := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+
+	debit, _ := transferFunds(t, router, token, from.ID, to.ID, "50.00")
+	path := fmt.Sprintf("/api/admin/transactions/%d/reverse", debit.ID)
+	if rec := testutil.Request(router, http.MethodPost, path, adminToken, map[string]interface{}{"amount": "20.00", "reason": "litige"}); rec.Code != http.StatusCreated {
+		t.Fatalf("annulation: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	export := func(token, format string) *httptest.ResponseRecorder {
+		url := fmt.Sprintf("/api/transactions/account/%d/export?format=%s&from=%s&to=%s", from.ID, format, today, today)
+		return testutil.Request(router, http.MethodGet, url, token, nil)
+	}
+
+	rec := export(token, "qif")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Disposition"), ".qif") {
+		t.Fatalf("export QIF: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, want := range []string{"T100.00\n", "T-50.00\n", "T20.00\n", "N" + debit.Reference + "\n"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("export QIF sans %q:\n%s", want, rec.Body.String())
+		}
+	}
+
+	rec = export(token, "ofx")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<TRNAMT>-50.00</TRNAMT>") ||
+		!strings.Contains(rec.Body.String(), "<BALAMT>70.00</BALAMT>") {
+		t.Errorf("export OFX: statut = %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = export(token, "camt053")
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "<RvslInd>true</RvslInd>") ||
+		!strings.Contains(body, `<Amt Ccy="EUR">70.00</Amt>`) || strings.Count(body, "<Ntry>") != 3 {
+		t.Errorf("export camt.053: statut = %d:\n%s", rec.Code, body)
+	}
+
+	if rec := export(otherToken, "ofx"); rec.Code != http.StatusNotFound {
+		t.Errorf("export du compte d'un autre client: statut = %d, attendu 404", rec.Code)
+	}
+	if rec := export(token, "csv"); rec.Code != http.StatusBadRequest {
+		t.Errorf("format inconnu: statut = %d, attendu 400", rec.Code)
+	}
+	url := fmt.Sprintf("/api/transactions/account/%d/export?format=ofx&from=%s", from.ID, today)
+	if rec := testutil.Request(router, http.MethodGet, url, token, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("plage incomplète: statut = %d, attendu 400", rec.Code)
+	}
+}
+
+// uploadBulk remet un fichier de virements tel quel, en corps de requête
+func uploadBulk(router *gin.Engine, token, query, content string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/bulk"+query, strings.NewReader(content))
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// bulkFile compose un message pain.001 débitant from de chaque montant vers to
+func bulkFile(messageID, from, to string, amounts ...string) string {
+	var transactions strings.Builder
+	for i, amount := range amounts {
+		fmt.Fprintf(&transactions, `<CdtTrfTxInf><PmtId><EndToEndId>E2E-%d</EndToEndId></PmtId>`+
+			`<Amt><InstdAmt Ccy="EUR">%s</InstdAmt></Amt><Cdtr><Nm>Fournisseur %d</Nm></Cdtr>`+
+			`<CdtrAcct><Id><IBAN>%s</IBAN></Id></CdtrAcct></CdtTrfTxInf>`, i+1, amount, i+1, to)
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"><CstmrCdtTrfInitn>
+<GrpHdr><MsgId>%s</MsgId><NbOfTxs>%d</NbOfTxs></GrpHdr>
+<PmtInf><PmtInfId>LOT-1</PmtInfId><PmtMtd>TRF</PmtMtd><DbtrAcct><Id><IBAN>%s</IBAN></Id></DbtrAcct>%s</PmtInf>
+</CstmrCdtTrfInitn></Document>`, messageID, len(amounts), from, transactions.String())
+}
+
+// TestBulkPayments vérifie l'import d'un fichier pain.001 : refus global en mode atomique,
+// exécution virement par virement en mode per_item, compte rendu pain.002 et doublons
+func TestBulkPayments(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	supplier, _ := testutil.CreateUser(t, store)
+	_, otherToken := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 10000)
+	to := testutil.CreateAccount(t, store, supplier.ID, 0)
+
+	// Le troisième virement dépasse le solde restant : le lot atomique est refusé en entier
+	rec := uploadBulk(router, token, "", bulkFile("LOT-A", from.AccountNumber, to.AccountNumber, "30.00", "50.00", "40.00"))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("lot atomique: statut = %d, attendu 422: %s", rec.Code, rec.Body.String())
+	}
+	var rejected struct {
+		Data models.PaymentBatch `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rejected); err != nil {
+		t.Fatal(err)
+	}
+	if batch := rejected.Data; batch.Status != models.PaymentStatusRejected || batch.Rejected != 3 || batch.Items[2].ReasonCode != "AM04" || batch.Items[0].ReasonCode != "NARR" {
+		t.Errorf("lot atomique = %+v", batch)
+	}
+	if balance := accountBalance(t, store, from.ID); balance != 10000 {
+		t.Errorf("solde après refus du lot = %d, attendu 10000", balance)
+	}
+	if rec := uploadBulk(router, token, "", bulkFile("LOT-A", from.AccountNumber, to.AccountNumber, "1.00")); rec.Code != http.StatusConflict {
+		t.Errorf("identifiant de message déjà remis: statut = %d, attendu 409", rec.Code)
+	}
+
+	// En mode per_item, les virements valides sont exécutés et les autres refusés
+	rec = uploadBulk(router, token, "?mode=per_item&report=pain002",
+		bulkFile("LOT-B", from.AccountNumber, to.AccountNumber, "30.00", "50.00", "40.00", "-1"))
+	if rec.Code != http.StatusCreated || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/xml") {
+		t.Fatalf("lot per_item: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+	report := rec.Body.String()
+	for _, want := range []string{"<OrgnlMsgId>LOT-B</OrgnlMsgId>", "<GrpSts>PART</GrpSts>", "<Cd>AM04</Cd>", "<Cd>AM12</Cd>"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("compte rendu pain.002 sans %q:\n%s", want, report)
+		}
+	}
+	if strings.Count(report, "<TxSts>ACSC</TxSts>") != 2 {
+		t.Errorf("compte rendu pain.002: attendu 2 virements exécutés:\n%s", report)
+	}
+	if from, to := accountBalance(t, store, from.ID), accountBalance(t, store, to.ID); from != 2000 || to != 8000 {
+		t.Errorf("soldes = %d / %d, attendu 2000 / 8000", from, to)
+	}
+
+	// Variante CSV : un bénéficiaire hors de la banque est refusé, le reste exécuté
+	csv := "debtor_iban,creditor_iban,amount,end_to_end_id\n" +
+		from.AccountNumber + "," + to.AccountNumber + ",5.00,CSV-OK\n" +
+		from.AccountNumber + ",DE89370400440532013000,5.00,CSV-EXT\n"
+	rec = uploadBulk(router, token, "?mode=per_item", csv)
+	var batch models.PaymentBatch
+	testutil.DecodeData(t, rec, &batch)
+	if rec.Code != http.StatusCreated || batch.MessageName != "csv" || batch.Accepted != 1 || batch.Items[1].ReasonCode != "AC01" {
+		t.Errorf("lot CSV: statut = %d, lot = %+v", rec.Code, batch)
+	}
+	if rec := uploadBulk(router, token, "?mode=per_item", csv); rec.Code != http.StatusConflict {
+		t.Errorf("même fichier CSV remis deux fois: statut = %d, attendu 409", rec.Code)
+	}
+
+	path := fmt.Sprintf("/api/transactions/bulk/%d", batch.ID)
+	if rec := testutil.Request(router, http.MethodGet, path, otherToken, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("lot d'un autre client: statut = %d, attendu 404", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodGet, "/api/transactions/bulk", token, nil)
+	var batches []models.PaymentBatch
+	testutil.DecodeData(t, rec, &batches)
+	if len(batches) != 3 {
+		t.Errorf("%d lots, attendu 3", len(batches))
+	}
+	if rec := uploadBulk(router, token, "", "<Document><CstmrCdtTrfInitn></CstmrCdtTrfInitn></Document>"); rec.Code != http.StatusBadRequest {
+		t.Errorf("fichier sans identifiant: statut = %d, attendu 400", rec.Code)
+	}
+}
+
+// TestBulkPaymentsFraudScreening vérifie que les virements d'un lot passent par le contrôle
+// anti-fraude : en mode per_item, un virement retenu est refusé dans le lot et placé dans la
+// file de vérification ; en mode atomique, il fait refuser le lot et sa décision est rejetée
+func TestBulkPaymentsFraudScreening(t *testing.T) {
+	router, store := setupTestRouter(t)
+	t.Setenv("FRAUD_LARGE_AMOUNT", "500")
+	user, token := testutil.CreateUser(t, store)
+	supplier, _ := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	from := testutil.CreateAccount(t, store, user.ID, 200000)
+	to := testutil.CreateAccount(t, store, supplier.ID, 0)
+	other := testutil.CreateAccount(t, store, supplier.ID, 0)
+
+	// Premier virement d'un montant élevé vers ce compte : retenu, le second est exécuté
+	rec := uploadBulk(router, token, "?mode=per_item", bulkFile("LOT-F", from.AccountNumber, to.AccountNumber, "600.00", "100.00"))
+	var batch models.PaymentBatch
+	testutil.DecodeData(t, rec, &batch)
+	if rec.Code != http.StatusCreated || batch.Status != models.PaymentStatusPartial ||
+		batch.Items[0].Status != models.PaymentStatusRejected || batch.Items[0].ReasonCode != "AG01" ||
+		batch.Items[1].Status != models.PaymentStatusCompleted {
+		t.Fatalf("lot per_item: statut = %d, lot = %+v", rec.Code, batch)
+	}
+	if balance := accountBalance(t, store, from.ID); balance != 200000-10000 {
+		t.Errorf("solde = %d centimes, attendu %d", balance, 200000-10000)
+	}
+
+	var queue []models.FraudDecision
+	testutil.DecodeData(t, testutil.Request(router, http.MethodGet, "/api/admin/transactions/fraud/decisions?status=pending", adminToken, nil), &queue)
+	if len(queue) != 1 || queue[0].Amount.Amount != 60000 || queue[0].ToAccountID != to.ID || queue[0].Hits[0].Rule != "new_payee_large_amount" {
+		t.Fatalf("file de vérification = %+v", queue)
+	}
+	if notifications, err := store.Notifications.ListByUser(user.ID); err != nil || len(notifications) != 1 {
+		t.Errorf("notifications = %+v (%v), attendu un email de mise en attente", notifications, err)
+	}
+	var released models.FraudDecision
+	testutil.DecodeData(t, testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/admin/transactions/fraud/decisions/%d/release", queue[0].ID), adminToken, nil), &released)
+	if released.Status != models.FraudStatusReleased || accountBalance(t, store, to.ID) != 70000 {
+		t.Errorf("décision libérée = %+v, solde destination %d", released, accountBalance(t, store, to.ID))
+	}
+
+	// En mode atomique, le virement retenu fait refuser le lot et ne peut plus être libéré
+	rec = uploadBulk(router, token, "", bulkFile("LOT-G", from.AccountNumber, other.AccountNumber, "50.00", "700.00"))
+	var rejected struct {
+		Data models.PaymentBatch `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rejected); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusUnprocessableEntity || rejected.Data.Items[1].ReasonCode != "AG01" || accountBalance(t, store, other.ID) != 0 {
+		t.Errorf("lot atomique: statut = %d, lot = %+v", rec.Code, rejected.Data)
+	}
+	var decisions []models.FraudDecision
+	testutil.DecodeData(t, testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/admin/transactions/fraud/decisions?user_id=%d", user.ID), adminToken, nil), &decisions)
+	statuses := map[string]int{}
+	for _, decision := range decisions {
+		statuses[decision.Status]++
+	}
+	if len(decisions) != 3 || statuses[models.FraudStatusAllowed] != 1 || statuses[models.FraudStatusReleased] != 1 ||
+		statuses[models.FraudStatusRejected] != 1 {
+		t.Errorf("décisions enregistrées = %v", statuses)
+	}
+}
+
+// TestBeneficiaries vérifie l'enregistrement des bénéficiaires, les virements par beneficiary_id
+// et le délai de carence des gros montants vers un nouveau bénéficiaire
+func TestBeneficiaries(t *testing.T) {
+	t.Setenv("BENEFICIARY_COOLING_OFF", "24h")
+	t.Setenv("BENEFICIARY_COOLING_OFF_LIMIT", "100")
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	other, otherToken := testutil.CreateUser(t, store)
+	from := testutil.CreateAccount(t, store, user.ID, 100000)
+	savings := testutil.CreateAccount(t, store, user.ID, 0)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+
+	create := func(nickname, iban string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/transactions/beneficiaries", token, map[string]interface{}{
+			"nickname": nickname,
+			"iban":     iban,
+		})
+	}
+	rec := create(" Loyer ", utils.FormatIBAN(to.AccountNumber))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	var landlord models.Beneficiary
+	testutil.DecodeData(t, rec, &landlord)
+	if landlord.Nickname != "Loyer" || landlord.IBAN != to.AccountNumber || !landlord.TrustedAt.After(time.Now().Add(23*time.Hour)) {
+		t.Errorf("bénéficiaire = %+v", landlord)
+	}
+	if notifications, err := store.Notifications.ListByUser(user.ID); err != nil || len(notifications) != 1 || notifications[0].Type != "email" {
+		t.Errorf("notifications = %+v (%v), attendu un email", notifications, err)
+	}
+
+	for name, tc := range map[string]struct {
+		nickname, iban string
+		status         int
+	}{
+		"déjà enregistré": {"Propriétaire", to.AccountNumber, http.StatusConflict},
+		"IBAN invalide":   {"Inconnu", "FR7630006000011234567890188", http.StatusBadRequest},
+		"IBAN externe":    {"Inconnu", "FR7630006000011234567890189", http.StatusNotFound},
+		"surnom vide":     {"  ", savings.AccountNumber, http.StatusBadRequest},
+	} {
+		if rec := create(tc.nickname, tc.iban); rec.Code != tc.status {
+			t.Errorf("%s: statut = %d, attendu %d: %s", name, rec.Code, tc.status, rec.Body.String())
+		}
+	}
+
+	transfer := func(token string, from, beneficiaryID uint, amount string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+			"from_account_id": from,
+			"beneficiary_id":  beneficiaryID,
+			"amount":          amount,
+		})
+	}
+
+	// Pendant le délai de carence, seuls les petits montants passent
+	if rec := transfer(token, from.ID, landlord.ID, "100.00"); rec.Code != http.StatusCreated {
+		t.Fatalf("petit montant: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	if rec := transfer(token, from.ID, landlord.ID, "100.01"); rec.Code != http.StatusForbidden {
+		t.Errorf("gros montant en carence: statut = %d, attendu 403: %s", rec.Code, rec.Body.String())
+	}
+
+	// Le délai de carence s'applique quelle que soit la façon de désigner le bénéficiaire
+	rec = testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": from.ID,
+		"to_iban":         to.AccountNumber,
+		"amount":          "100.01",
+	})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("gros montant en carence par IBAN: statut = %d, attendu 403: %s", rec.Code, rec.Body.String())
+	}
+	scheduled := func(startDate string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/transactions/scheduled", token, map[string]interface{}{
+			"from_account_id": from.ID,
+			"to_iban":         to.AccountNumber,
+			"amount":          "200.00",
+			"frequency":       models.FrequencyMonthly,
+			"start_date":      startDate,
+		})
+	}
+	if rec := scheduled(time.Now().UTC().Format(dateLayout)); rec.Code != http.StatusForbidden {
+		t.Errorf("virement programmé en carence: statut = %d, attendu 403: %s", rec.Code, rec.Body.String())
+	}
+	if rec := scheduled(time.Now().UTC().AddDate(0, 0, 3).Format(dateLayout)); rec.Code != http.StatusCreated {
+		t.Errorf("virement programmé après la carence: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	rec = uploadBulk(router, token, "?mode=per_item", bulkFile("LOT-CARENCE", from.AccountNumber, to.AccountNumber, "200.00"))
+	var held struct {
+		Data models.PaymentBatch `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &held); err != nil {
+		t.Fatal(err)
+	}
+	if batch := held.Data; rec.Code != http.StatusUnprocessableEntity || batch.Accepted != 0 || len(batch.Items) != 1 ||
+		!strings.Contains(batch.Items[0].ReasonInfo, "Bénéficiaire ajouté récemment") {
+		t.Errorf("lot en carence: statut = %d, lot = %+v", rec.Code, batch)
+	}
+	if balance := accountBalance(t, store, to.ID); balance != 10000 {
+		t.Errorf("solde du bénéficiaire = %d centimes, attendu 10000", balance)
+	}
+
+	// Un compte de l'utilisateur n'est pas soumis au délai de carence
+	rec = create("Épargne", savings.AccountNumber)
+	var own models.Beneficiary
+	testutil.DecodeData(t, rec, &own)
+	if rec := transfer(token, from.ID, own.ID, "500.00"); rec.Code != http.StatusCreated {
+		t.Errorf("vers son propre compte: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+
+	stored, err := store.Beneficiaries.FindForUser(landlord.ID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored.TrustedAt = time.Now().Add(-time.Minute)
+	if err := store.Beneficiaries.Save(stored); err != nil {
+		t.Fatal(err)
+	}
+	if rec := transfer(token, from.ID, landlord.ID, "300.00"); rec.Code != http.StatusCreated {
+		t.Errorf("après le délai de carence: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": from.ID,
+		"beneficiary_id":  landlord.ID,
+		"to_account_id":   to.ID,
+		"amount":          "1.00",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("destination ambiguë: statut = %d, attendu 400", rec.Code)
+	}
+	if rec := transfer(otherToken, to.ID, landlord.ID, "1.00"); rec.Code != http.StatusNotFound {
+		t.Errorf("bénéficiaire d'un autre utilisateur: statut = %d, attendu 404", rec.Code)
+	}
+
+	path := fmt.Sprintf("/api/transactions/beneficiaries/%d", landlord.ID)
+	if rec := testutil.Request(router, http.MethodGet, path, otherToken, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("lecture par un autre utilisateur: statut = %d, attendu 404", rec.Code)
+	}
+	rec = testutil.Request(router, http.MethodPut, path, token, map[string]interface{}{"nickname": "Propriétaire"})
+	var renamed models.Beneficiary
+	testutil.DecodeData(t, rec, &renamed)
+	if renamed.Nickname != "Propriétaire" || renamed.IBAN != landlord.IBAN {
+		t.Errorf("bénéficiaire renommé = %+v", renamed)
+	}
+
+	var list []models.Beneficiary
+	testutil.DecodeData(t, testutil.Request(router, http.MethodGet, "/api/transactions/beneficiaries", token, nil), &list)
+	if len(list) != 2 {
+		t.Errorf("%d bénéficiaires, attendu 2", len(list))
+	}
+
+	if rec := testutil.Request(router, http.MethodDelete, path, token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("suppression: statut = %d, attendu 200: %s", rec.Code, rec.Body.String())
+	}
+	if rec := transfer(token, from.ID, landlord.ID, "1.00"); rec.Code != http.StatusNotFound {
+		t.Errorf("bénéficiaire supprimé: statut = %d, attendu 404", rec.Code)
+	}
+}
+
+// TestTransferLimits vérifie l'application des plafonds de sorties de fonds, leur abaissement
+// par le client et le suivi de leur consommation
+func TestTransferLimits(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	from := testutil.CreateAccount(t, store, user.ID, 100000)
+	savings := testutil.CreateAccount(t, store, user.ID, 0)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+
+	bankLimits := map[string]interface{}{
+		"account_type":    "checking",
+		"per_transaction": "100",
+		"daily":           "150",
+		"hourly_count":    3,
+	}
+	if rec := testutil.Request(router, http.MethodPut, "/api/admin/transactions/limits", token, bankLimits); rec.Code != http.StatusForbidden {
+		t.Errorf("plafonds fixés par un client: statut = %d, attendu 403", rec.Code)
+	}
+	var saved []models.TransferLimit
+	testutil.DecodeData(t, testutil.Request(router, http.MethodPut, "/api/admin/transactions/limits", adminToken, bankLimits), &saved)
+	// Le plafond par défaut des comptes courants en euros, créé avec la base, est remplacé sur place
+	checking := 0
+	for _, limit := range saved {
+		if limit.AccountType == "checking" && limit.Currency == "EUR" {
+			checking++
+			if limit.Source != models.LimitSourceBank || limit.UserID != nil || limit.Daily.Amount != 15000 || limit.Monthly != nil {
+				t.Errorf("plafond de la banque = %+v", limit)
+			}
+		}
+	}
+	if len(saved) != 9 || checking != 1 {
+		t.Fatalf("plafonds de la banque = %+v, attendu les 9 plafonds par défaut (EUR, USD, GBP)", saved)
+	}
+
+	transfer := func(toAccountID uint, amount string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+			"from_account_id": from.ID,
+			"to_account_id":   toAccountID,
+			"amount":          amount,
+		})
+	}
+	refused := func(rec *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		var response models.APIResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("réponse illisible: %v", err)
+		}
+		if rec.Code != status || response.Error != code {
+			t.Errorf("statut = %d (%s), attendu %d (%s): %s", rec.Code, response.Error, status, code, response.Message)
+		}
+	}
+
+	refused(transfer(to.ID, "100.01"), http.StatusForbidden, "TRANSFER_LIMIT_PER_TRANSACTION")
+	if rec := transfer(to.ID, "100.00"); rec.Code != http.StatusCreated {
+		t.Fatalf("virement dans les plafonds: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+	refused(transfer(to.ID, "60.00"), http.StatusForbidden, "TRANSFER_LIMIT_DAILY")
+	if rec := transfer(savings.ID, "500.00"); rec.Code != http.StatusCreated {
+		t.Errorf("virement entre ses comptes: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/", token, map[string]interface{}{
+		"account_id": from.ID,
+		"type":       "debit",
+		"amount":     "50.00",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("débit dans les plafonds: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+	if balance := accountBalance(t, store, from.ID); balance != 100000-10000-50000-5000 {
+		t.Errorf("solde source = %d centimes", balance)
+	}
+
+	var statuses []limits.Status
+	testutil.DecodeData(t, testutil.Request(router, http.MethodGet, "/api/transactions/limits", token, nil), &statuses)
+	if len(statuses) != 2 {
+		t.Fatalf("%d comptes, attendu 2", len(statuses))
+	}
+	status := statuses[0]
+	if status.AccountID != from.ID || status.Consumed.Daily.Amount != 15000 || status.Consumed.HourlyCount != 2 ||
+		status.Remaining.Daily.Amount != 0 || *status.Remaining.HourlyCount != 1 || status.Consumed.HourlyResetsAt == nil {
+		t.Errorf("plafonds du compte = %+v", status)
+	}
+
+	if rec := testutil.Request(router, http.MethodPut, "/api/transactions/limits", token, map[string]interface{}{
+		"account_type": "checking",
+		"daily":        "200",
+	}); rec.Code != http.StatusBadRequest {
+		t.Errorf("plafond supérieur à celui de la banque: statut = %d, attendu 400", rec.Code)
+	}
+	var set limits.Set
+	testutil.DecodeData(t, testutil.Request(router, http.MethodPut, "/api/transactions/limits", token, map[string]interface{}{
+		"account_type":    "checking",
+		"per_transaction": "20",
+		"hourly_count":    2,
+	}), &set)
+	if set.Effective.PerTransaction.Amount != 2000 || set.Effective.Daily.Amount != 15000 || *set.Effective.HourlyCount != 2 {
+		t.Errorf("plafonds appliqués = %+v", set.Effective)
+	}
+	refused(testutil.Request(router, http.MethodPost, "/api/transactions/", token, map[string]interface{}{
+		"account_id": from.ID,
+		"type":       "debit",
+		"amount":     "1.00",
+	}), http.StatusTooManyRequests, "TRANSFER_LIMIT_HOURLY_COUNT")
+
+	// Sans plafond de la banque pour la devise du compte, la sortie de fonds est refusée
+	francs := testutil.CreateAccountInCurrency(t, store, user.ID, 10000, "CHF")
+	refused(testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": francs.ID,
+		"to_account_id":   testutil.CreateAccountInCurrency(t, store, other.ID, 0, "CHF").ID,
+		"amount":          "1.00",
+	}), http.StatusForbidden, "TRANSFER_LIMIT_UNDEFINED")
+}
+
+// TestHoldsRespectTransferLimits vérifie qu'une réservation hors plafonds est refusée et que
+// la capture, qui débite le compte, est imputée sur les plafonds
+func TestHoldsRespectTransferLimits(t *testing.T) {
+	router, store := setupTestRouter(t)
+	user, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	from := testutil.CreateAccount(t, store, user.ID, 100000)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+
+	rec := testutil.Request(router, http.MethodPut, "/api/admin/transactions/limits", adminToken, map[string]interface{}{
+		"account_type":    "checking",
+		"per_transaction": "100",
+		"daily":           "150",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("plafonds de la banque: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	authorize := func(amount string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/transactions/holds", token, map[string]interface{}{
+			"account_id": from.ID,
+			"amount":     amount,
+		})
+	}
+	if rec := authorize("100.01"); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "TRANSFER_LIMIT_PER_TRANSACTION") {
+		t.Errorf("réservation hors plafond: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = authorize("100.00")
+	var hold models.Hold
+	testutil.DecodeData(t, rec, &hold)
+
+	// Un virement consomme le plafond du jour : la capture complète le dépasserait
+	rec = testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": from.ID,
+		"to_account_id":   to.ID,
+		"amount":          "60.00",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("virement: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+	capturePath := fmt.Sprintf("/api/transactions/holds/%d/capture", hold.ID)
+	rec = testutil.Request(router, http.MethodPost, capturePath, token, nil)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "TRANSFER_LIMIT_DAILY") {
+		t.Fatalf("capture au-delà du plafond du jour: statut = %d, attendu 403: %s", rec.Code, rec.Body.String())
+	}
+	stored, err := store.Holds.FindForUser(hold.ID, user.ID)
+	if err != nil || stored.Status != models.HoldAuthorized {
+		t.Errorf("réservation après refus = %+v (%v), attendu toujours autorisée", stored, err)
+	}
+
+	rec = testutil.Request(router, http.MethodPost, capturePath, token, map[string]string{"amount": "90.00"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("capture dans le plafond: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+	if balance := accountBalance(t, store, from.ID); balance != 100000-6000-9000 {
+		t.Errorf("solde = %d centimes, attendu %d", balance, 100000-6000-9000)
+	}
+	var statuses []limits.Status
+	testutil.DecodeData(t, testutil.Request(router, http.MethodGet, "/api/transactions/limits", token, nil), &statuses)
+	if len(statuses) != 1 || statuses[0].Consumed.Daily.Amount != 15000 || statuses[0].Consumed.HourlyCount != 2 {
+		t.Errorf("plafonds du compte = %+v, attendu 150.00 consommés en 2 opérations", statuses)
+	}
+}
+
+// TestFraudReviewQueue vérifie le contrôle anti-fraude des virements, la file de vérification
+// et l'enregistrement des décisions
+func TestFraudReviewQueue(t *testing.T) {
+	router, store := setupTestRouter(t)
+	t.Setenv("FRAUD_LARGE_AMOUNT", "500")
+	user, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	_, supportToken := testutil.CreateUserWithRole(t, store, models.RoleSupport)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	from := testutil.CreateAccount(t, store, user.ID, 1000000)
+	savings := testutil.CreateAccount(t, store, user.ID, 0)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+	third := testutil.CreateAccount(t, store, other.ID, 0)
+
+	transfer := func(toAccountID uint, amount string) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+			"from_account_id": from.ID,
+			"to_account_id":   toAccountID,
+			"amount":          amount,
+		})
+	}
+	review := func(token string, id uint, action string, payload interface{}) *httptest.ResponseRecorder {
+		return testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/admin/transactions/fraud/decisions/%d/%s", id, action), token, payload)
+	}
+
+	// Premier virement d'un montant élevé vers ce compte : mis en attente
+	rec := transfer(to.ID, "600.00")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("nouveau destinataire: statut = %d, attendu 202: %s", rec.Code, rec.Body.String())
+	}
+	var held struct {
+		ReviewID uint   `json:"review_id"`
+		Status   string `json:"status"`
+	}
+	testutil.DecodeData(t, rec, &held)
+	if held.Status != models.FraudStatusPending || accountBalance(t, store, from.ID) != 1000000 {
+		t.Errorf("virement en attente = %+v, solde %d", held, accountBalance(t, store, from.ID))
+	}
+	if notifications, err := store.Notifications.ListByUser(user.ID); err != nil || len(notifications) != 1 {
+		t.Errorf("notifications = %+v (%v), attendu un email", notifications, err)
+	}
+
+	var queue []models.FraudDecision
+	testutil.DecodeData(t, testutil.Request(router, http.MethodGet, "/api/admin/transactions/fraud/decisions?status=pending", supportToken, nil), &queue)
+	if len(queue) != 1 || queue[0].ID != held.ReviewID || len(queue[0].Hits) != 1 || queue[0].Hits[0].Rule != "new_payee_large_amount" || queue[0].Score != 60 {
+		t.Fatalf("file de vérification = %+v", queue)
+	}
+
+	if rec := review(supportToken, held.ReviewID, "release", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("libération par le support: statut = %d, attendu 403", rec.Code)
+	}
+	var released models.FraudDecision
+	testutil.DecodeData(t, review(adminToken, held.ReviewID, "release", map[string]interface{}{"note": "client joint"}), &released)
+	if released.Status != models.FraudStatusReleased || released.TransactionID == nil || released.ReviewedBy == nil || released.ReviewNote != "client joint" {
+		t.Errorf("décision libérée = %+v", released)
+	}
+	if balance := accountBalance(t, store, from.ID); balance != 1000000-60000 {
+		t.Errorf("solde après libération = %d centimes, attendu %d", balance, 1000000-60000)
+	}
+	if rec := review(adminToken, held.ReviewID, "release", nil); rec.Code != http.StatusConflict {
+		t.Errorf("seconde libération: statut = %d, attendu 409", rec.Code)
+	}
+
+	// Sous le seuil, un virement vers un destinataire récent est exécuté
+	if rec := transfer(to.ID, "100.00"); rec.Code != http.StatusCreated {
+		t.Errorf("montant sous le seuil: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+
+	t.Setenv("FRAUD_BLOCK_SCORE", "60")
+	rec = transfer(third.ID, "600.00")
+	var response models.APIResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusForbidden || response.Error != "FRAUD_BLOCKED" {
+		t.Errorf("virement bloqué: statut = %d (%s), attendu 403 (FRAUD_BLOCKED)", rec.Code, response.Error)
+	}
+	t.Setenv("FRAUD_BLOCK_SCORE", "100")
+
+	// Un virement refusé par le contrôle figure dans la file de vérification ; son refus est confirmé
+	testutil.DecodeData(t, testutil.Request(router, http.MethodGet, "/api/admin/transactions/fraud/decisions?status=pending,blocked", supportToken, nil), &queue)
+	if len(queue) != 1 || queue[0].Status != models.FraudStatusBlocked || queue[0].ToAccountID != third.ID {
+		t.Fatalf("file de vérification = %+v", queue)
+	}
+	if rec := testutil.Request(router, http.MethodGet, "/api/admin/transactions/fraud/decisions?status=pending,unknown", supportToken, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("statut inconnu: statut = %d, attendu 400", rec.Code)
+	}
+	var confirmed models.FraudDecision
+	testutil.DecodeData(t, review(adminToken, queue[0].ID, "reject", map[string]interface{}{"reason": "refus confirmé"}), &confirmed)
+	if confirmed.Status != models.FraudStatusRejected || confirmed.ReviewedBy == nil || accountBalance(t, store, third.ID) != 0 {
+		t.Errorf("décision refusée confirmée = %+v", confirmed)
+	}
+
+	rec = transfer(third.ID, "700.00")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("second nouveau destinataire: statut = %d, attendu 202: %s", rec.Code, rec.Body.String())
+	}
+	testutil.DecodeData(t, rec, &held)
+	if rec := review(adminToken, held.ReviewID, "reject", map[string]interface{}{}); rec.Code != http.StatusBadRequest {
+		t.Errorf("rejet sans motif: statut = %d, attendu 400", rec.Code)
+	}
+	var rejected models.FraudDecision
+	testutil.DecodeData(t, review(adminToken, held.ReviewID, "reject", map[string]interface{}{"reason": "usurpation signalée"}), &rejected)
+	if rejected.Status != models.FraudStatusRejected || rejected.TransactionID != nil || rejected.ReviewNote != "usurpation signalée" {
+		t.Errorf("décision rejetée = %+v", rejected)
+	}
+
+	// Les virements entre les comptes du client ne sont pas contrôlés...
+	if rec := transfer(savings.ID, "5000.00"); rec.Code != http.StatusCreated {
+		t.Errorf("virement entre ses comptes: statut = %d, attendu 201: %s", rec.Code, rec.Body.String())
+	}
+	if balance := accountBalance(t, store, from.ID); balance != 1000000-60000-10000-500000 {
+		t.Errorf("solde final = %d centimes", balance)
+	}
+	// ni retenus dans l'historique examiné par les règles
+	history, err := store.Transactions.ListOutgoingTransfers(user.ID, time.Now().Add(-time.Hour), 10)
+	if err != nil || len(history) != 2 {
+		t.Errorf("historique = %d virements (%v), attendu les 2 virements vers l'autre client", len(history), err)
+	}
+	for _, transaction := range history {
+		if *transaction.ToAccountID == savings.ID {
+			t.Errorf("virement entre ses comptes dans l'historique: %+v", transaction)
+		}
+	}
+
+	// Un virement accepté par le contrôle mais dont l'exécution échoue ne laisse pas de décision
+	t.Setenv("FRAUD_LARGE_AMOUNT", "100000")
+	if rec := transfer(to.ID, "5000.00"); rec.Code < http.StatusBadRequest {
+		t.Errorf("solde insuffisant: statut = %d, attendu une erreur: %s", rec.Code, rec.Body.String())
+	}
+
+	var decisions []models.FraudDecision
+	testutil.DecodeData(t, testutil.Request(router, http.MethodGet, fmt.Sprintf("/api/admin/transactions/fraud/decisions?user_id=%d&limit=10", user.ID), adminToken, nil), &decisions)
+	statuses := map[string]int{}
+	for _, decision := range decisions {
+		statuses[decision.Status]++
+		if decision.Status == models.FraudStatusAllowed && decision.TransactionID == nil {
+			t.Errorf("décision sans transaction exécutée = %+v", decision)
+		}
+	}
+	if len(decisions) != 4 || statuses[models.FraudStatusAllowed] != 1 ||
+		statuses[models.FraudStatusReleased] != 1 || statuses[models.FraudStatusRejected] != 2 {
+		t.Errorf("décisions enregistrées = %v", statuses)
+	}
+}
+
+// TestFraudReleaseKeepsScreenedCoolingOff vérifie qu'un virement libéré n'est pas soumis de
+// nouveau au délai de carence : un bénéficiaire enregistré pendant la vérification ne le
+// bloque pas
+func TestFraudReleaseKeepsScreenedCoolingOff(t *testing.T) {
+	t.Setenv("BENEFICIARY_COOLING_OFF", "24h")
+	t.Setenv("BENEFICIARY_COOLING_OFF_LIMIT", "100")
+	router, store := setupTestRouter(t)
+	t.Setenv("FRAUD_LARGE_AMOUNT", "500")
+	user, token := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	_, adminToken := testutil.CreateUserWithRole(t, store, models.RoleAdmin)
+	from := testutil.CreateAccount(t, store, user.ID, 100000)
+	to := testutil.CreateAccount(t, store, other.ID, 0)
+
+	rec := testutil.Request(router, http.MethodPost, "/api/transactions/transfer", token, map[string]interface{}{
+		"from_account_id": from.ID,
+		"to_account_id":   to.ID,
+		"amount":          "600.00",
+	})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("nouveau destinataire: statut = %d, attendu 202: %s", rec.Code, rec.Body.String())
+	}
+	var held struct {
+		ReviewID uint `json:"review_id"`
+	}
+	testutil.DecodeData(t, rec, &held)
+
+	if rec := testutil.Request(router, http.MethodPost, "/api/transactions/beneficiaries", token, map[string]interface{}{
+		"nickname": "Fournisseur",
+		"iban":     to.AccountNumber,
+	}); rec.Code != http.StatusCreated {
+		t.Fatalf("bénéficiaire: statut = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = testutil.Request(router, http.MethodPost, fmt.Sprintf("/api/admin/transactions/fraud/decisions/%d/release", held.ReviewID), adminToken, nil)
+	if rec.Code != http.StatusOK || accountBalance(t, store, to.ID) != 60000 {
+		t.Errorf("libération: statut = %d, solde destination %d: %s", rec.Code, accountBalance(t, store, to.ID), rec.Body.String())
+	}
+}
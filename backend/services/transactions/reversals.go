@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/notify"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reversal est le résultat d'une annulation : la transaction d'origine mise à jour et les
+// transactions de compensation, une par jambe de l'opération
+type reversal struct {
+	Transaction *models.Transaction  `json:"transaction"`
+	Reversals   []models.Transaction `json:"reversals"`
+}
+
+// reverseTransaction annule tout ou partie (amount, le restant si nil) de la transaction id.
+// L'écriture d'origine est contre-passée au grand livre et chaque jambe de l'opération (les
+// deux jambes d'un virement) reçoit une transaction de compensation liée par ReversalOf ;
+// le cumul des annulations ne peut pas dépasser le montant d'origine. allow vérifie que
+// l'appelant peut annuler la transaction.
+func reverseTransaction(tx *repository.Store, id uint, amount *json.Number, reason, reference string, allow func(*models.Transaction) error) (*reversal, error) {
+	original, err := tx.Transactions.FindByID(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, newHandlerError(http.StatusNotFound, "Transaction non trouvée")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := allow(original); err != nil {
+		return nil, err
+	}
+	if original.ReversalOf != nil {
+		return nil, newHandlerError(http.StatusBadRequest, "Une annulation ne peut pas elle-même être annulée")
+	}
+	if original.JournalEntryID == nil {
+		return nil, newHandlerError(http.StatusUnprocessableEntity, "Transaction sans écriture comptable, annulation impossible")
+	}
+
+	// Les jambes sont verrouillées : deux annulations concurrentes ne peuvent pas dépasser le montant
+	legs, err := tx.Transactions.LockByJournalEntry(*original.JournalEntryID)
+	if err != nil {
+		return nil, err
+	}
+	var target *models.Transaction
+	for i := range legs {
+		if legs[i].Status != "completed" {
+			return nil, newHandlerError(http.StatusConflict, "Seule une transaction exécutée et pas encore totalement annulée peut être annulée")
+		}
+		if legs[i].ID == original.ID {
+			target = &legs[i]
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("transaction %d absente de son écriture %d", original.ID, *original.JournalEntryID)
+	}
+
+	remaining, err := target.ReversibleAmount()
+	if err != nil {
+		return nil, err
+	}
+	value := remaining
+	if amount != nil {
+		if value, err = parseAmount(*amount, target.Currency); err != nil {
+			return nil, err
+		}
+		if value.Amount > remaining.Amount {
+			return nil, newHandlerError(http.StatusBadRequest, "Le montant dépasse le restant annulable ("+remaining.String()+")")
+		}
+	}
+	full := value.Amount == target.Amount.Amount
+
+	entry, err := reversalEntry(tx, *original.JournalEntryID, target, value, full)
+	if err != nil {
+		return nil, err
+	}
+	entry.Reference = reference
+	entry.Description = "Annulation de " + target.Reference + " : " + reason
+
+	if err := lockReversedAccounts(tx, entry.Postings); err != nil {
+		return nil, err
+	}
+	if err := postEntry(tx.DB, entry); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := &reversal{Transaction: target}
+	for i := range legs {
+		leg := &legs[i]
+		legAmount := models.NewMoney(value.Amount, leg.Currency)
+		if full {
+			legAmount = leg.Amount
+		}
+
+		legID := leg.ID
+		compensation := models.Transaction{
+			AccountID:      leg.AccountID,
+			Type:           "reversal",
+			Amount:         legAmount,
+			Currency:       leg.Currency,
+			Description:    reason,
+			Reference:      reference + legSuffix(leg, len(legs)),
+			Status:         "completed",
+			ProcessedAt:    &now,
+			JournalEntryID: &entry.ID,
+			ReversalOf:     &legID,
+		}
+		if err := tx.Transactions.Create(&compensation); err != nil {
+			return nil, err
+		}
+		result.Reversals = append(result.Reversals, compensation)
+
+		if leg.ReversedAmount, err = leg.ReversedAmount.Add(legAmount); err != nil {
+			return nil, err
+		}
+		if leg.ReversedAmount.Amount == leg.Amount.Amount {
+			leg.Status = "cancelled"
+		}
+		if err := tx.Transactions.Save(leg); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// reversalEntry construit l'écriture opposée à l'écriture entryID. Une annulation partielle
+// s'applique à l'identique à chaque ligne, ce qui suppose une opération dans une seule devise.
+func reversalEntry(tx *repository.Store, entryID uint, target *models.Transaction, value models.Money, full bool) (*models.JournalEntry, error) {
+	postings, err := tx.Transactions.ListEntryPostings(entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.JournalEntry{}
+	for _, posting := range postings {
+		reversed := posting.Amount.Neg()
+		if !full {
+			if posting.Currency != target.Currency || (posting.Amount.Amount != target.Amount.Amount && posting.Amount.Amount != -target.Amount.Amount) {
+				return nil, newHandlerError(http.StatusBadRequest, "Une opération entre devises ne peut être annulée que totalement")
+			}
+			reversed = value
+			if posting.Amount.IsPositive() {
+				reversed = value.Neg()
+			}
+		}
+
+		if posting.AccountID != nil {
+			entry.Postings = append(entry.Postings, ledger.CustomerPosting(*posting.AccountID, reversed))
+		} else {
+			entry.Postings = append(entry.Postings, ledger.SystemPosting(posting.LedgerAccount, reversed))
+		}
+	}
+	return entry, nil
+}
+
+// lockReversedAccounts verrouille les comptes clients de l'écriture par identifiant croissant ;
+// les fonds ne peuvent pas être rendus à un compte clôturé
+func lockReversedAccounts(tx *repository.Store, postings []models.Posting) error {
+	var ids []uint
+	for _, posting := range postings {
+		if posting.AccountID != nil {
+			ids = append(ids, *posting.AccountID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		account, err := tx.Accounts.LockByID(id)
+		if err != nil {
+			return err
+		}
+		if account.Status == "closed" {
+			return newHandlerError(http.StatusConflict, "Le compte "+account.AccountNumber+" est clôturé")
+		}
+	}
+	return nil
+}
+
+// legSuffix reprend le suffixe de la jambe d'origine d'un virement (-OUT, -IN)
+func legSuffix(leg *models.Transaction, legs int) string {
+	switch {
+	case legs == 1:
+		return ""
+	case leg.ToAccountID != nil:
+		return "-OUT"
+	}
+	return "-IN"
+}
+
+// handleReversal traite une demande d'annulation de la transaction :id pour le compte de
+// actorID, puis notifie les titulaires des autres comptes concernés
+func (s *server) handleReversal(c *gin.Context, actorID uint, allow func(*models.Transaction) error, success string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de transaction invalide",
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	var request struct {
+		Amount *json.Number `json:"amount"` // restant annulable si absent
+		Reason string       `json:"reason" binding:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	reference, err := utils.GenerateTransactionReference()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la génération de la référence",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	var result *reversal
+	err = s.store.Transaction(func(tx *repository.Store) error {
+		var err error
+		result, err = reverseTransaction(tx, uint(id), request.Amount, request.Reason, reference, allow)
+		return err
+	})
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de l'annulation de la transaction")
+		return
+	}
+
+	s.notifyReversal(result, actorID)
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: success,
+		Data:    result,
+	})
+}
+
+// notifyReversal informe les titulaires des comptes mouvementés par l'annulation, hors son auteur
+func (s *server) notifyReversal(result *reversal, actorID uint) {
+	for _, compensation := range result.Reversals {
+		account, err := s.store.Accounts.FindByID(compensation.AccountID)
+		if err != nil || account.UserID == actorID {
+			continue
+		}
+		message := fmt.Sprintf("La transaction %s a été annulée à hauteur de %s (référence %s) : %s",
+			result.Transaction.Reference, compensation.Amount, compensation.Reference, compensation.Description)
+		if err := s.notifier.Notify(account.UserID, notify.ChannelSystem, "Transaction annulée", message); err != nil {
+			log.Printf("Erreur lors de la notification de l'annulation %s: %v", compensation.Reference, err)
+		}
+	}
+}
+
+// refundTransactionHandler rembourse tout ou partie d'un virement reçu : le compte de
+// l'utilisateur est débité et l'émetteur recrédité
+func (s *server) refundTransactionHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	s.handleReversal(c, userID.(uint), func(transaction *models.Transaction) error {
+		if transaction.Account.UserID != userID.(uint) {
+			return newHandlerError(http.StatusNotFound, "Transaction non trouvée")
+		}
+		if transaction.Account.Status != "active" {
+			return newHandlerError(http.StatusBadRequest, "Le compte n'est pas actif")
+		}
+		// Seule la jambe créditrice d'un virement est remboursable par son bénéficiaire
+		if transaction.Type != "transfer" || transaction.ToAccountID != nil {
+			return newHandlerError(http.StatusBadRequest, "Seul un virement reçu peut être remboursé")
+		}
+		return nil
+	}, "Virement remboursé avec succès")
+}
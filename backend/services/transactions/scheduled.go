@@ -0,0 +1,542 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recentRunsLimit est le nombre d'exécutions retournées avec le détail d'un ordre
+const recentRunsLimit = 20
+
+// isValidFrequency indique si frequency est une fréquence de virement programmé
+func isValidFrequency(frequency string) bool {
+	switch frequency {
+	case models.FrequencyOnce, models.FrequencyDaily, models.FrequencyWeekly,
+		models.FrequencyMonthly, models.FrequencyEndOfMonth:
+		return true
+	}
+	return false
+}
+
+// startOfDay retourne minuit UTC du jour de t
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// parseScheduleDate lit une date d'échéance (2024-01-31), à minuit UTC
+func parseScheduleDate(field, value string) (time.Time, error) {
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, newHandlerError(http.StatusBadRequest, field+" invalide: "+value+" (format attendu: 2006-01-02)")
+	}
+	return date, nil
+}
+
+// occurrence retourne la n-ième échéance (n à partir de 0) d'un ordre commençant à start
+func occurrence(start time.Time, frequency string, n int) time.Time {
+	start = startOfDay(start)
+	switch frequency {
+	case models.FrequencyDaily:
+		return start.AddDate(0, 0, n)
+	case models.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case models.FrequencyMonthly:
+		// Le quantième de départ est ramené au dernier jour des mois plus courts
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		lastDay := first.AddDate(0, 1, -1).Day()
+		return first.AddDate(0, 0, min(start.Day(), lastDay)-1)
+	case models.FrequencyEndOfMonth:
+		return time.Date(start.Year(), start.Month()+time.Month(n)+1, 0, 0, 0, 0, 0, time.UTC)
+	}
+	return start
+}
+
+// occurrencesUntil retourne le nombre d'échéances d'un ordre commençant à start qui tombent
+// au plus tard à t, sans date de fin. Le rang est estimé à partir de l'écart calendaire puis
+// ajusté d'au plus quelques pas, quel que soit l'écart entre start et t.
+func occurrencesUntil(start time.Time, frequency string, t time.Time) int {
+	first := occurrence(start, frequency, 0)
+	if t.Before(first) {
+		return 0
+	}
+	if frequency == models.FrequencyOnce {
+		return 1
+	}
+
+	var n int
+	switch frequency {
+	case models.FrequencyDaily:
+		n = int(t.Sub(first) / (24 * time.Hour))
+	case models.FrequencyWeekly:
+		n = int(t.Sub(first) / (7 * 24 * time.Hour))
+	default:
+		n = (t.Year()-first.Year())*12 + int(t.Month()) - int(first.Month())
+	}
+	for n > 0 && occurrence(start, frequency, n).After(t) {
+		n--
+	}
+	for !occurrence(start, frequency, n+1).After(t) {
+		n++
+	}
+	return n + 1
+}
+
+// nextOccurrence retourne la première échéance de l'ordre postérieure à after, ou false
+// s'il n'en a plus (virement unique passé, date de fin dépassée)
+func nextOccurrence(order *models.StandingOrder, after time.Time) (time.Time, bool) {
+	n := occurrencesUntil(order.StartDate, order.Frequency, after)
+	if n > 0 && order.Frequency == models.FrequencyOnce {
+		return time.Time{}, false
+	}
+	next := occurrence(order.StartDate, order.Frequency, n)
+	if order.EndDate != nil && next.After(*order.EndDate) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// schedule positionne l'ordre sur sa première échéance postérieure à after, ou le termine
+// lorsqu'il n'en a plus ou que MaxRuns échéances ont été traitées
+func schedule(order *models.StandingOrder, after time.Time) {
+	next, ok := nextOccurrence(order, after)
+	if !ok || (order.MaxRuns != nil && order.RunCount >= *order.MaxRuns) {
+		order.Status = models.StandingOrderCompleted
+		order.NextRunAt = nil
+		return
+	}
+	order.NextRunAt = &next
+}
+
+// advance enregistre le traitement de l'échéance scheduledFor et passe à la suivante
+func advance(order *models.StandingOrder, scheduledFor, now time.Time) {
+	order.RunCount++
+	order.LastRunAt = &now
+	schedule(order, scheduledFor)
+}
+
+// standingOrderCursor retourne la position d'un ordre dans une liste paginée
+func standingOrderCursor(order models.StandingOrder) utils.Cursor {
+	return utils.Cursor{CreatedAt: order.CreatedAt, ID: order.ID}
+}
+
+// standingOrderID lit l'identifiant :id ; écrit la réponse d'erreur et retourne false s'il est invalide
+func standingOrderID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "ID de virement programmé invalide",
+			Error:   "Bad Request",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// changeStandingOrder applique change à l'ordre :id de l'utilisateur, verrouillé pour ne pas
+// écraser une échéance que l'ordonnanceur serait en train d'exécuter. Seuls les ordres
+// actifs ou suspendus peuvent être modifiés.
+func (s *server) changeStandingOrder(c *gin.Context, userID uint, change func(order *models.StandingOrder) error, success string) {
+	id, ok := standingOrderID(c)
+	if !ok {
+		return
+	}
+
+	var order *models.StandingOrder
+	err := s.store.Transaction(func(tx *repository.Store) error {
+		var err error
+		order, err = tx.StandingOrders.LockForUser(id, userID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return newHandlerError(http.StatusNotFound, "Virement programmé non trouvé")
+		}
+		if err != nil {
+			return err
+		}
+		if order.Status != models.StandingOrderActive && order.Status != models.StandingOrderPaused {
+			return newHandlerError(http.StatusConflict, "Ce virement programmé est terminé ou annulé")
+		}
+		if err := change(order); err != nil {
+			return err
+		}
+		return tx.StandingOrders.Save(order)
+	})
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la mise à jour du virement programmé")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: success,
+		Data:    order,
+	})
+}
+
+// listStandingOrdersHandler récupère une page des virements programmés de l'utilisateur
+func (s *server) listStandingOrdersHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	page, err := utils.ParsePageRequest(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Error:   "Bad Request",
+		})
+		return
+	}
+
+	orders, err := s.store.StandingOrders.ListPage(userID.(uint), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des virements programmés",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	orders, info := utils.NewPage(orders, page, standingOrderCursor)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success:    true,
+		Message:    "Virements programmés récupérés avec succès",
+		Data:       orders,
+		Pagination: &info,
+	})
+}
+
+// createStandingOrderHandler programme un virement unique ou permanent
+func (s *server) createStandingOrderHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	var request struct {
+		FromAccountID uint        `json:"from_account_id" binding:"required"`
+		ToAccountID   uint        `json:"to_account_id"`
+		ToIBAN        string      `json:"to_iban"` // alternative à to_account_id
+		Amount        json.Number `json:"amount" binding:"required"`
+		Description   string      `json:"description"`
+		Frequency     string      `json:"frequency" binding:"required"`
+		StartDate     string      `json:"start_date" binding:"required"` // première échéance au plus tôt
+		EndDate       string      `json:"end_date"`                      // dernière échéance au plus tard
+		MaxRuns       *int        `json:"max_runs"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	order, err := s.newStandingOrder(userID.(uint), request.FromAccountID, request.ToAccountID, request.ToIBAN)
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la programmation du virement")
+		return
+	}
+	order.Description = request.Description
+	order.Frequency = request.Frequency
+	if order.Amount, err = parseAmount(request.Amount, order.Currency); err == nil {
+		err = setSchedule(order, request.StartDate, request.EndDate, request.MaxRuns, time.Now())
+	}
+	if err == nil {
+		err = s.checkStandingOrderCoolingOff(order)
+	}
+	if err != nil {
+		respondWithError(c, err, "Erreur lors de la programmation du virement")
+		return
+	}
+
+	if err := s.store.StandingOrders.Create(order); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la programmation du virement",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Virement programmé avec succès",
+		Data:    order,
+	})
+}
+
+// newStandingOrder vérifie les comptes d'un nouvel ordre : le compte source doit être un
+// compte actif de l'utilisateur, le compte destination un autre compte de la banque
+func (s *server) newStandingOrder(userID, fromAccountID, toAccountID uint, toIBAN string) (*models.StandingOrder, error) {
+	toAccountID, err := s.resolveDestination(toAccountID, toIBAN)
+	if err != nil {
+		return nil, err
+	}
+	if fromAccountID == toAccountID {
+		return nil, newHandlerError(http.StatusBadRequest, "Impossible de transférer vers le même compte")
+	}
+
+	fromAccount, err := s.store.Accounts.FindForUser(fromAccountID, userID)
+	if err != nil {
+		return nil, newHandlerError(http.StatusNotFound, "Compte source non trouvé")
+	}
+	if fromAccount.Status != "active" {
+		return nil, newHandlerError(http.StatusBadRequest, "Le compte source n'est pas actif")
+	}
+	if _, err := s.store.Accounts.FindByID(toAccountID); err != nil {
+		return nil, newHandlerError(http.StatusNotFound, "Compte destination non trouvé")
+	}
+
+	return &models.StandingOrder{
+		UserID:        userID,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccountID,
+		Currency:      fromAccount.Currency,
+		Status:        models.StandingOrderActive,
+	}, nil
+}
+
+// checkStandingOrderCoolingOff refuse un ordre dont la première échéance tomberait pendant le
+// délai de carence de son bénéficiaire ; chaque échéance est de nouveau contrôlée à l'exécution
+func (s *server) checkStandingOrderCoolingOff(order *models.StandingOrder) error {
+	if order.NextRunAt == nil {
+		return nil
+	}
+	toAccount, err := s.store.Accounts.FindByID(order.ToAccountID)
+	if err != nil {
+		return err
+	}
+	beneficiary, err := payeeBeneficiary(s.store, order.UserID, toAccount, nil)
+	if err != nil {
+		return err
+	}
+	return checkCoolingOff(beneficiary, order.Amount, *order.NextRunAt)
+}
+
+// setSchedule valide l'échéancier d'un nouvel ordre et le positionne sur sa première échéance
+func setSchedule(order *models.StandingOrder, startDate, endDate string, maxRuns *int, now time.Time) error {
+	if !isValidFrequency(order.Frequency) {
+		return newHandlerError(http.StatusBadRequest, "Fréquence invalide. Fréquences valides: once, daily, weekly, monthly, end_of_month")
+	}
+
+	start, err := parseScheduleDate("start_date", startDate)
+	if err != nil {
+		return err
+	}
+	if start.Before(startOfDay(now)) {
+		return newHandlerError(http.StatusBadRequest, "La date de début ne peut pas être passée")
+	}
+	order.StartDate = start
+
+	if err := setEndConditions(order, endDate, maxRuns); err != nil {
+		return err
+	}
+
+	schedule(order, start.Add(-time.Nanosecond))
+	if order.NextRunAt == nil {
+		return newHandlerError(http.StatusBadRequest, "Aucune échéance ne tombe avant la date de fin")
+	}
+	return nil
+}
+
+// setEndConditions applique la date de fin et le nombre maximal d'échéances ; une date
+// vide ou un nombre nul retire la condition correspondante
+func setEndConditions(order *models.StandingOrder, endDate string, maxRuns *int) error {
+	order.EndDate = nil
+	if endDate != "" {
+		end, err := parseScheduleDate("end_date", endDate)
+		if err != nil {
+			return err
+		}
+		if end.Before(order.StartDate) {
+			return newHandlerError(http.StatusBadRequest, "La date de fin doit suivre la date de début")
+		}
+		order.EndDate = &end
+	}
+
+	order.MaxRuns = nil
+	if maxRuns != nil && *maxRuns != 0 {
+		if *maxRuns < 0 {
+			return newHandlerError(http.StatusBadRequest, "max_runs doit être positif")
+		}
+		order.MaxRuns = maxRuns
+	}
+	return nil
+}
+
+// getStandingOrderHandler récupère un virement programmé et ses dernières exécutions
+func (s *server) getStandingOrderHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	id, ok := standingOrderID(c)
+	if !ok {
+		return
+	}
+	order, err := s.store.StandingOrders.FindForUser(id, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Virement programmé non trouvé",
+			Error:   "Not Found",
+		})
+		return
+	}
+
+	runs, err := s.store.StandingOrders.ListRuns(order.ID, recentRunsLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Erreur lors de la récupération des exécutions",
+			Error:   "Internal Server Error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Virement programmé récupéré avec succès",
+		Data: map[string]interface{}{
+			"standing_order": order,
+			"runs":           runs,
+		},
+	})
+}
+
+// updateStandingOrderHandler modifie le montant, le libellé ou les conditions de fin d'un
+// ordre, ou le suspend (paused) et le reprend (active). À la reprise, les échéances
+// tombées pendant la suspension ne sont pas rattrapées.
+func (s *server) updateStandingOrderHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	var request struct {
+		Amount      *json.Number `json:"amount"`
+		Description *string      `json:"description"`
+		EndDate     *string      `json:"end_date"` // "" retire la date de fin
+		MaxRuns     *int         `json:"max_runs"` // 0 retire la limite
+		Status      *string      `json:"status"`   // active, paused
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Données invalides",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	s.changeStandingOrder(c, userID.(uint), func(order *models.StandingOrder) error {
+		return updateStandingOrder(order, request.Amount, request.Description, request.EndDate, request.MaxRuns, request.Status, time.Now())
+	}, "Virement programmé mis à jour avec succès")
+}
+
+// updateStandingOrder applique les champs renseignés puis recalcule la prochaine échéance
+func updateStandingOrder(order *models.StandingOrder, amount *json.Number, description, endDate *string, maxRuns *int, status *string, now time.Time) error {
+	if amount != nil {
+		parsed, err := parseAmount(*amount, order.Currency)
+		if err != nil {
+			return err
+		}
+		order.Amount = parsed
+	}
+	if description != nil {
+		order.Description = *description
+	}
+
+	if endDate != nil || maxRuns != nil {
+		end := ""
+		if endDate != nil {
+			end = *endDate
+		} else if order.EndDate != nil {
+			end = order.EndDate.Format(dateLayout)
+		}
+		limit := maxRuns
+		if limit == nil {
+			limit = order.MaxRuns
+		}
+		if err := setEndConditions(order, end, limit); err != nil {
+			return err
+		}
+	}
+
+	resumed := false
+	if status != nil {
+		switch *status {
+		case models.StandingOrderActive:
+			resumed = order.Status == models.StandingOrderPaused
+		case models.StandingOrderPaused:
+		default:
+			return newHandlerError(http.StatusBadRequest, "Statut invalide. Statuts valides: active, paused")
+		}
+		order.Status = *status
+	}
+
+	// La prochaine échéance est conservée si elle reste dans les conditions de fin ;
+	// une reprise repart de la première échéance à venir
+	from := *order.NextRunAt
+	if resumed && from.Before(startOfDay(now)) {
+		from = startOfDay(now)
+	}
+	schedule(order, from.Add(-time.Nanosecond))
+	return nil
+}
+
+// cancelStandingOrderHandler annule un virement programmé ; ses exécutions restent consultables
+func (s *server) cancelStandingOrderHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Utilisateur non authentifié",
+			Error:   "Unauthorized",
+		})
+		return
+	}
+
+	s.changeStandingOrder(c, userID.(uint), func(order *models.StandingOrder) error {
+		order.Status = models.StandingOrderCancelled
+		order.NextRunAt = nil
+		return nil
+	}, "Virement programmé annulé avec succès")
+}
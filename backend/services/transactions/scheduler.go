@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/notify"
+	"banking-app/shared/repository"
+	"banking-app/shared/statement"
+	"banking-app/shared/utils"
+)
+
+// schedulerBatchSize borne le nombre d'ordres traités par passage
+const schedulerBatchSize = 100
+
+// Statuts d'exécution d'une échéance
+const (
+	runSucceeded = "succeeded"
+	runFailed    = "failed"
+	runSkipped   = "skipped"
+	// runHeld : virement mis en attente de vérification par le contrôle anti-fraude, exécuté
+	// sous la référence de l'échéance s'il est libéré
+	runHeld = "held"
+)
+
+// schedulerEnabled indique si l'instance exécute les tâches planifiées (SCHEDULER_ENABLED,
+// actif par défaut ; plusieurs instances peuvent l'être simultanément)
+func schedulerEnabled() bool {
+	return os.Getenv("SCHEDULER_ENABLED") != "false"
+}
+
+// schedulerInterval est l'intervalle entre deux passages des tâches planifiées (SCHEDULER_INTERVAL)
+func schedulerInterval() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("SCHEDULER_INTERVAL")); err == nil && value > 0 {
+		return value
+	}
+	return time.Minute
+}
+
+// catchUpLimit est le nombre d'échéances manquées exécutées par un ordre en retard, par
+// exemple après une interruption de l'ordonnanceur (STANDING_ORDER_CATCH_UP_LIMIT) ; les
+// plus anciennes sont sautées
+func catchUpLimit() int {
+	if value, err := strconv.Atoi(os.Getenv("STANDING_ORDER_CATCH_UP_LIMIT")); err == nil && value > 0 {
+		return value
+	}
+	return 1
+}
+
+// runScheduler exécute les virements programmés échus, libère les réservations expirées,
+// facture les intérêts débiteurs et établit les relevés mensuels toutes les interval
+func (s *server) runScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		if _, err := s.runDueOrders(now); err != nil {
+			log.Printf("Erreur lors de la recherche des virements programmés échus: %v", err)
+		}
+		if _, err := s.expireHolds(now); err != nil {
+			log.Printf("Erreur lors de la recherche des réservations expirées: %v", err)
+		}
+		if _, err := s.chargeOverdraftInterest(now); err != nil {
+			log.Printf("Erreur lors de la recherche des intérêts débiteurs à facturer: %v", err)
+		}
+		if _, err := statement.GenerateDue(s.store, now); err != nil {
+			log.Printf("Erreur lors de la recherche des relevés à établir: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// runDueOrders exécute une échéance de chaque ordre échu à now et retourne le nombre
+// d'échéances traitées. Un ordre en retard de plusieurs échéances n'exécute que les
+// catchUpLimit plus récentes, une par passage.
+func (s *server) runDueOrders(now time.Time) (int, error) {
+	ids, err := s.store.StandingOrders.ListDue(now, schedulerBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, id := range ids {
+		done, err := s.runStandingOrder(id, now)
+		if err != nil {
+			// L'ordre n'avance pas : l'échéance sera retentée au prochain passage
+			log.Printf("Erreur lors de l'exécution du virement programmé %d: %v", id, err)
+			continue
+		}
+		if done {
+			processed++
+		}
+	}
+	return processed, nil
+}
+
+// runStandingOrder exécute l'échéance courante de l'ordre id. Le verrou posé sur l'ordre,
+// le virement, la trace de l'échéance et le passage à l'échéance suivante sont validés
+// ensemble : une échéance n'est exécutée qu'une fois, même avec plusieurs instances ou
+// après un redémarrage. Retourne false si l'ordre n'est plus échu.
+func (s *server) runStandingOrder(id uint, now time.Time) (bool, error) {
+	var order *models.StandingOrder
+	var run models.StandingOrderRun
+	var skipped *models.StandingOrderRun
+
+	err := s.store.Transaction(func(tx *repository.Store) error {
+		var err error
+		order, err = tx.StandingOrders.LockDue(id, now)
+		if err != nil {
+			return err
+		}
+		if skipped = skipMissedRuns(order, now, catchUpLimit()); skipped != nil {
+			if err := tx.StandingOrders.CreateRun(skipped); err != nil {
+				return err
+			}
+		}
+		run = models.StandingOrderRun{
+			StandingOrderID: order.ID,
+			ScheduledFor:    *order.NextRunAt,
+			Status:          runSucceeded,
+		}
+
+		// Le virement s'exécute dans un point de sauvegarde : un échec métier (solde
+		// insuffisant, compte inactif) n'annule que le virement, l'échec étant tracé
+		err = tx.Transaction(func(transfer *repository.Store) error {
+			return s.executeStandingOrder(transfer, order, &run)
+		})
+		// Un virement refusé par le contrôle anti-fraude échoue ; mis en attente de
+		// vérification, il n'est pas un échec
+		var held *heldTransferError
+		var herr *handlerError
+		switch {
+		case errors.As(err, &held):
+			if err := tx.Fraud.Create(held.decision); err != nil {
+				return err
+			}
+			if held.decision.Status == models.FraudStatusPending {
+				run.Status = runHeld
+			} else {
+				run.Status, run.Error = runFailed, held.Error()
+				order.FailureCount++
+			}
+		case errors.As(err, &herr):
+			run.Status, run.Reference, run.Error = runFailed, "", herr.message
+			order.FailureCount++
+		case err != nil:
+			return err
+		}
+
+		advance(order, run.ScheduledFor, now)
+		if err := tx.StandingOrders.CreateRun(&run); err != nil {
+			return err
+		}
+		return tx.StandingOrders.Save(order)
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if skipped != nil {
+		s.notifyRun(order, *skipped)
+	}
+	s.notifyRun(order, run)
+	return true, nil
+}
+
+// skipMissedRuns applique la politique de rattrapage à un ordre échu : seules les limit
+// dernières échéances manquées à now restent à exécuter, les plus anciennes sont sautées
+// d'un bloc et comptent dans max_runs. La dernière échéance permise par max_runs n'est
+// jamais sautée. Retourne la trace des échéances sautées, ou nil.
+func skipMissedRuns(order *models.StandingOrder, now time.Time, limit int) *models.StandingOrderRun {
+	until := now
+	if order.EndDate != nil && order.EndDate.Before(until) {
+		until = *order.EndDate
+	}
+	first := *order.NextRunAt
+	done := occurrencesUntil(order.StartDate, order.Frequency, first.Add(-time.Nanosecond))
+	count := occurrencesUntil(order.StartDate, order.Frequency, until) - done - limit
+	if order.MaxRuns != nil {
+		count = min(count, *order.MaxRuns-order.RunCount-1)
+	}
+	if count <= 0 {
+		return nil
+	}
+
+	last := occurrence(order.StartDate, order.Frequency, done+count-1)
+	next := occurrence(order.StartDate, order.Frequency, done+count)
+	order.RunCount += count
+	order.NextRunAt = &next
+	return &models.StandingOrderRun{
+		StandingOrderID: order.ID,
+		ScheduledFor:    first,
+		Status:          runSkipped,
+		Error: fmt.Sprintf("%d échéance(s) du %s au %s non exécutée(s) après une interruption",
+			count, first.Format("02/01/2006"), last.Format("02/01/2006")),
+	}
+}
+
+// executeStandingOrder soumet au contrôle anti-fraude puis exécute le virement d'une échéance,
+// et en reporte la référence dans run
+func (s *server) executeStandingOrder(tx *repository.Store, order *models.StandingOrder, run *models.StandingOrderRun) error {
+	user, err := tx.Users.FindByID(order.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return newHandlerError(http.StatusForbidden, "Utilisateur désactivé")
+	}
+
+	reference, err := utils.GenerateTransactionReference()
+	if err != nil {
+		return err
+	}
+	run.Reference = reference
+
+	_, err = s.screenedTransfer(tx, transferOrder{
+		UserID:        order.UserID,
+		FromAccountID: order.FromAccountID,
+		ToAccountID:   order.ToAccountID,
+		Amount:        json.Number(order.Amount.Decimal()),
+		Description:   order.Description,
+		Reference:     reference,
+	})
+	return err
+}
+
+// notifyRun informe l'utilisateur du résultat d'une échéance ; un échec, une mise en attente
+// de vérification ou des échéances sautées sont signalés par email
+func (s *server) notifyRun(order *models.StandingOrder, run models.StandingOrderRun) {
+	channel, title := notify.ChannelSystem, "Virement programmé exécuté"
+	message := fmt.Sprintf("Votre virement programmé n°%d de %s du %s a été exécuté (référence %s).",
+		order.ID, order.Amount, run.ScheduledFor.Format("02/01/2006"), run.Reference)
+	switch run.Status {
+	case runFailed:
+		channel, title = notify.ChannelEmail, "Échec d'un virement programmé"
+		message = fmt.Sprintf("Votre virement programmé n°%d de %s du %s n'a pas pu être exécuté : %s.",
+			order.ID, order.Amount, run.ScheduledFor.Format("02/01/2006"), run.Error)
+	case runHeld:
+		channel, title = notify.ChannelEmail, "Virement programmé en cours de vérification"
+		message = fmt.Sprintf("Votre virement programmé n°%d de %s du %s (référence %s) est en cours de vérification par nos services. "+
+			"Il sera exécuté dès sa validation ; vous serez averti de la décision.",
+			order.ID, order.Amount, run.ScheduledFor.Format("02/01/2006"), run.Reference)
+	case runSkipped:
+		channel, title = notify.ChannelEmail, "Échéances de virement programmé non exécutées"
+		message = fmt.Sprintf("Votre virement programmé n°%d de %s : %s. Seules les échéances les plus récentes sont exécutées.",
+			order.ID, order.Amount, run.Error)
+	}
+	if order.Status == models.StandingOrderCompleted && run.Status != runSkipped {
+		message += " Il s'agissait de la dernière échéance de cet ordre."
+	}
+
+	if err := s.notifier.Notify(order.UserID, channel, title, message); err != nil {
+		log.Printf("Erreur lors de la notification du virement programmé %d: %v", order.ID, err)
+	}
+}
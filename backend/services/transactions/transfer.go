@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"banking-app/shared/fx"
+	"banking-app/shared/ledger"
+	"banking-app/shared/limits"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+)
+
+// transferOrder décrit un virement entre deux comptes de la banque, le compte source
+// appartenant à UserID ; Amount est exprimé dans la devise du compte source
+type transferOrder struct {
+	UserID        uint
+	FromAccountID uint
+	ToAccountID   uint
+	Amount        json.Number
+	Description   string
+	QuoteID       string
+	Reference     string
+	// Beneficiary est le bénéficiaire désigné ; nil sinon, le bénéficiaire enregistré pour le
+	// compte destination étant alors soumis à son délai de carence
+	Beneficiary *models.Beneficiary
+	// Batch est la référence du lot remis par fichier dont le virement fait partie
+	Batch string
+	// CoolingOffChecked indique que le délai de carence a été contrôlé avant la mise en
+	// attente du virement par le contrôle anti-fraude : il ne l'est pas de nouveau à sa
+	// libération, pour que la décision porte sur le virement tel qu'il a été contrôlé
+	CoolingOffChecked bool
+}
+
+// transferResult regroupe les deux jambes d'un virement exécuté
+type transferResult struct {
+	Debit  models.Transaction
+	Credit models.Transaction
+}
+
+// resolveDestination retourne le compte destination désigné soit par toAccountID, soit
+// par toIBAN (un compte de la banque) ; exactement l'un des deux doit être renseigné
+func (s *server) resolveDestination(toAccountID uint, toIBAN string) (uint, error) {
+	if (toAccountID == 0) == (toIBAN == "") {
+		return 0, newHandlerError(http.StatusBadRequest, "Indiquez le compte destination par to_account_id ou par to_iban")
+	}
+	if toIBAN == "" {
+		return toAccountID, nil
+	}
+
+	if err := utils.ValidateIBAN(toIBAN); err != nil {
+		return 0, newHandlerError(http.StatusBadRequest, err.Error())
+	}
+	account, err := s.store.Accounts.FindByNumber(utils.NormalizeIBAN(toIBAN))
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, newHandlerError(http.StatusNotFound, "Aucun compte de la banque ne correspond à cet IBAN")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return account.ID, nil
+}
+
+// executeTransfer exécute un virement dans la transaction tx : verrouillage des comptes,
+// conversion éventuelle, écriture au grand livre et création des deux transactions.
+// Les erreurs métier sont des handlerError.
+func (s *server) executeTransfer(tx *repository.Store, order transferOrder) (transferResult, error) {
+	var fromAccount, toAccount *models.Account
+
+	lockFrom := func() (err error) {
+		// Vérifier que le compte source appartient à l'utilisateur
+		fromAccount, err = tx.Accounts.LockForUser(order.FromAccountID, order.UserID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return newHandlerError(http.StatusNotFound, "Compte source non trouvé")
+		}
+		return err
+	}
+	lockTo := func() (err error) {
+		// Vérifier que le compte destination existe
+		toAccount, err = tx.Accounts.LockByID(order.ToAccountID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return newHandlerError(http.StatusNotFound, "Compte destination non trouvé")
+		}
+		return err
+	}
+
+	// Les comptes sont toujours verrouillés par identifiant croissant pour éviter
+	// les interblocages entre deux transferts croisés
+	locks := []func() error{lockFrom, lockTo}
+	if order.ToAccountID < order.FromAccountID {
+		locks = []func() error{lockTo, lockFrom}
+	}
+	for _, lock := range locks {
+		if err := lock(); err != nil {
+			return transferResult{}, err
+		}
+	}
+
+	// Vérifications
+	if fromAccount.Status != "active" {
+		return transferResult{}, newHandlerError(http.StatusBadRequest, "Le compte source n'est pas actif")
+	}
+	if toAccount.Status != "active" {
+		return transferResult{}, newHandlerError(http.StatusBadRequest, "Le compte destination n'est pas actif")
+	}
+
+	// Le montant est exprimé dans la devise du compte source
+	amount, err := parseAmount(order.Amount, fromAccount.Currency)
+	if err != nil {
+		return transferResult{}, err
+	}
+	if !order.CoolingOffChecked {
+		beneficiary, err := payeeBeneficiary(tx, order.UserID, toAccount, order.Beneficiary)
+		if err != nil {
+			return transferResult{}, err
+		}
+		if err := checkCoolingOff(beneficiary, amount, time.Now()); err != nil {
+			return transferResult{}, err
+		}
+	}
+	// Les virements entre comptes du même client ne sont pas plafonnés
+	if toAccount.UserID != fromAccount.UserID {
+		outflow := limits.Outflow{Amount: amount, Reference: order.Reference, Batch: order.Batch}
+		if err := authorizeOutflow(tx, fromAccount, outflow); err != nil {
+			return transferResult{}, err
+		}
+	}
+	// Une seule écriture équilibrée porte le débit et le crédit
+	entry := models.JournalEntry{
+		Reference:   order.Reference,
+		Description: order.Description,
+		Postings: []models.Posting{
+			ledger.CustomerPosting(fromAccount.ID, amount.Neg()),
+			ledger.CustomerPosting(toAccount.ID, amount),
+		},
+	}
+	credited := amount
+
+	// Entre deux devises, le montant crédité est converti au cours de la cotation
+	// fournie, ou à défaut au cours courant
+	var conversion *fx.Conversion
+	if toAccount.Currency != fromAccount.Currency {
+		var converted fx.Conversion
+		if order.QuoteID != "" {
+			converted, err = useQuote(tx, order.QuoteID, order.UserID, amount, toAccount.Currency)
+		} else {
+			converted, err = s.convert(amount, toAccount.Currency)
+		}
+		if err != nil {
+			return transferResult{}, err
+		}
+		conversion = &converted
+		entry.Postings = conversionPostings(fromAccount.ID, toAccount.ID, converted)
+		credited = converted.Target
+	} else if order.QuoteID != "" {
+		return transferResult{}, newHandlerError(http.StatusBadRequest, "Une cotation ne s'applique qu'à un virement entre devises")
+	}
+
+	if err := postEntry(tx.DB, &entry); err != nil {
+		return transferResult{}, err
+	}
+
+	now := time.Now()
+	toAccountID := toAccount.ID
+
+	// Créer la transaction de débit
+	debit := models.Transaction{
+		AccountID:      fromAccount.ID,
+		Type:           "transfer",
+		Amount:         amount,
+		Currency:       fromAccount.Currency,
+		Description:    order.Description,
+		Reference:      order.Reference + "-OUT",
+		Status:         "completed",
+		ToAccountID:    &toAccountID,
+		ProcessedAt:    &now,
+		JournalEntryID: &entry.ID,
+	}
+	if conversion != nil {
+		recordConversion(&debit, *conversion, order.QuoteID)
+	}
+	if err := tx.Transactions.Create(&debit); err != nil {
+		return transferResult{}, err
+	}
+
+	// Créer la transaction de crédit
+	credit := models.Transaction{
+		AccountID:      toAccount.ID,
+		Type:           "transfer",
+		Amount:         credited,
+		Currency:       toAccount.Currency,
+		Description:    order.Description,
+		Reference:      order.Reference + "-IN",
+		Status:         "completed",
+		ProcessedAt:    &now,
+		JournalEntryID: &entry.ID,
+	}
+	if conversion != nil {
+		recordConversion(&credit, *conversion, order.QuoteID)
+	}
+	if err := tx.Transactions.Create(&credit); err != nil {
+		return transferResult{}, err
+	}
+	return transferResult{Debit: debit, Credit: credit}, nil
+}
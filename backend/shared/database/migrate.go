@@ -0,0 +1,398 @@
+package database
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// migrationFilePattern reconnaît les fichiers NNN_nom.up.sql et NNN_nom.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration représente une migration SQL versionnée
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 du script up
+}
+
+// MigrationStatus décrit l'état d'une migration dans la base
+type MigrationStatus struct {
+	Version          int64
+	Name             string
+	Applied          bool
+	AppliedAt        *time.Time
+	ChecksumMismatch bool
+}
+
+// appliedMigration est une ligne de la table schema_migrations
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applique les migrations versionnées et les enregistre dans schema_migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator charge les migrations embarquées dans le binaire
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrationsFS, err := fs.Sub(embeddedMigrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return NewMigratorFromFS(db, migrationsFS)
+}
+
+// NewMigratorFromFS charge les migrations depuis un système de fichiers
+func NewMigratorFromFS(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// loadMigrations lit et associe les fichiers up/down de chaque version
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("lecture des migrations: %v", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("nom de migration invalide: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("version de migration invalide: %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Clean(entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("version %d utilisée par deux migrations (%s, %s)", version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %03d_%s: fichiers up et down requis", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrations retourne les migrations connues, triées par version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// LatestVersion retourne la version de la dernière migration connue
+func (m *Migrator) LatestVersion() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// ensureTable crée la table schema_migrations si nécessaire
+func (m *Migrator) ensureTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    applied_at DATETIME NOT NULL
+)`).Error
+}
+
+// applied retourne les migrations déjà appliquées, indexées par version
+func (m *Migrator) applied(db *gorm.DB) (map[int64]appliedMigration, error) {
+	var rows []appliedMigration
+	if err := db.Table("schema_migrations").Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// verifyChecksums refuse de continuer si une migration appliquée a été modifiée
+// ou si la base contient une version inconnue du binaire
+func (m *Migrator) verifyChecksums(applied map[int64]appliedMigration) error {
+	known := map[int64]Migration{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, row := range applied {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("la migration %d appliquée en base est inconnue", version)
+		}
+		if migration.Checksum != row.Checksum {
+			return fmt.Errorf("la migration %03d_%s a été modifiée après son application (checksum différent)",
+				version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// withLock exécute fn sur une connexion dédiée, protégée par un verrou applicatif
+// MySQL afin que plusieurs instances ne migrent pas en même temps
+func (m *Migrator) withLock(fn func(db *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() == "mysql" {
+			var acquired int
+			if err := conn.Raw("SELECT GET_LOCK('schema_migrations', 60)").Scan(&acquired).Error; err != nil {
+				return err
+			}
+			if acquired != 1 {
+				return fmt.Errorf("impossible d'obtenir le verrou de migration")
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK('schema_migrations')")
+		}
+
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// Up applique toutes les migrations en attente et retourne leur nombre
+func (m *Migrator) Up() (int, error) {
+	return m.migrate(m.LatestVersion())
+}
+
+// Down annule les steps dernières migrations appliquées
+func (m *Migrator) Down(steps int) (int, error) {
+	count := 0
+	err := m.withLock(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		if err := m.verifyChecksums(applied); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.revert(db, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// To amène le schéma à la version indiquée (montée ou descente)
+func (m *Migrator) To(version int64) (int, error) {
+	if version != 0 {
+		found := false
+		for _, migration := range m.migrations {
+			if migration.Version == version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("version de migration inconnue: %d", version)
+		}
+	}
+	return m.migrate(version)
+}
+
+// migrate applique les migrations jusqu'à target inclus et annule celles au-delà
+func (m *Migrator) migrate(target int64) (int, error) {
+	count := 0
+	err := m.withLock(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		if err := m.verifyChecksums(applied); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(db, migration); err != nil {
+				return err
+			}
+			count++
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version <= target {
+				break
+			}
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.revert(db, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// apply exécute le script up et enregistre la version dans une même transaction.
+// Sous MySQL, chaque instruction DDL valide implicitement la transaction : seules les
+// instructions de données (INSERT, UPDATE) qui suivent le dernier DDL sont annulées avec
+// l'enregistrement de la version. Une migration interrompue après un DDL n'est pas
+// enregistrée et doit être reprise à la main (voir la procédure de reprise de la
+// documentation d'architecture).
+func (m *Migrator) apply(db *gorm.DB, migration Migration) error {
+	log.Printf("Migration %03d_%s: up", migration.Version, migration.Name)
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i, statement := range splitStatements(migration.Up) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("migration %03d_%s, instruction %d: %v", migration.Version, migration.Name, i+1, err)
+			}
+		}
+		return tx.Exec("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+			migration.Version, migration.Name, migration.Checksum, time.Now().UTC()).Error
+	})
+}
+
+// revert exécute le script down et supprime la version dans une même transaction, avec
+// les mêmes limites que apply sous MySQL
+func (m *Migrator) revert(db *gorm.DB, migration Migration) error {
+	log.Printf("Migration %03d_%s: down", migration.Version, migration.Name)
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i, statement := range splitStatements(migration.Down) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("rollback %03d_%s, instruction %d: %v", migration.Version, migration.Name, i+1, err)
+			}
+		}
+		return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+	})
+}
+
+// Status retourne l'état de chaque migration connue
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if row, ok := applied[migration.Version]; ok {
+				appliedAt := row.AppliedAt
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+				status.ChecksumMismatch = row.Checksum != migration.Checksum
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// splitStatements découpe un script SQL en instructions, en ignorant les commentaires
+// et les points-virgules contenus dans les chaînes
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote rune
+
+	lines := strings.Split(script, "\n")
+	for _, line := range lines {
+		if quote == 0 && strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+
+		for _, r := range line {
+			switch {
+			case quote != 0:
+				if r == quote {
+					quote = 0
+				}
+			case r == '\'' || r == '"' || r == '`':
+				quote = r
+			case r == ';':
+				if statement := strings.TrimSpace(current.String()); statement != "" {
+					statements = append(statements, statement)
+				}
+				current.Reset()
+				continue
+			}
+			current.WriteRune(r)
+		}
+		current.WriteRune('\n')
+	}
+
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_add_index.up.sql":        {Data: []byte("CREATE INDEX idx ON t (c);")},
+		"002_add_index.down.sql":      {Data: []byte("DROP INDEX idx ON t;")},
+		"001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"001_initial_schema.down.sql": {Data: []byte("DROP TABLE t;")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Fatalf("migrations = %+v, attendu versions 1 puis 2", migrations)
+	}
+	if migrations[0].Name != "initial_schema" || migrations[0].Checksum == "" {
+		t.Errorf("migration 1 = %+v", migrations[0])
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidSets(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"down manquant": {
+			"001_initial.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"nom invalide": {
+			"initial.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"version dupliquée": {
+			"001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"001_a.down.sql": {Data: []byte("SELECT 1;")},
+			"001_b.up.sql":   {Data: []byte("SELECT 1;")},
+			"001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+
+	for name, fsys := range tests {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: erreur attendue", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsAreValid(t *testing.T) {
+	migrator, err := NewMigrator(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrator.LatestVersion() == 0 {
+		t.Error("aucune migration embarquée")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- commentaire; ignoré
+CREATE TABLE a (id INT);
+
+INSERT INTO a VALUES ('x;y');
+-- fin
+DROP TABLE b`
+
+	want := []string{
+		"CREATE TABLE a (id INT)",
+		"INSERT INTO a VALUES ('x;y')",
+		"DROP TABLE b",
+	}
+	if got := splitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements() = %q, attendu %q", got, want)
+	}
+}
+
+// TestMigratorRollsBackFailedMigration vérifie qu'une migration en échec n'est ni appliquée
+// à moitié ni enregistrée (SQLite exécute les DDL dans la transaction)
+func TestMigratorRollsBackFailedMigration(t *testing.T) {
+	db, err := Open(DriverSQLite, filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"001_accounts.up.sql":   {Data: []byte("CREATE TABLE accounts (id INTEGER PRIMARY KEY);")},
+		"001_accounts.down.sql": {Data: []byte("DROP TABLE accounts;")},
+		"002_holds.up.sql":      {Data: []byte("CREATE TABLE holds (id INTEGER PRIMARY KEY);\nINSERT INTO missing VALUES (1);")},
+		"002_holds.down.sql":    {Data: []byte("DROP TABLE holds;")},
+	}
+	migrator, err := NewMigratorFromFS(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.Up(); err == nil {
+		t.Fatal("erreur attendue pour la migration 002")
+	}
+	if db.Migrator().HasTable("holds") || !db.Migrator().HasTable("accounts") {
+		t.Error("la migration 002 a été appliquée à moitié ou la 001 annulée")
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("état = %+v, attendu 001 seule appliquée", statuses)
+	}
+
+	fsys["002_holds.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE holds (id INTEGER PRIMARY KEY);")}
+	if migrator, err = NewMigratorFromFS(db, fsys); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := migrator.Up(); err != nil || count != 1 || !db.Migrator().HasTable("holds") {
+		t.Fatalf("reprise: %d migration(s), err = %v", count, err)
+	}
+	if count, err := migrator.To(0); err != nil || count != 2 || db.Migrator().HasTable("accounts") {
+		t.Errorf("rollback complet: %d migration(s), err = %v", count, err)
+	}
+}
@@ -1,7 +1,10 @@
 -- Rollback de la migration initiale
 
 DROP TABLE IF EXISTS auth_sessions;
+DROP TABLE IF EXISTS idempotency_keys;
 DROP TABLE IF EXISTS notifications;
 DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS postings;
+DROP TABLE IF EXISTS journal_entries;
 DROP TABLE IF EXISTS accounts;
 DROP TABLE IF EXISTS users;
@@ -0,0 +1,138 @@
+-- Schéma initial de l'application bancaire, aligné sur shared/models
+
+-- Table des utilisateurs
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    email VARCHAR(255) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    first_name VARCHAR(100) NOT NULL,
+    last_name VARCHAR(100) NOT NULL,
+    phone VARCHAR(20),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+
+    UNIQUE INDEX idx_users_email (email),
+    INDEX idx_users_deleted_at (deleted_at)
+);
+
+-- Table des comptes bancaires (soldes en unités mineures)
+CREATE TABLE IF NOT EXISTS accounts (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    account_number VARCHAR(20) NOT NULL,
+    account_type VARCHAR(20) NOT NULL,
+    balance_minor BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3) DEFAULT 'EUR',
+    status VARCHAR(20) DEFAULT 'active',
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE INDEX idx_accounts_account_number (account_number),
+    INDEX idx_accounts_user_id (user_id),
+    INDEX idx_accounts_deleted_at (deleted_at)
+);
+
+-- Écritures comptables (partie double)
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    reference VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at DATETIME(3) NULL,
+
+    UNIQUE INDEX idx_journal_entries_reference (reference)
+);
+
+-- Lignes d'écriture
+CREATE TABLE IF NOT EXISTS postings (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    journal_entry_id BIGINT UNSIGNED NOT NULL,
+    ledger_account VARCHAR(100) NOT NULL,
+    account_id BIGINT UNSIGNED NULL,
+    amount_minor BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    created_at DATETIME(3) NULL,
+
+    FOREIGN KEY (journal_entry_id) REFERENCES journal_entries(id),
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    INDEX idx_postings_journal_entry_id (journal_entry_id),
+    INDEX idx_postings_ledger_account (ledger_account),
+    INDEX idx_postings_account_id (account_id)
+);
+
+-- Table des transactions (montants en unités mineures)
+CREATE TABLE IF NOT EXISTS transactions (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id BIGINT UNSIGNED NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    currency VARCHAR(3) DEFAULT 'EUR',
+    description TEXT,
+    reference VARCHAR(100),
+    status VARCHAR(20) DEFAULT 'pending',
+    processed_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    to_account_id BIGINT UNSIGNED NULL,
+    journal_entry_id BIGINT UNSIGNED NULL,
+
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    FOREIGN KEY (to_account_id) REFERENCES accounts(id),
+    FOREIGN KEY (journal_entry_id) REFERENCES journal_entries(id),
+    UNIQUE INDEX idx_transactions_reference (reference),
+    INDEX idx_transactions_account_id (account_id),
+    INDEX idx_transactions_journal_entry_id (journal_entry_id),
+    INDEX idx_transactions_created_at (created_at),
+    INDEX idx_transactions_deleted_at (deleted_at)
+);
+
+-- Table des notifications
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',
+    sent_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_notifications_user_id (user_id),
+    INDEX idx_notifications_deleted_at (deleted_at)
+);
+
+-- Clés d'idempotence des requêtes POST
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    response_status INT DEFAULT 0,
+    response_body MEDIUMTEXT,
+    expires_at DATETIME(3) NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE INDEX idx_idempotency_user_key (user_id, idempotency_key),
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
+
+-- Table des sessions d'authentification
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id VARCHAR(255) PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_auth_sessions_user_id (user_id),
+    INDEX idx_auth_sessions_expires_at (expires_at)
+);
@@ -0,0 +1,11 @@
+-- Rollback des refresh tokens
+
+ALTER TABLE auth_sessions
+    DROP INDEX idx_auth_sessions_previous_token_hash,
+    DROP INDEX idx_auth_sessions_refresh_token_hash,
+    DROP COLUMN revoked_at,
+    DROP COLUMN last_used_at,
+    DROP COLUMN ip_address,
+    DROP COLUMN user_agent,
+    DROP COLUMN previous_token_hash,
+    DROP COLUMN refresh_token_hash;
@@ -0,0 +1,11 @@
+-- Sessions de connexion adossées à des refresh tokens renouvelables
+
+ALTER TABLE auth_sessions
+    ADD COLUMN refresh_token_hash CHAR(64) NOT NULL AFTER user_id,
+    ADD COLUMN previous_token_hash CHAR(64) NOT NULL DEFAULT '' AFTER refresh_token_hash,
+    ADD COLUMN user_agent VARCHAR(255) NULL AFTER previous_token_hash,
+    ADD COLUMN ip_address VARCHAR(45) NULL AFTER user_agent,
+    ADD COLUMN last_used_at TIMESTAMP NULL AFTER expires_at,
+    ADD COLUMN revoked_at TIMESTAMP NULL AFTER last_used_at,
+    ADD UNIQUE INDEX idx_auth_sessions_refresh_token_hash (refresh_token_hash),
+    ADD INDEX idx_auth_sessions_previous_token_hash (previous_token_hash);
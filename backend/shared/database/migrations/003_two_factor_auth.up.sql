@@ -0,0 +1,18 @@
+-- Double authentification TOTP et codes de secours
+
+ALTER TABLE users
+    ADD COLUMN totp_secret VARCHAR(64) NULL AFTER is_active,
+    ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE AFTER totp_secret,
+    ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0 AFTER totp_enabled;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE INDEX idx_recovery_codes_code_hash (code_hash),
+    INDEX idx_recovery_codes_user_id (user_id)
+);
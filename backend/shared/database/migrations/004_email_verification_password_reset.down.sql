@@ -0,0 +1,6 @@
+-- Rollback de la vérification d'email et de la réinitialisation du mot de passe
+
+DROP TABLE IF EXISTS user_tokens;
+
+ALTER TABLE users
+    DROP COLUMN email_verified_at;
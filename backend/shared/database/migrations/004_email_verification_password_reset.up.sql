@@ -0,0 +1,19 @@
+-- Vérification d'email et réinitialisation du mot de passe
+
+ALTER TABLE users
+    ADD COLUMN email_verified_at DATETIME(3) NULL AFTER is_active;
+
+-- Les comptes existants sont considérés comme vérifiés pour ne pas bloquer leurs virements
+UPDATE users SET email_verified_at = COALESCE(created_at, NOW(3)) WHERE email_verified_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    purpose VARCHAR(32) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    used_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_user_tokens_user_id (user_id)
+);
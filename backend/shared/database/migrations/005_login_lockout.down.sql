@@ -0,0 +1,7 @@
+-- Rollback de la protection contre la force brute
+
+DROP TABLE IF EXISTS login_attempts;
+
+ALTER TABLE users
+    DROP COLUMN locked_until,
+    DROP COLUMN role;
@@ -0,0 +1,11 @@
+-- Protection contre la force brute : compteurs d'échecs et verrouillage des comptes
+
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'customer' AFTER email_verified_at,
+    ADD COLUMN locked_until DATETIME(3) NULL AFTER role;
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    attempt_key VARCHAR(255) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at DATETIME(3) NOT NULL
+);
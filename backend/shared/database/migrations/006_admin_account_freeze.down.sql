@@ -0,0 +1,6 @@
+-- Rollback du gel administratif des comptes
+
+ALTER TABLE accounts
+    DROP FOREIGN KEY fk_accounts_frozen_by,
+    DROP COLUMN freeze_reason,
+    DROP COLUMN frozen_by;
@@ -0,0 +1,6 @@
+-- Gel administratif des comptes : auteur et motif du gel
+
+ALTER TABLE accounts
+    ADD COLUMN frozen_by BIGINT UNSIGNED NULL AFTER status,
+    ADD COLUMN freeze_reason VARCHAR(255) NULL AFTER frozen_by,
+    ADD CONSTRAINT fk_accounts_frozen_by FOREIGN KEY (frozen_by) REFERENCES users(id);
@@ -0,0 +1,5 @@
+-- Rollback des index de pagination
+
+DROP INDEX idx_accounts_user_created ON accounts;
+DROP INDEX idx_notifications_user_created ON notifications;
+DROP INDEX idx_transactions_account_created ON transactions;
@@ -0,0 +1,5 @@
+-- Index de pagination par curseur (created_at, id) des listes de transactions et de notifications
+
+CREATE INDEX idx_transactions_account_created ON transactions (account_id, created_at, id);
+CREATE INDEX idx_notifications_user_created ON notifications (user_id, created_at, id);
+CREATE INDEX idx_accounts_user_created ON accounts (user_id, created_at, id);
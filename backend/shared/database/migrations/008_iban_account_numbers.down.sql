@@ -0,0 +1,5 @@
+-- Rollback : les IBAN de 27 caractères ne tiennent plus dans l'ancienne colonne,
+-- le rollback échoue tant que des IBAN sont attribués
+
+ALTER TABLE accounts
+    MODIFY COLUMN account_number VARCHAR(20) NOT NULL;
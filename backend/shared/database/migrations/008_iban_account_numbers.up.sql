@@ -0,0 +1,5 @@
+-- Numéros de compte au format IBAN (jusqu'à 34 caractères). Les anciens numéros
+-- (ACC/SAV/CRD + hexadécimal) sont remplacés au démarrage par des IBAN générés.
+
+ALTER TABLE accounts
+    MODIFY COLUMN account_number VARCHAR(34) NOT NULL;
@@ -0,0 +1,8 @@
+-- Rollback des virements entre devises
+
+ALTER TABLE transactions
+    DROP COLUMN fx_quote_id,
+    DROP COLUMN exchange_spread_bps,
+    DROP COLUMN exchange_rate;
+
+DROP TABLE IF EXISTS fx_quotes;
@@ -0,0 +1,25 @@
+-- Virements entre devises : cotations de change et change appliqué aux transactions
+
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    source_amount_minor BIGINT NOT NULL,
+    mid_amount_minor BIGINT NOT NULL,
+    target_amount_minor BIGINT NOT NULL,
+    rate VARCHAR(32) NOT NULL,
+    spread_bps INT NOT NULL,
+    rate_date DATETIME(3) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    used_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_fx_quotes_user_id (user_id)
+);
+
+ALTER TABLE transactions
+    ADD COLUMN exchange_rate VARCHAR(32) NULL AFTER journal_entry_id,
+    ADD COLUMN exchange_spread_bps INT NULL AFTER exchange_rate,
+    ADD COLUMN fx_quote_id VARCHAR(64) NULL AFTER exchange_spread_bps;
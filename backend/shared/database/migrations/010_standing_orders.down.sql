@@ -0,0 +1,4 @@
+-- Rollback des virements programmés
+
+DROP TABLE IF EXISTS standing_order_runs;
+DROP TABLE IF EXISTS standing_orders;
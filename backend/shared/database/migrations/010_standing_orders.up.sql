@@ -0,0 +1,41 @@
+-- Virements programmés (ordres permanents) et trace de leurs exécutions
+
+CREATE TABLE IF NOT EXISTS standing_orders (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    from_account_id BIGINT UNSIGNED NOT NULL,
+    to_account_id BIGINT UNSIGNED NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    description VARCHAR(255),
+    frequency VARCHAR(20) NOT NULL,
+    start_date DATETIME(3) NOT NULL,
+    end_date DATETIME(3) NULL,
+    max_runs INT NULL,
+    run_count INT NOT NULL DEFAULT 0,
+    failure_count INT NOT NULL DEFAULT 0,
+    next_run_at DATETIME(3) NULL,
+    last_run_at DATETIME(3) NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+    FOREIGN KEY (to_account_id) REFERENCES accounts(id),
+    INDEX idx_standing_orders_user_id (user_id),
+    INDEX idx_standing_orders_next_run_at (next_run_at)
+);
+
+CREATE TABLE IF NOT EXISTS standing_order_runs (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    standing_order_id BIGINT UNSIGNED NOT NULL,
+    scheduled_for DATETIME(3) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reference VARCHAR(100),
+    error VARCHAR(255),
+    created_at DATETIME(3) NULL,
+
+    FOREIGN KEY (standing_order_id) REFERENCES standing_orders(id),
+    UNIQUE INDEX idx_standing_order_runs_occurrence (standing_order_id, scheduled_for)
+);
@@ -0,0 +1,7 @@
+-- Rollback des annulations de transactions
+
+ALTER TABLE transactions
+    DROP FOREIGN KEY fk_transactions_reversal_of,
+    DROP INDEX idx_transactions_reversal_of,
+    DROP COLUMN reversed_amount_minor,
+    DROP COLUMN reversal_of;
@@ -0,0 +1,7 @@
+-- Annulations de transactions : lien vers la transaction compensée et montant déjà annulé
+
+ALTER TABLE transactions
+    ADD COLUMN reversal_of BIGINT UNSIGNED NULL AFTER fx_quote_id,
+    ADD COLUMN reversed_amount_minor BIGINT NOT NULL DEFAULT 0 AFTER reversal_of,
+    ADD INDEX idx_transactions_reversal_of (reversal_of),
+    ADD CONSTRAINT fk_transactions_reversal_of FOREIGN KEY (reversal_of) REFERENCES transactions(id);
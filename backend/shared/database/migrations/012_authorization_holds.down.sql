@@ -0,0 +1,6 @@
+-- Rollback des autorisations de paiement
+
+DROP TABLE IF EXISTS holds;
+
+ALTER TABLE accounts
+    DROP COLUMN held_minor;
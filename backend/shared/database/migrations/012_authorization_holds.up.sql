@@ -0,0 +1,27 @@
+-- Autorisations de paiement : réservations de fonds et solde réservé des comptes
+
+ALTER TABLE accounts
+    ADD COLUMN held_minor BIGINT NOT NULL DEFAULT 0 AFTER balance_minor;
+
+CREATE TABLE IF NOT EXISTS holds (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id BIGINT UNSIGNED NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    captured_minor BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL,
+    description VARCHAR(255),
+    reference VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'authorized',
+    expires_at DATETIME(3) NOT NULL,
+    transaction_id BIGINT UNSIGNED NULL,
+    captured_at DATETIME(3) NULL,
+    released_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    UNIQUE INDEX idx_holds_reference (reference),
+    INDEX idx_holds_account_id (account_id),
+    INDEX idx_holds_expires_at (expires_at)
+);
@@ -0,0 +1,9 @@
+-- Rollback des découverts et plafonds de crédit
+
+DROP TABLE IF EXISTS account_limit_changes;
+
+ALTER TABLE accounts
+    DROP INDEX idx_accounts_interest_due,
+    DROP COLUMN interest_charged_through,
+    DROP COLUMN overdraft_rate_bps,
+    DROP COLUMN credit_limit_minor;
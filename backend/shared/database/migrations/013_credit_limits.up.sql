@@ -0,0 +1,24 @@
+-- Découverts et plafonds de crédit : plafond par compte, intérêts débiteurs et historique des modifications
+
+ALTER TABLE accounts
+    ADD COLUMN credit_limit_minor BIGINT NOT NULL DEFAULT 0 AFTER held_minor,
+    ADD COLUMN overdraft_rate_bps INT NOT NULL DEFAULT 0 AFTER credit_limit_minor,
+    ADD COLUMN interest_charged_through DATETIME(3) NULL AFTER overdraft_rate_bps,
+    ADD INDEX idx_accounts_interest_due (overdraft_rate_bps, interest_charged_through);
+
+CREATE TABLE IF NOT EXISTS account_limit_changes (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id BIGINT UNSIGNED NOT NULL,
+    changed_by BIGINT UNSIGNED NOT NULL,
+    previous_limit_minor BIGINT NOT NULL,
+    new_limit_minor BIGINT NOT NULL,
+    previous_rate_bps INT NOT NULL,
+    new_rate_bps INT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    created_at DATETIME(3) NULL,
+
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    FOREIGN KEY (changed_by) REFERENCES users(id),
+    INDEX idx_account_limit_changes_account_id (account_id)
+);
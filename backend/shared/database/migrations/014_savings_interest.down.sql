@@ -0,0 +1,10 @@
+-- Rollback de la rémunération des comptes épargne
+
+DROP TABLE IF EXISTS interest_accruals;
+
+ALTER TABLE accounts
+    DROP FOREIGN KEY fk_accounts_interest_product,
+    DROP COLUMN interest_product_id;
+
+DROP TABLE IF EXISTS interest_tiers;
+DROP TABLE IF EXISTS interest_products;
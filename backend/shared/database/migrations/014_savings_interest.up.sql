@@ -0,0 +1,49 @@
+-- Rémunération des comptes épargne : produits à tranches, intérêts courus quotidiens
+
+CREATE TABLE IF NOT EXISTS interest_products (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    day_count VARCHAR(10) NOT NULL,
+    compounding VARCHAR(10) NOT NULL,
+    payout_frequency VARCHAR(10) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+
+    UNIQUE INDEX idx_interest_products_code (code)
+);
+
+CREATE TABLE IF NOT EXISTS interest_tiers (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    product_id BIGINT UNSIGNED NOT NULL,
+    min_balance_minor BIGINT NOT NULL,
+    rate_bps INT NOT NULL,
+
+    FOREIGN KEY (product_id) REFERENCES interest_products(id) ON DELETE CASCADE,
+    INDEX idx_interest_tiers_product_id (product_id)
+);
+
+ALTER TABLE accounts
+    ADD COLUMN interest_product_id BIGINT UNSIGNED NULL AFTER interest_charged_through,
+    ADD CONSTRAINT fk_accounts_interest_product FOREIGN KEY (interest_product_id) REFERENCES interest_products(id);
+
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id BIGINT UNSIGNED NOT NULL,
+    product_id BIGINT UNSIGNED NOT NULL,
+    accrual_date DATETIME(3) NOT NULL,
+    balance_minor BIGINT NOT NULL,
+    base_micros BIGINT NOT NULL,
+    amount_micros BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    transaction_id BIGINT UNSIGNED NULL,
+    created_at DATETIME(3) NULL,
+
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    FOREIGN KEY (product_id) REFERENCES interest_products(id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    UNIQUE INDEX idx_interest_accruals_day (account_id, accrual_date),
+    INDEX idx_interest_accruals_transaction_id (transaction_id)
+);
@@ -0,0 +1,4 @@
+-- Rollback des relevés mensuels
+
+DROP TABLE IF EXISTS statement_lines;
+DROP TABLE IF EXISTS statements;
@@ -0,0 +1,38 @@
+-- Relevés mensuels des comptes, figés à leur établissement
+
+CREATE TABLE IF NOT EXISTS statements (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id BIGINT UNSIGNED NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    period_start DATETIME(3) NOT NULL,
+    period_end DATETIME(3) NOT NULL,
+    account_number VARCHAR(34) NOT NULL,
+    holder_name VARCHAR(255) NOT NULL,
+    opening_balance_minor BIGINT NOT NULL,
+    total_debits_minor BIGINT NOT NULL,
+    total_credits_minor BIGINT NOT NULL,
+    closing_balance_minor BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    created_at DATETIME(3) NULL,
+
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    UNIQUE INDEX idx_statements_period (account_id, period)
+);
+
+CREATE TABLE IF NOT EXISTS statement_lines (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    statement_id BIGINT UNSIGNED NOT NULL,
+    position INT NOT NULL,
+    posting_id BIGINT UNSIGNED NOT NULL,
+    transaction_id BIGINT UNSIGNED NULL,
+    date DATETIME(3) NOT NULL,
+    reference VARCHAR(100),
+    description TEXT,
+    amount_minor BIGINT NOT NULL,
+    balance_minor BIGINT NOT NULL,
+
+    FOREIGN KEY (statement_id) REFERENCES statements(id),
+    FOREIGN KEY (posting_id) REFERENCES postings(id),
+    INDEX idx_statement_lines_statement_id (statement_id)
+);
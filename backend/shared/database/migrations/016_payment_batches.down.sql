@@ -0,0 +1,4 @@
+-- Rollback des lots de virements
+
+DROP TABLE IF EXISTS payment_batch_items;
+DROP TABLE IF EXISTS payment_batches;
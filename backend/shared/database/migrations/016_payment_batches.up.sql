@@ -0,0 +1,43 @@
+-- Lots de virements remis par fichier (pain.001 ou CSV) et statut de chaque virement
+
+CREATE TABLE IF NOT EXISTS payment_batches (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    message_id VARCHAR(35) NOT NULL,
+    message_name VARCHAR(32) NOT NULL,
+    mode VARCHAR(16) NOT NULL,
+    status VARCHAR(4) NOT NULL,
+    nb_of_txs INT NOT NULL,
+    control_sum VARCHAR(32),
+    accepted INT NOT NULL,
+    rejected INT NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE INDEX idx_payment_batches_message (user_id, message_id)
+);
+
+CREATE TABLE IF NOT EXISTS payment_batch_items (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    batch_id BIGINT UNSIGNED NOT NULL,
+    position INT NOT NULL,
+    payment_info_id VARCHAR(35),
+    instruction_id VARCHAR(35),
+    end_to_end_id VARCHAR(35),
+    debtor_iban VARCHAR(34),
+    creditor_iban VARCHAR(34),
+    creditor_name VARCHAR(140),
+    amount_minor BIGINT NOT NULL,
+    currency VARCHAR(3),
+    description TEXT,
+    status VARCHAR(4) NOT NULL,
+    reason_code VARCHAR(4),
+    reason_info VARCHAR(255),
+    reference VARCHAR(100),
+    debit_transaction_id BIGINT UNSIGNED NULL,
+
+    FOREIGN KEY (batch_id) REFERENCES payment_batches(id),
+    FOREIGN KEY (debit_transaction_id) REFERENCES transactions(id),
+    INDEX idx_payment_batch_items_batch_id (batch_id)
+);
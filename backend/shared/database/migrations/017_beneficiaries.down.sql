@@ -0,0 +1,3 @@
+-- Rollback des bénéficiaires
+
+DROP TABLE IF EXISTS beneficiaries;
@@ -0,0 +1,17 @@
+-- Bénéficiaires enregistrés par les clients, avec la fin de leur délai de carence
+
+CREATE TABLE IF NOT EXISTS beneficiaries (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    nickname VARCHAR(50) NOT NULL,
+    iban VARCHAR(34) NOT NULL,
+    account_id BIGINT UNSIGNED NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    trusted_at DATETIME(3) NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    UNIQUE INDEX idx_beneficiaries_user_iban (user_id, iban)
+);
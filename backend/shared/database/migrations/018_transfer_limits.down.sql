@@ -0,0 +1,4 @@
+-- Rollback des plafonds de sorties de fonds
+
+DROP TABLE IF EXISTS transfer_limit_usages;
+DROP TABLE IF EXISTS transfer_limits;
@@ -0,0 +1,39 @@
+-- Plafonds des sorties de fonds (par type de compte et devise, propres à un client ou
+-- abaissés par lui) et consommation de ces plafonds par compte
+
+CREATE TABLE IF NOT EXISTS transfer_limits (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NULL,
+    account_type VARCHAR(20) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    source VARCHAR(10) NOT NULL,
+    per_transaction_minor BIGINT NULL,
+    daily_minor BIGINT NULL,
+    monthly_minor BIGINT NULL,
+    hourly_count INT NULL,
+    updated_by BIGINT UNSIGNED NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE INDEX idx_transfer_limits_scope (user_id, account_type, currency, source)
+);
+
+CREATE TABLE IF NOT EXISTS transfer_limit_usages (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    account_id BIGINT UNSIGNED NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reference VARCHAR(100),
+    created_at DATETIME(3) NULL,
+
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    INDEX idx_transfer_limit_usages_account (account_id, created_at)
+);
+
+-- Plafonds par défaut des comptes en euros ; les autres devises ne sont pas plafonnées
+-- tant que l'administration n'en a pas fixé
+INSERT INTO transfer_limits (account_type, currency, source, per_transaction_minor, daily_minor, monthly_minor, hourly_count, created_at, updated_at) VALUES
+    ('checking', 'EUR', 'bank', 500000, 1000000, 3000000, 20, NOW(3), NOW(3)),
+    ('savings', 'EUR', 'bank', 500000, 500000, 2000000, 5, NOW(3), NOW(3)),
+    ('credit', 'EUR', 'bank', 200000, 300000, 1000000, 10, NOW(3), NOW(3));
@@ -0,0 +1,4 @@
+-- Rollback des décisions anti-fraude
+
+DROP TABLE IF EXISTS fraud_rule_hits;
+DROP TABLE IF EXISTS fraud_decisions;
@@ -0,0 +1,42 @@
+-- Décisions du contrôle anti-fraude des virements, règles déclenchées et file de vérification
+
+CREATE TABLE IF NOT EXISTS fraud_decisions (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    from_account_id BIGINT UNSIGNED NOT NULL,
+    to_account_id BIGINT UNSIGNED NOT NULL,
+    beneficiary_id BIGINT UNSIGNED NULL,
+    amount_minor BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    reference VARCHAR(100) NOT NULL,
+    score INT NOT NULL,
+    outcome VARCHAR(10) NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    reviewed_by BIGINT UNSIGNED NULL,
+    reviewed_at DATETIME(3) NULL,
+    review_note VARCHAR(255),
+    transaction_id BIGINT UNSIGNED NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+    FOREIGN KEY (to_account_id) REFERENCES accounts(id),
+    FOREIGN KEY (reviewed_by) REFERENCES users(id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    UNIQUE INDEX idx_fraud_decisions_reference (reference),
+    INDEX idx_fraud_decisions_user_id (user_id),
+    INDEX idx_fraud_decisions_status (status, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS fraud_rule_hits (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    decision_id BIGINT UNSIGNED NOT NULL,
+    rule VARCHAR(50) NOT NULL,
+    score INT NOT NULL,
+    detail VARCHAR(255),
+
+    FOREIGN KEY (decision_id) REFERENCES fraud_decisions(id),
+    INDEX idx_fraud_rule_hits_decision_id (decision_id)
+);
@@ -0,0 +1,3 @@
+-- Rollback de la file d'envoi des emails confidentiels
+
+DROP TABLE IF EXISTS outbound_emails;
@@ -0,0 +1,16 @@
+-- File d'envoi des emails confidentiels (liens de vérification et de réinitialisation),
+-- séparée des notifications consultables par l'utilisateur
+
+CREATE TABLE IF NOT EXISTS outbound_emails (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',
+    sent_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_outbound_emails_user_id (user_id)
+);
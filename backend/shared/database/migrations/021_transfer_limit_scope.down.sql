@@ -0,0 +1,3 @@
+-- Rollback de l'unicité des plafonds par portée
+
+ALTER TABLE transfer_limits DROP INDEX idx_transfer_limits_owner, DROP COLUMN scope_user_id;
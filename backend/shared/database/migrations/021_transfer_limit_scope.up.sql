@@ -0,0 +1,17 @@
+-- Un seul plafond par portée : user_id vaut NULL pour les plafonds par défaut de la banque,
+-- que l'index idx_transfer_limits_scope ne départage donc pas. scope_user_id (0 pour les
+-- plafonds par défaut) porte l'unicité.
+
+ALTER TABLE transfer_limits ADD COLUMN scope_user_id BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER user_id;
+
+UPDATE transfer_limits SET scope_user_id = user_id WHERE user_id IS NOT NULL;
+
+-- Des plafonds par défaut en double ne gardent que le plus récent
+DELETE older FROM transfer_limits older
+JOIN transfer_limits newer ON newer.scope_user_id = older.scope_user_id
+    AND newer.account_type = older.account_type
+    AND newer.currency = older.currency
+    AND newer.source = older.source
+    AND newer.id > older.id;
+
+ALTER TABLE transfer_limits ADD UNIQUE INDEX idx_transfer_limits_owner (scope_user_id, account_type, currency, source);
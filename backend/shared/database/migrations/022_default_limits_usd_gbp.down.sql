@@ -0,0 +1,3 @@
+-- Rollback des plafonds par défaut des comptes en dollars et en livres
+
+DELETE FROM transfer_limits WHERE user_id IS NULL AND source = 'bank' AND currency IN ('USD', 'GBP');
@@ -0,0 +1,12 @@
+-- Plafonds par défaut des comptes en dollars et en livres, aux montants (en unités mineures)
+-- de ceux des comptes en euros. Une sortie de fonds sans plafond de la banque est désormais
+-- refusée ; les autres devises doivent recevoir des plafonds de l'administration. Un plafond
+-- par défaut déjà fixé est conservé (index idx_transfer_limits_owner).
+
+INSERT IGNORE INTO transfer_limits (account_type, currency, source, per_transaction_minor, daily_minor, monthly_minor, hourly_count, created_at, updated_at) VALUES
+    ('checking', 'USD', 'bank', 500000, 1000000, 3000000, 20, NOW(3), NOW(3)),
+    ('savings', 'USD', 'bank', 500000, 500000, 2000000, 5, NOW(3), NOW(3)),
+    ('credit', 'USD', 'bank', 200000, 300000, 1000000, 10, NOW(3), NOW(3)),
+    ('checking', 'GBP', 'bank', 500000, 1000000, 3000000, 20, NOW(3), NOW(3)),
+    ('savings', 'GBP', 'bank', 500000, 500000, 2000000, 5, NOW(3), NOW(3)),
+    ('credit', 'GBP', 'bank', 200000, 300000, 1000000, 10, NOW(3), NOW(3));
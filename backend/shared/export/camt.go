@@ -0,0 +1,170 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// camtNamespace est l'espace de noms du relevé camt.053 produit
+const camtNamespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+type camtDocument struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Statement struct {
+		Header struct {
+			MessageID string `xml:"MsgId"`
+			CreatedAt string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Statement camtStatement `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	ID        string `xml:"Id"`
+	CreatedAt string `xml:"CreDtTm"`
+	Period    struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	} `xml:"FrToDt"`
+	Account struct {
+		IBAN     string `xml:"Id>IBAN"`
+		Currency string `xml:"Ccy"`
+		Owner    string `xml:"Ownr>Nm,omitempty"`
+	} `xml:"Acct"`
+	Balances []camtBalance `xml:"Bal"`
+	Summary  struct {
+		Credits camtTotal `xml:"TtlCdtNtries"`
+		Debits  camtTotal `xml:"TtlDbtNtries"`
+	} `xml:"TxsSummry"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Code   string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount camtAmount `xml:"Amt"`
+	Sign   string     `xml:"CdtDbtInd"`
+	Date   string     `xml:"Dt>Dt"`
+}
+
+type camtTotal struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	Reference   string     `xml:"NtryRef"`
+	Amount      camtAmount `xml:"Amt"`
+	Sign        string     `xml:"CdtDbtInd"`
+	Reversal    bool       `xml:"RvslInd,omitempty"`
+	Status      string     `xml:"Sts"`
+	BookingDate string     `xml:"BookgDt>Dt"`
+	ValueDate   string     `xml:"ValDt>Dt"`
+	ServicerRef string     `xml:"AcctSvcrRef,omitempty"`
+	Code        string     `xml:"BkTxCd>Prtry>Cd"`
+	Details     struct {
+		EndToEndID   string `xml:"Refs>EndToEndId"`
+		Unstructured string `xml:"RmtInf>Ustrd,omitempty"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+// WriteCAMT053 écrit le document au format ISO 20022 camt.053.001.02 : soldes d'ouverture
+// (OPBD) et de clôture (CLBD), totaux des crédits et des débits, puis une entrée comptabilisée
+// (BOOK) par opération avec ses dates de comptabilisation et de valeur
+func WriteCAMT053(w io.Writer, document *Document) error {
+	camt := camtDocument{Namespace: camtNamespace}
+	camt.Statement.Header.MessageID = messageID(document)
+	camt.Statement.Header.CreatedAt = camtTime(document.GeneratedAt)
+
+	statement := &camt.Statement.Statement
+	statement.ID = messageID(document)
+	statement.CreatedAt = camtTime(document.GeneratedAt)
+	statement.Period.From = camtTime(document.From)
+	statement.Period.To = camtTime(document.To)
+	statement.Account.IBAN = document.AccountNumber
+	statement.Account.Currency = document.Currency
+	statement.Account.Owner = truncate(document.HolderName, 70)
+	statement.Balances = []camtBalance{
+		camtBalanceOf("OPBD", document.Opening, document.Currency, document.From),
+		camtBalanceOf("CLBD", document.Closing, document.Currency, document.LastDay()),
+	}
+
+	var credits, debits int64
+	for _, entry := range document.Entries {
+		if entry.Amount < 0 {
+			statement.Summary.Debits.Count++
+			debits -= entry.Amount
+		} else {
+			statement.Summary.Credits.Count++
+			credits += entry.Amount
+		}
+
+		ntry := camtEntry{
+			Reference:   entry.ID,
+			Amount:      camtAmount{Currency: document.Currency, Value: decimal(entry.Amount, document.Currency)},
+			Sign:        creditDebit(entry.Amount),
+			Reversal:    entry.Type == "reversal",
+			Status:      "BOOK",
+			BookingDate: entry.BookingDate.Format(dateLayout),
+			ValueDate:   entry.ValueDate.Format(dateLayout),
+			ServicerRef: truncate(entry.Reference, 35),
+			Code:        camtTransactionCode(entry),
+		}
+		ntry.Details.EndToEndID = "NOTPROVIDED"
+		if entry.Reference != "" {
+			ntry.Details.EndToEndID = truncate(entry.Reference, 35)
+		}
+		ntry.Details.Unstructured = truncate(oneLine(entry.Description), 140)
+		statement.Entries = append(statement.Entries, ntry)
+	}
+	statement.Summary.Credits.Sum = decimal(credits, document.Currency)
+	statement.Summary.Debits.Sum = decimal(debits, document.Currency)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(camt); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func camtBalanceOf(code string, amount int64, currency string, date time.Time) camtBalance {
+	return camtBalance{
+		Code:   code,
+		Amount: camtAmount{Currency: currency, Value: decimal(amount, currency)},
+		Sign:   creditDebit(amount),
+		Date:   date.Format(dateLayout),
+	}
+}
+
+// creditDebit retourne l'indicateur de sens ISO 20022 d'un montant signé
+func creditDebit(amount int64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// camtTransactionCode retourne le code propriétaire de l'opération : son type de
+// transaction, ou LEDGER pour une écriture sans transaction
+func camtTransactionCode(entry Entry) string {
+	if entry.Type == "" {
+		return "LEDGER"
+	}
+	return entry.Type
+}
+
+// camtTime formate un instant au format ISO 8601 en UTC
+func camtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
@@ -0,0 +1,211 @@
+// Package export restitue l'historique d'un compte dans les formats importés par les
+// logiciels comptables : OFX 2.x, QIF et ISO 20022 camt.053. Les montants sont signés
+// (débit négatif) et datés en valeur d'après le grand livre ; la référence, le libellé et
+// la date de comptabilisation sont ceux des transactions du compte.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+)
+
+// Formats d'export
+const (
+	FormatOFX     = "ofx"
+	FormatQIF     = "qif"
+	FormatCAMT053 = "camt053"
+)
+
+// MaxDays borne la plage de dates d'un export
+const MaxDays = 366
+
+// dateLayout est le format des dates sans heure
+const dateLayout = "2006-01-02"
+
+// Erreurs de construction d'un export
+var (
+	ErrInvalidFormat = errors.New("format invalide, formats valides : ofx, qif, camt053")
+	ErrInvalidRange  = errors.New("plage de dates invalide")
+)
+
+// Entry est une opération exportée
+type Entry struct {
+	// ID identifie l'opération de façon stable d'un export à l'autre : l'identifiant de la
+	// transaction, ou celui de la ligne d'écriture (préfixé de P) pour une écriture sans
+	// transaction comme un solde d'ouverture
+	ID          string
+	Type        string // type de la transaction (debit, credit, transfer, reversal), vide à défaut
+	Reference   string
+	Description string
+	Amount      int64 // unités mineures, négatif pour un débit
+	BookingDate time.Time
+	ValueDate   time.Time
+}
+
+// Document est l'historique d'un compte sur une plage de dates [From, To)
+type Document struct {
+	AccountID     uint
+	AccountNumber string
+	AccountType   string
+	HolderName    string
+	Currency      string
+	From          time.Time
+	To            time.Time // exclu
+	Opening       int64
+	Closing       int64
+	Entries       []Entry
+	// GeneratedAt est la date de production du fichier, seule donnée qui varie entre deux
+	// exports d'une même plage
+	GeneratedAt time.Time
+}
+
+// LastDay retourne le dernier jour inclus dans l'export
+func (d *Document) LastDay() time.Time {
+	return d.To.AddDate(0, 0, -1)
+}
+
+// ParseRange convertit les bornes from et to (AAAA-MM-JJ, UTC, to incluse) en plage [start, end)
+func ParseRange(from, to string) (start, end time.Time, err error) {
+	if start, err = time.Parse(dateLayout, from); err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidRange
+	}
+	if end, err = time.Parse(dateLayout, to); err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidRange
+	}
+	end = end.AddDate(0, 0, 1)
+	if !start.Before(end) || end.Sub(start) > MaxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, ErrInvalidRange
+	}
+	return start, end, nil
+}
+
+// Build reconstitue l'historique du compte sur [from, to) à partir de ses lignes d'écriture,
+// dans l'ordre chronologique des dates de valeur
+func Build(store *repository.Store, account *models.Account, holder string, from, to, now time.Time) (*Document, error) {
+	opening, err := ledger.BalanceAt(store.DB, account.ID, account.Currency, from)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := ledger.Movements(store.DB, account.ID, account.Currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	for _, movement := range movements {
+		if movement.TransactionID != nil {
+			ids = append(ids, *movement.TransactionID)
+		}
+	}
+	transactions, err := store.Transactions.ListByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Transaction, len(transactions))
+	for _, transaction := range transactions {
+		byID[transaction.ID] = transaction
+	}
+
+	document := &Document{
+		AccountID:     account.ID,
+		AccountNumber: account.AccountNumber,
+		AccountType:   account.AccountType,
+		HolderName:    holder,
+		Currency:      account.Currency,
+		From:          from,
+		To:            to,
+		Opening:       opening,
+		Closing:       opening,
+		GeneratedAt:   now.UTC(),
+	}
+	for _, movement := range movements {
+		entry := Entry{
+			ID:          "P" + strconv.FormatUint(uint64(movement.PostingID), 10),
+			Reference:   movement.Reference,
+			Description: movement.Description,
+			Amount:      movement.Amount,
+			BookingDate: movement.Date.UTC(),
+			ValueDate:   movement.Date.UTC(),
+		}
+		if movement.TransactionID != nil {
+			if transaction, ok := byID[*movement.TransactionID]; ok {
+				entry.ID = strconv.FormatUint(uint64(transaction.ID), 10)
+				entry.Type = transaction.Type
+				entry.BookingDate = transaction.CreatedAt.UTC()
+				if transaction.ProcessedAt != nil {
+					entry.BookingDate = transaction.ProcessedAt.UTC()
+				}
+			}
+		}
+		document.Closing += movement.Amount
+		document.Entries = append(document.Entries, entry)
+	}
+	return document, nil
+}
+
+// Write écrit le document au format demandé
+func Write(w io.Writer, format string, document *Document) error {
+	switch format {
+	case FormatOFX:
+		return WriteOFX(w, document)
+	case FormatQIF:
+		return WriteQIF(w, document)
+	case FormatCAMT053:
+		return WriteCAMT053(w, document)
+	}
+	return ErrInvalidFormat
+}
+
+// ContentType retourne le type MIME d'un format d'export
+func ContentType(format string) string {
+	switch format {
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatCAMT053:
+		return "application/xml"
+	}
+	return "application/qif"
+}
+
+// FileName retourne le nom de fichier de l'export
+func FileName(document *Document, format string) string {
+	ext := format
+	if format == FormatCAMT053 {
+		ext = "xml"
+	}
+	return fmt.Sprintf("operations-%s-%s-%s.%s", document.AccountNumber,
+		document.From.Format("20060102"), document.LastDay().Format("20060102"), ext)
+}
+
+// decimal formate un montant en unités mineures avec le point décimal, sans signe
+func decimal(amount int64, currency string) string {
+	value := models.NewMoney(amount, currency).Decimal()
+	return strings.TrimPrefix(value, "-")
+}
+
+// signedDecimal formate un montant en unités mineures avec le point décimal et son signe
+func signedDecimal(amount int64, currency string) string {
+	return models.NewMoney(amount, currency).Decimal()
+}
+
+// oneLine remplace les retours à la ligne d'un libellé, qu'aucun format n'admet
+func oneLine(value string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+}
+
+// truncate coupe value à width caractères
+func truncate(value string, width int) string {
+	runes := []rune(value)
+	if len(runes) <= width {
+		return value
+	}
+	return string(runes[:width])
+}
@@ -0,0 +1,147 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/testutil"
+)
+
+// update régénère les fichiers de référence : go test ./shared/export -update
+var update = flag.Bool("update", false, "régénère les fichiers de référence de testdata")
+
+func date(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+// sampleDocument couvre un débit, un crédit dont la date de valeur précède la
+// comptabilisation, un virement, une annulation et une écriture sans transaction
+func sampleDocument() *Document {
+	return &Document{
+		AccountID:     42,
+		AccountNumber: "FR7699999000010000000004242",
+		AccountType:   "checking",
+		HolderName:    "Zoé Martin & Fils",
+		Currency:      "EUR",
+		From:          date(2024, time.January, 1, 0),
+		To:            date(2024, time.February, 1, 0),
+		Opening:       150000,
+		Closing:       167575,
+		GeneratedAt:   date(2024, time.February, 2, 8),
+		Entries: []Entry{
+			{ID: "101", Type: "debit", Reference: "TXN-101", Description: "Carte <Boulangerie> du marché", Amount: -1250, BookingDate: date(2024, time.January, 3, 9), ValueDate: date(2024, time.January, 3, 9)},
+			{ID: "102", Type: "credit", Reference: "INTPAY-42-20231231", Description: "Intérêts créditeurs au 31/12/2023", Amount: 325, BookingDate: date(2024, time.January, 5, 2), ValueDate: date(2024, time.January, 1, 0)},
+			{ID: "103", Type: "transfer", Reference: "VIR-7-IN", Description: "Loyer janvier", Amount: 20000, BookingDate: date(2024, time.January, 10, 14), ValueDate: date(2024, time.January, 10, 14)},
+			{ID: "104", Type: "reversal", Reference: "REV-101", Description: "Remboursement partiel", Amount: 500, BookingDate: date(2024, time.January, 12, 11), ValueDate: date(2024, time.January, 12, 11)},
+			{ID: "P905", Reference: "ADJ-1", Description: "Frais de tenue de compte\ntrimestriels", Amount: -2000, BookingDate: date(2024, time.January, 31, 23), ValueDate: date(2024, time.January, 31, 23)},
+		},
+	}
+}
+
+func TestWriteGolden(t *testing.T) {
+	for _, format := range []string{FormatOFX, FormatQIF, FormatCAMT053} {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Write(&out, format, sampleDocument()); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "sample."+format+".golden")
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("export %s différent de %s:\n%s", format, golden, out.String())
+			}
+		})
+	}
+
+	if err := Write(&bytes.Buffer{}, "csv", sampleDocument()); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("format inconnu: %v, attendu ErrInvalidFormat", err)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	start, end, err := ParseRange("2024-01-01", "2024-01-31")
+	if err != nil || !start.Equal(date(2024, time.January, 1, 0)) || !end.Equal(date(2024, time.February, 1, 0)) {
+		t.Errorf("ParseRange = %s, %s (%v)", start, end, err)
+	}
+	for _, bounds := range [][2]string{{"2024-02-01", "2024-01-31"}, {"2024-01-01", "2025-06-01"}, {"01/01/2024", "2024-01-31"}} {
+		if _, _, err := ParseRange(bounds[0], bounds[1]); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("ParseRange(%s, %s) = %v, attendu ErrInvalidRange", bounds[0], bounds[1], err)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	store := testutil.NewStore(t)
+	user, _ := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 10000)
+	opened := date(2023, time.December, 20, 10)
+	store.DB.Model(&models.Account{}).Where("id = ?", account.ID).Update("created_at", opened)
+	store.DB.Model(&models.Posting{}).Where("account_id = ?", account.ID).Update("created_at", opened)
+
+	// Un débit de 30,00 comptabilisé le 5 janvier
+	amount := models.NewMoney(3000, "EUR")
+	debit := ledger.CustomerPosting(account.ID, amount.Neg())
+	external := ledger.SystemPosting(ledger.ExternalAccount, amount)
+	valueDate := date(2024, time.January, 5, 12)
+	debit.CreatedAt, external.CreatedAt = valueDate, valueDate
+	entry := models.JournalEntry{Reference: "ENTRY-1", Description: "Retrait", Postings: []models.Posting{debit, external}}
+	if err := ledger.Post(store.DB, &entry); err != nil {
+		t.Fatal(err)
+	}
+	processed := date(2024, time.January, 6, 8)
+	transaction := models.Transaction{
+		AccountID:      account.ID,
+		Type:           "debit",
+		Amount:         amount,
+		Currency:       "EUR",
+		Description:    "Retrait DAB",
+		Reference:      "TXN-RETRAIT",
+		Status:         "completed",
+		ProcessedAt:    &processed,
+		JournalEntryID: &entry.ID,
+	}
+	if err := store.Transactions.Create(&transaction); err != nil {
+		t.Fatal(err)
+	}
+
+	from, to := date(2024, time.January, 1, 0), date(2024, time.February, 1, 0)
+	document, err := Build(store, &account, "Jean Dupont", from, to, date(2024, time.February, 2, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document.Opening != 10000 || document.Closing != 7000 || len(document.Entries) != 1 {
+		t.Fatalf("document = %+v, attendu 100,00 puis un débit de 30,00", document)
+	}
+	got := document.Entries[0]
+	if got.Amount != -3000 || got.Type != "debit" || got.Reference != "TXN-RETRAIT" || got.Description != "Retrait DAB" {
+		t.Errorf("opération = %+v", got)
+	}
+	if !got.ValueDate.Equal(valueDate) || !got.BookingDate.Equal(processed) {
+		t.Errorf("dates = valeur %s, comptabilisation %s", got.ValueDate, got.BookingDate)
+	}
+
+	// Le solde d'ouverture est une écriture sans transaction, exportée depuis le grand livre
+	december, err := Build(store, &account, "Jean Dupont", date(2023, time.December, 1, 0), from, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(december.Entries) != 1 || december.Entries[0].ID[0] != 'P' || december.Entries[0].Amount != 10000 {
+		t.Errorf("décembre = %+v, attendu l'écriture d'ouverture", december.Entries)
+	}
+}
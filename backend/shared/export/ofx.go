@@ -0,0 +1,164 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ofxHeader est l'en-tête d'un fichier OFX 2.2
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// ofxNameWidth est la longueur maximale de l'élément NAME d'une opération
+const ofxNameWidth = 32
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Status   ofxStatus `xml:"SONRS>STATUS"`
+		Server   string    `xml:"SONRS>DTSERVER"`
+		Language string    `xml:"SONRS>LANGUAGE"`
+	} `xml:"SIGNONMSGSRSV1"`
+	Statement struct {
+		TransactionID string    `xml:"TRNUID"`
+		Status        ofxStatus `xml:"STATUS"`
+		Response      struct {
+			Currency string `xml:"CURDEF"`
+			Account  struct {
+				BankID    string `xml:"BANKID"`
+				AccountID string `xml:"ACCTID"`
+				Type      string `xml:"ACCTTYPE"`
+			} `xml:"BANKACCTFROM"`
+			List struct {
+				Start        string           `xml:"DTSTART"`
+				End          string           `xml:"DTEND"`
+				Transactions []ofxTransaction `xml:"STMTTRN"`
+			} `xml:"BANKTRANLIST"`
+			LedgerBalance ofxBalance `xml:"LEDGERBAL"`
+		} `xml:"STMTRS"`
+	} `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type      string `xml:"TRNTYPE"`
+	Posted    string `xml:"DTPOSTED"`
+	User      string `xml:"DTUSER"`
+	Available string `xml:"DTAVAIL"`
+	Amount    string `xml:"TRNAMT"`
+	FITID     string `xml:"FITID"`
+	RefNum    string `xml:"REFNUM,omitempty"`
+	Name      string `xml:"NAME"`
+	Memo      string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+// WriteOFX écrit le document au format OFX 2.2 (relevé bancaire STMTRS). DTPOSTED et
+// DTAVAIL portent la date de valeur, DTUSER la date de comptabilisation ; FITID est
+// stable d'un export à l'autre, ce qui permet au logiciel d'écarter les doublons.
+func WriteOFX(w io.Writer, document *Document) error {
+	ok := ofxStatus{Code: 0, Severity: "INFO"}
+
+	var ofx ofxDocument
+	ofx.SignOn.Status = ok
+	ofx.SignOn.Server = ofxTime(document.GeneratedAt)
+	ofx.SignOn.Language = "FRA"
+	ofx.Statement.TransactionID = messageID(document)
+	ofx.Statement.Status = ok
+
+	response := &ofx.Statement.Response
+	response.Currency = document.Currency
+	response.Account.BankID = bankID(document.AccountNumber)
+	response.Account.AccountID = document.AccountNumber
+	response.Account.Type = ofxAccountType(document.AccountType)
+	response.List.Start = ofxTime(document.From)
+	response.List.End = ofxTime(document.To)
+	for _, entry := range document.Entries {
+		description := oneLine(entry.Description)
+		name := description
+		if name == "" {
+			name = entry.Reference
+		}
+		transaction := ofxTransaction{
+			Type:      ofxTransactionType(entry),
+			Posted:    ofxTime(entry.ValueDate),
+			User:      ofxTime(entry.BookingDate),
+			Available: ofxTime(entry.ValueDate),
+			Amount:    signedDecimal(entry.Amount, document.Currency),
+			FITID:     entry.ID,
+			RefNum:    entry.Reference,
+			Name:      truncate(name, ofxNameWidth),
+		}
+		if transaction.Name != description {
+			transaction.Memo = description
+		}
+		response.List.Transactions = append(response.List.Transactions, transaction)
+	}
+	response.LedgerBalance = ofxBalance{
+		Amount: signedDecimal(document.Closing, document.Currency),
+		AsOf:   ofxTime(document.To),
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(ofx); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ofxTime formate un instant au format OFX, en UTC
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+// ofxAccountType convertit le type de compte en ACCTTYPE
+func ofxAccountType(accountType string) string {
+	switch accountType {
+	case "savings":
+		return "SAVINGS"
+	case "credit":
+		return "CREDITLINE"
+	}
+	return "CHECKING"
+}
+
+// ofxTransactionType convertit une opération en TRNTYPE
+func ofxTransactionType(entry Entry) string {
+	switch {
+	case entry.Type == "transfer":
+		return "XFER"
+	case entry.Amount < 0:
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// bankID retourne le code établissement d'un IBAN : les 5 premiers caractères du BBAN
+func bankID(iban string) string {
+	if len(iban) < 9 {
+		return iban
+	}
+	return iban[4:9]
+}
+
+// messageID identifie l'export de façon déterministe (35 caractères au plus)
+func messageID(document *Document) string {
+	return fmt.Sprintf("EXP-%d-%s-%s", document.AccountID,
+		document.From.Format("20060102"), document.LastDay().Format("20060102"))
+}
@@ -0,0 +1,32 @@
+package export
+
+import (
+	"bufio"
+	"io"
+)
+
+// qifDateLayout est le format des dates QIF, jour en tête comme l'attendent les logiciels
+// configurés en français
+const qifDateLayout = "02/01/2006"
+
+// WriteQIF écrit le document au format QIF (section !Type:Bank, CCard pour un compte de
+// crédit). Le format ne connaît qu'une date par opération : c'est la date de valeur. Le
+// numéro (N) porte la référence.
+func WriteQIF(w io.Writer, document *Document) error {
+	out := bufio.NewWriter(w)
+	qifType := "Bank"
+	if document.AccountType == "credit" {
+		qifType = "CCard"
+	}
+	out.WriteString("!Type:" + qifType + "\n")
+	for _, entry := range document.Entries {
+		out.WriteString("D" + entry.ValueDate.Format(qifDateLayout) + "\n")
+		out.WriteString("T" + signedDecimal(entry.Amount, document.Currency) + "\n")
+		if entry.Reference != "" {
+			out.WriteString("N" + oneLine(entry.Reference) + "\n")
+		}
+		out.WriteString("P" + oneLine(entry.Description) + "\n")
+		out.WriteString("^\n")
+	}
+	return out.Flush()
+}
@@ -0,0 +1,124 @@
+// Package fraud contrôle les virements avant leur exécution. Chaque règle déclenchée
+// attribue un score au virement ; selon le score total, le virement est exécuté, mis en
+// attente de vérification ou refusé. Les règles sont interchangeables : un moteur est
+// construit à partir de n'importe quelle liste de Rule.
+package fraud
+
+import (
+	"sort"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+)
+
+// maxHistory borne le nombre de virements passés examinés par les règles
+const maxHistory = 500
+
+// Payment décrit un virement soumis au contrôle
+type Payment struct {
+	UserID      uint
+	FromAccount *models.Account
+	ToAccount   *models.Account
+	// Amount est exprimé dans la devise du compte source
+	Amount models.Money
+	// Beneficiary est le bénéficiaire désigné ; nil pour un virement sans bénéficiaire
+	Beneficiary *models.Beneficiary
+	At          time.Time
+	// History regroupe les virements émis par le client sur la période d'observation du
+	// moteur, le plus récent d'abord
+	History []models.Transaction
+}
+
+// Rule est une règle du contrôle anti-fraude
+type Rule interface {
+	// Name identifie la règle dans les décisions enregistrées
+	Name() string
+	// Evaluate retourne le score attribué au virement et son motif, ou 0 si la règle
+	// n'est pas déclenchée
+	Evaluate(p Payment) (int, string)
+}
+
+// Engine évalue les règles d'un virement et en déduit l'issue du contrôle
+type Engine struct {
+	Rules []Rule
+	// ReviewScore et BlockScore sont les scores totaux à partir desquels un virement est
+	// mis en attente de vérification ou refusé
+	ReviewScore int
+	BlockScore  int
+	// Lookback est la période d'observation des virements passés du client
+	Lookback time.Duration
+}
+
+// Result est l'issue du contrôle d'un virement
+type Result struct {
+	Outcome string
+	Score   int
+	Hits    []models.FraudRuleHit
+}
+
+// Screen charge l'historique du client puis évalue le virement
+func (e *Engine) Screen(store *repository.Store, p Payment) (Result, error) {
+	history, err := store.Transactions.ListOutgoingTransfers(p.UserID, p.At.Add(-e.Lookback), maxHistory)
+	if err != nil {
+		return Result{}, err
+	}
+	p.History = history
+	return e.Evaluate(p), nil
+}
+
+// Evaluate applique les règles au virement
+func (e *Engine) Evaluate(p Payment) Result {
+	result := Result{Outcome: models.FraudOutcomeAllow, Hits: []models.FraudRuleHit{}}
+	for _, rule := range e.Rules {
+		score, detail := rule.Evaluate(p)
+		if score <= 0 {
+			continue
+		}
+		result.Score += score
+		result.Hits = append(result.Hits, models.FraudRuleHit{Rule: rule.Name(), Score: score, Detail: detail})
+	}
+
+	switch {
+	case result.Score >= e.BlockScore:
+		result.Outcome = models.FraudOutcomeBlock
+	case result.Score >= e.ReviewScore:
+		result.Outcome = models.FraudOutcomeReview
+	}
+	return result
+}
+
+// payeeSince retourne la date depuis laquelle le destinataire est connu du client : l'ajout
+// du bénéficiaire ou, à défaut, le premier virement vers ce compte ; nil s'il est inconnu
+func payeeSince(p Payment) *time.Time {
+	if p.Beneficiary != nil {
+		return &p.Beneficiary.CreatedAt
+	}
+	var since *time.Time
+	for i := range p.History {
+		if to := p.History[i].ToAccountID; to != nil && *to == p.ToAccount.ID {
+			since = &p.History[i].CreatedAt
+		}
+	}
+	return since
+}
+
+// median retourne la médiane des montants des virements passés dans la devise currency, et
+// leur nombre
+func median(history []models.Transaction, currency string) (int64, int) {
+	var amounts []int64
+	for _, transaction := range history {
+		if transaction.Currency == currency {
+			amounts = append(amounts, transaction.Amount.Amount)
+		}
+	}
+	if len(amounts) == 0 {
+		return 0, 0
+	}
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i] < amounts[j] })
+	middle := len(amounts) / 2
+	if len(amounts)%2 == 0 {
+		return (amounts[middle-1] + amounts[middle]) / 2, len(amounts)
+	}
+	return amounts[middle], len(amounts)
+}
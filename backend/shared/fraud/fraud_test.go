@@ -0,0 +1,86 @@
+package fraud
+
+import (
+	"testing"
+	"time"
+
+	"banking-app/shared/models"
+)
+
+func transfer(toAccountID uint, amount int64, at time.Time) models.Transaction {
+	return models.Transaction{Amount: models.NewMoney(amount, "EUR"), Currency: "EUR", ToAccountID: &toAccountID, CreatedAt: at}
+}
+
+func TestRules(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 14, 0, 0, 0, time.UTC)
+	from, to := &models.Account{ID: 1, UserID: 1}, &models.Account{ID: 2, UserID: 2}
+	payment := func(amount int64, at time.Time, history ...models.Transaction) Payment {
+		return Payment{UserID: 1, FromAccount: from, ToAccount: to, Amount: models.NewMoney(amount, "EUR"), At: at, History: history}
+	}
+	known := transfer(2, 10000, now.AddDate(0, -1, 0))
+	recent := &models.Beneficiary{CreatedAt: now.Add(-time.Hour)}
+	withBeneficiary := payment(100000, now, known)
+	withBeneficiary.Beneficiary = recent
+	var usual []models.Transaction
+	for i := 0; i < 5; i++ {
+		usual = append(usual, transfer(3, 2000+int64(i)*100, now.AddDate(0, 0, -i-1)))
+	}
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		payment Payment
+		hit     bool
+	}{
+		{"nouveau destinataire", NewPayee{Window: 72 * time.Hour, Threshold: "1000", Score: 60}, payment(100000, now), true},
+		{"destinataire connu", NewPayee{Window: 72 * time.Hour, Threshold: "1000", Score: 60}, payment(100000, now, known), false},
+		{"bénéficiaire récent", NewPayee{Window: 72 * time.Hour, Threshold: "1000", Score: 60}, withBeneficiary, true},
+		{"petit montant", NewPayee{Window: 72 * time.Hour, Threshold: "1000", Score: 60}, payment(99999, now), false},
+		{"heure habituelle", UnusualHour{From: 0, To: 5, Score: 20}, payment(100, now), false},
+		{"heure inhabituelle", UnusualHour{From: 0, To: 5, Score: 20}, payment(100, now.Add(-11*time.Hour)), true},
+		{"plage passant minuit", UnusualHour{From: 22, To: 5, Score: 20}, payment(100, now.Add(9*time.Hour)), true},
+		{"plage vide", UnusualHour{From: 0, To: 0, Score: 20}, payment(100, now.Add(-14*time.Hour)), false},
+		{"rafale", RapidSuccession{Window: 10 * time.Minute, Count: 2, Score: 40},
+			payment(100, now, transfer(3, 100, now.Add(-time.Minute)), transfer(3, 100, now.Add(-5*time.Minute))), true},
+		{"virements espacés", RapidSuccession{Window: 10 * time.Minute, Count: 2, Score: 40},
+			payment(100, now, transfer(3, 100, now.Add(-time.Minute)), transfer(3, 100, now.Add(-time.Hour))), false},
+		{"montant hors norme", AboveMedian{Factor: 5, MinHistory: 5, Score: 50}, payment(11001, now, usual...), true},
+		{"montant habituel", AboveMedian{Factor: 5, MinHistory: 5, Score: 50}, payment(11000, now, usual...), false},
+		{"historique insuffisant", AboveMedian{Factor: 5, MinHistory: 5, Score: 50}, payment(100000, now, usual[:4]...), false},
+	}
+	for _, tt := range tests {
+		score, detail := tt.rule.Evaluate(tt.payment)
+		if (score > 0) != tt.hit || (score > 0) != (detail != "") {
+			t.Errorf("%s: score = %d (%q), déclenchée attendu %v", tt.name, score, detail, tt.hit)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 2, 0, 0, 0, time.UTC)
+	engine := Engine{Rules: DefaultRules("1000", 0, 5), ReviewScore: 50, BlockScore: 100}
+	to := &models.Account{ID: 2, UserID: 2}
+	known := transfer(2, 10000, now.AddDate(0, -1, 0))
+
+	tests := []struct {
+		name    string
+		amount  int64
+		history []models.Transaction
+		outcome string
+		score   int
+	}{
+		{"heure inhabituelle seule", 10000, []models.Transaction{known}, models.FraudOutcomeAllow, 20},
+		{"nouveau destinataire, montant élevé", 100000, nil, models.FraudOutcomeReview, 80},
+		{"nouveau destinataire et montant hors norme", 100000, []models.Transaction{
+			transfer(3, 1000, now.Add(-24*time.Hour)), transfer(3, 1000, now.Add(-48*time.Hour)),
+			transfer(3, 1000, now.Add(-72*time.Hour)), transfer(3, 1000, now.Add(-96*time.Hour)),
+			transfer(3, 1000, now.Add(-120*time.Hour)),
+		}, models.FraudOutcomeBlock, 130},
+	}
+	for _, tt := range tests {
+		result := engine.Evaluate(Payment{UserID: 1, ToAccount: to, Amount: models.NewMoney(tt.amount, "EUR"), At: now, History: tt.history})
+		if result.Outcome != tt.outcome || result.Score != tt.score {
+			t.Errorf("%s: issue = %s (%d), attendu %s (%d): %+v", tt.name, result.Outcome, result.Score, tt.outcome, tt.score, result.Hits)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package fraud
+
+import (
+	"fmt"
+	"time"
+
+	"banking-app/shared/models"
+)
+
+// Noms des règles, repris dans les décisions enregistrées
+const (
+	RuleNewPayee        = "new_payee_large_amount"
+	RuleUnusualHour     = "unusual_hour"
+	RuleRapidSuccession = "rapid_succession"
+	RuleAboveMedian     = "above_median"
+)
+
+// NewPayee signale un montant élevé vers un destinataire nouveau pour le client : bénéficiaire
+// ajouté depuis moins de Window, ou compte vers lequel il n'a jamais viré
+type NewPayee struct {
+	Window time.Duration
+	// Threshold est le montant à partir duquel la règle se déclenche, en décimal dans la
+	// devise du virement
+	Threshold string
+	Score     int
+}
+
+// Name identifie la règle
+func (r NewPayee) Name() string { return RuleNewPayee }
+
+// Evaluate applique la règle
+func (r NewPayee) Evaluate(p Payment) (int, string) {
+	threshold, err := models.ParseMoney(r.Threshold, p.Amount.Currency)
+	if err != nil {
+		return 0, ""
+	}
+	if cmp, err := p.Amount.Cmp(threshold); err != nil || cmp < 0 {
+		return 0, ""
+	}
+	since := payeeSince(p)
+	if since == nil {
+		return r.Score, fmt.Sprintf("premier virement vers ce compte, %s (seuil %s)", p.Amount, threshold)
+	}
+	if age := p.At.Sub(*since); age < r.Window {
+		return r.Score, fmt.Sprintf("destinataire connu depuis %s, %s (seuil %s)", age.Round(time.Minute), p.Amount, threshold)
+	}
+	return 0, ""
+}
+
+// UnusualHour signale un virement émis entre From et To heures (UTC) ; la plage peut
+// passer minuit (22 à 5) et une plage vide (From = To) désactive la règle
+type UnusualHour struct {
+	From, To int
+	Score    int
+}
+
+// Name identifie la règle
+func (r UnusualHour) Name() string { return RuleUnusualHour }
+
+// Evaluate applique la règle
+func (r UnusualHour) Evaluate(p Payment) (int, string) {
+	hour := p.At.UTC().Hour()
+	inRange := hour >= r.From && hour < r.To
+	if r.From > r.To {
+		inRange = hour >= r.From || hour < r.To
+	}
+	if !inRange {
+		return 0, ""
+	}
+	return r.Score, fmt.Sprintf("virement émis à %s UTC (plage %02dh-%02dh)", p.At.UTC().Format("15:04"), r.From, r.To)
+}
+
+// RapidSuccession signale un virement précédé d'au moins Count virements du client dans
+// les Window précédentes
+type RapidSuccession struct {
+	Window time.Duration
+	Count  int
+	Score  int
+}
+
+// Name identifie la règle
+func (r RapidSuccession) Name() string { return RuleRapidSuccession }
+
+// Evaluate applique la règle
+func (r RapidSuccession) Evaluate(p Payment) (int, string) {
+	since := p.At.Add(-r.Window)
+	recent := 0
+	for _, transaction := range p.History {
+		if !transaction.CreatedAt.Before(since) {
+			recent++
+		}
+	}
+	if recent < r.Count {
+		return 0, ""
+	}
+	return r.Score, fmt.Sprintf("%d virements dans les %s précédentes", recent, r.Window)
+}
+
+// AboveMedian signale un montant supérieur à Factor fois la médiane des virements passés du
+// client dans la même devise, dès qu'il en compte au moins MinHistory
+type AboveMedian struct {
+	Factor     int64
+	MinHistory int
+	Score      int
+}
+
+// Name identifie la règle
+func (r AboveMedian) Name() string { return RuleAboveMedian }
+
+// Evaluate applique la règle
+func (r AboveMedian) Evaluate(p Payment) (int, string) {
+	value, count := median(p.History, p.Amount.Currency)
+	if count < r.MinHistory || value <= 0 || p.Amount.Amount <= r.Factor*value {
+		return 0, ""
+	}
+	return r.Score, fmt.Sprintf("%s, plus de %d fois la médiane de %d virements (%s)",
+		p.Amount, r.Factor, count, models.NewMoney(value, p.Amount.Currency))
+}
+
+// DefaultRules retourne les règles du contrôle avec leurs scores par défaut : un nouveau
+// destinataire et un montant d'au moins largeAmount, une plage horaire inhabituelle
+// (nightFrom à nightTo, UTC), une rafale de virements et un montant hors norme pour le client
+func DefaultRules(largeAmount string, nightFrom, nightTo int) []Rule {
+	return []Rule{
+		NewPayee{Window: 72 * time.Hour, Threshold: largeAmount, Score: 60},
+		UnusualHour{From: nightFrom, To: nightTo, Score: 20},
+		RapidSuccession{Window: 10 * time.Minute, Count: 3, Score: 40},
+		AboveMedian{Factor: 5, MinHistory: 5, Score: 50},
+	}
+}
@@ -0,0 +1,151 @@
+package fx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"banking-app/shared/models"
+)
+
+// ecbBaseCurrency est la devise de référence des cours publiés par la BCE
+const ecbBaseCurrency = "EUR"
+
+// ecbEnvelope reprend la structure de eurofxref-daily.xml :
+// <Cube><Cube time="2024-01-31"><Cube currency="USD" rate="1.0837"/>...</Cube></Cube>
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// Table est un jeu de cours contre l'euro publiés à une date donnée
+type Table struct {
+	AsOf  time.Time
+	rates map[string]*big.Rat
+}
+
+// NewTable crée une table à partir de cours décimaux contre l'euro
+func NewTable(asOf time.Time, rates map[string]string) (*Table, error) {
+	table := &Table{AsOf: asOf, rates: map[string]*big.Rat{ecbBaseCurrency: big.NewRat(1, 1)}}
+	for currency, value := range rates {
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", currency, err)
+		}
+		table.rates[currency] = rate
+	}
+	return table, nil
+}
+
+// ParseECB lit un fichier de cours au format BCE ; seule la journée la plus récente est retenue
+func ParseECB(r io.Reader) (*Table, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("lecture des cours BCE: %w", err)
+	}
+
+	var latest *Table
+	for _, day := range envelope.Days {
+		asOf, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("date de cours invalide: %q", day.Time)
+		}
+		if latest != nil && !asOf.After(latest.AsOf) {
+			continue
+		}
+
+		rates := make(map[string]string, len(day.Rates))
+		for _, rate := range day.Rates {
+			rates[rate.Currency] = rate.Rate
+		}
+		if latest, err = NewTable(asOf, rates); err != nil {
+			return nil, err
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("lecture des cours BCE: aucun cours publié")
+	}
+	return latest, nil
+}
+
+// Rate calcule le cours de from vers to, éventuellement croisé via l'euro
+func (t *Table) Rate(from, to string) (Rate, error) {
+	fromRate, okFrom := t.rates[from]
+	toRate, okTo := t.rates[to]
+	if !okFrom || !okTo || !models.IsSupportedCurrency(from) || !models.IsSupportedCurrency(to) {
+		return Rate{}, fmt.Errorf("%w pour %s/%s", ErrRateUnavailable, from, to)
+	}
+	return Rate{
+		From:  from,
+		To:    to,
+		Value: new(big.Rat).Quo(toRate, fromRate),
+		AsOf:  t.AsOf,
+	}, nil
+}
+
+// FileProvider lit les cours dans un fichier au format BCE, relu dès qu'il est modifié.
+// Des cours plus anciens que maxAge sont refusés.
+type FileProvider struct {
+	path   string
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	table   *Table
+	modTime time.Time
+}
+
+// NewFileProvider crée un fournisseur adossé au fichier path (FX_RATES_FILE)
+func NewFileProvider(path string, maxAge time.Duration) *FileProvider {
+	return &FileProvider{path: path, maxAge: maxAge}
+}
+
+// Rate retourne le cours courant de from vers to
+func (p *FileProvider) Rate(from, to string) (Rate, error) {
+	table, err := p.load()
+	if err != nil {
+		return Rate{}, fmt.Errorf("%w: %v", ErrRateUnavailable, err)
+	}
+	if time.Since(table.AsOf) > p.maxAge {
+		return Rate{}, fmt.Errorf("%w: cours du %s périmés", ErrRateUnavailable, table.AsOf.Format("2006-01-02"))
+	}
+	return table.Rate(from, to)
+}
+
+// load relit le fichier si sa date de modification a changé
+func (p *FileProvider) load() (*Table, error) {
+	if p.path == "" {
+		return nil, fmt.Errorf("FX_RATES_FILE non configuré")
+	}
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.table != nil && info.ModTime().Equal(p.modTime) {
+		return p.table, nil
+	}
+
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table, err := ParseECB(file)
+	if err != nil {
+		return nil, err
+	}
+	p.table, p.modTime = table, info.ModTime()
+	return table, nil
+}
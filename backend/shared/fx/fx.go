@@ -0,0 +1,168 @@
+// Package fx fournit les taux de change et la conversion exacte des montants entre devises.
+// Les taux proviennent d'un fichier XML au format de la Banque centrale européenne
+// (cours de référence exprimés contre l'euro) ; les taux croisés sont calculés via l'euro.
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"banking-app/shared/models"
+)
+
+// Paramètres par défaut
+const (
+	defaultSpreadBps  = 50 // 0,50 %
+	defaultMaxRateAge = 96 * time.Hour
+	defaultQuoteTTL   = 30 * time.Second
+)
+
+// Erreurs de conversion
+var (
+	ErrRateUnavailable = errors.New("aucun taux de change disponible")
+	ErrAmountTooSmall  = errors.New("montant trop faible pour être converti")
+)
+
+// Rate est le cours moyen d'une paire de devises : Value unités de To pour une unité de From
+type Rate struct {
+	From  string
+	To    string
+	Value *big.Rat
+	AsOf  time.Time
+}
+
+// String retourne le cours au format décimal (10 décimales au plus)
+func (r Rate) String() string {
+	return trimDecimal(r.Value.FloatString(10))
+}
+
+// Provider fournit le cours moyen d'une paire de devises, ou ErrRateUnavailable
+type Provider interface {
+	Rate(from, to string) (Rate, error)
+}
+
+// Conversion est le résultat d'une conversion : Mid est la contre-valeur au cours moyen,
+// Target le montant remis au client après application de la marge (SpreadBps)
+type Conversion struct {
+	Source    models.Money
+	Mid       models.Money
+	Target    models.Money
+	Rate      Rate
+	SpreadBps int
+}
+
+// Spread retourne la marge prélevée, dans la devise cible
+func (c Conversion) Spread() models.Money {
+	return models.NewMoney(c.Mid.Amount-c.Target.Amount, c.Target.Currency)
+}
+
+// Convert convertit un montant positif au cours rate en prélevant spreadBps points de base.
+// La contre-valeur au cours moyen est arrondie au plus proche, le montant client est
+// arrondi à l'unité mineure inférieure.
+func Convert(amount models.Money, rate Rate, spreadBps int) (Conversion, error) {
+	if amount.Currency != rate.From {
+		return Conversion{}, models.ErrCurrencyMismatch
+	}
+	fromExponent, ok := models.CurrencyExponent(rate.From)
+	if !ok {
+		return Conversion{}, models.ErrUnsupportedCurrency
+	}
+	toExponent, ok := models.CurrencyExponent(rate.To)
+	if !ok {
+		return Conversion{}, models.ErrUnsupportedCurrency
+	}
+
+	// Montant cible exact en unités mineures de la devise cible
+	exact := new(big.Rat).Mul(new(big.Rat).SetInt64(amount.Amount), rate.Value)
+	exact.Mul(exact, pow10Rat(toExponent-fromExponent))
+
+	mid, err := roundHalfUp(exact)
+	if err != nil {
+		return Conversion{}, err
+	}
+	customer := new(big.Rat).Mul(exact, big.NewRat(int64(10000-spreadBps), 10000))
+	target, err := floor(customer)
+	if err != nil {
+		return Conversion{}, err
+	}
+	if target <= 0 {
+		return Conversion{}, ErrAmountTooSmall
+	}
+
+	return Conversion{
+		Source:    amount,
+		Mid:       models.NewMoney(mid, rate.To),
+		Target:    models.NewMoney(target, rate.To),
+		Rate:      rate,
+		SpreadBps: spreadBps,
+	}, nil
+}
+
+// SpreadBps retourne la marge de change en points de base (FX_SPREAD_BPS)
+func SpreadBps() int {
+	if value, err := strconv.Atoi(os.Getenv("FX_SPREAD_BPS")); err == nil && value >= 0 && value < 10000 {
+		return value
+	}
+	return defaultSpreadBps
+}
+
+// MaxRateAge retourne l'ancienneté au-delà de laquelle un cours est refusé (FX_MAX_RATE_AGE)
+func MaxRateAge() time.Duration {
+	return durationFromEnv("FX_MAX_RATE_AGE", defaultMaxRateAge)
+}
+
+// QuoteTTL retourne la durée de validité d'une cotation (FX_QUOTE_TTL)
+func QuoteTTL() time.Duration {
+	return durationFromEnv("FX_QUOTE_TTL", defaultQuoteTTL)
+}
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func pow10Rat(exponent int) *big.Rat {
+	if exponent >= 0 {
+		return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil))
+	}
+	return new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-exponent)), nil))
+}
+
+// floor arrondit un rationnel positif à l'entier inférieur
+func floor(value *big.Rat) (int64, error) {
+	quotient := new(big.Int).Quo(value.Num(), value.Denom())
+	if !quotient.IsInt64() {
+		return 0, models.ErrAmountOverflow
+	}
+	return quotient.Int64(), nil
+}
+
+// roundHalfUp arrondit un rationnel positif à l'entier le plus proche
+func roundHalfUp(value *big.Rat) (int64, error) {
+	return floor(new(big.Rat).Add(value, big.NewRat(1, 2)))
+}
+
+// ParseRate convertit un cours décimal ("1.0837") en rationnel exact
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("cours invalide: %q", value)
+	}
+	return rate, nil
+}
+
+func trimDecimal(value string) string {
+	for len(value) > 1 && value[len(value)-1] == '0' {
+		value = value[:len(value)-1]
+	}
+	if value[len(value)-1] == '.' {
+		value = value[:len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+package fx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"banking-app/shared/models"
+)
+
+func loadTestTable(t *testing.T) *Table {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join("testdata", "eurofxref-daily.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	table, err := ParseECB(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestParseECBKeepsLatestDay(t *testing.T) {
+	table := loadTestTable(t)
+
+	if want := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC); !table.AsOf.Equal(want) {
+		t.Errorf("date des cours = %s, attendu %s", table.AsOf, want)
+	}
+	rate, err := table.Rate("EUR", "USD")
+	if err != nil || rate.String() != "1.0837" {
+		t.Errorf("EUR/USD = %v, %v, attendu 1.0837", rate, err)
+	}
+	if _, err := table.Rate("EUR", "CAD"); !errors.Is(err, ErrRateUnavailable) {
+		t.Errorf("EUR/CAD: erreur = %v, attendu ErrRateUnavailable", err)
+	}
+}
+
+func TestCrossRateViaEuro(t *testing.T) {
+	table := loadTestTable(t)
+
+	rate, err := table.Rate("USD", "GBP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0.853 / 1.0837
+	if got := rate.String(); got != "0.7871182061" {
+		t.Errorf("USD/GBP = %s, attendu 0.7871182061", got)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	table := loadTestTable(t)
+	rate, _ := table.Rate("EUR", "USD")
+
+	conversion, err := Convert(models.NewMoney(10000, "EUR"), rate, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 100.00 EUR × 1.0837 = 108.37 USD, moins 0,50 % = 107.82815 arrondi à 107.82 USD
+	if conversion.Mid.Amount != 10837 || conversion.Target.Amount != 10782 || conversion.Spread().Amount != 55 {
+		t.Errorf("conversion = %+v", conversion)
+	}
+
+	// Les décimales de chaque devise sont respectées (JPY sans décimale)
+	rate, _ = table.Rate("EUR", "JPY")
+	conversion, err = Convert(models.NewMoney(1050, "EUR"), rate, 0)
+	if err != nil || conversion.Target.Amount != 1676 || conversion.Target.Currency != "JPY" {
+		t.Errorf("conversion EUR/JPY = %+v, %v, attendu 1676 JPY", conversion, err)
+	}
+
+	rate, _ = table.Rate("JPY", "EUR")
+	if _, err := Convert(models.NewMoney(1, "JPY"), rate, 50); !errors.Is(err, ErrAmountTooSmall) {
+		t.Errorf("erreur = %v, attendu ErrAmountTooSmall", err)
+	}
+}
+
+func TestFileProviderRejectsStaleRates(t *testing.T) {
+	path := filepath.Join("testdata", "eurofxref-daily.xml")
+
+	if _, err := NewFileProvider(path, time.Hour).Rate("EUR", "USD"); !errors.Is(err, ErrRateUnavailable) {
+		t.Errorf("cours périmés: erreur = %v, attendu ErrRateUnavailable", err)
+	}
+	if _, err := NewFileProvider(path, 100*365*24*time.Hour).Rate("EUR", "USD"); err != nil {
+		t.Errorf("cours récents: %v", err)
+	}
+	if _, err := NewFileProvider("", time.Hour).Rate("EUR", "USD"); !errors.Is(err, ErrRateUnavailable) {
+		t.Errorf("fichier absent: erreur = %v, attendu ErrRateUnavailable", err)
+	}
+}
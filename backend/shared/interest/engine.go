@@ -0,0 +1,219 @@
+package interest
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+)
+
+// errAlreadyAccrued signale une journée déjà courue, ignorée lors d'une reprise
+var errAlreadyAccrued = errors.New("journée déjà courue")
+
+// Options délimite un passage du calcul des intérêts
+type Options struct {
+	// AccountID limite le passage à un compte (0 pour tous les comptes épargne rémunérés)
+	AccountID uint
+	// From est la première journée à courir ; à défaut, chaque compte reprend au lendemain
+	// de sa dernière journée courue, ou au jour de son ouverture
+	From time.Time
+	// To est la dernière journée à courir, au plus tard la veille (journée par défaut)
+	To time.Time
+}
+
+// Result résume un passage du calcul des intérêts
+type Result struct {
+	Accounts int `json:"accounts"`
+	Accrued  int `json:"accrued"` // journées courues
+	Skipped  int `json:"skipped"` // journées déjà courues lors d'un passage précédent
+	Payouts  int `json:"payouts"`
+}
+
+// Run court les intérêts de chaque journée de [From, To] des comptes concernés, dans l'ordre
+// chronologique, et verse les intérêts en fin de période. Le résultat ne dépend que du grand
+// livre et de la configuration des produits : une journée déjà courue est ignorée et un
+// versement porte une référence propre à la période, si bien que Run peut être relancé
+// sur une plage déjà traitée. Une erreur sur un compte n'interrompt pas les autres.
+func Run(store *repository.Store, options Options, now time.Time) (Result, error) {
+	to := Day(now).AddDate(0, 0, -1)
+	if !options.To.IsZero() && Day(options.To).Before(to) {
+		to = Day(options.To)
+	}
+
+	accounts, err := store.Interest.ListAccruingAccounts(options.AccountID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+	var errs []error
+	products := map[uint]*models.InterestProduct{}
+	for _, account := range accounts {
+		product, ok := products[*account.InterestProductID]
+		if !ok {
+			if product, err = store.Interest.FindProduct(*account.InterestProductID); err != nil {
+				errs = append(errs, fmt.Errorf("compte %d: %w", account.ID, err))
+				continue
+			}
+			products[product.ID] = product
+		}
+
+		start, err := firstDay(store, account, options.From)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("compte %d: %w", account.ID, err))
+			continue
+		}
+
+		result.Accounts++
+		for day := start; !day.After(to); day = day.AddDate(0, 0, 1) {
+			paid, err := accrueDay(store, account.ID, *product, day)
+			if errors.Is(err, errAlreadyAccrued) {
+				result.Skipped++
+				continue
+			}
+			if err != nil {
+				// Les journées suivantes dépendent de celle-ci : le compte reprendra au prochain passage
+				errs = append(errs, fmt.Errorf("compte %d, journée %s: %w", account.ID, day.Format("2006-01-02"), err))
+				break
+			}
+			result.Accrued++
+			if paid {
+				result.Payouts++
+			}
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// firstDay retourne la première journée à courir pour account
+func firstDay(store *repository.Store, account models.Account, from time.Time) (time.Time, error) {
+	opened := Day(account.CreatedAt)
+	if !from.IsZero() {
+		if from = Day(from); from.After(opened) {
+			return from, nil
+		}
+		return opened, nil
+	}
+
+	last, err := store.Interest.LastAccrualDate(account.ID)
+	if err != nil || last == nil {
+		return opened, err
+	}
+	return Day(*last).AddDate(0, 0, 1), nil
+}
+
+// accrueDay court les intérêts d'une journée et verse ceux de la période si elle s'achève ;
+// le compte est verrouillé pour qu'un passage concurrent ne courre pas la même journée
+func accrueDay(store *repository.Store, accountID uint, product models.InterestProduct, day time.Time) (bool, error) {
+	paid := false
+	err := store.Transaction(func(tx *repository.Store) error {
+		account, err := tx.Accounts.LockByID(accountID)
+		if err != nil {
+			return err
+		}
+		exists, err := tx.Interest.AccrualExists(account.ID, day)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errAlreadyAccrued
+		}
+
+		next := day.AddDate(0, 0, 1)
+		balances, err := ledger.DailyBalances(tx.DB, account.ID, account.Currency, day, next)
+		if err != nil {
+			return err
+		}
+		balance := balances[0]
+
+		base := max(balance, 0) * models.MicrosPerMinor
+		var compoundedBefore time.Time
+		switch product.Compounding {
+		case models.CompoundingDaily:
+			compoundedBefore = day
+		case models.CompoundingMonthly:
+			compoundedBefore = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		}
+		if !compoundedBefore.IsZero() && balance > 0 {
+			unpaid, err := tx.Interest.SumUnpaid(account.ID, compoundedBefore)
+			if err != nil {
+				return err
+			}
+			base += unpaid
+		}
+
+		amount, err := DailyAccrual(product, base, day)
+		if err != nil {
+			return err
+		}
+		accrual := models.InterestAccrual{
+			AccountID:    account.ID,
+			ProductID:    product.ID,
+			AccrualDate:  day,
+			Balance:      models.NewMoney(balance, account.Currency),
+			BaseMicros:   base,
+			AmountMicros: amount,
+			Currency:     account.Currency,
+		}
+		if err := tx.Interest.CreateAccrual(&accrual); err != nil {
+			return err
+		}
+
+		if !PeriodEnd(product.PayoutFrequency, day) {
+			return nil
+		}
+		paid, err = payout(tx, account, day)
+		return err
+	})
+	return paid, err
+}
+
+// payout verse les intérêts courus non versés jusqu'au jour end inclus. Le versement est
+// daté du lendemain de end, premier jour où il porte intérêt ; des intérêts inférieurs à
+// une demi-unité mineure sont reportés sur la période suivante.
+func payout(tx *repository.Store, account *models.Account, end time.Time) (bool, error) {
+	valueDate := end.AddDate(0, 0, 1)
+	micros, err := tx.Interest.SumUnpaid(account.ID, valueDate)
+	if err != nil {
+		return false, err
+	}
+	minor, err := PayoutAmount(micros)
+	if err != nil || minor == 0 {
+		return false, err
+	}
+
+	amount := models.NewMoney(minor, account.Currency)
+	reference := payoutReference(account.ID, end)
+	description := "Intérêts créditeurs au " + end.Format("02/01/2006")
+	credit := ledger.CustomerPosting(account.ID, amount)
+	expense := ledger.SystemPosting(ledger.InterestExpenseAccount, amount.Neg())
+	credit.CreatedAt, expense.CreatedAt = valueDate, valueDate
+	entry := models.JournalEntry{
+		Reference:   reference,
+		Description: description,
+		Postings:    []models.Posting{credit, expense},
+	}
+	if err := ledger.Post(tx.DB, &entry); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	transaction := models.Transaction{
+		AccountID:      account.ID,
+		Type:           "credit",
+		Amount:         amount,
+		Currency:       account.Currency,
+		Description:    description,
+		Reference:      reference,
+		Status:         "completed",
+		ProcessedAt:    &now,
+		JournalEntryID: &entry.ID,
+	}
+	if err := tx.Transactions.Create(&transaction); err != nil {
+		return false, err
+	}
+	return true, tx.Interest.MarkPaid(account.ID, valueDate, transaction.ID)
+}
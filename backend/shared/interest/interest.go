@@ -0,0 +1,154 @@
+// Package interest calcule et verse la rémunération des comptes épargne. Chaque journée
+// donne lieu à un intérêt couru, calculé sur le solde de clôture reconstitué depuis le grand
+// livre selon les tranches, la base annuelle et la capitalisation du produit ; les intérêts
+// courus sont versés en fin de période par une transaction de crédit.
+package interest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"banking-app/shared/models"
+)
+
+// Erreurs de configuration d'un produit
+var (
+	ErrNoTier          = errors.New("un produit doit comporter au moins une tranche")
+	ErrFirstTier       = errors.New("la première tranche doit commencer à un solde nul")
+	ErrTierOrder       = errors.New("les seuils des tranches doivent être strictement croissants")
+	ErrTierRate        = errors.New("le taux d'une tranche doit être compris entre 0 et 10000 points de base")
+	ErrDayCount        = errors.New("base de calcul invalide. Bases valides: act/365, act/360, act/act")
+	ErrCompounding     = errors.New("capitalisation invalide. Valeurs valides: none, daily, monthly")
+	ErrPayoutFrequency = errors.New("fréquence de versement invalide. Fréquences valides: monthly, quarterly, annually")
+)
+
+// Validate vérifie la configuration d'un produit ; les tranches doivent être triées par seuil
+func Validate(product *models.InterestProduct) error {
+	switch product.DayCount {
+	case models.DayCountAct365, models.DayCountAct360, models.DayCountActAct:
+	default:
+		return ErrDayCount
+	}
+	switch product.Compounding {
+	case models.CompoundingNone, models.CompoundingDaily, models.CompoundingMonthly:
+	default:
+		return ErrCompounding
+	}
+	switch product.PayoutFrequency {
+	case models.PayoutMonthly, models.PayoutQuarterly, models.PayoutAnnually:
+	default:
+		return ErrPayoutFrequency
+	}
+
+	if len(product.Tiers) == 0 {
+		return ErrNoTier
+	}
+	if product.Tiers[0].MinBalance.Amount != 0 {
+		return ErrFirstTier
+	}
+	for i, tier := range product.Tiers {
+		if tier.RateBps < 0 || tier.RateBps > 10000 {
+			return ErrTierRate
+		}
+		if i > 0 && tier.MinBalance.Amount <= product.Tiers[i-1].MinBalance.Amount {
+			return ErrTierOrder
+		}
+	}
+	return nil
+}
+
+// YearDays retourne la base annuelle de la convention dayCount pour l'année de day
+func YearDays(dayCount string, day time.Time) (int64, error) {
+	switch dayCount {
+	case models.DayCountAct365:
+		return 365, nil
+	case models.DayCountAct360:
+		return 360, nil
+	case models.DayCountActAct:
+		year := day.Year()
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			return 366, nil
+		}
+		return 365, nil
+	}
+	return 0, ErrDayCount
+}
+
+// DailyAccrual calcule l'intérêt d'une journée sur une assiette exprimée en millionièmes
+// d'unité mineure : chaque part de l'assiette est rémunérée au taux de sa tranche, le total
+// est divisé par la base annuelle et arrondi au millionième le plus proche
+func DailyAccrual(product models.InterestProduct, baseMicros int64, day time.Time) (int64, error) {
+	if baseMicros <= 0 {
+		return 0, nil
+	}
+	yearDays, err := YearDays(product.DayCount, day)
+	if err != nil {
+		return 0, err
+	}
+
+	base := big.NewInt(baseMicros)
+	annual := new(big.Int)
+	for i, tier := range product.Tiers {
+		lower := new(big.Int).Mul(big.NewInt(tier.MinBalance.Amount), big.NewInt(models.MicrosPerMinor))
+		if base.Cmp(lower) <= 0 {
+			break
+		}
+		upper := base
+		if i+1 < len(product.Tiers) {
+			next := new(big.Int).Mul(big.NewInt(product.Tiers[i+1].MinBalance.Amount), big.NewInt(models.MicrosPerMinor))
+			if next.Cmp(base) < 0 {
+				upper = next
+			}
+		}
+		portion := new(big.Int).Sub(upper, lower)
+		annual.Add(annual, portion.Mul(portion, big.NewInt(int64(tier.RateBps))))
+	}
+
+	daily := new(big.Rat).SetFrac(annual, big.NewInt(10000*yearDays))
+	return roundHalfUp(daily)
+}
+
+// PeriodEnd indique si day est le dernier jour d'une période de versement
+func PeriodEnd(frequency string, day time.Time) bool {
+	next := day.AddDate(0, 0, 1)
+	if next.Day() != 1 {
+		return false
+	}
+	switch frequency {
+	case models.PayoutMonthly:
+		return true
+	case models.PayoutQuarterly:
+		return next.Month()%3 == 1
+	case models.PayoutAnnually:
+		return next.Month() == time.January
+	}
+	return false
+}
+
+// PayoutAmount arrondit des intérêts courus en millionièmes à l'unité mineure la plus proche
+func PayoutAmount(micros int64) (int64, error) {
+	return roundHalfUp(big.NewRat(micros, models.MicrosPerMinor))
+}
+
+// roundHalfUp arrondit un rationnel positif à l'entier le plus proche
+func roundHalfUp(value *big.Rat) (int64, error) {
+	value = new(big.Rat).Add(value, big.NewRat(1, 2))
+	rounded := new(big.Int).Quo(value.Num(), value.Denom())
+	if !rounded.IsInt64() {
+		return 0, models.ErrAmountOverflow
+	}
+	return rounded.Int64(), nil
+}
+
+// Day ramène t au début de sa journée UTC, unité des intérêts courus
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// payoutReference est la référence du versement de la période se terminant le jour end
+func payoutReference(accountID uint, end time.Time) string {
+	return fmt.Sprintf("INTPAY-%d-%s", accountID, end.Format("20060102"))
+}
@@ -0,0 +1,215 @@
+package interest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/testutil"
+)
+
+func eur(amount int64) models.Money { return models.NewMoney(amount, "EUR") }
+
+// tieredProduct rémunère 1 % jusqu'à 1 000,00 et 2 % au-delà, versés chaque mois
+func tieredProduct(compounding string) models.InterestProduct {
+	return models.InterestProduct{
+		Code:            "LIVRET-" + compounding,
+		Name:            "Livret",
+		Currency:        "EUR",
+		DayCount:        models.DayCountAct365,
+		Compounding:     compounding,
+		PayoutFrequency: models.PayoutMonthly,
+		Active:          true,
+		Tiers: []models.InterestTier{
+			{MinBalance: eur(0), RateBps: 100},
+			{MinBalance: eur(100000), RateBps: 200},
+		},
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestValidate(t *testing.T) {
+	valid := tieredProduct(models.CompoundingNone)
+	if err := Validate(&valid); err != nil {
+		t.Fatalf("produit valide: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(*models.InterestProduct)
+		err    error
+	}{
+		{"sans tranche", func(p *models.InterestProduct) { p.Tiers = nil }, ErrNoTier},
+		{"première tranche non nulle", func(p *models.InterestProduct) { p.Tiers[0].MinBalance = eur(100) }, ErrFirstTier},
+		{"seuils non croissants", func(p *models.InterestProduct) { p.Tiers[1].MinBalance = eur(0) }, ErrTierOrder},
+		{"taux négatif", func(p *models.InterestProduct) { p.Tiers[1].RateBps = -1 }, ErrTierRate},
+		{"base inconnue", func(p *models.InterestProduct) { p.DayCount = "30/360" }, ErrDayCount},
+		{"capitalisation inconnue", func(p *models.InterestProduct) { p.Compounding = "yearly" }, ErrCompounding},
+		{"versement inconnu", func(p *models.InterestProduct) { p.PayoutFrequency = "weekly" }, ErrPayoutFrequency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := tieredProduct(models.CompoundingNone)
+			tt.change(&product)
+			if err := Validate(&product); !errors.Is(err, tt.err) {
+				t.Errorf("Validate() = %v, attendu %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDailyAccrual(t *testing.T) {
+	product := tieredProduct(models.CompoundingNone)
+	day := date(2024, time.March, 1)
+
+	tests := []struct {
+		name    string
+		balance int64
+		want    int64
+	}{
+		{"solde nul", 0, 0},
+		{"solde négatif", -50000, 0},
+		// 500,00 × 1 % / 365 = 0,0136986... centime
+		{"première tranche", 50000, 1369863},
+		// 1 000,00 × 1 % + 1 000,00 × 2 %, / 365
+		{"deux tranches", 200000, 8219178},
+	}
+	for _, tt := range tests {
+		got, err := DailyAccrual(product, tt.balance*models.MicrosPerMinor, day)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: DailyAccrual = %d (%v), attendu %d", tt.name, got, err, tt.want)
+		}
+	}
+
+	product.DayCount = models.DayCountAct360
+	if got, _ := DailyAccrual(product, 36000*models.MicrosPerMinor, day); got != 1000000 {
+		t.Errorf("act/360: DailyAccrual = %d, attendu 1000000", got)
+	}
+}
+
+func TestYearDaysAndPeriodEnd(t *testing.T) {
+	if days, _ := YearDays(models.DayCountActAct, date(2024, time.June, 1)); days != 366 {
+		t.Errorf("act/act 2024 = %d jours, attendu 366", days)
+	}
+	if days, _ := YearDays(models.DayCountActAct, date(2100, time.June, 1)); days != 365 {
+		t.Errorf("act/act 2100 = %d jours, attendu 365", days)
+	}
+	if days, _ := YearDays(models.DayCountAct365, date(2024, time.June, 1)); days != 365 {
+		t.Errorf("act/365 2024 = %d jours, attendu 365", days)
+	}
+
+	tests := []struct {
+		frequency string
+		day       time.Time
+		want      bool
+	}{
+		{models.PayoutMonthly, date(2024, time.February, 29), true},
+		{models.PayoutMonthly, date(2024, time.February, 28), false},
+		{models.PayoutQuarterly, date(2024, time.March, 31), true},
+		{models.PayoutQuarterly, date(2024, time.April, 30), false},
+		{models.PayoutAnnually, date(2024, time.December, 31), true},
+		{models.PayoutAnnually, date(2024, time.June, 30), false},
+	}
+	for _, tt := range tests {
+		if got := PeriodEnd(tt.frequency, tt.day); got != tt.want {
+			t.Errorf("PeriodEnd(%s, %s) = %v, attendu %v", tt.frequency, tt.day.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestRunAccruesPaysAndReplays(t *testing.T) {
+	store := testutil.NewStore(t)
+	product := tieredProduct(models.CompoundingNone)
+	if err := store.Interest.CreateProduct(&product); err != nil {
+		t.Fatal(err)
+	}
+	user, _ := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 200000)
+	opened := date(2024, time.January, 1).Add(9 * time.Hour)
+	store.DB.Model(&models.Account{}).Where("id = ?", account.ID).
+		Updates(map[string]interface{}{"account_type": "savings", "interest_product_id": product.ID, "created_at": opened})
+	store.DB.Model(&models.Posting{}).Where("account_id = ?", account.ID).Update("created_at", opened)
+
+	now := date(2024, time.March, 1)
+	options := Options{To: date(2024, time.February, 10)}
+	result, err := Run(store, options, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Accounts != 1 || result.Accrued != 41 || result.Payouts != 1 {
+		t.Fatalf("résultat = %+v, attendu 41 journées et 1 versement", result)
+	}
+
+	// Janvier : 31 × 8 219 178 millionièmes = 2,55 EUR, versés le 1er février
+	var payouts []models.Transaction
+	store.DB.Where("account_id = ? AND type = ?", account.ID, "credit").Find(&payouts)
+	if len(payouts) != 1 || payouts[0].Amount.Amount != 255 {
+		t.Fatalf("versements = %+v, attendu un crédit de 255 centimes", payouts)
+	}
+	if paid, _ := store.Accounts.FindByID(account.ID); paid.Balance.Amount != 200255 {
+		t.Errorf("solde = %d centimes, attendu 200255", paid.Balance.Amount)
+	}
+
+	// Le versement porte intérêt dès le 1er février
+	accruals, err := store.Interest.ListAccruals(account.ID, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	february := accruals[0]
+	if february.Balance.Amount != 200255 {
+		t.Errorf("solde du %s = %d, attendu 200255", february.AccrualDate.Format("2006-01-02"), february.Balance.Amount)
+	}
+	amounts := map[string]int64{}
+	for _, accrual := range accruals {
+		amounts[accrual.AccrualDate.Format("2006-01-02")] = accrual.AmountMicros
+	}
+
+	// Un second passage ne recalcule rien et ne verse rien
+	replay, err := Run(store, Options{From: date(2024, time.January, 1), To: options.To}, now)
+	if err != nil || replay.Accrued != 0 || replay.Skipped != 41 || replay.Payouts != 0 {
+		t.Errorf("second passage = %+v (%v), attendu 41 journées ignorées", replay, err)
+	}
+
+	// Une plage effacée puis recalculée redonne les mêmes montants
+	store.DB.Where("account_id = ? AND accrual_date >= ?", account.ID, date(2024, time.February, 1)).Delete(&models.InterestAccrual{})
+	backfill, err := Run(store, Options{From: date(2024, time.February, 1), To: options.To}, now)
+	if err != nil || backfill.Accrued != 10 {
+		t.Fatalf("rattrapage = %+v (%v), attendu 10 journées", backfill, err)
+	}
+	accruals, _ = store.Interest.ListAccruals(account.ID, 100)
+	for _, accrual := range accruals {
+		if day := accrual.AccrualDate.Format("2006-01-02"); amounts[day] != accrual.AmountMicros {
+			t.Errorf("%s: %d millionièmes après rattrapage, attendu %d", day, accrual.AmountMicros, amounts[day])
+		}
+	}
+}
+
+func TestRunCompoundsDaily(t *testing.T) {
+	store := testutil.NewStore(t)
+	product := tieredProduct(models.CompoundingDaily)
+	if err := store.Interest.CreateProduct(&product); err != nil {
+		t.Fatal(err)
+	}
+	user, _ := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 200000)
+	opened := date(2024, time.January, 1)
+	store.DB.Model(&models.Account{}).Where("id = ?", account.ID).
+		Updates(map[string]interface{}{"account_type": "savings", "interest_product_id": product.ID, "created_at": opened})
+	store.DB.Model(&models.Posting{}).Where("account_id = ?", account.ID).Update("created_at", opened)
+
+	if _, err := Run(store, Options{To: date(2024, time.January, 2)}, date(2024, time.March, 1)); err != nil {
+		t.Fatal(err)
+	}
+	accruals, _ := store.Interest.ListAccruals(account.ID, 2)
+	if len(accruals) != 2 {
+		t.Fatalf("%d journées courues, attendu 2", len(accruals))
+	}
+	second, first := accruals[0], accruals[1]
+	if second.BaseMicros != 200000*models.MicrosPerMinor+first.AmountMicros {
+		t.Errorf("assiette du 2 janvier = %d, attendu le solde plus les intérêts de la veille", second.BaseMicros)
+	}
+}
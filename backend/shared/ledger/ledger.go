@@ -0,0 +1,322 @@
+// Package ledger implémente le grand livre en partie double : chaque mouvement de fonds
+// est une écriture (JournalEntry) dont les lignes (Posting) s'équilibrent à zéro par devise.
+// Les soldes des comptes clients sont mis à jour dans la même transaction que l'écriture
+// et peuvent être rapprochés à tout moment de la somme de leurs lignes.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"banking-app/shared/models"
+
+	"gorm.io/gorm"
+)
+
+// Comptes internes de la banque
+const (
+	// ExternalAccount est la contrepartie des dépôts et retraits (fonds entrant ou sortant de la banque)
+	ExternalAccount = "system:external"
+	// OpeningBalanceAccount est la contrepartie des soldes antérieurs à la mise en place du grand livre
+	OpeningBalanceAccount = "system:opening-balance"
+	// FXAccount est la position de change de la banque, contrepartie des deux jambes d'un change
+	FXAccount = "system:fx"
+	// FXRevenueAccount reçoit la marge prélevée sur les opérations de change
+	FXRevenueAccount = "system:fx-revenue"
+	// InterestIncomeAccount reçoit les intérêts débiteurs facturés aux comptes à découvert
+	InterestIncomeAccount = "system:interest-income"
+	// InterestExpenseAccount est la contrepartie des intérêts versés aux comptes épargne
+	InterestExpenseAccount = "system:interest-expense"
+)
+
+// Erreurs du grand livre
+var (
+	ErrEmptyEntry        = errors.New("une écriture doit comporter au moins deux lignes")
+	ErrZeroPosting       = errors.New("une ligne d'écriture ne peut pas être nulle")
+	ErrUnbalancedEntry   = errors.New("écriture déséquilibrée")
+	ErrInsufficientFunds = errors.New("solde insuffisant")
+	ErrAccountCurrency   = errors.New("la devise de la ligne ne correspond pas à celle du compte")
+)
+
+// CustomerAccount retourne le nom du compte du grand livre associé à un compte client
+func CustomerAccount(accountID uint) string {
+	return fmt.Sprintf("account:%d", accountID)
+}
+
+// CustomerPosting crée une ligne sur un compte client (montant positif = crédit)
+func CustomerPosting(accountID uint, amount models.Money) models.Posting {
+	id := accountID
+	return models.Posting{
+		LedgerAccount: CustomerAccount(accountID),
+		AccountID:     &id,
+		Amount:        amount,
+		Currency:      amount.Currency,
+	}
+}
+
+// SystemPosting crée une ligne sur un compte interne de la banque
+func SystemPosting(ledgerAccount string, amount models.Money) models.Posting {
+	return models.Posting{
+		LedgerAccount: ledgerAccount,
+		Amount:        amount,
+		Currency:      amount.Currency,
+	}
+}
+
+// Validate vérifie qu'une écriture est équilibrée pour chaque devise
+func Validate(entry *models.JournalEntry) error {
+	if len(entry.Postings) < 2 {
+		return ErrEmptyEntry
+	}
+
+	totals := map[string]models.Money{}
+	for _, posting := range entry.Postings {
+		if posting.Amount.IsZero() {
+			return ErrZeroPosting
+		}
+		if posting.Currency != posting.Amount.Currency {
+			return models.ErrCurrencyMismatch
+		}
+
+		total, ok := totals[posting.Currency]
+		if !ok {
+			total = models.NewMoney(0, posting.Currency)
+		}
+		sum, err := total.Add(posting.Amount)
+		if err != nil {
+			return err
+		}
+		totals[posting.Currency] = sum
+	}
+
+	for currency, total := range totals {
+		if !total.IsZero() {
+			return fmt.Errorf("%w: %s %s", ErrUnbalancedEntry, total.Decimal(), currency)
+		}
+	}
+	return nil
+}
+
+// Post valide puis enregistre l'écriture et applique ses lignes aux soldes des comptes clients.
+// tx doit être une transaction de base de données ; les comptes débités devraient déjà être
+// verrouillés par l'appelant. Un débit qui rendrait le solde disponible (solde moins les fonds
+// réservés, plus le découvert autorisé) négatif retourne ErrInsufficientFunds.
+func Post(tx *gorm.DB, entry *models.JournalEntry) error {
+	return post(tx, entry, true)
+}
+
+// PostCharge enregistre une écriture imposée par la banque (intérêts, frais) : les débits
+// sont appliqués même s'ils dépassent le découvert autorisé
+func PostCharge(tx *gorm.DB, entry *models.JournalEntry) error {
+	return post(tx, entry, false)
+}
+
+func post(tx *gorm.DB, entry *models.JournalEntry, checkFunds bool) error {
+	if err := Validate(entry); err != nil {
+		return err
+	}
+
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+
+	// Appliquer les lignes dans l'ordre des comptes pour un verrouillage déterministe
+	postings := make([]models.Posting, len(entry.Postings))
+	copy(postings, entry.Postings)
+	sort.SliceStable(postings, func(i, j int) bool {
+		return accountIDOf(postings[i]) < accountIDOf(postings[j])
+	})
+
+	for _, posting := range postings {
+		if posting.AccountID == nil {
+			continue
+		}
+		if err := applyPosting(tx, posting, checkFunds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func accountIDOf(posting models.Posting) uint {
+	if posting.AccountID == nil {
+		return 0
+	}
+	return *posting.AccountID
+}
+
+// applyPosting met à jour le solde d'un compte client de façon atomique
+func applyPosting(tx *gorm.DB, posting models.Posting, checkFunds bool) error {
+	query := tx.Model(&models.Account{}).
+		Where("id = ? AND currency = ?", *posting.AccountID, posting.Currency)
+	checkFunds = checkFunds && posting.Amount.IsNegative()
+	if checkFunds {
+		query = query.Where("balance_minor - held_minor + credit_limit_minor + ? >= 0", posting.Amount.Amount)
+	}
+
+	result := query.Update("balance_minor", gorm.Expr("balance_minor + ?", posting.Amount.Amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if checkFunds {
+			return ErrInsufficientFunds
+		}
+		return ErrAccountCurrency
+	}
+	return nil
+}
+
+// Reconciliation compare le solde stocké d'un compte à celui dérivé de ses lignes d'écriture
+type Reconciliation struct {
+	AccountID     uint         `json:"account_id"`
+	StoredBalance models.Money `json:"stored_balance"`
+	PostedBalance models.Money `json:"posted_balance"`
+	Difference    models.Money `json:"difference"`
+	PostingsCount int64        `json:"postings_count"`
+	Balanced      bool         `json:"balanced"`
+}
+
+// PostedBalance calcule le solde d'un compte client à partir de ses lignes d'écriture
+func PostedBalance(db *gorm.DB, accountID uint, currency string) (models.Money, int64, error) {
+	var result struct {
+		Total int64
+		Count int64
+	}
+	err := db.Model(&models.Posting{}).
+		Select("COALESCE(SUM(amount_minor), 0) AS total, COUNT(*) AS count").
+		Where("account_id = ? AND currency = ?", accountID, currency).
+		Scan(&result).Error
+	if err != nil {
+		return models.Money{}, 0, err
+	}
+	return models.NewMoney(result.Total, currency), result.Count, nil
+}
+
+// BalanceAt retourne le solde d'un compte client juste avant at, d'après ses lignes d'écriture
+func BalanceAt(db *gorm.DB, accountID uint, currency string, at time.Time) (int64, error) {
+	var balance int64
+	err := db.Model(&models.Posting{}).
+		Select("COALESCE(SUM(amount_minor), 0)").
+		Where("account_id = ? AND currency = ? AND created_at < ?", accountID, currency, at).
+		Scan(&balance).Error
+	return balance, err
+}
+
+// Movement est une ligne d'écriture d'un compte client accompagnée du libellé de l'opération
+type Movement struct {
+	PostingID     uint
+	TransactionID *uint
+	Date          time.Time
+	Reference     string
+	Description   string
+	Amount        int64
+}
+
+// Movements retourne les lignes d'un compte client datées de [from, to), dans l'ordre
+// chronologique ; la référence et le libellé sont ceux de la transaction du compte, à
+// défaut ceux de l'écriture
+func Movements(db *gorm.DB, accountID uint, currency string, from, to time.Time) ([]Movement, error) {
+	var movements []Movement
+	err := db.Table("postings").
+		Select("postings.id AS posting_id, transactions.id AS transaction_id, postings.created_at AS date, "+
+			"COALESCE(transactions.reference, journal_entries.reference) AS reference, "+
+			"COALESCE(NULLIF(transactions.description, ''), journal_entries.description) AS description, "+
+			"postings.amount_minor AS amount").
+		Joins("JOIN journal_entries ON journal_entries.id = postings.journal_entry_id").
+		Joins("LEFT JOIN transactions ON transactions.journal_entry_id = postings.journal_entry_id "+
+			"AND transactions.account_id = postings.account_id AND transactions.deleted_at IS NULL").
+		Where("postings.account_id = ? AND postings.currency = ?", accountID, currency).
+		Where("postings.created_at >= ? AND postings.created_at < ?", from, to).
+		Order("postings.created_at ASC, postings.id ASC").
+		Scan(&movements).Error
+	return movements, err
+}
+
+// DailyBalances retourne le solde de clôture d'un compte client pour chaque jour de
+// [from, to), reconstitué à partir de ses lignes d'écriture ; from et to sont des débuts de journée
+func DailyBalances(db *gorm.DB, accountID uint, currency string, from, to time.Time) ([]int64, error) {
+	opening, err := BalanceAt(db, accountID, currency, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var postings []models.Posting
+	err = db.Where("account_id = ? AND currency = ? AND created_at >= ? AND created_at < ?", accountID, currency, from, to).
+		Order("created_at ASC, id ASC").
+		Find(&postings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var balances []int64
+	balance := opening
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		for len(postings) > 0 && postings[0].CreatedAt.Before(next) {
+			balance += postings[0].Amount.Amount
+			postings = postings[1:]
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// Reconcile rapproche le solde stocké d'un compte de la somme de ses lignes
+func Reconcile(db *gorm.DB, account models.Account) (Reconciliation, error) {
+	posted, count, err := PostedBalance(db, account.ID, account.Currency)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+
+	stored := models.NewMoney(account.Balance.Amount, account.Currency)
+	difference, err := stored.Sub(posted)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+
+	return Reconciliation{
+		AccountID:     account.ID,
+		StoredBalance: stored,
+		PostedBalance: posted,
+		Difference:    difference,
+		PostingsCount: count,
+		Balanced:      difference.IsZero(),
+	}, nil
+}
+
+// BackfillOpeningBalances crée une écriture d'ouverture pour les comptes qui ont un solde
+// mais aucune ligne d'écriture (comptes antérieurs au grand livre). Les comptes ayant
+// déjà des lignes ne sont jamais modifiés, afin de ne pas masquer un écart réel.
+func BackfillOpeningBalances(db *gorm.DB) (int, error) {
+	var accounts []models.Account
+	err := db.Where("balance_minor <> 0").
+		Where("NOT EXISTS (SELECT 1 FROM postings WHERE postings.account_id = accounts.id)").
+		Find(&accounts).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, account := range accounts {
+		balance := models.NewMoney(account.Balance.Amount, account.Currency)
+		entry := models.JournalEntry{
+			Reference:   fmt.Sprintf("OPENING-%d", account.ID),
+			Description: "Solde d'ouverture",
+			Postings: []models.Posting{
+				CustomerPosting(account.ID, balance),
+				SystemPosting(OpeningBalanceAccount, balance.Neg()),
+			},
+		}
+
+		// Le solde stocké est déjà correct : seules les lignes sont enregistrées
+		if err := Validate(&entry); err != nil {
+			return 0, err
+		}
+		if err := db.Create(&entry).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(accounts), nil
+}
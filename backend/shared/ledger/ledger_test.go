@@ -0,0 +1,81 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+
+	"banking-app/shared/models"
+)
+
+func TestValidate(t *testing.T) {
+	eur := func(amount int64) models.Money { return models.NewMoney(amount, "EUR") }
+	usd := func(amount int64) models.Money { return models.NewMoney(amount, "USD") }
+
+	tests := []struct {
+		name     string
+		postings []models.Posting
+		err      error
+	}{
+		{
+			name: "transfert équilibré",
+			postings: []models.Posting{
+				CustomerPosting(1, eur(-1000)),
+				CustomerPosting(2, eur(1000)),
+			},
+		},
+		{
+			name: "dépôt contre le compte externe",
+			postings: []models.Posting{
+				CustomerPosting(1, eur(500)),
+				SystemPosting(ExternalAccount, eur(-500)),
+			},
+		},
+		{
+			name: "équilibré par devise",
+			postings: []models.Posting{
+				CustomerPosting(1, eur(-1000)),
+				SystemPosting("system:fx", eur(1000)),
+				SystemPosting("system:fx", usd(-1100)),
+				CustomerPosting(2, usd(1100)),
+			},
+		},
+		{
+			name:     "une seule ligne",
+			postings: []models.Posting{CustomerPosting(1, eur(100))},
+			err:      ErrEmptyEntry,
+		},
+		{
+			name: "ligne nulle",
+			postings: []models.Posting{
+				CustomerPosting(1, eur(0)),
+				CustomerPosting(2, eur(0)),
+			},
+			err: ErrZeroPosting,
+		},
+		{
+			name: "déséquilibrée",
+			postings: []models.Posting{
+				CustomerPosting(1, eur(-1000)),
+				CustomerPosting(2, eur(999)),
+			},
+			err: ErrUnbalancedEntry,
+		},
+		{
+			name: "devises non compensées",
+			postings: []models.Posting{
+				CustomerPosting(1, eur(-1000)),
+				CustomerPosting(2, usd(1000)),
+			},
+			err: ErrUnbalancedEntry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&models.JournalEntry{Postings: tt.postings})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Validate() = %v, attendu %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+// Package limits applique les plafonds des sorties de fonds (débits et virements vers un
+// tiers) : montant par opération, cumuls du jour et du mois civils (UTC) et nombre
+// d'opérations sur une heure glissante. La banque fixe des plafonds par défaut par type de
+// compte et devise, qu'elle peut adapter pour un client ; le client peut les abaisser. Une
+// sortie de fonds depuis un compte sans plafond de la banque est refusée.
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+)
+
+// Plafonds, repris dans les codes d'erreur
+const (
+	KindPerTransaction = "per_transaction"
+	KindDaily          = "daily"
+	KindMonthly        = "monthly"
+	KindHourlyCount    = "hourly_count"
+)
+
+// ErrAboveBankLimit refuse un plafond client supérieur à celui de la banque
+var ErrAboveBankLimit = errors.New("un plafond ne peut pas dépasser celui fixé par la banque")
+
+// ErrNoBankLimit refuse une sortie de fonds depuis un compte dont le type et la devise n'ont
+// aucun plafond fixé par la banque : faute de plafond, l'opération n'est pas autorisée
+var ErrNoBankLimit = errors.New("aucun plafond n'est fixé par la banque pour ce type de compte et cette devise")
+
+// Limits regroupe les plafonds d'un compte ; un plafond nil n'est pas limité
+type Limits struct {
+	PerTransaction *models.Money `json:"per_transaction"`
+	Daily          *models.Money `json:"daily"`
+	Monthly        *models.Money `json:"monthly"`
+	HourlyCount    *int          `json:"hourly_count"`
+}
+
+// FromModel retourne les plafonds enregistrés dans limit
+func FromModel(limit *models.TransferLimit) Limits {
+	return Limits{
+		PerTransaction: limit.PerTransaction,
+		Daily:          limit.Daily,
+		Monthly:        limit.Monthly,
+		HourlyCount:    limit.HourlyCount,
+	}
+}
+
+// Apply enregistre les plafonds dans limit
+func (l Limits) Apply(limit *models.TransferLimit) {
+	limit.PerTransaction = l.PerTransaction
+	limit.Daily = l.Daily
+	limit.Monthly = l.Monthly
+	limit.HourlyCount = l.HourlyCount
+}
+
+// IsEmpty indique qu'aucun plafond n'est fixé
+func (l Limits) IsEmpty() bool {
+	return l.PerTransaction == nil && l.Daily == nil && l.Monthly == nil && l.HourlyCount == nil
+}
+
+// override retourne base où chaque plafond fixé par o remplace celui de base
+func override(base, o Limits) Limits {
+	if o.PerTransaction != nil {
+		base.PerTransaction = o.PerTransaction
+	}
+	if o.Daily != nil {
+		base.Daily = o.Daily
+	}
+	if o.Monthly != nil {
+		base.Monthly = o.Monthly
+	}
+	if o.HourlyCount != nil {
+		base.HourlyCount = o.HourlyCount
+	}
+	return base
+}
+
+// lower retourne, plafond par plafond, le plus bas de a et b
+func lower(a, b Limits) Limits {
+	return Limits{
+		PerTransaction: lowerMoney(a.PerTransaction, b.PerTransaction),
+		Daily:          lowerMoney(a.Daily, b.Daily),
+		Monthly:        lowerMoney(a.Monthly, b.Monthly),
+		HourlyCount:    lowerCount(a.HourlyCount, b.HourlyCount),
+	}
+}
+
+func lowerMoney(a, b *models.Money) *models.Money {
+	if a == nil || (b != nil && b.Amount < a.Amount) {
+		return b
+	}
+	return a
+}
+
+func lowerCount(a, b *int) *int {
+	if a == nil || (b != nil && *b < *a) {
+		return b
+	}
+	return a
+}
+
+// Set détaille les plafonds d'un compte : ceux de la banque, ceux choisis par le client et
+// les plafonds appliqués, le plus bas des deux
+type Set struct {
+	Bank      Limits `json:"bank"`
+	Customer  Limits `json:"customer"`
+	Effective Limits `json:"effective"`
+}
+
+// Resolve retourne les plafonds des comptes d'un type et d'une devise du client. Les plafonds
+// propres au client fixés par la banque remplacent, un à un, les plafonds par défaut.
+func Resolve(store *repository.Store, userID uint, accountType, currency string) (Set, error) {
+	rows, err := store.TransferLimits.ListFor(userID, accountType, currency)
+	if err != nil {
+		return Set{}, err
+	}
+	var defaults, bank, customer Limits
+	for i := range rows {
+		switch {
+		case rows[i].Source == models.LimitSourceCustomer:
+			customer = FromModel(&rows[i])
+		case rows[i].UserID == nil:
+			defaults = FromModel(&rows[i])
+		default:
+			bank = FromModel(&rows[i])
+		}
+	}
+	bank = override(defaults, bank)
+	return Set{Bank: bank, Customer: customer, Effective: lower(bank, customer)}, nil
+}
+
+// CheckCustomer vérifie que les plafonds choisis par le client ne dépassent pas ceux de la banque
+func CheckCustomer(bank, customer Limits) error {
+	above := func(kind, limit string) error {
+		return fmt.Errorf("%w (%s : %s)", ErrAboveBankLimit, label(kind), limit)
+	}
+	moneyAbove := func(bank, customer *models.Money) bool {
+		return bank != nil && customer != nil && customer.Amount > bank.Amount
+	}
+	switch {
+	case moneyAbove(bank.PerTransaction, customer.PerTransaction):
+		return above(KindPerTransaction, bank.PerTransaction.String())
+	case moneyAbove(bank.Daily, customer.Daily):
+		return above(KindDaily, bank.Daily.String())
+	case moneyAbove(bank.Monthly, customer.Monthly):
+		return above(KindMonthly, bank.Monthly.String())
+	case bank.HourlyCount != nil && customer.HourlyCount != nil && *customer.HourlyCount > *bank.HourlyCount:
+		return above(KindHourlyCount, strconv.Itoa(*bank.HourlyCount))
+	}
+	return nil
+}
+
+// Usage est la consommation des plafonds des comptes d'un type et d'une devise du client
+type Usage struct {
+	Daily       models.Money `json:"daily"`
+	Monthly     models.Money `json:"monthly"`
+	HourlyCount int          `json:"hourly_count"`
+	// Dates de remise à zéro des cumuls ; HourlyResetsAt est celle de la plus ancienne
+	// opération de l'heure écoulée, nil s'il n'y en a aucune
+	DailyResetsAt   time.Time  `json:"daily_resets_at"`
+	MonthlyResetsAt time.Time  `json:"monthly_resets_at"`
+	HourlyResetsAt  *time.Time `json:"hourly_resets_at"`
+}
+
+// windows retourne les débuts du jour et du mois civils (UTC) de now et de l'heure glissante
+func windows(now time.Time) (day, month, hour time.Time) {
+	year, m, d := now.UTC().Date()
+	day = time.Date(year, m, d, 0, 0, 0, 0, time.UTC)
+	month = time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+	return day, month, now.Add(-time.Hour)
+}
+
+// Consumed retourne la consommation à now des plafonds du compte : les plafonds valant pour
+// le client, elle cumule les sorties de tous ses comptes du même type et de la même devise
+func Consumed(store *repository.Store, account *models.Account, now time.Time) (Usage, error) {
+	day, month, hour := windows(now)
+	usage := Usage{
+		DailyResetsAt:   day.AddDate(0, 0, 1),
+		MonthlyResetsAt: month.AddDate(0, 1, 0),
+	}
+
+	daily, _, err := store.TransferLimits.SumUsage(account.UserID, account.AccountType, account.Currency, day)
+	if err != nil {
+		return Usage{}, err
+	}
+	monthly, _, err := store.TransferLimits.SumUsage(account.UserID, account.AccountType, account.Currency, month)
+	if err != nil {
+		return Usage{}, err
+	}
+	_, hourly, err := store.TransferLimits.SumUsage(account.UserID, account.AccountType, account.Currency, hour)
+	if err != nil {
+		return Usage{}, err
+	}
+	usage.Daily = models.NewMoney(daily, account.Currency)
+	usage.Monthly = models.NewMoney(monthly, account.Currency)
+	usage.HourlyCount = hourly
+
+	if hourly > 0 {
+		first, err := store.TransferLimits.FirstUsage(account.UserID, account.AccountType, account.Currency, hour)
+		if err != nil {
+			return Usage{}, err
+		}
+		resetsAt := first.CreatedAt.Add(time.Hour)
+		usage.HourlyResetsAt = &resetsAt
+	}
+	return usage, nil
+}
+
+// ExceededError signale une opération qui dépasserait un plafond
+type ExceededError struct {
+	Kind      string
+	Limit     string     // plafond dépassé ("5000.00 EUR", "20")
+	Remaining string     // reste disponible sur la période, vide pour le plafond par opération
+	ResetsAt  *time.Time // remise à zéro du cumul, nil pour le plafond par opération
+}
+
+// Code retourne le code d'erreur du plafond dépassé (TRANSFER_LIMIT_DAILY...)
+func (e *ExceededError) Code() string {
+	switch e.Kind {
+	case KindPerTransaction:
+		return "TRANSFER_LIMIT_PER_TRANSACTION"
+	case KindDaily:
+		return "TRANSFER_LIMIT_DAILY"
+	case KindMonthly:
+		return "TRANSFER_LIMIT_MONTHLY"
+	}
+	return "TRANSFER_LIMIT_HOURLY_COUNT"
+}
+
+func (e *ExceededError) Error() string {
+	switch e.Kind {
+	case KindPerTransaction:
+		return "Le montant dépasse le plafond par opération (" + e.Limit + ")"
+	case KindHourlyCount:
+		if e.ResetsAt == nil {
+			return "Nombre maximal d'opérations par heure atteint (" + e.Limit + ")"
+		}
+		return fmt.Sprintf("Nombre maximal d'opérations par heure atteint (%s), réessayez à partir de %s",
+			e.Limit, e.ResetsAt.UTC().Format("15:04 UTC"))
+	}
+	return fmt.Sprintf("Plafond %s dépassé (%s) : %s disponibles jusqu'au %s",
+		label(e.Kind), e.Limit, e.Remaining, e.ResetsAt.UTC().Format("02/01/2006 15:04 UTC"))
+}
+
+// Check vérifie qu'une sortie de amount respecte les plafonds, compte tenu de la consommation
+func Check(limits Limits, usage Usage, amount models.Money) error {
+	if limits.PerTransaction != nil && amount.Amount > limits.PerTransaction.Amount {
+		return &ExceededError{Kind: KindPerTransaction, Limit: limits.PerTransaction.String()}
+	}
+	if limits.HourlyCount != nil && usage.HourlyCount >= *limits.HourlyCount {
+		// Avec un plafond nul, aucune opération n'est possible et HourlyResetsAt vaut nil
+		return &ExceededError{Kind: KindHourlyCount, Limit: strconv.Itoa(*limits.HourlyCount), ResetsAt: usage.HourlyResetsAt}
+	}
+	if limits.Daily != nil && usage.Daily.Amount+amount.Amount > limits.Daily.Amount {
+		return &ExceededError{Kind: KindDaily, Limit: limits.Daily.String(),
+			Remaining: remaining(*limits.Daily, usage.Daily).String(), ResetsAt: &usage.DailyResetsAt}
+	}
+	if limits.Monthly != nil && usage.Monthly.Amount+amount.Amount > limits.Monthly.Amount {
+		return &ExceededError{Kind: KindMonthly, Limit: limits.Monthly.String(),
+			Remaining: remaining(*limits.Monthly, usage.Monthly).String(), ResetsAt: &usage.MonthlyResetsAt}
+	}
+	return nil
+}
+
+// remaining retourne le reste disponible d'un cumul, nul une fois le plafond atteint
+func remaining(limit, consumed models.Money) models.Money {
+	return models.NewMoney(max(limit.Amount-consumed.Amount, 0), limit.Currency)
+}
+
+// Outflow est une sortie de fonds soumise aux plafonds
+type Outflow struct {
+	Amount    models.Money
+	Reference string
+	// Batch est la référence du lot remis par fichier dont l'opération fait partie : les
+	// virements d'un lot sont imputés sur les cumuls mais ne comptent que pour une opération
+	// et ne sont pas refusés par le plafond horaire
+	Batch string
+}
+
+// Verify vérifie, sans l'imputer, que la sortie de fonds respecterait les plafonds du compte
+func Verify(store *repository.Store, account *models.Account, outflow Outflow, now time.Time) error {
+	return verify(store, account, outflow, now)
+}
+
+// Authorize vérifie que la sortie de fonds respecte les plafonds du compte et l'impute sur
+// leur consommation. Elle s'appelle dans la transaction de l'opération, après le verrouillage
+// des comptes : elle verrouille le client, si bien que deux opérations concurrentes, même
+// depuis deux de ses comptes, ne dépassent pas ensemble un plafond.
+func Authorize(tx *repository.Store, account *models.Account, outflow Outflow, now time.Time) error {
+	if _, err := tx.Users.LockByID(account.UserID); err != nil {
+		return err
+	}
+	if err := verify(tx, account, outflow, now); err != nil {
+		return err
+	}
+	reference := outflow.Reference
+	if outflow.Batch != "" {
+		reference = outflow.Batch
+	}
+	return tx.TransferLimits.CreateUsage(&models.TransferLimitUsage{
+		AccountID: account.ID,
+		Amount:    outflow.Amount,
+		Currency:  account.Currency,
+		Reference: reference,
+		CreatedAt: now,
+	})
+}
+
+// verify contrôle la sortie de fonds ; sans plafond de la banque, elle est refusée
+// (ErrNoBankLimit)
+func verify(store *repository.Store, account *models.Account, outflow Outflow, now time.Time) error {
+	set, err := Resolve(store, account.UserID, account.AccountType, account.Currency)
+	if err != nil {
+		return err
+	}
+	if set.Bank.IsEmpty() {
+		return ErrNoBankLimit
+	}
+	usage, err := Consumed(store, account, now)
+	if err != nil {
+		return err
+	}
+	limits := set.Effective
+	if outflow.Batch != "" {
+		limits.HourlyCount = nil
+	}
+	return Check(limits, usage, outflow.Amount)
+}
+
+// Remaining est le disponible d'un compte ; un disponible nil n'est pas limité
+type Remaining struct {
+	// PerTransaction est le montant maximal d'une prochaine opération, tous plafonds confondus
+	PerTransaction *models.Money `json:"per_transaction"`
+	Daily          *models.Money `json:"daily"`
+	Monthly        *models.Money `json:"monthly"`
+	HourlyCount    *int          `json:"hourly_count"`
+}
+
+// Status détaille les plafonds d'un compte, leur consommation et le disponible
+type Status struct {
+	AccountID   uint      `json:"account_id"`
+	AccountType string    `json:"account_type"`
+	Currency    string    `json:"currency"`
+	Limits      Set       `json:"limits"`
+	Consumed    Usage     `json:"consumed"`
+	Remaining   Remaining `json:"remaining"`
+}
+
+// AccountStatus retourne les plafonds du compte, leur consommation et le disponible à now
+func AccountStatus(store *repository.Store, account *models.Account, now time.Time) (Status, error) {
+	set, err := Resolve(store, account.UserID, account.AccountType, account.Currency)
+	if err != nil {
+		return Status{}, err
+	}
+	usage, err := Consumed(store, account, now)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{
+		AccountID:   account.ID,
+		AccountType: account.AccountType,
+		Currency:    account.Currency,
+		Limits:      set,
+		Consumed:    usage,
+	}
+	limits := set.Effective
+	if limits.Daily != nil {
+		daily := remaining(*limits.Daily, usage.Daily)
+		status.Remaining.Daily = &daily
+	}
+	if limits.Monthly != nil {
+		monthly := remaining(*limits.Monthly, usage.Monthly)
+		status.Remaining.Monthly = &monthly
+	}
+	if limits.HourlyCount != nil {
+		count := max(*limits.HourlyCount-usage.HourlyCount, 0)
+		status.Remaining.HourlyCount = &count
+	}
+	next := lowerMoney(lowerMoney(limits.PerTransaction, status.Remaining.Daily), status.Remaining.Monthly)
+	if status.Remaining.HourlyCount != nil && *status.Remaining.HourlyCount == 0 {
+		next = &models.Money{Currency: account.Currency}
+	}
+	status.Remaining.PerTransaction = next
+	return status, nil
+}
+
+// label retourne le nom d'un plafond dans les messages
+func label(kind string) string {
+	switch kind {
+	case KindPerTransaction:
+		return "par opération"
+	case KindDaily:
+		return "journalier"
+	case KindMonthly:
+		return "mensuel"
+	}
+	return "d'opérations par heure"
+}
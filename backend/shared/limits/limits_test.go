@@ -0,0 +1,226 @@
+package limits
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/testutil"
+)
+
+func eur(amount int64) *models.Money {
+	money := models.NewMoney(amount, "EUR")
+	return &money
+}
+
+func count(n int) *int { return &n }
+
+// clearLimits retire les plafonds par défaut créés avec la base de test
+func clearLimits(t *testing.T, store *repository.Store) {
+	t.Helper()
+
+	if err := store.DB.Where("1 = 1").Delete(&models.TransferLimit{}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC)
+	limits := Limits{PerTransaction: eur(50000), Daily: eur(100000), Monthly: eur(300000), HourlyCount: count(3)}
+	hourly := now.Add(20 * time.Minute)
+	usage := Usage{
+		Daily:           *eur(60000),
+		Monthly:         *eur(250000),
+		HourlyCount:     2,
+		DailyResetsAt:   time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC),
+		MonthlyResetsAt: time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+		HourlyResetsAt:  &hourly,
+	}
+
+	tests := []struct {
+		name   string
+		amount int64
+		change func(*Usage)
+		kind   string
+	}{
+		{"dans les plafonds", 40000, nil, ""},
+		{"plafond par opération", 50001, nil, KindPerTransaction},
+		{"plafond journalier", 40001, nil, KindDaily},
+		{"plafond mensuel", 30000, func(u *Usage) { u.Monthly = *eur(280000) }, KindMonthly},
+		{"nombre d'opérations", 100, func(u *Usage) { u.HourlyCount = 3 }, KindHourlyCount},
+	}
+	for _, tt := range tests {
+		current := usage
+		if tt.change != nil {
+			tt.change(&current)
+		}
+		err := Check(limits, current, *eur(tt.amount))
+		var exceeded *ExceededError
+		switch {
+		case tt.kind == "" && err != nil:
+			t.Errorf("%s: err = %v", tt.name, err)
+		case tt.kind != "" && (!errors.As(err, &exceeded) || exceeded.Kind != tt.kind):
+			t.Errorf("%s: err = %v, attendu un dépassement %s", tt.name, err, tt.kind)
+		}
+	}
+
+	err := Check(limits, usage, *eur(40001))
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Code() != "TRANSFER_LIMIT_DAILY" || exceeded.Remaining != "400.00 EUR" || !exceeded.ResetsAt.Equal(usage.DailyResetsAt) {
+		t.Errorf("dépassement journalier = %+v", exceeded)
+	}
+	if err := Check(Limits{}, usage, *eur(1 << 40)); err != nil {
+		t.Errorf("sans plafond: err = %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	store := testutil.NewStore(t)
+	user, _ := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	clearLimits(t, store)
+
+	for _, limit := range []models.TransferLimit{
+		{AccountType: "checking", Currency: "EUR", Source: models.LimitSourceBank, PerTransaction: eur(500000), Daily: eur(1000000), HourlyCount: count(20)},
+		{UserID: &user.ID, AccountType: "checking", Currency: "EUR", Source: models.LimitSourceBank, Daily: eur(2000000)},
+		{UserID: &user.ID, AccountType: "checking", Currency: "EUR", Source: models.LimitSourceCustomer, PerTransaction: eur(100000), Daily: eur(5000000)},
+		{AccountType: "savings", Currency: "EUR", Source: models.LimitSourceBank, Daily: eur(1)},
+	} {
+		if err := store.TransferLimits.Save(&limit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set, err := Resolve(store, user.ID, "checking", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	effective := set.Effective
+	if set.Bank.Daily.Amount != 2000000 || set.Bank.PerTransaction.Amount != 500000 ||
+		effective.PerTransaction.Amount != 100000 || effective.Daily.Amount != 2000000 ||
+		effective.Monthly != nil || *effective.HourlyCount != 20 || effective.Daily.Currency != "EUR" {
+		t.Errorf("plafonds du client = %+v", set)
+	}
+
+	set, err = Resolve(store, other.ID, "checking", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Effective.Daily.Amount != 1000000 || !set.Customer.IsEmpty() {
+		t.Errorf("plafonds par défaut = %+v", set)
+	}
+
+	if err := CheckCustomer(set.Bank, Limits{Daily: eur(1000001)}); !errors.Is(err, ErrAboveBankLimit) {
+		t.Errorf("plafond client supérieur: err = %v", err)
+	}
+	if err := CheckCustomer(set.Bank, Limits{Daily: eur(1000000), Monthly: eur(1)}); err != nil {
+		t.Errorf("plafond client inférieur: err = %v", err)
+	}
+}
+
+func TestAuthorizeCountsBatchOnce(t *testing.T) {
+	store := testutil.NewStore(t)
+	user, _ := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 0)
+	clearLimits(t, store)
+	limit := models.TransferLimit{AccountType: "checking", Currency: "EUR", Source: models.LimitSourceBank, HourlyCount: count(2)}
+	if err := store.TransferLimits.Save(&limit); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for _, outflow := range []Outflow{
+		{Amount: *eur(100), Reference: "TXN-1"},
+		{Amount: *eur(100), Reference: "TXN-2", Batch: "LOT-1"},
+		{Amount: *eur(100), Reference: "TXN-3", Batch: "LOT-1"},
+	} {
+		if err := Authorize(store, &account, outflow, now); err != nil {
+			t.Fatalf("%s: %v", outflow.Reference, err)
+		}
+	}
+
+	usage, err := Consumed(store, &account, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.HourlyCount != 2 || usage.Daily.Amount != 300 || usage.HourlyResetsAt == nil {
+		t.Errorf("consommation = %+v", usage)
+	}
+	err = Authorize(store, &account, Outflow{Amount: *eur(100), Reference: "TXN-4"}, now)
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Kind != KindHourlyCount {
+		t.Errorf("troisième opération: err = %v, attendu un dépassement du nombre d'opérations", err)
+	}
+
+	status, err := AccountStatus(store, &account, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *status.Remaining.HourlyCount != 0 || status.Remaining.PerTransaction == nil || !status.Remaining.PerTransaction.IsZero() {
+		t.Errorf("disponible = %+v", status.Remaining)
+	}
+}
+
+// TestUsageIsSharedByCustomerAccounts vérifie que les cumuls portent sur tous les comptes du
+// client de même type et de même devise, et non sur chaque compte
+func TestUsageIsSharedByCustomerAccounts(t *testing.T) {
+	store := testutil.NewStore(t)
+	user, _ := testutil.CreateUser(t, store)
+	other, _ := testutil.CreateUser(t, store)
+	first := testutil.CreateAccount(t, store, user.ID, 0)
+	second := testutil.CreateAccount(t, store, user.ID, 0)
+	stranger := testutil.CreateAccount(t, store, other.ID, 0)
+	clearLimits(t, store)
+	limit := models.TransferLimit{AccountType: "checking", Currency: "EUR", Source: models.LimitSourceBank, Daily: eur(500)}
+	if err := store.TransferLimits.Save(&limit); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if err := Authorize(store, &first, Outflow{Amount: *eur(300), Reference: "TXN-1"}, now); err != nil {
+		t.Fatal(err)
+	}
+	err := Authorize(store, &second, Outflow{Amount: *eur(300), Reference: "TXN-2"}, now)
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Kind != KindDaily || exceeded.Remaining != eur(200).String() {
+		t.Errorf("second compte: err = %v, attendu un dépassement du plafond journalier", err)
+	}
+	// Les sorties d'un autre client ne sont pas comptées
+	if err := Authorize(store, &stranger, Outflow{Amount: *eur(500), Reference: "TXN-3"}, now); err != nil {
+		t.Errorf("autre client: %v", err)
+	}
+
+	status, err := AccountStatus(store, &second, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Consumed.Daily.Amount != 300 || status.Remaining.Daily.Amount != 200 {
+		t.Errorf("consommation du second compte = %+v, disponible %+v", status.Consumed, status.Remaining)
+	}
+}
+
+// TestDefaultLimits vérifie que la base est créée avec les plafonds par défaut et qu'une portée
+// n'a qu'un plafond, y compris pour les plafonds par défaut dont user_id est NULL
+func TestDefaultLimits(t *testing.T) {
+	store := testutil.NewStore(t)
+	user, _ := testutil.CreateUser(t, store)
+
+	set, err := Resolve(store, user.ID, "checking", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Bank.PerTransaction == nil || set.Bank.PerTransaction.Amount != 500000 || set.Bank.Daily.Amount != 1000000 || *set.Bank.HourlyCount != 20 {
+		t.Errorf("plafonds par défaut = %+v", set.Bank)
+	}
+
+	duplicate := models.TransferLimit{AccountType: "checking", Currency: "EUR", Source: models.LimitSourceBank, Daily: eur(1)}
+	if err := store.TransferLimits.Save(&duplicate); err == nil {
+		t.Error("second plafond par défaut accepté pour la même portée")
+	}
+	own := models.TransferLimit{UserID: &user.ID, AccountType: "checking", Currency: "EUR", Source: models.LimitSourceBank, Daily: eur(1)}
+	if err := store.TransferLimits.Save(&own); err != nil {
+		t.Errorf("plafond propre au client: %v", err)
+	}
+}
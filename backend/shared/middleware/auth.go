@@ -4,16 +4,17 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
-	"banking-app/shared/database"
 	"banking-app/shared/models"
+	"banking-app/shared/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware vérifie le token JWT
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware vérifie le token JWT, la session associée (non révoquée) et charge l'utilisateur
+func AuthMiddleware(users repository.UserRepo, sessions repository.SessionRepo) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -59,7 +60,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Extraire les claims
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
 			userID, ok := claims["user_id"].(float64)
-			if !ok {
+			sessionID, hasSession := claims["sid"].(string)
+			if !ok || !hasSession {
 				c.JSON(http.StatusUnauthorized, models.APIResponse{
 					Success: false,
 					Message: "Claims de token invalides",
@@ -69,21 +71,47 @@ func AuthMiddleware() gin.HandlerFunc {
 				return
 			}
 
-			// Vérifier que l'utilisateur existe toujours
-			var user models.User
-			if err := database.GetDB().First(&user, uint(userID)).Error; err != nil {
+			// Vérifier que la session n'a pas été révoquée (déconnexion, changement de mot de passe)
+			session, err := sessions.FindByID(sessionID)
+			if err != nil || session.UserID != uint(userID) || !session.IsActive(time.Now()) {
 				c.JSON(http.StatusUnauthorized, models.APIResponse{
 					Success: false,
-					Message: "Utilisateur non trouvé",
+					Message: "Session expirée ou révoquée",
 					Error:   "Unauthorized",
 				})
 				c.Abort()
 				return
 			}
 
-			// Ajouter l'utilisateur au contexte
-			c.Set("user", user)
+			// Vérifier que l'utilisateur existe toujours et n'a pas été désactivé
+			user, err := users.FindByID(uint(userID))
+			if err != nil || !user.IsActive {
+				c.JSON(http.StatusUnauthorized, models.APIResponse{
+					Success: false,
+					Message: "Utilisateur non trouvé ou désactivé",
+					Error:   "Unauthorized",
+				})
+				c.Abort()
+				return
+			}
+
+			// Un token émis avant un changement de rôle porte des permissions périmées
+			role, _ := claims["role"].(string)
+			if role != user.Role {
+				c.JSON(http.StatusUnauthorized, models.APIResponse{
+					Success: false,
+					Message: "Rôle modifié, veuillez renouveler votre token",
+					Error:   "Unauthorized",
+				})
+				c.Abort()
+				return
+			}
+
+			// Ajouter l'utilisateur et ses permissions au contexte
+			c.Set("user", *user)
 			c.Set("user_id", uint(userID))
+			c.Set("session_id", sessionID)
+			c.Set("permissions", permissionsFromClaims(claims))
 		} else {
 			c.JSON(http.StatusUnauthorized, models.APIResponse{
 				Success: false,
@@ -97,3 +125,15 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// permissionsFromClaims lit la liste des permissions embarquées dans le token
+func permissionsFromClaims(claims jwt.MapClaims) []string {
+	values, _ := claims["permissions"].([]interface{})
+	permissions := make([]string, 0, len(values))
+	for _, value := range values {
+		if permission, ok := value.(string); ok {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"banking-app/shared/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// IdempotencyHeader est l'en-tête utilisé par les clients pour rejouer une requête sans effet de bord
+const IdempotencyHeader = "Idempotency-Key"
+
+// defaultIdempotencyTTL est la durée de rétention par défaut des clés d'idempotence
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// idempotencyWriter capture la réponse envoyée au client pour pouvoir la rejouer
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyTTL lit la durée de rétention depuis IDEMPOTENCY_KEY_TTL
+func idempotencyTTL() time.Duration {
+	if value := os.Getenv("IDEMPOTENCY_KEY_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err == nil && ttl > 0 {
+			return ttl
+		}
+		log.Printf("IDEMPOTENCY_KEY_TTL invalide (%s), utilisation de %s", value, defaultIdempotencyTTL)
+	}
+	return defaultIdempotencyTTL
+}
+
+// requestFingerprint calcule l'empreinte d'une requête (méthode, chemin avec sa chaîne de
+// requête, et corps)
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// IdempotencyMiddleware rejoue la réponse d'origine lorsqu'une requête est renvoyée
+// avec le même en-tête Idempotency-Key. Doit être placé après AuthMiddleware.
+func IdempotencyMiddleware(db *gorm.DB) gin.HandlerFunc {
+	ttl := idempotencyTTL()
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Clé d'idempotence trop longue (255 caractères maximum)",
+				Error:   "Bad Request",
+			})
+			c.Abort()
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Utilisateur non authentifié",
+				Error:   "Unauthorized",
+			})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Impossible de lire le corps de la requête",
+				Error:   "Bad Request",
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.RequestURI(), body)
+
+		// Les clés expirées peuvent être réutilisées
+		db.Where("user_id = ? AND idempotency_key = ? AND expires_at <= ?", userID, key, time.Now()).
+			Delete(&models.IdempotencyKey{})
+
+		record := models.IdempotencyKey{
+			UserID:      userID.(uint),
+			Key:         key,
+			RequestHash: fingerprint,
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+
+		// L'index unique (user_id, idempotency_key) garantit qu'une seule requête s'exécute
+		if err := db.Create(&record).Error; err != nil {
+			var existing models.IdempotencyKey
+			if err := db.Where("user_id = ? AND idempotency_key = ?", userID, key).
+				First(&existing).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, models.APIResponse{
+					Success: false,
+					Message: "Erreur lors de la vérification de la clé d'idempotence",
+					Error:   "Internal Server Error",
+				})
+				c.Abort()
+				return
+			}
+
+			if existing.RequestHash != fingerprint {
+				c.JSON(http.StatusConflict, models.APIResponse{
+					Success: false,
+					Message: "Clé d'idempotence déjà utilisée pour une requête différente",
+					Error:   "Conflict",
+				})
+				c.Abort()
+				return
+			}
+
+			if existing.ResponseStatus == 0 {
+				c.JSON(http.StatusConflict, models.APIResponse{
+					Success: false,
+					Message: "Une requête avec cette clé d'idempotence est en cours de traitement",
+					Error:   "Conflict",
+				})
+				c.Abort()
+				return
+			}
+
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(existing.ResponseStatus, "application/json; charset=utf-8", []byte(existing.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		// Une panique du handler libère la clé, qui resterait sinon « en cours de traitement »
+		// jusqu'à son expiration
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				db.Delete(&record)
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+
+		// Les erreurs serveur ne sont pas mémorisées afin que le client puisse réessayer
+		if writer.Status() >= http.StatusInternalServerError {
+			db.Delete(&record)
+			return
+		}
+
+		if err := db.Model(&record).Updates(map[string]interface{}{
+			"response_status": writer.Status(),
+			"response_body":   writer.body.String(),
+		}).Error; err != nil {
+			log.Printf("Erreur lors de l'enregistrement de la clé d'idempotence %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotentRouter expose des routes protégées par IdempotencyMiddleware qui comptent leurs
+// exécutions ; l'utilisateur est lu dans l'en-tête X-User et le statut dans le corps, un
+// statut négatif faisant paniquer le handler
+func idempotentRouter(t *testing.T) (*gin.Engine, *int, func(key string) time.Time) {
+	t.Helper()
+
+	store := testutil.NewStore(t)
+	executions := 0
+	r := gin.New()
+	r.Use(gin.Recovery(), func(c *gin.Context) {
+		if c.GetHeader("X-User") == "2" {
+			c.Set("user_id", uint(2))
+		} else {
+			c.Set("user_id", uint(1))
+		}
+	})
+	handler := func(c *gin.Context) {
+		executions++
+		var request struct {
+			Status int `json:"status"`
+		}
+		c.ShouldBindJSON(&request)
+		if request.Status < 0 {
+			panic("échec du handler")
+		}
+		c.JSON(request.Status, models.APIResponse{Success: request.Status < 400, Data: executions})
+	}
+	r.POST("/debit", IdempotencyMiddleware(store.DB), handler)
+	r.POST("/accounts/:id/debit", IdempotencyMiddleware(store.DB), handler)
+
+	expiresAt := func(key string) time.Time {
+		var record models.IdempotencyKey
+		if err := store.DB.Where("idempotency_key = ?", key).First(&record).Error; err != nil {
+			t.Fatalf("clé %s: %v", key, err)
+		}
+		return record.ExpiresAt
+	}
+	return r, &executions, expiresAt
+}
+
+func sendIdempotent(router http.Handler, key, user, body string) *httptest.ResponseRecorder {
+	return sendIdempotentTo(router, "/debit", key, user, body)
+}
+
+func sendIdempotentTo(router http.Handler, path, key, user, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(IdempotencyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysOriginalResponse(t *testing.T) {
+	router, executions, expiresAt := idempotentRouter(t)
+
+	first := sendIdempotent(router, "key-1", "1", `{"status":201}`)
+	replay := sendIdempotent(router, "key-1", "1", `{"status":201}`)
+	if first.Code != http.StatusCreated || replay.Code != http.StatusCreated || *executions != 1 {
+		t.Fatalf("statuts = %d / %d, exécutions = %d, attendu 201 / 201 et 1", first.Code, replay.Code, *executions)
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" || replay.Body.String() != first.Body.String() {
+		t.Errorf("réponse rejouée = %q, attendu %q", replay.Body.String(), first.Body.String())
+	}
+	if ttl := time.Until(expiresAt("key-1")); ttl < 23*time.Hour || ttl > 24*time.Hour {
+		t.Errorf("rétention = %s, attendu 24 h", ttl)
+	}
+
+	if rec := sendIdempotent(router, "key-1", "1", `{"status":202}`); rec.Code != http.StatusConflict {
+		t.Errorf("corps différent: statut = %d, attendu 409", rec.Code)
+	}
+	// Les clés sont propres à chaque utilisateur
+	if rec := sendIdempotent(router, "key-1", "2", `{"status":202}`); rec.Code != http.StatusAccepted {
+		t.Errorf("même clé, autre utilisateur: statut = %d, attendu 202", rec.Code)
+	}
+	// Sans clé, chaque requête est exécutée
+	sendIdempotent(router, "", "1", `{"status":201}`)
+	sendIdempotent(router, "", "1", `{"status":201}`)
+	if *executions != 4 {
+		t.Errorf("exécutions = %d, attendu 4", *executions)
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	router, executions, _ := idempotentRouter(t)
+
+	if rec := sendIdempotent(router, "key-500", "1", `{"status":500}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("statut = %d, attendu 500", rec.Code)
+	}
+	// Une erreur serveur libère la clé : le client peut réessayer avec la même clé
+	if rec := sendIdempotent(router, "key-500", "1", `{"status":500}`); rec.Code != http.StatusInternalServerError || *executions != 2 {
+		t.Errorf("nouvel essai: statut = %d, exécutions = %d, attendu 500 et 2", rec.Code, *executions)
+	}
+	// Une erreur client est mémorisée comme toute autre réponse
+	sendIdempotent(router, "key-400", "1", `{"status":400}`)
+	if rec := sendIdempotent(router, "key-400", "1", `{"status":400}`); rec.Header().Get("Idempotent-Replayed") != "true" || *executions != 3 {
+		t.Errorf("erreur client rejouée: exécutions = %d, attendu 3", *executions)
+	}
+}
+
+func TestIdempotencyKeyExpiry(t *testing.T) {
+	t.Setenv("IDEMPOTENCY_KEY_TTL", "1ms")
+	router, executions, _ := idempotentRouter(t)
+
+	sendIdempotent(router, "key-ttl", "1", `{"status":201}`)
+	time.Sleep(5 * time.Millisecond)
+	rec := sendIdempotent(router, "key-ttl", "1", `{"status":202}`)
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Idempotent-Replayed") != "" || *executions != 2 {
+		t.Errorf("clé expirée: statut = %d, exécutions = %d, attendu 202 et 2", rec.Code, *executions)
+	}
+
+	if rec := sendIdempotent(router, strings.Repeat("k", 256), "1", `{"status":201}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("clé trop longue: statut = %d, attendu 400", rec.Code)
+	}
+}
+
+func TestIdempotencyKeyIsBoundToRequestPath(t *testing.T) {
+	router, executions, _ := idempotentRouter(t)
+
+	if rec := sendIdempotentTo(router, "/accounts/1/debit", "key-path", "1", `{"status":201}`); rec.Code != http.StatusCreated {
+		t.Fatalf("statut = %d, attendu 201", rec.Code)
+	}
+	// Même route, même corps mais autre ressource ou autre chaîne de requête : requête différente
+	for _, path := range []string{"/accounts/2/debit", "/accounts/1/debit?dry_run=1"} {
+		if rec := sendIdempotentTo(router, path, "key-path", "1", `{"status":201}`); rec.Code != http.StatusConflict {
+			t.Errorf("%s: statut = %d, attendu 409", path, rec.Code)
+		}
+	}
+	if rec := sendIdempotentTo(router, "/accounts/1/debit", "key-path", "1", `{"status":201}`); rec.Header().Get("Idempotent-Replayed") != "true" || *executions != 1 {
+		t.Errorf("même chemin: rejeu attendu, exécutions = %d", *executions)
+	}
+}
+
+func TestIdempotencyReleasesKeyAfterPanic(t *testing.T) {
+	router, executions, _ := idempotentRouter(t)
+
+	if rec := sendIdempotent(router, "key-panic", "1", `{"status":-1}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("panique: statut = %d, attendu 500", rec.Code)
+	}
+	// La clé n'est pas restée « en cours de traitement » : la requête est exécutée de nouveau
+	if rec := sendIdempotent(router, "key-panic", "1", `{"status":-1}`); rec.Code != http.StatusInternalServerError || *executions != 2 {
+		t.Errorf("nouvel essai: statut = %d, exécutions = %d, attendu 500 et 2", rec.Code, *executions)
+	}
+}
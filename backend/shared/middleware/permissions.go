@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+
+	"banking-app/shared/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission réserve l'accès aux utilisateurs dont le token porte toutes les
+// permissions indiquées. Doit être placé après AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("permissions")
+		granted, ok := value.([]string)
+		if !exists || !ok {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Utilisateur non authentifié",
+				Error:   "Unauthorized",
+			})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !containsPermission(granted, permission) {
+				c.JSON(http.StatusForbidden, models.APIResponse{
+					Success: false,
+					Message: "Permission requise: " + permission,
+					Error:   "Forbidden",
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func containsPermission(granted []string, permission string) bool {
+	for _, p := range granted {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
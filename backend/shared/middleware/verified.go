@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"banking-app/shared/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail refuse l'accès aux utilisateurs dont l'adresse email n'est pas vérifiée.
+// Doit être placé après AuthMiddleware.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("user")
+		user, ok := value.(models.User)
+		if !exists || !ok {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Utilisateur non authentifié",
+				Error:   "Unauthorized",
+			})
+			c.Abort()
+			return
+		}
+
+		if user.EmailVerifiedAt == nil {
+			c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Message: "Veuillez vérifier votre adresse email avant d'effectuer un virement",
+				Error:   "Forbidden",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Beneficiary est un bénéficiaire enregistré par un client : un compte de la banque, désigné
+// par son IBAN, auquel il peut ensuite virer par beneficiary_id. Jusqu'à TrustedAt (fin du
+// délai de carence), les virements de gros montants vers ce bénéficiaire sont refusés.
+type Beneficiary struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"type:bigint unsigned;not null;uniqueIndex:idx_beneficiaries_user_iban"`
+	Nickname  string `json:"nickname" gorm:"type:varchar(50);not null"`
+	IBAN      string `json:"iban" gorm:"type:varchar(34);not null;uniqueIndex:idx_beneficiaries_user_iban"`
+	AccountID uint   `json:"-" gorm:"type:bigint unsigned;not null"` // compte désigné par l'IBAN
+	Currency  string `json:"currency" gorm:"type:varchar(3);not null"`
+	// TrustedAt est la fin du délai de carence ; immédiate pour un compte du client lui-même
+	TrustedAt time.Time `json:"trusted_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
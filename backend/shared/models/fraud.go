@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Issues du contrôle anti-fraude d'un virement
+const (
+	FraudOutcomeAllow  = "allow"  // exécuté immédiatement
+	FraudOutcomeReview = "review" // mis en attente de vérification
+	FraudOutcomeBlock  = "block"  // refusé
+)
+
+// Statuts d'une décision anti-fraude
+const (
+	FraudStatusAllowed  = "allowed"
+	FraudStatusPending  = "pending"  // en attente de vérification
+	FraudStatusReleased = "released" // libéré et exécuté par un administrateur
+	FraudStatusRejected = "rejected" // rejeté par un administrateur
+	FraudStatusBlocked  = "blocked"
+)
+
+// FraudDecision trace le contrôle anti-fraude d'un virement : le score total des règles
+// déclenchées (Hits), l'issue qui en découle et, pour un virement mis en attente, sa
+// vérification par un administrateur
+type FraudDecision struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	UserID        uint           `json:"user_id" gorm:"type:bigint unsigned;not null;index"`
+	FromAccountID uint           `json:"from_account_id" gorm:"type:bigint unsigned;not null"`
+	ToAccountID   uint           `json:"to_account_id" gorm:"type:bigint unsigned;not null"`
+	BeneficiaryID *uint          `json:"beneficiary_id,omitempty" gorm:"type:bigint unsigned"`
+	Amount        Money          `json:"amount" gorm:"column:amount_minor;type:bigint;not null"`
+	Currency      string         `json:"currency" gorm:"type:varchar(3);not null"`
+	Description   string         `json:"description,omitempty"`
+	Reference     string         `json:"reference" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Score         int            `json:"score" gorm:"not null"`
+	Outcome       string         `json:"outcome" gorm:"type:varchar(10);not null"`
+	Status        string         `json:"status" gorm:"type:varchar(10);not null;index:idx_fraud_decisions_status,priority:1"`
+	Hits          []FraudRuleHit `json:"hits" gorm:"foreignKey:DecisionID"`
+	ReviewedBy    *uint          `json:"reviewed_by,omitempty" gorm:"type:bigint unsigned"`
+	ReviewedAt    *time.Time     `json:"reviewed_at,omitempty"`
+	ReviewNote    string         `json:"review_note,omitempty" gorm:"type:varchar(255)"`
+	// TransactionID est la transaction de débit du virement exécuté
+	TransactionID *uint     `json:"transaction_id,omitempty" gorm:"type:bigint unsigned"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index:idx_fraud_decisions_status,priority:2"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// AfterFind renseigne la devise du montant du virement
+func (d *FraudDecision) AfterFind(tx *gorm.DB) error {
+	d.Amount.Currency = d.Currency
+	return nil
+}
+
+// FraudRuleHit est une règle déclenchée par un virement, avec le score qu'elle lui attribue
+type FraudRuleHit struct {
+	ID         uint   `json:"-" gorm:"primaryKey"`
+	DecisionID uint   `json:"-" gorm:"type:bigint unsigned;not null;index"`
+	Rule       string `json:"rule" gorm:"type:varchar(50);not null"`
+	Score      int    `json:"score" gorm:"not null"`
+	Detail     string `json:"detail" gorm:"type:varchar(255)"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Conventions de décompte des jours : base annuelle appliquée au taux
+const (
+	DayCountAct365 = "act/365" // 365 jours, y compris les années bissextiles
+	DayCountAct360 = "act/360"
+	DayCountActAct = "act/act" // 365 ou 366 jours selon l'année
+)
+
+// Capitalisation des intérêts courus entre deux versements
+const (
+	CompoundingNone    = "none"    // intérêts simples, calculés sur le solde comptable
+	CompoundingDaily   = "daily"   // les intérêts courus de la veille portent intérêt
+	CompoundingMonthly = "monthly" // les intérêts courus des mois précédents portent intérêt
+)
+
+// Fréquences de versement des intérêts, alignées sur le calendrier civil
+const (
+	PayoutMonthly   = "monthly"
+	PayoutQuarterly = "quarterly"
+	PayoutAnnually  = "annually"
+)
+
+// MicrosPerMinor est la précision des intérêts courus : millionièmes d'unité mineure
+const MicrosPerMinor = 1000000
+
+// InterestProduct est un produit d'épargne rémunéré, rattaché aux comptes épargne
+type InterestProduct struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Code            string         `json:"code" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Name            string         `json:"name" gorm:"type:varchar(100);not null"`
+	Currency        string         `json:"currency" gorm:"type:varchar(3);not null"`
+	DayCount        string         `json:"day_count" gorm:"type:varchar(10);not null"`
+	Compounding     string         `json:"compounding" gorm:"type:varchar(10);not null"`
+	PayoutFrequency string         `json:"payout_frequency" gorm:"type:varchar(10);not null"`
+	Active          bool           `json:"active" gorm:"not null;default:true"`
+	Tiers           []InterestTier `json:"tiers" gorm:"foreignKey:ProductID"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// InterestTier est une tranche de taux : la part du solde au-delà de MinBalance, jusqu'au
+// seuil de la tranche suivante, est rémunérée au taux annuel RateBps
+type InterestTier struct {
+	ID         uint  `json:"id" gorm:"primaryKey"`
+	ProductID  uint  `json:"product_id" gorm:"type:bigint unsigned;not null;index"`
+	MinBalance Money `json:"min_balance" gorm:"column:min_balance_minor;type:bigint;not null"`
+	RateBps    int   `json:"rate_bps" gorm:"not null"`
+}
+
+// AfterFind renseigne la devise des seuils à partir de celle du produit
+func (p *InterestProduct) AfterFind(tx *gorm.DB) error {
+	for i := range p.Tiers {
+		p.Tiers[i].MinBalance.Currency = p.Currency
+	}
+	return nil
+}
+
+// InterestAccrual est l'intérêt couru par un compte pour une journée, en millionièmes
+// d'unité mineure ; il est versé en fin de période par une transaction de crédit
+type InterestAccrual struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	AccountID     uint      `json:"account_id" gorm:"type:bigint unsigned;not null;uniqueIndex:idx_interest_accruals_day"`
+	ProductID     uint      `json:"product_id" gorm:"type:bigint unsigned;not null"`
+	AccrualDate   time.Time `json:"accrual_date" gorm:"not null;uniqueIndex:idx_interest_accruals_day"`
+	Balance       Money     `json:"balance" gorm:"column:balance_minor;type:bigint;not null"` // solde de clôture du jour
+	BaseMicros    int64     `json:"base_micros" gorm:"not null"`                              // assiette, intérêts capitalisés compris
+	AmountMicros  int64     `json:"amount_micros" gorm:"not null"`
+	Currency      string    `json:"currency" gorm:"type:varchar(3);not null"`
+	TransactionID *uint     `json:"transaction_id,omitempty" gorm:"type:bigint unsigned;index"` // versement
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AfterFind renseigne la devise du solde de l'intérêt couru
+func (a *InterestAccrual) AfterFind(tx *gorm.DB) error {
+	a.Balance.Currency = a.Currency
+	return nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Origines d'un plafond de sorties de fonds
+const (
+	LimitSourceBank     = "bank"     // fixé par la banque, par défaut (sans UserID) ou pour un client
+	LimitSourceCustomer = "customer" // abaissé par le client lui-même
+)
+
+// TransferLimit fixe les plafonds des sorties de fonds des comptes d'un type et d'une devise :
+// montant par opération, cumuls du jour et du mois, nombre d'opérations par heure glissante.
+// Un plafond nil n'est pas limité.
+type TransferLimit struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	UserID         *uint  `json:"user_id,omitempty" gorm:"type:bigint unsigned;uniqueIndex:idx_transfer_limits_scope"`
+	AccountType    string `json:"account_type" gorm:"type:varchar(20);not null;uniqueIndex:idx_transfer_limits_scope;uniqueIndex:idx_transfer_limits_owner"`
+	Currency       string `json:"currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_transfer_limits_scope;uniqueIndex:idx_transfer_limits_owner"`
+	Source         string `json:"source" gorm:"type:varchar(10);not null;uniqueIndex:idx_transfer_limits_scope;uniqueIndex:idx_transfer_limits_owner"`
+	PerTransaction *Money `json:"per_transaction" gorm:"column:per_transaction_minor;type:bigint"`
+	Daily          *Money `json:"daily" gorm:"column:daily_minor;type:bigint"`
+	Monthly        *Money `json:"monthly" gorm:"column:monthly_minor;type:bigint"`
+	HourlyCount    *int   `json:"hourly_count"`
+	// ScopeUserID reprend UserID, 0 pour un plafond par défaut : contrairement à user_id (NULL),
+	// la colonne départage les plafonds par défaut dans l'index unique idx_transfer_limits_owner
+	ScopeUserID uint `json:"-" gorm:"type:bigint unsigned;not null;default:0;uniqueIndex:idx_transfer_limits_owner,priority:1"`
+	// UpdatedBy est l'auteur de la dernière modification (administrateur ou client)
+	UpdatedBy *uint     `json:"updated_by,omitempty" gorm:"type:bigint unsigned"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeSave renseigne la portée du plafond
+func (l *TransferLimit) BeforeSave(tx *gorm.DB) error {
+	l.ScopeUserID = 0
+	if l.UserID != nil {
+		l.ScopeUserID = *l.UserID
+	}
+	return nil
+}
+
+// AfterFind renseigne la devise des plafonds
+func (l *TransferLimit) AfterFind(tx *gorm.DB) error {
+	for _, amount := range []*Money{l.PerTransaction, l.Daily, l.Monthly} {
+		if amount != nil {
+			amount.Currency = l.Currency
+		}
+	}
+	return nil
+}
+
+// TransferLimitUsage trace une sortie de fonds imputée sur les plafonds de son compte
+type TransferLimitUsage struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	AccountID uint   `json:"account_id" gorm:"type:bigint unsigned;not null;index:idx_transfer_limit_usages_account,priority:1"`
+	Amount    Money  `json:"amount" gorm:"column:amount_minor;type:bigint;not null"`
+	Currency  string `json:"currency" gorm:"type:varchar(3);not null"`
+	// Reference désigne l'opération ; les virements d'un même lot partagent celle du lot
+	Reference string    `json:"reference" gorm:"type:varchar(100)"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_transfer_limit_usages_account,priority:2"`
+}
+
+// AfterFind renseigne la devise du montant imputé
+func (u *TransferLimitUsage) AfterFind(tx *gorm.DB) error {
+	u.Amount.Currency = u.Currency
+	return nil
+}
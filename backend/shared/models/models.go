@@ -1,14 +1,17 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
+	"banking-app/shared/utils"
+
 	"gorm.io/gorm"
 )
 
 // User représente un utilisateur du système bancaire
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey;type:bigint unsigned"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
 	Email     string         `json:"email" gorm:"type:varchar(255);uniqueIndex;not null"`
 	Password  string         `json:"-" gorm:"not null"`
 	FirstName string         `json:"first_name" gorm:"not null"`
@@ -19,22 +22,54 @@ type User struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// Renseigné lorsque l'utilisateur a prouvé la possession de son adresse email
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// Rôle applicatif, qui détermine les permissions (voir permissions.go)
+	Role string `json:"role" gorm:"type:varchar(20);not null;default:'customer'"`
+
+	// Connexion refusée jusqu'à cette date après trop d'échecs (déverrouillage par un administrateur)
+	LockedUntil *time.Time `json:"locked_until"`
+
+	// Double authentification (TOTP) ; le secret est renseigné dès l'enrôlement,
+	// mais n'est exigé à la connexion qu'une fois TOTPEnabled confirmé
+	TOTPSecret   string `json:"-" gorm:"column:totp_secret;type:varchar(64)"`
+	TOTPEnabled  bool   `json:"two_factor_enabled" gorm:"column:totp_enabled;default:false"`
+	TOTPLastStep int64  `json:"-" gorm:"column:totp_last_step;default:0"` // dernier pas accepté, contre le rejeu
+
 	// Relations
 	Accounts []Account `json:"accounts,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// IsLocked indique si la connexion est verrouillée à l'instant now
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
 // Account représente un compte bancaire
 type Account struct {
-	ID            uint           `json:"id" gorm:"primaryKey;type:bigint unsigned"`
-	UserID        uint           `json:"user_id" gorm:"type:bigint unsigned;not null"`
-	AccountNumber string         `json:"account_number" gorm:"type:varchar(20);uniqueIndex;not null"`
-	AccountType   string         `json:"account_type" gorm:"not null"` // checking, savings, credit
-	Balance       float64        `json:"balance" gorm:"type:decimal(15,2);default:0"`
-	Currency      string         `json:"currency" gorm:"default:'EUR'"`
-	Status        string         `json:"status" gorm:"default:'active'"` // active, frozen, closed
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	UserID        uint   `json:"user_id" gorm:"type:bigint unsigned;not null"`
+	AccountNumber string `json:"account_number" gorm:"type:varchar(34);uniqueIndex;not null"`        // IBAN, format électronique
+	AccountType   string `json:"account_type" gorm:"not null"`                                       // checking, savings, credit
+	Balance       Money  `json:"balance" gorm:"column:balance_minor;type:bigint;not null;default:0"` // unités mineures, solde comptable
+	// Fonds réservés par des autorisations en cours, déduits du solde disponible
+	HeldAmount Money `json:"held_amount" gorm:"column:held_minor;type:bigint;not null;default:0"`
+	// Découvert autorisé (comptes courants) ou plafond de crédit : le solde peut descendre jusqu'à -CreditLimit
+	CreditLimit Money `json:"credit_limit" gorm:"column:credit_limit_minor;type:bigint;not null;default:0"`
+	// Taux annuel des intérêts débiteurs, en points de base, et dernier mois facturé
+	OverdraftRateBps       int        `json:"overdraft_rate_bps" gorm:"not null;default:0"`
+	InterestChargedThrough *time.Time `json:"interest_charged_through,omitempty"`
+	// Produit d'épargne rémunérant le compte (comptes épargne uniquement)
+	InterestProductID *uint  `json:"interest_product_id,omitempty" gorm:"type:bigint unsigned"`
+	Currency          string `json:"currency" gorm:"default:'EUR'"`
+	Status            string `json:"status" gorm:"default:'active'"` // active, frozen, closed
+	// Gel posé par l'administration : le client ne peut pas le lever lui-même
+	FrozenBy     *uint          `json:"frozen_by,omitempty" gorm:"type:bigint unsigned"`
+	FreezeReason string         `json:"freeze_reason,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
 	User         User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
@@ -43,10 +78,10 @@ type Account struct {
 
 // Transaction représente une transaction bancaire
 type Transaction struct {
-	ID          uint           `json:"id" gorm:"primaryKey;type:bigint unsigned"`
+	ID          uint           `json:"id" gorm:"primaryKey"`
 	AccountID   uint           `json:"account_id" gorm:"type:bigint unsigned;not null"`
-	Type        string         `json:"type" gorm:"not null"` // debit, credit, transfer
-	Amount      float64        `json:"amount" gorm:"type:decimal(15,2);not null"`
+	Type        string         `json:"type" gorm:"not null"`                                   // debit, credit, transfer, reversal
+	Amount      Money          `json:"amount" gorm:"column:amount_minor;type:bigint;not null"` // unités mineures
 	Currency    string         `json:"currency" gorm:"default:'EUR'"`
 	Description string         `json:"description"`
 	Reference   string         `json:"reference" gorm:"type:varchar(100);uniqueIndex"`
@@ -59,14 +94,88 @@ type Transaction struct {
 	// Pour les transferts
 	ToAccountID *uint `json:"to_account_id" gorm:"type:bigint unsigned"`
 
+	// Écriture comptable à l'origine de la transaction
+	JournalEntryID *uint `json:"journal_entry_id" gorm:"type:bigint unsigned;index"`
+
+	// Change appliqué aux deux jambes d'un virement entre devises
+	ExchangeRate      *string `json:"exchange_rate,omitempty" gorm:"type:varchar(32)"` // cours moyen source → destination
+	ExchangeSpreadBps *int    `json:"exchange_spread_bps,omitempty"`
+	FXQuoteID         *string `json:"fx_quote_id,omitempty" gorm:"type:varchar(64)"`
+
+	// Annulations : une transaction de type reversal compense tout ou partie de la transaction
+	// ReversalOf ; ReversedAmount cumule les montants déjà annulés de la transaction d'origine
+	ReversalOf     *uint `json:"reversal_of,omitempty" gorm:"type:bigint unsigned;index"`
+	ReversedAmount Money `json:"reversed_amount" gorm:"column:reversed_amount_minor;type:bigint;not null;default:0"`
+
+	// Relations
+	Account   Account       `json:"account,omitempty" gorm:"foreignKey:AccountID"`
+	ToAccount *Account      `json:"to_account,omitempty" gorm:"foreignKey:ToAccountID"`
+	Reversals []Transaction `json:"reversals,omitempty" gorm:"foreignKey:ReversalOf"`
+}
+
+// AfterFind renseigne la devise des soldes à partir de celle du compte
+func (a *Account) AfterFind(tx *gorm.DB) error {
+	a.Balance.Currency = a.Currency
+	a.HeldAmount.Currency = a.Currency
+	a.CreditLimit.Currency = a.Currency
+	return nil
+}
+
+// AvailableBalance retourne le solde disponible : solde comptable moins les fonds réservés,
+// plus le découvert ou le crédit autorisé
+func (a *Account) AvailableBalance() (Money, error) {
+	available, err := NewMoney(a.Balance.Amount, a.Currency).Sub(NewMoney(a.HeldAmount.Amount, a.Currency))
+	if err != nil {
+		return Money{}, err
+	}
+	return available.Add(NewMoney(a.CreditLimit.Amount, a.Currency))
+}
+
+// AfterFind renseigne la devise des montants à partir de celle de la transaction
+func (t *Transaction) AfterFind(tx *gorm.DB) error {
+	t.Amount.Currency = t.Currency
+	t.ReversedAmount.Currency = t.Currency
+	return nil
+}
+
+// ReversibleAmount retourne le montant restant à annuler
+func (t *Transaction) ReversibleAmount() (Money, error) {
+	return t.Amount.Sub(t.ReversedAmount)
+}
+
+// JournalEntry représente une écriture comptable en partie double :
+// la somme de ses lignes est nulle pour chaque devise
+type JournalEntry struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Reference   string    `json:"reference" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+
 	// Relations
-	Account   Account  `json:"account,omitempty" gorm:"foreignKey:AccountID"`
-	ToAccount *Account `json:"to_account,omitempty" gorm:"foreignKey:ToAccountID"`
+	Postings []Posting `json:"postings,omitempty" gorm:"foreignKey:JournalEntryID"`
+}
+
+// Posting représente une ligne d'écriture : un montant signé porté au crédit (positif)
+// ou au débit (négatif) d'un compte du grand livre
+type Posting struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	JournalEntryID uint      `json:"journal_entry_id" gorm:"type:bigint unsigned;not null;index"`
+	LedgerAccount  string    `json:"ledger_account" gorm:"type:varchar(100);not null;index"` // account:{id}, system:external...
+	AccountID      *uint     `json:"account_id" gorm:"type:bigint unsigned;index"`           // renseigné pour les comptes clients
+	Amount         Money     `json:"amount" gorm:"column:amount_minor;type:bigint;not null"` // unités mineures
+	Currency       string    `json:"currency" gorm:"type:varchar(3);not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// AfterFind renseigne la devise du montant à partir de celle de la ligne
+func (p *Posting) AfterFind(tx *gorm.DB) error {
+	p.Amount.Currency = p.Currency
+	return nil
 }
 
 // Notification représente une notification utilisateur
 type Notification struct {
-	ID        uint           `json:"id" gorm:"primaryKey;type:bigint unsigned"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
 	UserID    uint           `json:"user_id" gorm:"type:bigint unsigned;not null"`
 	Type      string         `json:"type" gorm:"not null"` // email, sms, push
 	Title     string         `json:"title" gorm:"not null"`
@@ -81,12 +190,229 @@ type Notification struct {
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// OutboundEmail est un email en attente d'envoi contenant des données confidentielles (lien
+// de vérification, de réinitialisation). Il n'est jamais exposé par l'API des notifications.
+type OutboundEmail struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"type:bigint unsigned;not null;index"`
+	Subject   string     `json:"subject" gorm:"not null"`
+	Body      string     `json:"-" gorm:"type:text;not null"`
+	Status    string     `json:"status" gorm:"default:'pending'"` // pending, sent, failed
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IdempotencyKey conserve le résultat d'une requête rejouable (en-tête Idempotency-Key)
+type IdempotencyKey struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id" gorm:"type:bigint unsigned;not null;uniqueIndex:idx_idempotency_user_key"`
+	Key            string    `json:"key" gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key"`
+	RequestHash    string    `json:"request_hash" gorm:"type:char(64);not null"`
+	ResponseStatus int       `json:"response_status" gorm:"default:0"` // 0 tant que la requête est en cours
+	ResponseBody   string    `json:"-" gorm:"type:mediumtext"`
+	ExpiresAt      time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// AuthSession représente une session de connexion ; le refresh token n'est conservé que haché
+type AuthSession struct {
+	ID                string     `json:"id" gorm:"primaryKey;type:varchar(255)"`
+	UserID            uint       `json:"user_id" gorm:"type:bigint unsigned;not null;index"`
+	RefreshTokenHash  string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	PreviousTokenHash string     `json:"-" gorm:"type:char(64);not null;default:'';index"` // détecte la réutilisation d'un token déjà renouvelé
+	UserAgent         string     `json:"user_agent" gorm:"type:varchar(255)"`
+	IPAddress         string     `json:"ip_address" gorm:"type:varchar(45)"`
+	ExpiresAt         time.Time  `json:"expires_at" gorm:"not null;index"`
+	LastUsedAt        *time.Time `json:"last_used_at"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// IsActive indique si la session peut encore être utilisée à l'instant now
+func (s *AuthSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RecoveryCode est un code de secours à usage unique de la double authentification
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"type:bigint unsigned;not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// LoginAttempt compte les échecs de connexion récents pour une clé (email ou adresse IP)
+type LoginAttempt struct {
+	Key           string    `json:"key" gorm:"column:attempt_key;primaryKey;type:varchar(255)"`
+	Failures      int       `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time `json:"last_failure_at" gorm:"not null"`
+}
+
+// Usages des tokens à usage unique envoyés par email
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+// UserToken enregistre un token signé à usage unique (réinitialisation, vérification d'email).
+// Le token lui-même n'est pas stocké : son identifiant (jti) permet de le consommer une seule fois.
+type UserToken struct {
+	ID        string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	UserID    uint       `json:"user_id" gorm:"type:bigint unsigned;not null;index"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(32);not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// FXQuote est une cotation de change garantie jusqu'à ExpiresAt et utilisable pour un seul virement
+type FXQuote struct {
+	ID           string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	UserID       uint       `json:"-" gorm:"type:bigint unsigned;not null;index"`
+	FromCurrency string     `json:"from_currency" gorm:"type:varchar(3);not null"`
+	ToCurrency   string     `json:"to_currency" gorm:"type:varchar(3);not null"`
+	SourceAmount Money      `json:"source_amount" gorm:"column:source_amount_minor;type:bigint;not null"`
+	MidAmount    Money      `json:"mid_amount" gorm:"column:mid_amount_minor;type:bigint;not null"`       // contre-valeur au cours moyen
+	TargetAmount Money      `json:"target_amount" gorm:"column:target_amount_minor;type:bigint;not null"` // crédité, marge déduite
+	Rate         string     `json:"rate" gorm:"type:varchar(32);not null"`                                // cours moyen
+	SpreadBps    int        `json:"spread_bps" gorm:"not null"`
+	RateDate     time.Time  `json:"rate_date" gorm:"not null"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// AfterFind renseigne les devises des montants de la cotation
+func (q *FXQuote) AfterFind(tx *gorm.DB) error {
+	q.SourceAmount.Currency = q.FromCurrency
+	q.MidAmount.Currency = q.ToCurrency
+	q.TargetAmount.Currency = q.ToCurrency
+	return nil
+}
+
+// Statuts d'une réservation de fonds
+const (
+	HoldAuthorized = "authorized"
+	HoldCaptured   = "captured"
+	HoldVoided     = "voided"
+	HoldExpired    = "expired"
+)
+
+// Hold est une autorisation de paiement : Amount est réservé sur le compte jusqu'à sa
+// capture (débit effectif, partiel ou majoré dans la tolérance), son annulation ou ExpiresAt
+type Hold struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	AccountID      uint       `json:"account_id" gorm:"type:bigint unsigned;not null;index"`
+	Amount         Money      `json:"amount" gorm:"column:amount_minor;type:bigint;not null"`
+	CapturedAmount Money      `json:"captured_amount" gorm:"column:captured_minor;type:bigint;not null;default:0"`
+	Currency       string     `json:"currency" gorm:"type:varchar(3);not null"`
+	Description    string     `json:"description"`
+	Reference      string     `json:"reference" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'authorized'"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null;index"`
+	TransactionID  *uint      `json:"transaction_id,omitempty" gorm:"type:bigint unsigned"` // débit créé à la capture
+	CapturedAt     *time.Time `json:"captured_at,omitempty"`
+	ReleasedAt     *time.Time `json:"released_at,omitempty"` // annulation ou expiration
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// AfterFind renseigne la devise des montants de la réservation
+func (h *Hold) AfterFind(tx *gorm.DB) error {
+	h.Amount.Currency = h.Currency
+	h.CapturedAmount.Currency = h.Currency
+	return nil
+}
+
+// AccountLimitChange trace une modification du découvert ou du plafond de crédit d'un compte
+type AccountLimitChange struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	AccountID       uint      `json:"account_id" gorm:"type:bigint unsigned;not null;index"`
+	ChangedBy       uint      `json:"changed_by" gorm:"type:bigint unsigned;not null"`
+	PreviousLimit   Money     `json:"previous_limit" gorm:"column:previous_limit_minor;type:bigint;not null"`
+	NewLimit        Money     `json:"new_limit" gorm:"column:new_limit_minor;type:bigint;not null"`
+	PreviousRateBps int       `json:"previous_rate_bps" gorm:"not null"`
+	NewRateBps      int       `json:"new_rate_bps" gorm:"not null"`
+	Currency        string    `json:"currency" gorm:"type:varchar(3);not null"`
+	Reason          string    `json:"reason" gorm:"type:varchar(255);not null"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// AfterFind renseigne la devise des plafonds tracés
+func (l *AccountLimitChange) AfterFind(tx *gorm.DB) error {
+	l.PreviousLimit.Currency = l.Currency
+	l.NewLimit.Currency = l.Currency
+	return nil
+}
+
+// Fréquences des virements programmés
+const (
+	FrequencyOnce       = "once"
+	FrequencyDaily      = "daily"
+	FrequencyWeekly     = "weekly"
+	FrequencyMonthly    = "monthly"      // même quantième, ramené au dernier jour des mois plus courts
+	FrequencyEndOfMonth = "end_of_month" // dernier jour de chaque mois
+)
+
+// Statuts d'un virement programmé
+const (
+	StandingOrderActive    = "active"
+	StandingOrderPaused    = "paused"
+	StandingOrderCompleted = "completed"
+	StandingOrderCancelled = "cancelled"
+)
+
+// StandingOrder est un virement programmé, unique ou permanent. Les échéances tombent à
+// minuit UTC ; l'ordre se termine à EndDate (incluse) ou après MaxRuns échéances.
+type StandingOrder struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"type:bigint unsigned;not null;index"`
+	FromAccountID uint       `json:"from_account_id" gorm:"type:bigint unsigned;not null"`
+	ToAccountID   uint       `json:"to_account_id" gorm:"type:bigint unsigned;not null"`
+	Amount        Money      `json:"amount" gorm:"column:amount_minor;type:bigint;not null"` // devise du compte source
+	Currency      string     `json:"currency" gorm:"type:varchar(3);not null"`
+	Description   string     `json:"description"`
+	Frequency     string     `json:"frequency" gorm:"type:varchar(20);not null"`
+	StartDate     time.Time  `json:"start_date" gorm:"not null"`
+	EndDate       *time.Time `json:"end_date"`
+	MaxRuns       *int       `json:"max_runs"`
+	RunCount      int        `json:"run_count" gorm:"not null;default:0"` // échéances traitées, y compris en échec
+	FailureCount  int        `json:"failure_count" gorm:"not null;default:0"`
+	NextRunAt     *time.Time `json:"next_run_at" gorm:"index"` // nil une fois l'ordre terminé
+	LastRunAt     *time.Time `json:"last_run_at"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// AfterFind renseigne la devise du montant de l'ordre
+func (o *StandingOrder) AfterFind(tx *gorm.DB) error {
+	o.Amount.Currency = o.Currency
+	return nil
+}
+
+// StandingOrderRun trace l'exécution d'une échéance ; l'index unique sur
+// (standing_order_id, scheduled_for) garantit qu'une échéance n'est exécutée qu'une fois
+type StandingOrderRun struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	StandingOrderID uint      `json:"standing_order_id" gorm:"type:bigint unsigned;not null;uniqueIndex:idx_standing_order_runs_occurrence"`
+	ScheduledFor    time.Time `json:"scheduled_for" gorm:"not null;uniqueIndex:idx_standing_order_runs_occurrence"`
+	Status          string    `json:"status" gorm:"type:varchar(20);not null"`      // succeeded, failed, skipped, held
+	Reference       string    `json:"reference,omitempty" gorm:"type:varchar(100)"` // référence du virement exécuté
+	Error           string    `json:"error,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
 // APIResponse structure standard pour les réponses API
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Data       interface{}     `json:"data,omitempty"`
+	Pagination *utils.PageInfo `json:"pagination,omitempty"` // listes paginées uniquement
+	Error      string          `json:"error,omitempty"`
 }
 
 // LoginRequest structure pour la connexion
@@ -97,20 +423,30 @@ type LoginRequest struct {
 
 // LoginResponse structure pour la réponse de connexion
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"` // access token JWT de courte durée
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // durée de validité du token en secondes
+	User         User   `json:"user"`
+}
+
+// TwoFactorChallengeResponse est retournée par la connexion lorsque la double authentification est active
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"`
 }
 
 // CreateAccountRequest structure pour créer un compte
 type CreateAccountRequest struct {
-	AccountType string `json:"account_type" binding:"required"`
-	Currency    string `json:"currency"`
+	AccountType       string `json:"account_type" binding:"required"`
+	Currency          string `json:"currency"`
+	InterestProductID *uint  `json:"interest_product_id"` // comptes épargne
 }
 
 // TransactionRequest structure pour créer une transaction
 type TransactionRequest struct {
-	Type        string  `json:"type" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	Description string  `json:"description"`
-	ToAccountID *uint   `json:"to_account_id"` // Pour les transferts
+	Type        string      `json:"type" binding:"required"`
+	Amount      json.Number `json:"amount" binding:"required"` // converti avec ParseMoney dans la devise du compte
+	Description string      `json:"description"`
+	ToAccountID *uint       `json:"to_account_id"` // Pour les transferts
 }
@@ -0,0 +1,295 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Erreurs liées aux montants
+var (
+	ErrCurrencyMismatch    = errors.New("opération impossible entre deux devises différentes")
+	ErrUnsupportedCurrency = errors.New("devise non supportée")
+	ErrInvalidAmount       = errors.New("montant invalide")
+	ErrTooManyDecimals     = errors.New("trop de décimales pour la devise")
+	ErrAmountOverflow      = errors.New("montant hors limites")
+)
+
+// DefaultCurrency est la devise utilisée lorsqu'aucune n'est précisée
+const DefaultCurrency = "EUR"
+
+// defaultExponent est utilisé pour formater un montant dont la devise est inconnue
+const defaultExponent = 2
+
+// currencyExponents associe chaque devise ISO 4217 supportée à son nombre de décimales
+var currencyExponents = map[string]int{
+	"EUR": 2,
+	"USD": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"SEK": 2,
+	"NOK": 2,
+	"DKK": 2,
+	"PLN": 2,
+	"CZK": 2,
+	"MAD": 2,
+	"CNY": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"XOF": 0,
+	"XAF": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"TND": 3,
+}
+
+// CurrencyExponent retourne le nombre de décimales d'une devise ISO 4217
+func CurrencyExponent(currency string) (int, bool) {
+	exponent, ok := currencyExponents[currency]
+	return exponent, ok
+}
+
+// IsSupportedCurrency indique si la devise est connue
+func IsSupportedCurrency(currency string) bool {
+	_, ok := currencyExponents[currency]
+	return ok
+}
+
+// SupportedCurrencies retourne les codes des devises supportées
+func SupportedCurrencies() []string {
+	currencies := make([]string, 0, len(currencyExponents))
+	for currency := range currencyExponents {
+		currencies = append(currencies, currency)
+	}
+	return currencies
+}
+
+func exponentOf(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return defaultExponent
+}
+
+// Money représente un montant exact en unités mineures (centimes) dans une devise ISO 4217.
+// En base, seules les unités mineures sont stockées (colonne BIGINT) ; la devise provient
+// de la colonne currency du modèle et est renseignée par les hooks AfterFind.
+// En JSON, le montant est sérialisé comme un nombre décimal exact (ex: 1500.00).
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney crée un montant à partir d'unités mineures
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney convertit une chaîne décimale ("12.50", "-3", "1e2" refusé) en montant exact
+func ParseMoney(value, currency string) (Money, error) {
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, ErrUnsupportedCurrency
+	}
+
+	minor, err := parseDecimal(strings.TrimSpace(value), exponent)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// parseDecimal convertit une chaîne décimale en entier multiplié par 10^exponent
+func parseDecimal(value string, exponent int) (int64, error) {
+	if value == "" {
+		return 0, ErrInvalidAmount
+	}
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	integerPart, fractionPart, hasFraction := strings.Cut(value, ".")
+	if integerPart == "" && fractionPart == "" {
+		return 0, ErrInvalidAmount
+	}
+	if hasFraction && fractionPart == "" {
+		return 0, ErrInvalidAmount
+	}
+	for _, r := range integerPart + fractionPart {
+		if r < '0' || r > '9' {
+			return 0, ErrInvalidAmount
+		}
+	}
+
+	// Les zéros finaux n'ajoutent pas de précision
+	fractionPart = strings.TrimRight(fractionPart, "0")
+	if len(fractionPart) > exponent {
+		return 0, ErrTooManyDecimals
+	}
+	fractionPart += strings.Repeat("0", exponent-len(fractionPart))
+
+	digits := strings.TrimLeft(integerPart+fractionPart, "0")
+	if digits == "" {
+		return 0, nil
+	}
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, ErrAmountOverflow
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// Decimal retourne le montant au format décimal sans devise (ex: "-12.50")
+func (m Money) Decimal() string {
+	exponent := exponentOf(m.Currency)
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+
+	// Conversion via uint64 pour gérer math.MinInt64
+	abs := uint64(amount)
+	if amount < 0 {
+		abs = uint64(-(amount + 1)) + 1
+	}
+	digits := strconv.FormatUint(abs, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	cut := len(digits) - exponent
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+// String retourne le montant suivi de sa devise (ex: "12.50 EUR")
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero indique si le montant est nul
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive indique si le montant est strictement positif
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative indique si le montant est strictement négatif
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Neg retourne l'opposé du montant
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Add additionne deux montants de même devise
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub soustrait deux montants de même devise
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+	return m.Add(other.Neg())
+}
+
+// Cmp compare deux montants de même devise (-1, 0 ou 1)
+func (m Money) Cmp(other Money) (int, error) {
+	if m.Currency != other.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// MarshalJSON sérialise le montant comme un nombre décimal exact
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON lit un nombre (ou une chaîne) décimal. La devise du récepteur,
+// si elle est déjà renseignée, détermine le nombre de décimales accepté.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return ErrInvalidAmount
+	}
+	minor, err := parseDecimal(number.String(), exponentOf(m.Currency))
+	if err != nil {
+		return err
+	}
+	m.Amount = minor
+	return nil
+}
+
+// Value stocke les unités mineures en base
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+// Scan lit les unités mineures depuis la base
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		m.Amount = 0
+	case int64:
+		m.Amount = v
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("type incompatible avec Money: %T", value)
+	}
+	return nil
+}
+
+func (m *Money) scanString(value string) error {
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("montant invalide en base: %s", value)
+	}
+	m.Amount = amount
+	return nil
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     int64
+		err      error
+	}{
+		{"12.50", "EUR", 1250, nil},
+		{"0.1", "EUR", 10, nil},
+		{"-3", "EUR", -300, nil},
+		{"1500.000", "EUR", 150000, nil},
+		{"1500", "JPY", 1500, nil},
+		{"1.5", "JPY", 0, ErrTooManyDecimals},
+		{"1.234", "KWD", 1234, nil},
+		{"1.001", "EUR", 0, ErrTooManyDecimals},
+		{"abc", "EUR", 0, ErrInvalidAmount},
+		{"1e2", "EUR", 0, ErrInvalidAmount},
+		{"", "EUR", 0, ErrInvalidAmount},
+		{"10", "XXX", 0, ErrUnsupportedCurrency},
+		{"99999999999999999999", "EUR", 0, ErrAmountOverflow},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.value, tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseMoney(%q, %s) erreur = %v, attendu %v", tt.value, tt.currency, err, tt.err)
+			continue
+		}
+		if err == nil && got.Amount != tt.want {
+			t.Errorf("ParseMoney(%q, %s) = %d, attendu %d", tt.value, tt.currency, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{NewMoney(150000, "EUR"), "1500.00"},
+		{NewMoney(5, "EUR"), "0.05"},
+		{NewMoney(-1250, "EUR"), "-12.50"},
+		{NewMoney(1500, "JPY"), "1500"},
+		{NewMoney(1, "KWD"), "0.001"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("Decimal(%d %s) = %s, attendu %s", tt.money.Amount, tt.money.Currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyArithmeticRefusesMixedCurrencies(t *testing.T) {
+	eur := NewMoney(1000, "EUR")
+	usd := NewMoney(1000, "USD")
+
+	if _, err := eur.Add(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add: erreur = %v, attendu ErrCurrencyMismatch", err)
+	}
+	if _, err := eur.Sub(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub: erreur = %v, attendu ErrCurrencyMismatch", err)
+	}
+	if _, err := eur.Cmp(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp: erreur = %v, attendu ErrCurrencyMismatch", err)
+	}
+
+	sum, err := eur.Add(NewMoney(1, "EUR"))
+	if err != nil || sum.Amount != 1001 {
+		t.Errorf("Add = %v (%v), attendu 1001", sum, err)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Balance Money `json:"balance"`
+	}{NewMoney(123456, "EUR")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"balance":1234.56}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	decoded := Money{Currency: "EUR"}
+	if err := json.Unmarshal([]byte(`"0.30"`), &decoded); err != nil || decoded.Amount != 30 {
+		t.Errorf("Unmarshal = %d (%v), attendu 30", decoded.Amount, err)
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Modes d'exécution d'un lot de virements
+const (
+	BatchModeAtomic  = "atomic"   // tout ou rien : un seul refus annule le lot
+	BatchModePerItem = "per_item" // chaque virement est exécuté ou refusé isolément
+)
+
+// Statuts ISO 20022 (pain.002) d'un lot et de ses virements
+const (
+	PaymentStatusPending   = "PDNG" // en cours d'exécution
+	PaymentStatusCompleted = "ACSC" // exécuté
+	PaymentStatusPartial   = "PART" // lot partiellement exécuté
+	PaymentStatusRejected  = "RJCT" // refusé
+)
+
+// PaymentBatch est un fichier de virements (pain.001 ou CSV) remis par un client. L'identifiant
+// du message (MessageID) est unique par client : un même fichier ne peut pas être exécuté deux fois.
+type PaymentBatch struct {
+	ID          uint               `json:"id" gorm:"primaryKey"`
+	UserID      uint               `json:"user_id" gorm:"type:bigint unsigned;not null;uniqueIndex:idx_payment_batches_message"`
+	MessageID   string             `json:"message_id" gorm:"type:varchar(35);not null;uniqueIndex:idx_payment_batches_message"`
+	MessageName string             `json:"message_name" gorm:"type:varchar(32);not null"` // pain.001.001.03, csv
+	Mode        string             `json:"mode" gorm:"type:varchar(16);not null"`
+	Status      string             `json:"status" gorm:"type:varchar(4);not null"` // PDNG, ACSC, PART, RJCT
+	NbOfTxs     int                `json:"nb_of_txs" gorm:"not null"`
+	ControlSum  string             `json:"control_sum" gorm:"type:varchar(32)"`
+	Accepted    int                `json:"accepted" gorm:"not null"`
+	Rejected    int                `json:"rejected" gorm:"not null"`
+	Items       []PaymentBatchItem `json:"items,omitempty" gorm:"foreignKey:BatchID"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// PaymentBatchItem est un virement d'un lot et son statut d'exécution
+type PaymentBatchItem struct {
+	ID            uint   `json:"-" gorm:"primaryKey"`
+	BatchID       uint   `json:"-" gorm:"type:bigint unsigned;not null;index"`
+	Position      int    `json:"position" gorm:"not null"`
+	PaymentInfoID string `json:"payment_info_id" gorm:"type:varchar(35)"`
+	InstructionID string `json:"instruction_id,omitempty" gorm:"type:varchar(35)"`
+	EndToEndID    string `json:"end_to_end_id" gorm:"type:varchar(35)"`
+	DebtorIBAN    string `json:"debtor_iban" gorm:"type:varchar(34)"`
+	CreditorIBAN  string `json:"creditor_iban" gorm:"type:varchar(34)"`
+	CreditorName  string `json:"creditor_name,omitempty" gorm:"type:varchar(140)"`
+	Amount        Money  `json:"amount" gorm:"column:amount_minor;type:bigint;not null"`
+	Currency      string `json:"currency" gorm:"type:varchar(3)"`
+	Description   string `json:"description,omitempty"`
+	Status        string `json:"status" gorm:"type:varchar(4);not null"` // PDNG, ACSC, RJCT
+	ReasonCode    string `json:"reason_code,omitempty" gorm:"type:varchar(4)"`
+	ReasonInfo    string `json:"reason_info,omitempty" gorm:"type:varchar(255)"`
+	// Reference est la référence du virement exécuté (jambes -OUT et -IN)
+	Reference          string `json:"reference,omitempty" gorm:"type:varchar(100)"`
+	DebitTransactionID *uint  `json:"debit_transaction_id,omitempty" gorm:"type:bigint unsigned"`
+}
+
+// AfterFind renseigne la devise du montant du virement
+func (i *PaymentBatchItem) AfterFind(tx *gorm.DB) error {
+	i.Amount.Currency = i.Currency
+	return nil
+}
@@ -0,0 +1,67 @@
+package models
+
+// Rôles des utilisateurs
+const (
+	RoleCustomer = "customer"
+	RoleSupport  = "support"
+	RoleAdmin    = "admin"
+)
+
+// Permissions des routes d'administration (/api/admin)
+const (
+	PermUsersRead           = "users:read"
+	PermUsersManage         = "users:manage" // activation, déverrouillage, changement de rôle
+	PermAccountsRead        = "accounts:read"
+	PermAccountsFreeze      = "accounts:freeze"
+	PermAccountsClose       = "accounts:close"
+	PermAccountsLimits      = "accounts:limits"
+	PermInterestManage      = "interest:manage" // produits d'épargne rémunérés
+	PermTransactionsRead    = "transactions:read"
+	PermTransactionsReverse = "transactions:reverse"
+	PermFraudReview         = "fraud:review" // file de vérification des virements
+)
+
+// rolePermissions associe chaque rôle à ses permissions ; un client n'a aucune permission
+// d'administration, ses routes étant limitées à ses propres données
+var rolePermissions = map[string][]string{
+	RoleCustomer: {},
+	RoleSupport: {
+		PermUsersRead,
+		PermAccountsRead,
+		PermAccountsFreeze,
+		PermTransactionsRead,
+	},
+	RoleAdmin: {
+		PermUsersRead,
+		PermUsersManage,
+		PermAccountsRead,
+		PermAccountsFreeze,
+		PermAccountsClose,
+		PermAccountsLimits,
+		PermInterestManage,
+		PermTransactionsRead,
+		PermTransactionsReverse,
+		PermFraudReview,
+	},
+}
+
+// IsValidRole indique si role est un rôle connu
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// PermissionsForRole retourne les permissions accordées au rôle
+func PermissionsForRole(role string) []string {
+	return append([]string{}, rolePermissions[role]...)
+}
+
+// HasPermission indique si le rôle de l'utilisateur accorde permission
+func (u *User) HasPermission(permission string) bool {
+	for _, granted := range rolePermissions[u.Role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
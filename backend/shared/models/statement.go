@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StatementPeriodLayout est le format d'une période de relevé : mois civil AAAA-MM (UTC)
+const StatementPeriodLayout = "2006-01"
+
+// Statement est le relevé mensuel d'un compte. Il est figé à sa création : ses lignes et
+// son empreinte (Checksum) ne sont plus jamais modifiées.
+type Statement struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	AccountID      uint            `json:"account_id" gorm:"type:bigint unsigned;not null;uniqueIndex:idx_statements_period"`
+	Period         string          `json:"period" gorm:"type:varchar(7);not null;uniqueIndex:idx_statements_period"`
+	PeriodStart    time.Time       `json:"period_start" gorm:"not null"`
+	PeriodEnd      time.Time       `json:"period_end" gorm:"not null"` // exclu
+	AccountNumber  string          `json:"account_number" gorm:"type:varchar(34);not null"`
+	HolderName     string          `json:"holder_name" gorm:"type:varchar(255);not null"`
+	OpeningBalance Money           `json:"opening_balance" gorm:"column:opening_balance_minor;type:bigint;not null"`
+	TotalDebits    Money           `json:"total_debits" gorm:"column:total_debits_minor;type:bigint;not null"`
+	TotalCredits   Money           `json:"total_credits" gorm:"column:total_credits_minor;type:bigint;not null"`
+	ClosingBalance Money           `json:"closing_balance" gorm:"column:closing_balance_minor;type:bigint;not null"`
+	Currency       string          `json:"currency" gorm:"type:varchar(3);not null"`
+	Checksum       string          `json:"checksum" gorm:"type:varchar(64);not null"` // SHA-256 du relevé au format CSV
+	Lines          []StatementLine `json:"lines,omitempty" gorm:"foreignKey:StatementID"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// StatementLine est une opération du relevé, avec le solde après opération
+type StatementLine struct {
+	ID            uint      `json:"-" gorm:"primaryKey"`
+	StatementID   uint      `json:"-" gorm:"type:bigint unsigned;not null;index"`
+	Position      int       `json:"position" gorm:"not null"`
+	PostingID     uint      `json:"posting_id" gorm:"type:bigint unsigned;not null"`
+	TransactionID *uint     `json:"transaction_id,omitempty" gorm:"type:bigint unsigned"`
+	Date          time.Time `json:"date" gorm:"not null"`
+	Reference     string    `json:"reference" gorm:"type:varchar(100)"`
+	Description   string    `json:"description"`
+	Amount        Money     `json:"amount" gorm:"column:amount_minor;type:bigint;not null"`
+	Balance       Money     `json:"balance" gorm:"column:balance_minor;type:bigint;not null"`
+}
+
+// AfterFind renseigne la devise des montants du relevé et de ses lignes
+func (s *Statement) AfterFind(tx *gorm.DB) error {
+	s.OpeningBalance.Currency = s.Currency
+	s.TotalDebits.Currency = s.Currency
+	s.TotalCredits.Currency = s.Currency
+	s.ClosingBalance.Currency = s.Currency
+	for i := range s.Lines {
+		s.Lines[i].Amount.Currency = s.Currency
+		s.Lines[i].Balance.Currency = s.Currency
+	}
+	return nil
+}
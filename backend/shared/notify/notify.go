@@ -0,0 +1,64 @@
+// Package notify permet aux services de remettre un message à un utilisateur via le
+// sous-système de notifications : le message est enregistré dans la table notifications,
+// d'où il est consulté (canal system) ou envoyé (canaux email, sms, push). Les emails
+// confidentiels passent par une file d'envoi distincte, absente de l'API des notifications.
+package notify
+
+import (
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+)
+
+// Canaux de notification, enregistrés dans Notification.Type
+const (
+	ChannelEmail  = "email"
+	ChannelSMS    = "sms"
+	ChannelPush   = "push"
+	ChannelSystem = "system"
+)
+
+// Notifier remet un message à un utilisateur
+type Notifier interface {
+	Notify(userID uint, channel, title, message string) error
+	// Mail envoie un email contenant un secret (lien à usage unique) : il est placé dans la
+	// file d'envoi et n'apparaît jamais parmi les notifications de l'utilisateur
+	Mail(userID uint, subject, body string) error
+}
+
+// storeNotifier enregistre les notifications et les emails via les dépôts partagés
+type storeNotifier struct {
+	notifications repository.NotificationRepo
+	emails        repository.OutboundEmailRepo
+}
+
+// NewStoreNotifier crée un Notifier adossé aux dépôts du store
+func NewStoreNotifier(store *repository.Store) Notifier {
+	return &storeNotifier{notifications: store.Notifications, emails: store.OutboundEmails}
+}
+
+// Notify enregistre la notification ; les notifications système sont considérées
+// comme remises immédiatement, les autres restent en attente d'envoi
+func (n *storeNotifier) Notify(userID uint, channel, title, message string) error {
+	status := "pending"
+	if channel == ChannelSystem {
+		status = "sent"
+	}
+
+	return n.notifications.Create(&models.Notification{
+		UserID:  userID,
+		Type:    channel,
+		Title:   title,
+		Message: message,
+		Status:  status,
+	})
+}
+
+// Mail place l'email dans la file d'envoi
+func (n *storeNotifier) Mail(userID uint, subject, body string) error {
+	return n.emails.Create(&models.OutboundEmail{
+		UserID:  userID,
+		Subject: subject,
+		Body:    body,
+		Status:  "pending",
+	})
+}
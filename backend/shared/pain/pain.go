@@ -0,0 +1,292 @@
+// Package pain lit les ordres de virements groupés remis par les clients, au format ISO 20022
+// pain.001 (initiation de virements) ou dans une variante CSV, et produit le compte rendu
+// d'exécution au format pain.002 (statut des paiements).
+package pain
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxInstructions borne le nombre de virements d'un fichier
+const MaxInstructions = 1000
+
+// MessageNameCSV désigne la variante CSV dans le compte rendu
+const MessageNameCSV = "csv"
+
+// Erreurs de lecture d'un fichier ; le fichier est alors refusé dans son ensemble
+var (
+	ErrMalformed    = errors.New("fichier de virements illisible")
+	ErrEmpty        = errors.New("le fichier ne contient aucun virement")
+	ErrTooMany      = fmt.Errorf("le fichier contient plus de %d virements", MaxInstructions)
+	ErrNbOfTxs      = errors.New("le nombre de virements annoncé (NbOfTxs) ne correspond pas au contenu")
+	ErrControlSum   = errors.New("la somme de contrôle (CtrlSum) ne correspond pas au contenu")
+	ErrMissingMsgID = errors.New("identifiant du message (MsgId) manquant")
+)
+
+// Batch est un fichier de virements lu
+type Batch struct {
+	MessageID    string
+	MessageName  string // pain.001.001.03, csv...
+	NbOfTxs      int
+	ControlSum   string // somme de contrôle annoncée, vide si absente
+	Instructions []Instruction
+}
+
+// Instruction est un virement du fichier. Les champs sont ceux du fichier, sans contrôle
+// métier : la validation incombe à l'appelant.
+type Instruction struct {
+	PaymentInfoID string
+	InstructionID string
+	EndToEndID    string
+	DebtorIBAN    string
+	CreditorIBAN  string
+	CreditorName  string
+	Amount        string // décimal avec le point, dans Currency
+	Currency      string // vide : devise du compte débité
+	Remittance    string
+	// RequestedDate est la date d'exécution demandée (AAAA-MM-JJ), vide si absente
+	RequestedDate string
+}
+
+// pain001 reprend les éléments utiles d'un message pain.001, toutes versions confondues
+type pain001 struct {
+	XMLName  xml.Name
+	Initiate struct {
+		Header struct {
+			MessageID  string `xml:"MsgId"`
+			NbOfTxs    string `xml:"NbOfTxs"`
+			ControlSum string `xml:"CtrlSum"`
+		} `xml:"GrpHdr"`
+		Payments []struct {
+			ID            string `xml:"PmtInfId"`
+			NbOfTxs       string `xml:"NbOfTxs"`
+			ControlSum    string `xml:"CtrlSum"`
+			RequestedDate struct {
+				Value string `xml:",chardata"`
+				Date  string `xml:"Dt"` // à partir de pain.001.001.08
+			} `xml:"ReqdExctnDt"`
+			DebtorIBAN   string `xml:"DbtrAcct>Id>IBAN"`
+			Transactions []struct {
+				InstructionID string `xml:"PmtId>InstrId"`
+				EndToEndID    string `xml:"PmtId>EndToEndId"`
+				Amount        struct {
+					Currency string `xml:"Ccy,attr"`
+					Value    string `xml:",chardata"`
+				} `xml:"Amt>InstdAmt"`
+				CreditorName string   `xml:"Cdtr>Nm"`
+				CreditorIBAN string   `xml:"CdtrAcct>Id>IBAN"`
+				Remittance   []string `xml:"RmtInf>Ustrd"`
+			} `xml:"CdtTrfTxInf"`
+		} `xml:"PmtInf"`
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+// ParsePain001 lit un message pain.001 (CstmrCdtTrfInitn). Le nombre de virements et les
+// sommes de contrôle annoncés, au niveau du message comme de chaque lot, sont vérifiés.
+func ParsePain001(r io.Reader) (*Batch, error) {
+	var document pain001
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	header := document.Initiate.Header
+	if header.MessageID == "" {
+		return nil, ErrMissingMsgID
+	}
+
+	batch := &Batch{
+		MessageID:   strings.TrimSpace(header.MessageID),
+		MessageName: messageName(document.XMLName.Space),
+		ControlSum:  strings.TrimSpace(header.ControlSum),
+	}
+	for _, payment := range document.Initiate.Payments {
+		requested := strings.TrimSpace(payment.RequestedDate.Date)
+		if requested == "" {
+			requested = strings.TrimSpace(payment.RequestedDate.Value)
+		}
+		var amounts []string
+		for _, transaction := range payment.Transactions {
+			amounts = append(amounts, strings.TrimSpace(transaction.Amount.Value))
+			batch.Instructions = append(batch.Instructions, Instruction{
+				PaymentInfoID: strings.TrimSpace(payment.ID),
+				InstructionID: strings.TrimSpace(transaction.InstructionID),
+				EndToEndID:    strings.TrimSpace(transaction.EndToEndID),
+				DebtorIBAN:    strings.TrimSpace(payment.DebtorIBAN),
+				CreditorIBAN:  strings.TrimSpace(transaction.CreditorIBAN),
+				CreditorName:  strings.TrimSpace(transaction.CreditorName),
+				Amount:        strings.TrimSpace(transaction.Amount.Value),
+				Currency:      strings.TrimSpace(transaction.Amount.Currency),
+				Remittance:    strings.TrimSpace(strings.Join(transaction.Remittance, " ")),
+				RequestedDate: requested,
+			})
+		}
+		if err := checkTotals(payment.NbOfTxs, payment.ControlSum, amounts); err != nil {
+			return nil, fmt.Errorf("lot %s: %w", payment.ID, err)
+		}
+	}
+
+	var amounts []string
+	for _, instruction := range batch.Instructions {
+		amounts = append(amounts, instruction.Amount)
+	}
+	if err := checkTotals(header.NbOfTxs, header.ControlSum, amounts); err != nil {
+		return nil, err
+	}
+	return finish(batch)
+}
+
+// csvColumns sont les colonnes reconnues de la variante CSV ; debtor_iban, creditor_iban et
+// amount sont obligatoires
+var csvColumns = []string{"debtor_iban", "creditor_iban", "creditor_name", "amount", "currency", "end_to_end_id", "remittance_info", "execution_date"}
+
+// ParseCSV lit la variante CSV : une ligne d'en-tête nommant les colonnes (csvColumns, dans
+// un ordre quelconque, séparées par des virgules ou des points-virgules) puis un virement par
+// ligne. Le fichier n'ayant pas d'identifiant, messageID est fourni par l'appelant.
+func ParseCSV(r io.Reader, messageID string) (*Batch, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimPrefix(string(content), "\ufeff")
+
+	reader := csv.NewReader(strings.NewReader(text))
+	if header, _, _ := strings.Cut(text, "\n"); strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
+	}
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"debtor_iban", "creditor_iban", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: colonne %s absente (colonnes reconnues : %s)", ErrMalformed, required, strings.Join(csvColumns, ", "))
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	batch := &Batch{MessageID: messageID, MessageName: MessageNameCSV}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		// À défaut de référence, un virement est désigné par sa ligne dans le fichier
+		endToEnd := field(row, "end_to_end_id")
+		if endToEnd == "" {
+			line, _ := reader.FieldPos(0)
+			endToEnd = "LIGNE-" + strconv.Itoa(line)
+		}
+		batch.Instructions = append(batch.Instructions, Instruction{
+			PaymentInfoID: MessageNameCSV,
+			EndToEndID:    endToEnd,
+			DebtorIBAN:    field(row, "debtor_iban"),
+			CreditorIBAN:  field(row, "creditor_iban"),
+			CreditorName:  field(row, "creditor_name"),
+			Amount:        strings.Replace(field(row, "amount"), ",", ".", 1),
+			Currency:      strings.ToUpper(field(row, "currency")),
+			Remittance:    field(row, "remittance_info"),
+			RequestedDate: field(row, "execution_date"),
+		})
+		if len(batch.Instructions) > MaxInstructions {
+			return nil, ErrTooMany
+		}
+	}
+	return finish(batch)
+}
+
+// finish vérifie le nombre de virements et calcule les totaux du lot
+func finish(batch *Batch) (*Batch, error) {
+	switch {
+	case len(batch.Instructions) == 0:
+		return nil, ErrEmpty
+	case len(batch.Instructions) > MaxInstructions:
+		return nil, ErrTooMany
+	}
+	batch.NbOfTxs = len(batch.Instructions)
+	if batch.ControlSum == "" {
+		var amounts []string
+		for _, instruction := range batch.Instructions {
+			amounts = append(amounts, instruction.Amount)
+		}
+		if sum, ok := sumAmounts(amounts); ok {
+			batch.ControlSum = sum.FloatString(2)
+		}
+	}
+	return batch, nil
+}
+
+// checkTotals compare le nombre de virements et la somme de contrôle annoncés au contenu
+func checkTotals(nbOfTxs, controlSum string, amounts []string) error {
+	if nbOfTxs = strings.TrimSpace(nbOfTxs); nbOfTxs != "" && nbOfTxs != strconv.Itoa(len(amounts)) {
+		return ErrNbOfTxs
+	}
+	if controlSum = strings.TrimSpace(controlSum); controlSum == "" {
+		return nil
+	}
+	announced, ok := new(big.Rat).SetString(controlSum)
+	if !ok {
+		return ErrControlSum
+	}
+	// Un montant illisible sera refusé avec son virement ; la somme n'est alors pas vérifiable
+	if sum, ok := sumAmounts(amounts); ok && sum.Cmp(announced) != 0 {
+		return ErrControlSum
+	}
+	return nil
+}
+
+// sumAmounts additionne des montants décimaux sans perte de précision
+func sumAmounts(amounts []string) (*big.Rat, bool) {
+	sum := new(big.Rat)
+	for _, amount := range amounts {
+		value, ok := new(big.Rat).SetString(amount)
+		if !ok {
+			return nil, false
+		}
+		sum.Add(sum, value)
+	}
+	return sum, true
+}
+
+// messageName déduit la version du message de son espace de noms
+// (urn:iso:std:iso:20022:tech:xsd:pain.001.001.03)
+func messageName(namespace string) string {
+	if i := strings.LastIndex(namespace, ":"); i >= 0 && strings.HasPrefix(namespace[i+1:], "pain.001.") {
+		return namespace[i+1:]
+	}
+	return "pain.001"
+}
+
+// IsXML indique si le contenu d'un fichier remis est un message XML plutôt qu'un CSV
+func IsXML(content []byte) bool {
+	trimmed := strings.TrimLeft(strings.TrimPrefix(string(content), "\ufeff"), " \t\r\n")
+	return strings.HasPrefix(trimmed, "<")
+}
+
+// ParseDate lit une date d'exécution demandée (AAAA-MM-JJ)
+func ParseDate(value string) (time.Time, error) {
+	return time.Parse("2006-01-02", value)
+}
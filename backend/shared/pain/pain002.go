@@ -0,0 +1,152 @@
+package pain
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"banking-app/shared/models"
+)
+
+// reportNamespace est l'espace de noms du compte rendu produit
+const reportNamespace = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.03"
+
+// Codes de motif de refus ISO 20022 (ExternalStatusReason1Code)
+const (
+	ReasonIncorrectAccount  = "AC01" // IBAN invalide ou compte inconnu
+	ReasonClosedAccount     = "AC04" // compte clôturé
+	ReasonBlockedAccount    = "AC06" // compte inactif ou bloqué
+	ReasonTransactionDenied = "AG01" // opération non autorisée sur ce compte
+	ReasonNotAllowedAmount  = "AM02" // plafond de sorties de fonds dépassé
+	ReasonInsufficientFunds = "AM04"
+	ReasonDuplicate         = "AM05"
+	ReasonInvalidAmount     = "AM12"
+	ReasonCurrency          = "AM03" // devise différente de celle du compte débité
+	ReasonInvalidDate       = "DT01"
+	ReasonNarrative         = "NARR" // motif libre, détaillé dans AddtlInf
+)
+
+// reasonInfoWidth est la longueur maximale d'une information complémentaire (AddtlInf)
+const reasonInfoWidth = 105
+
+type report struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Report    struct {
+		Header struct {
+			MessageID string `xml:"MsgId"`
+			CreatedAt string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Group struct {
+			MessageID   string `xml:"OrgnlMsgId"`
+			MessageName string `xml:"OrgnlMsgNmId"`
+			NbOfTxs     int    `xml:"OrgnlNbOfTxs"`
+			ControlSum  string `xml:"OrgnlCtrlSum,omitempty"`
+			Status      string `xml:"GrpSts"`
+		} `xml:"OrgnlGrpInfAndSts"`
+		Payments []reportPayment `xml:"OrgnlPmtInfAndSts"`
+	} `xml:"CstmrPmtStsRpt"`
+}
+
+type reportPayment struct {
+	ID           string              `xml:"OrgnlPmtInfId"`
+	Status       string              `xml:"PmtInfSts"`
+	Transactions []reportTransaction `xml:"TxInfAndSts"`
+}
+
+type reportTransaction struct {
+	InstructionID string        `xml:"OrgnlInstrId,omitempty"`
+	EndToEndID    string        `xml:"OrgnlEndToEndId"`
+	Status        string        `xml:"TxSts"`
+	Reason        *reportReason `xml:"StsRsnInf,omitempty"`
+	Reference     string        `xml:"AcctSvcrRef,omitempty"`
+}
+
+type reportReason struct {
+	Code string `xml:"Rsn>Cd"`
+	Info string `xml:"AddtlInf,omitempty"`
+}
+
+// WriteReport écrit le compte rendu pain.002 (CstmrPmtStsRpt) d'un lot exécuté : statut du
+// message, de chaque lot (PmtInf) et de chaque virement avec son motif de refus. Le
+// compte rendu est daté de la dernière mise à jour du lot et se reproduit donc à l'identique.
+func WriteReport(w io.Writer, batch *models.PaymentBatch) error {
+	document := report{Namespace: reportNamespace}
+	document.Report.Header.MessageID = fmt.Sprintf("STS-%d", batch.ID)
+	document.Report.Header.CreatedAt = batch.UpdatedAt.UTC().Format(time.RFC3339)
+	group := &document.Report.Group
+	group.MessageID = batch.MessageID
+	group.MessageName = batch.MessageName
+	group.NbOfTxs = batch.NbOfTxs
+	group.ControlSum = batch.ControlSum
+	group.Status = batch.Status
+
+	positions := map[string]int{}
+	for _, item := range batch.Items {
+		i, ok := positions[item.PaymentInfoID]
+		if !ok {
+			i = len(document.Report.Payments)
+			positions[item.PaymentInfoID] = i
+			document.Report.Payments = append(document.Report.Payments, reportPayment{ID: item.PaymentInfoID})
+		}
+		payment := &document.Report.Payments[i]
+
+		transaction := reportTransaction{
+			InstructionID: item.InstructionID,
+			EndToEndID:    item.EndToEndID,
+			Status:        item.Status,
+			Reference:     item.Reference,
+		}
+		if item.ReasonCode != "" {
+			transaction.Reason = &reportReason{Code: item.ReasonCode, Info: truncate(item.ReasonInfo, reasonInfoWidth)}
+		}
+		payment.Transactions = append(payment.Transactions, transaction)
+	}
+	for i := range document.Report.Payments {
+		document.Report.Payments[i].Status = paymentStatus(document.Report.Payments[i].Transactions)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// GroupStatus retourne le statut d'un ensemble de virements : ACSC s'ils sont tous exécutés,
+// RJCT s'ils sont tous refusés, PART sinon
+func GroupStatus(accepted, rejected int) string {
+	switch {
+	case rejected == 0:
+		return models.PaymentStatusCompleted
+	case accepted == 0:
+		return models.PaymentStatusRejected
+	}
+	return models.PaymentStatusPartial
+}
+
+func paymentStatus(transactions []reportTransaction) string {
+	accepted, rejected := 0, 0
+	for _, transaction := range transactions {
+		if transaction.Status == models.PaymentStatusCompleted {
+			accepted++
+		} else {
+			rejected++
+		}
+	}
+	return GroupStatus(accepted, rejected)
+}
+
+func truncate(value string, width int) string {
+	runes := []rune(value)
+	if len(runes) <= width {
+		return value
+	}
+	return string(runes[:width])
+}
@@ -0,0 +1,166 @@
+package pain
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-app/shared/models"
+)
+
+const samplePain001 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>FOURN-2024-03</MsgId>
+      <CreDtTm>2024-03-01T09:00:00</CreDtTm>
+      <NbOfTxs>3</NbOfTxs>
+      <CtrlSum>1250.50</CtrlSum>
+      <InitgPty><Nm>Martin SARL</Nm></InitgPty>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>LOT-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <NbOfTxs>2</NbOfTxs>
+      <CtrlSum>250.50</CtrlSum>
+      <ReqdExctnDt>2024-03-01</ReqdExctnDt>
+      <Dbtr><Nm>Martin SARL</Nm></Dbtr>
+      <DbtrAcct><Id><IBAN>FR7699999000010000000000101</IBAN></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><InstrId>I-1</InstrId><EndToEndId>FACT-001</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="EUR">200.00</InstdAmt></Amt>
+        <Cdtr><Nm>Papeterie Durand</Nm></Cdtr>
+        <CdtrAcct><Id><IBAN>FR7699999000010000000000202</IBAN></Id></CdtrAcct>
+        <RmtInf><Ustrd>Facture 001</Ustrd></RmtInf>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>FACT-002</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="EUR">50.50</InstdAmt></Amt>
+        <Cdtr><Nm>Café du Port</Nm></Cdtr>
+        <CdtrAcct><Id><IBAN>FR7699999000010000000000303</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+    <PmtInf>
+      <PmtInfId>LOT-2</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <ReqdExctnDt><Dt>2024-03-05</Dt></ReqdExctnDt>
+      <DbtrAcct><Id><IBAN>FR7699999000010000000000101</IBAN></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>LOYER-03</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="EUR">1000</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>FR7699999000010000000000404</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func TestParsePain001(t *testing.T) {
+	batch, err := ParsePain001(strings.NewReader(samplePain001))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.MessageID != "FOURN-2024-03" || batch.MessageName != "pain.001.001.03" || batch.NbOfTxs != 3 || batch.ControlSum != "1250.50" {
+		t.Fatalf("lot = %+v", batch)
+	}
+
+	first, last := batch.Instructions[0], batch.Instructions[2]
+	if first.PaymentInfoID != "LOT-1" || first.InstructionID != "I-1" || first.EndToEndID != "FACT-001" ||
+		first.DebtorIBAN != "FR7699999000010000000000101" || first.CreditorName != "Papeterie Durand" ||
+		first.Amount != "200.00" || first.Currency != "EUR" || first.Remittance != "Facture 001" || first.RequestedDate != "2024-03-01" {
+		t.Errorf("premier virement = %+v", first)
+	}
+	if last.PaymentInfoID != "LOT-2" || last.RequestedDate != "2024-03-05" || last.Amount != "1000" {
+		t.Errorf("dernier virement = %+v", last)
+	}
+}
+
+func TestParsePain001RejectsInconsistentTotals(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string
+		err     error
+	}{
+		{"somme de contrôle du message", [2]string{"<CtrlSum>1250.50</CtrlSum>", "<CtrlSum>1250.00</CtrlSum>"}, ErrControlSum},
+		{"somme de contrôle d'un lot", [2]string{"<CtrlSum>250.50</CtrlSum>", "<CtrlSum>250.51</CtrlSum>"}, ErrControlSum},
+		{"nombre de virements", [2]string{"<NbOfTxs>3</NbOfTxs>", "<NbOfTxs>4</NbOfTxs>"}, ErrNbOfTxs},
+		{"sans identifiant", [2]string{"<MsgId>FOURN-2024-03</MsgId>", ""}, ErrMissingMsgID},
+		{"XML invalide", [2]string{"</Document>", ""}, ErrMalformed},
+	}
+	for _, tt := range tests {
+		content := strings.Replace(samplePain001, tt.replace[0], tt.replace[1], 1)
+		if _, err := ParsePain001(strings.NewReader(content)); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, attendu %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	content := "\ufeffdebtor_iban;creditor_iban;amount;creditor_name;remittance_info\n" +
+		"FR7699999000010000000000101;FR7699999000010000000000202;12,50;Durand;Facture 7\n" +
+		"\n" +
+		"FR7699999000010000000000101;FR7699999000010000000000303;7.5;;\n"
+	batch, err := ParseCSV(strings.NewReader(content), "CSV-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.MessageID != "CSV-1" || batch.MessageName != MessageNameCSV || batch.NbOfTxs != 2 || batch.ControlSum != "20.00" {
+		t.Fatalf("lot = %+v", batch)
+	}
+	if first := batch.Instructions[0]; first.Amount != "12.50" || first.CreditorName != "Durand" || first.EndToEndID != "LIGNE-2" {
+		t.Errorf("premier virement = %+v", first)
+	}
+	if second := batch.Instructions[1]; second.EndToEndID != "LIGNE-4" || second.Currency != "" {
+		t.Errorf("second virement = %+v", second)
+	}
+
+	if _, err := ParseCSV(strings.NewReader("iban,montant\nFR76,1\n"), "CSV-2"); !errors.Is(err, ErrMalformed) {
+		t.Errorf("colonnes inconnues: err = %v, attendu ErrMalformed", err)
+	}
+	if _, err := ParseCSV(strings.NewReader("debtor_iban,creditor_iban,amount\n"), "CSV-3"); !errors.Is(err, ErrEmpty) {
+		t.Errorf("fichier vide: err = %v, attendu ErrEmpty", err)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	debit := uint(7)
+	batch := &models.PaymentBatch{
+		ID:          12,
+		MessageID:   "FOURN-2024-03",
+		MessageName: "pain.001.001.03",
+		Status:      models.PaymentStatusPartial,
+		NbOfTxs:     2,
+		ControlSum:  "250.50",
+		UpdatedAt:   time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC),
+		Items: []models.PaymentBatchItem{
+			{PaymentInfoID: "LOT-1", InstructionID: "I-1", EndToEndID: "FACT-001", Status: models.PaymentStatusCompleted, Reference: "TXN-1", DebitTransactionID: &debit},
+			{PaymentInfoID: "LOT-1", EndToEndID: "FACT-002", Status: models.PaymentStatusRejected, ReasonCode: ReasonInsufficientFunds, ReasonInfo: "Solde insuffisant"},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := WriteReport(&out, batch); err != nil {
+		t.Fatal(err)
+	}
+	report := out.String()
+	for _, want := range []string{
+		`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03">`,
+		"<MsgId>STS-12</MsgId>",
+		"<CreDtTm>2024-03-01T09:30:00Z</CreDtTm>",
+		"<OrgnlMsgId>FOURN-2024-03</OrgnlMsgId>",
+		"<GrpSts>PART</GrpSts>",
+		"<OrgnlPmtInfId>LOT-1</OrgnlPmtInfId>",
+		"<PmtInfSts>PART</PmtInfSts>",
+		"<OrgnlInstrId>I-1</OrgnlInstrId>",
+		"<AcctSvcrRef>TXN-1</AcctSvcrRef>",
+		"<Cd>AM04</Cd>",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("compte rendu sans %q:\n%s", want, report)
+		}
+	}
+	if strings.Count(report, "<TxInfAndSts>") != 2 || strings.Count(report, "<OrgnlPmtInfAndSts>") != 1 {
+		t.Errorf("compte rendu mal structuré:\n%s", report)
+	}
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormAccountRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormAccountRepo) ListByUser(userID uint) ([]models.Account, error) {
+	var accounts []models.Account
+	if err := r.db.Where("user_id = ?", userID).Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *gormAccountRepo) Search(userID uint, status string, page utils.PageRequest) ([]models.Account, error) {
+	var accounts []models.Account
+	db := paginate(r.db, "accounts", page)
+	if userID != 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	err := db.Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *gormAccountRepo) FindByID(id uint) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.First(&account, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &account, nil
+}
+
+func (r *gormAccountRepo) FindByNumber(accountNumber string) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Where("account_number = ?", accountNumber).First(&account).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &account, nil
+}
+
+func (r *gormAccountRepo) FindForUser(id, userID uint) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&account).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &account, nil
+}
+
+func (r *gormAccountRepo) FindForUserWithTransactions(id, userID uint) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).
+		Preload("Transactions").First(&account).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &account, nil
+}
+
+func (r *gormAccountRepo) LockByID(id uint) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).First(&account).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &account, nil
+}
+
+func (r *gormAccountRepo) LockForUser(id, userID uint) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", id, userID).First(&account).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &account, nil
+}
+
+func (r *gormAccountRepo) Create(account *models.Account) error {
+	return r.db.Create(account).Error
+}
+
+// maxNumberAttempts borne les tirages de numéro de compte ; une collision est déjà improbable
+const maxNumberAttempts = 5
+
+func (r *gormAccountRepo) CreateWithNumber(account *models.Account, generate func() (string, error)) error {
+	for attempt := 0; attempt < maxNumberAttempts; attempt++ {
+		number, err := generate()
+		if err != nil {
+			return err
+		}
+		taken, err := r.numberTaken(number)
+		if err != nil {
+			return err
+		}
+		if taken {
+			continue
+		}
+
+		account.AccountNumber = number
+		err = r.db.Create(account).Error
+		if err == nil {
+			return nil
+		}
+		// Un compte concurrent a pu prendre le numéro entre la vérification et l'insertion
+		if taken, _ := r.numberTaken(number); !taken {
+			return err
+		}
+		account.ID = 0
+	}
+	return ErrNumberUnavailable
+}
+
+// numberTaken indique si le numéro est attribué, y compris à un compte supprimé
+func (r *gormAccountRepo) numberTaken(number string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Account{}).Where("account_number = ?", number).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormAccountRepo) Save(account *models.Account) error {
+	// Les soldes ne sont modifiés que par le grand livre et les réservations, en SQL atomique
+	return r.db.Omit("balance_minor", "held_minor").Save(account).Error
+}
+
+func (r *gormAccountRepo) Reserve(id uint, amount models.Money) error {
+	result := r.db.Model(&models.Account{}).
+		Where("id = ? AND currency = ? AND balance_minor - held_minor + credit_limit_minor >= ?", id, amount.Currency, amount.Amount).
+		Update("held_minor", gorm.Expr("held_minor + ?", amount.Amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ledger.ErrInsufficientFunds
+	}
+	return nil
+}
+
+func (r *gormAccountRepo) Release(id uint, amount models.Money) error {
+	result := r.db.Model(&models.Account{}).
+		Where("id = ? AND currency = ? AND held_minor >= ?", id, amount.Currency, amount.Amount).
+		Update("held_minor", gorm.Expr("held_minor - ?", amount.Amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("réservation de %s supérieure aux fonds réservés du compte %d", amount, id)
+	}
+	return nil
+}
+
+func (r *gormAccountRepo) ListInterestDue(through time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Account{}).
+		Where("overdraft_rate_bps > 0 AND status <> ?", "closed").
+		Where("interest_charged_through IS NULL OR interest_charged_through < ?", through).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *gormAccountRepo) CreateLimitChange(change *models.AccountLimitChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *gormAccountRepo) ListLimitChanges(accountID uint) ([]models.AccountLimitChange, error) {
+	var changes []models.AccountLimitChange
+	err := r.db.Where("account_id = ?", accountID).Order("created_at DESC, id DESC").Find(&changes).Error
+	return changes, err
+}
+
+func (r *gormAccountRepo) Delete(account *models.Account) error {
+	return r.db.Delete(account).Error
+}
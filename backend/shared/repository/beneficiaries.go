@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+
+	"gorm.io/gorm"
+)
+
+type gormBeneficiaryRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormBeneficiaryRepo) Create(beneficiary *models.Beneficiary) error {
+	return r.db.Create(beneficiary).Error
+}
+
+func (r *gormBeneficiaryRepo) FindForUser(id, userID uint) (*models.Beneficiary, error) {
+	var beneficiary models.Beneficiary
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&beneficiary).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &beneficiary, nil
+}
+
+func (r *gormBeneficiaryRepo) FindByIBAN(userID uint, iban string) (*models.Beneficiary, error) {
+	var beneficiary models.Beneficiary
+	if err := r.db.Where("user_id = ? AND iban = ?", userID, iban).First(&beneficiary).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &beneficiary, nil
+}
+
+func (r *gormBeneficiaryRepo) ListPage(userID uint, page utils.PageRequest) ([]models.Beneficiary, error) {
+	var beneficiaries []models.Beneficiary
+	err := paginate(r.db, "beneficiaries", page).
+		Where("user_id = ?", userID).
+		Find(&beneficiaries).Error
+	return beneficiaries, err
+}
+
+func (r *gormBeneficiaryRepo) Save(beneficiary *models.Beneficiary) error {
+	return r.db.Save(beneficiary).Error
+}
+
+func (r *gormBeneficiaryRepo) Delete(beneficiary *models.Beneficiary) error {
+	return r.db.Delete(beneficiary).Error
+}
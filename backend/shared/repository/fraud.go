@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormFraudRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormFraudRepo) Create(decision *models.FraudDecision) error {
+	return r.db.Create(decision).Error
+}
+
+func (r *gormFraudRepo) Save(decision *models.FraudDecision) error {
+	return r.db.Omit(clause.Associations).Save(decision).Error
+}
+
+func (r *gormFraudRepo) FindByID(id uint) (*models.FraudDecision, error) {
+	var decision models.FraudDecision
+	if err := r.db.Preload("Hits", orderByID).First(&decision, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &decision, nil
+}
+
+func (r *gormFraudRepo) LockByID(id uint) (*models.FraudDecision, error) {
+	var decision models.FraudDecision
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&decision, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &decision, nil
+}
+
+func (r *gormFraudRepo) ListPage(filter FraudFilter, page utils.PageRequest) ([]models.FraudDecision, error) {
+	db := paginate(r.db, "fraud_decisions", page).Preload("Hits", orderByID)
+	if len(filter.Statuses) > 0 {
+		db = db.Where("status IN ?", filter.Statuses)
+	}
+	if filter.UserID != 0 {
+		db = db.Where("user_id = ?", filter.UserID)
+	}
+	var decisions []models.FraudDecision
+	err := db.Find(&decisions).Error
+	return decisions, err
+}
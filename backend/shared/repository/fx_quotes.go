@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"time"
+
+	"banking-app/shared/models"
+
+	"gorm.io/gorm"
+)
+
+type gormFXQuoteRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormFXQuoteRepo) Create(quote *models.FXQuote) error {
+	return r.db.Create(quote).Error
+}
+
+func (r *gormFXQuoteRepo) FindForUser(id string, userID uint) (*models.FXQuote, error) {
+	var quote models.FXQuote
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&quote).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &quote, nil
+}
+
+func (r *gormFXQuoteRepo) Consume(id string, userID uint, now time.Time) error {
+	// Mise à jour conditionnelle : une cotation ne sert qu'à un seul virement, même en concurrence
+	result := r.db.Model(&models.FXQuote{}).
+		Where("id = ? AND user_id = ? AND used_at IS NULL AND expires_at > ?", id, userID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
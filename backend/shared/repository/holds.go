@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormHoldRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormHoldRepo) Create(hold *models.Hold) error {
+	return r.db.Create(hold).Error
+}
+
+func (r *gormHoldRepo) FindForUser(id, userID uint) (*models.Hold, error) {
+	var hold models.Hold
+	if err := r.db.
+		Joins("JOIN accounts ON holds.account_id = accounts.id").
+		Where("holds.id = ? AND accounts.user_id = ?", id, userID).
+		First(&hold).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &hold, nil
+}
+
+func (r *gormHoldRepo) LockByID(id uint) (*models.Hold, error) {
+	var hold models.Hold
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).First(&hold).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &hold, nil
+}
+
+func (r *gormHoldRepo) List(filter HoldFilter, page utils.PageRequest) ([]models.Hold, error) {
+	db := paginate(r.db, "holds", page)
+	if filter.UserID != 0 {
+		db = db.Joins("JOIN accounts ON holds.account_id = accounts.id").
+			Where("accounts.user_id = ?", filter.UserID)
+	}
+	if filter.AccountID != 0 {
+		db = db.Where("holds.account_id = ?", filter.AccountID)
+	}
+	if filter.Status != "" {
+		db = db.Where("holds.status = ?", filter.Status)
+	}
+
+	var holds []models.Hold
+	err := db.Find(&holds).Error
+	return holds, err
+}
+
+func (r *gormHoldRepo) Save(hold *models.Hold) error {
+	return r.db.Save(hold).Error
+}
+
+func (r *gormHoldRepo) ListExpired(now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Hold{}).
+		Where("status = ? AND expires_at <= ?", models.HoldAuthorized, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
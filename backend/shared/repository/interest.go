@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"time"
+
+	"banking-app/shared/models"
+
+	"gorm.io/gorm"
+)
+
+type gormInterestRepo struct {
+	db *gorm.DB
+}
+
+// orderTiers précharge les tranches d'un produit par seuil croissant
+func orderTiers(db *gorm.DB) *gorm.DB {
+	return db.Order("min_balance_minor ASC")
+}
+
+func (r *gormInterestRepo) CreateProduct(product *models.InterestProduct) error {
+	return r.db.Create(product).Error
+}
+
+func (r *gormInterestRepo) FindProduct(id uint) (*models.InterestProduct, error) {
+	var product models.InterestProduct
+	if err := r.db.Preload("Tiers", orderTiers).Where("id = ?", id).First(&product).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &product, nil
+}
+
+func (r *gormInterestRepo) FindProductByCode(code string) (*models.InterestProduct, error) {
+	var product models.InterestProduct
+	if err := r.db.Preload("Tiers", orderTiers).Where("code = ?", code).First(&product).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &product, nil
+}
+
+func (r *gormInterestRepo) ListProducts(activeOnly bool) ([]models.InterestProduct, error) {
+	db := r.db.Preload("Tiers", orderTiers).Order("id ASC")
+	if activeOnly {
+		db = db.Where("active = ?", true)
+	}
+	var products []models.InterestProduct
+	err := db.Find(&products).Error
+	return products, err
+}
+
+func (r *gormInterestRepo) SaveProduct(product *models.InterestProduct) error {
+	return r.db.Omit("Tiers").Save(product).Error
+}
+
+func (r *gormInterestRepo) ListAccruingAccounts(accountID uint) ([]models.Account, error) {
+	db := r.db.Where("account_type = ? AND interest_product_id IS NOT NULL AND status <> ?", "savings", "closed")
+	if accountID != 0 {
+		db = db.Where("id = ?", accountID)
+	}
+	var accounts []models.Account
+	err := db.Order("id ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *gormInterestRepo) LastAccrualDate(accountID uint) (*time.Time, error) {
+	var accruals []models.InterestAccrual
+	err := r.db.Where("account_id = ?", accountID).Order("accrual_date DESC").Limit(1).Find(&accruals).Error
+	if err != nil || len(accruals) == 0 {
+		return nil, err
+	}
+	return &accruals[0].AccrualDate, nil
+}
+
+func (r *gormInterestRepo) AccrualExists(accountID uint, day time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.InterestAccrual{}).
+		Where("account_id = ? AND accrual_date = ?", accountID, day).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormInterestRepo) CreateAccrual(accrual *models.InterestAccrual) error {
+	return r.db.Create(accrual).Error
+}
+
+func (r *gormInterestRepo) SumUnpaid(accountID uint, before time.Time) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.InterestAccrual{}).
+		Select("COALESCE(SUM(amount_micros), 0)").
+		Where("account_id = ? AND transaction_id IS NULL AND accrual_date < ?", accountID, before).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *gormInterestRepo) MarkPaid(accountID uint, before time.Time, transactionID uint) error {
+	return r.db.Model(&models.InterestAccrual{}).
+		Where("account_id = ? AND transaction_id IS NULL AND accrual_date < ?", accountID, before).
+		Update("transaction_id", transactionID).Error
+}
+
+func (r *gormInterestRepo) ListAccruals(accountID uint, limit int) ([]models.InterestAccrual, error) {
+	var accruals []models.InterestAccrual
+	err := r.db.Where("account_id = ?", accountID).
+		Order("accrual_date DESC").
+		Limit(limit).
+		Find(&accruals).Error
+	return accruals, err
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"time"
+
+	"banking-app/shared/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormLoginAttemptRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormLoginAttemptRepo) Get(key string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	if err := r.db.Where("attempt_key = ?", key).First(&attempt).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &attempt, nil
+}
+
+func (r *gormLoginAttemptRepo) RecordFailure(key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	// Incrément atomique ; le compteur repart de 1 si le dernier échec est plus ancien que window.
+	// failures est assigné avant last_failure_at (ordre alphabétique) : MySQL évalue les
+	// affectations dans l'ordre et la condition doit voir l'ancienne date.
+	attempt := models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "attempt_key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END", now.Add(-window)),
+			"last_failure_at": now,
+		}),
+	}).Create(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(key)
+}
+
+func (r *gormLoginAttemptRepo) Reset(key string) error {
+	return r.db.Where("attempt_key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
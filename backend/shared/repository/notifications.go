@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+
+	"gorm.io/gorm"
+)
+
+type gormNotificationRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormNotificationRepo) ListByUser(userID uint) ([]models.Notification, error) {
+	var notifications []models.Notification
+	if err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *gormNotificationRepo) ListPage(userID uint, page utils.PageRequest) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := paginate(r.db, "notifications", page).
+		Where("user_id = ?", userID).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *gormNotificationRepo) FindForUser(id, userID uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &notification, nil
+}
+
+func (r *gormNotificationRepo) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *gormNotificationRepo) Save(notification *models.Notification) error {
+	return r.db.Save(notification).Error
+}
+
+func (r *gormNotificationRepo) Delete(notification *models.Notification) error {
+	return r.db.Delete(notification).Error
+}
+
+type gormOutboundEmailRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormOutboundEmailRepo) ListByUser(userID uint) ([]models.OutboundEmail, error) {
+	var emails []models.OutboundEmail
+	if err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Find(&emails).Error; err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+func (r *gormOutboundEmailRepo) Create(email *models.OutboundEmail) error {
+	return r.db.Create(email).Error
+}
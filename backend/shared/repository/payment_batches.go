@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"banking-app/shared/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormPaymentBatchRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormPaymentBatchRepo) Create(batch *models.PaymentBatch) error {
+	return r.db.Create(batch).Error
+}
+
+func (r *gormPaymentBatchRepo) Save(batch *models.PaymentBatch) error {
+	return r.db.Omit(clause.Associations).Save(batch).Error
+}
+
+func (r *gormPaymentBatchRepo) SaveItem(item *models.PaymentBatchItem) error {
+	return r.db.Save(item).Error
+}
+
+func (r *gormPaymentBatchRepo) FindForUser(id, userID uint) (*models.PaymentBatch, error) {
+	var batch models.PaymentBatch
+	err := r.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&batch).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &batch, nil
+}
+
+func (r *gormPaymentBatchRepo) FindByMessageID(userID uint, messageID string) (*models.PaymentBatch, error) {
+	var batch models.PaymentBatch
+	if err := r.db.Where("user_id = ? AND message_id = ?", userID, messageID).First(&batch).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &batch, nil
+}
+
+func (r *gormPaymentBatchRepo) ListForUser(userID uint) ([]models.PaymentBatch, error) {
+	var batches []models.PaymentBatch
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&batches).Error
+	return batches, err
+}
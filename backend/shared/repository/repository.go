@@ -0,0 +1,382 @@
+// Package repository définit l'accès aux données des services derrière des interfaces,
+// avec une implémentation gorm utilisable sur MySQL comme sur SQLite.
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound est retourné lorsqu'aucun enregistrement ne correspond
+var ErrNotFound = errors.New("enregistrement non trouvé")
+
+// ErrNumberUnavailable est retourné lorsqu'aucun numéro de compte libre n'a pu être tiré
+var ErrNumberUnavailable = errors.New("aucun numéro de compte disponible")
+
+// UserRepo donne accès aux utilisateurs
+type UserRepo interface {
+	FindByID(id uint) (*models.User, error)
+	// LockByID pose un verrou SELECT ... FOR UPDATE jusqu'à la fin de la transaction
+	LockByID(id uint) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	Create(user *models.User) error
+	Save(user *models.User) error
+	// Search recherche les utilisateurs dont l'email, le prénom ou le nom contient query
+	Search(query string, page utils.PageRequest) ([]models.User, error)
+	// AdvanceTOTPStep enregistre le pas TOTP consommé ; ErrNotFound s'il n'est pas postérieur au dernier
+	AdvanceTOTPStep(id uint, step int64) error
+}
+
+// AccountRepo donne accès aux comptes bancaires
+type AccountRepo interface {
+	ListByUser(userID uint) ([]models.Account, error)
+	// Search liste une page de comptes ; un filtre vide (0, "") est ignoré
+	Search(userID uint, status string, page utils.PageRequest) ([]models.Account, error)
+	FindByID(id uint) (*models.Account, error)
+	FindByNumber(accountNumber string) (*models.Account, error)
+	FindForUser(id, userID uint) (*models.Account, error)
+	FindForUserWithTransactions(id, userID uint) (*models.Account, error)
+	// LockByID et LockForUser posent un verrou SELECT ... FOR UPDATE jusqu'à la fin de la transaction
+	LockByID(id uint) (*models.Account, error)
+	LockForUser(id, userID uint) (*models.Account, error)
+	Create(account *models.Account) error
+	// CreateWithNumber crée le compte avec un numéro tiré par generate, retiré en cas de collision
+	CreateWithNumber(account *models.Account, generate func() (string, error)) error
+	// Save enregistre le compte sans toucher aux soldes, modifiés par le grand livre et les réservations
+	Save(account *models.Account) error
+	Delete(account *models.Account) error
+	// Reserve ajoute amount aux fonds réservés ; ledger.ErrInsufficientFunds si le solde disponible ne suffit pas
+	Reserve(id uint, amount models.Money) error
+	// Release libère amount des fonds réservés
+	Release(id uint, amount models.Money) error
+	// ListInterestDue retourne les comptes soumis aux intérêts débiteurs non facturés jusqu'à through
+	ListInterestDue(through time.Time, limit int) ([]uint, error)
+	// CreateLimitChange trace une modification de plafond ; ListLimitChanges les retourne, la plus récente d'abord
+	CreateLimitChange(change *models.AccountLimitChange) error
+	ListLimitChanges(accountID uint) ([]models.AccountLimitChange, error)
+}
+
+// TransactionRepo donne accès aux transactions et aux lignes du grand livre
+type TransactionRepo interface {
+	// List retourne une page de transactions correspondant au filtre
+	List(filter TransactionFilter, page utils.PageRequest) ([]models.Transaction, error)
+	FindByID(id uint) (*models.Transaction, error)
+	FindForUser(id, userID uint) (*models.Transaction, error)
+	// ListByIDs retourne les transactions ids, dans un ordre quelconque
+	ListByIDs(ids []uint) ([]models.Transaction, error)
+	// LockByJournalEntry verrouille les transactions nées de l'écriture entryID (les deux
+	// jambes d'un virement), hors annulations
+	LockByJournalEntry(entryID uint) ([]models.Transaction, error)
+	// ListOutgoingTransfers retourne les virements émis par les comptes du client vers un
+	// autre client depuis since (jambes de débit), au plus limit, le plus récent d'abord
+	ListOutgoingTransfers(userID uint, since time.Time, limit int) ([]models.Transaction, error)
+	Create(transaction *models.Transaction) error
+	Save(transaction *models.Transaction) error
+	ListPostings(accountID uint) ([]models.Posting, error)
+	ListEntryPostings(entryID uint) ([]models.Posting, error)
+}
+
+// TransactionFilter restreint une liste de transactions ; les champs vides sont ignorés
+type TransactionFilter struct {
+	UserID      uint
+	AccountID   uint
+	Type        string
+	Status      string
+	Currency    string
+	Description string // recherche sans distinction de casse
+	MinAmount   *int64 // unités mineures, bornes incluses
+	MaxAmount   *int64
+	From        *time.Time // created_at >= From
+	To          *time.Time // created_at < To
+}
+
+// NotificationRepo donne accès aux notifications
+type NotificationRepo interface {
+	ListByUser(userID uint) ([]models.Notification, error)
+	ListPage(userID uint, page utils.PageRequest) ([]models.Notification, error)
+	FindForUser(id, userID uint) (*models.Notification, error)
+	Create(notification *models.Notification) error
+	Save(notification *models.Notification) error
+	Delete(notification *models.Notification) error
+}
+
+// OutboundEmailRepo donne accès à la file d'envoi des emails confidentiels
+type OutboundEmailRepo interface {
+	// ListByUser retourne les emails de l'utilisateur, le plus récent d'abord
+	ListByUser(userID uint) ([]models.OutboundEmail, error)
+	Create(email *models.OutboundEmail) error
+}
+
+// SessionRepo donne accès aux sessions de connexion (refresh tokens)
+type SessionRepo interface {
+	Create(session *models.AuthSession) error
+	FindByID(id string) (*models.AuthSession, error)
+	FindByRefreshHash(hash string) (*models.AuthSession, error)
+	FindByPreviousHash(hash string) (*models.AuthSession, error)
+	ListActiveByUser(userID uint, now time.Time) ([]models.AuthSession, error)
+	// Rotate remplace le refresh token oldHash par newHash ; ErrNotFound si oldHash n'est plus courant
+	Rotate(id, oldHash, newHash string, now time.Time) error
+	// Revoke révoque une session de l'utilisateur ; ErrNotFound si elle est absente ou déjà révoquée
+	Revoke(id string, userID uint, now time.Time) error
+	RevokeAllForUser(userID uint, now time.Time) (int64, error)
+}
+
+// RecoveryCodeRepo donne accès aux codes de secours de la double authentification
+type RecoveryCodeRepo interface {
+	// Replace remplace tous les codes de l'utilisateur par hashes
+	Replace(userID uint, hashes []string) error
+	// Consume marque un code comme utilisé ; ErrNotFound s'il est inconnu ou déjà utilisé
+	Consume(userID uint, hash string, now time.Time) error
+	CountUnused(userID uint) (int64, error)
+	DeleteForUser(userID uint) error
+}
+
+// UserTokenRepo donne accès aux tokens à usage unique envoyés par email
+type UserTokenRepo interface {
+	Create(token *models.UserToken) error
+	// Consume marque le token comme utilisé ; ErrNotFound s'il est inconnu, expiré ou déjà utilisé
+	Consume(id string, userID uint, purpose string, now time.Time) error
+	// InvalidateForUser invalide les tokens encore valides de l'utilisateur pour cet usage
+	InvalidateForUser(userID uint, purpose string, now time.Time) error
+}
+
+// LoginAttemptRepo compte les échecs de connexion par clé (email, adresse IP)
+type LoginAttemptRepo interface {
+	Get(key string) (*models.LoginAttempt, error)
+	// RecordFailure incrémente le compteur de key, remis à zéro après window sans échec
+	RecordFailure(key string, now time.Time, window time.Duration) (*models.LoginAttempt, error)
+	Reset(key string) error
+}
+
+// FXQuoteRepo donne accès aux cotations de change
+type FXQuoteRepo interface {
+	Create(quote *models.FXQuote) error
+	FindForUser(id string, userID uint) (*models.FXQuote, error)
+	// Consume marque la cotation comme utilisée ; ErrNotFound si elle est inconnue, expirée ou déjà utilisée
+	Consume(id string, userID uint, now time.Time) error
+}
+
+// StandingOrderRepo donne accès aux virements programmés et à la trace de leurs exécutions
+type StandingOrderRepo interface {
+	Create(order *models.StandingOrder) error
+	FindForUser(id, userID uint) (*models.StandingOrder, error)
+	// LockForUser pose un verrou SELECT ... FOR UPDATE jusqu'à la fin de la transaction
+	LockForUser(id, userID uint) (*models.StandingOrder, error)
+	ListPage(userID uint, page utils.PageRequest) ([]models.StandingOrder, error)
+	Save(order *models.StandingOrder) error
+	// ListDue retourne les identifiants des ordres actifs échus à now, les plus en retard d'abord
+	ListDue(now time.Time, limit int) ([]uint, error)
+	// LockDue verrouille l'ordre s'il est toujours actif et échu ; ErrNotFound sinon,
+	// par exemple lorsqu'une autre instance vient d'exécuter l'échéance
+	LockDue(id uint, now time.Time) (*models.StandingOrder, error)
+	CreateRun(run *models.StandingOrderRun) error
+	// ListRuns retourne les dernières exécutions de l'ordre, les plus récentes d'abord
+	ListRuns(orderID uint, limit int) ([]models.StandingOrderRun, error)
+}
+
+// HoldFilter restreint une liste de réservations ; les champs vides sont ignorés
+type HoldFilter struct {
+	UserID    uint
+	AccountID uint
+	Status    string
+}
+
+// HoldRepo donne accès aux réservations de fonds (autorisations de paiement)
+type HoldRepo interface {
+	Create(hold *models.Hold) error
+	FindForUser(id, userID uint) (*models.Hold, error)
+	// LockByID pose un verrou SELECT ... FOR UPDATE jusqu'à la fin de la transaction
+	LockByID(id uint) (*models.Hold, error)
+	List(filter HoldFilter, page utils.PageRequest) ([]models.Hold, error)
+	Save(hold *models.Hold) error
+	// ListExpired retourne les identifiants des réservations en cours échues à now
+	ListExpired(now time.Time, limit int) ([]uint, error)
+}
+
+// InterestRepo donne accès aux produits d'épargne et aux intérêts courus
+type InterestRepo interface {
+	// CreateProduct crée le produit et ses tranches
+	CreateProduct(product *models.InterestProduct) error
+	FindProduct(id uint) (*models.InterestProduct, error)
+	FindProductByCode(code string) (*models.InterestProduct, error)
+	ListProducts(activeOnly bool) ([]models.InterestProduct, error)
+	// SaveProduct enregistre le produit sans toucher à ses tranches
+	SaveProduct(product *models.InterestProduct) error
+	// ListAccruingAccounts retourne les comptes épargne rémunérés non clôturés (tous si accountID vaut 0)
+	ListAccruingAccounts(accountID uint) ([]models.Account, error)
+	// LastAccrualDate retourne la dernière journée courue d'un compte, nil s'il n'y en a aucune
+	LastAccrualDate(accountID uint) (*time.Time, error)
+	AccrualExists(accountID uint, day time.Time) (bool, error)
+	CreateAccrual(accrual *models.InterestAccrual) error
+	// SumUnpaid retourne les intérêts courus non versés des journées antérieures à before
+	SumUnpaid(accountID uint, before time.Time) (int64, error)
+	// MarkPaid rattache au versement transactionID les intérêts non versés antérieurs à before
+	MarkPaid(accountID uint, before time.Time, transactionID uint) error
+	// ListAccruals retourne les limit dernières journées courues, la plus récente d'abord
+	ListAccruals(accountID uint, limit int) ([]models.InterestAccrual, error)
+}
+
+// StatementRepo donne accès aux relevés de compte, qui ne sont jamais modifiés après leur création
+type StatementRepo interface {
+	// Create enregistre le relevé et ses lignes
+	Create(statement *models.Statement) error
+	// Find retourne le relevé d'une période avec ses lignes
+	Find(accountID uint, period string) (*models.Statement, error)
+	// ListForAccount retourne les relevés d'un compte, sans leurs lignes, le plus récent d'abord
+	ListForAccount(accountID uint) ([]models.Statement, error)
+	// ListMissing retourne les comptes ouverts avant periodEnd et non clôturés sans relevé pour period
+	ListMissing(period string, periodEnd time.Time, limit int) ([]uint, error)
+}
+
+// PaymentBatchRepo donne accès aux lots de virements remis par fichier
+type PaymentBatchRepo interface {
+	// Create enregistre le lot et ses virements ; l'identifiant du message est unique par client
+	Create(batch *models.PaymentBatch) error
+	// Save met à jour le lot, sans ses virements
+	Save(batch *models.PaymentBatch) error
+	SaveItem(item *models.PaymentBatchItem) error
+	// FindForUser retourne un lot du client avec ses virements, dans l'ordre du fichier
+	FindForUser(id, userID uint) (*models.PaymentBatch, error)
+	FindByMessageID(userID uint, messageID string) (*models.PaymentBatch, error)
+	// ListForUser retourne les lots du client, sans leurs virements, le plus récent d'abord
+	ListForUser(userID uint) ([]models.PaymentBatch, error)
+}
+
+// BeneficiaryRepo donne accès aux bénéficiaires enregistrés par les clients
+type BeneficiaryRepo interface {
+	// Create enregistre le bénéficiaire ; un IBAN n'est enregistré qu'une fois par client
+	Create(beneficiary *models.Beneficiary) error
+	FindForUser(id, userID uint) (*models.Beneficiary, error)
+	FindByIBAN(userID uint, iban string) (*models.Beneficiary, error)
+	ListPage(userID uint, page utils.PageRequest) ([]models.Beneficiary, error)
+	Save(beneficiary *models.Beneficiary) error
+	Delete(beneficiary *models.Beneficiary) error
+}
+
+// TransferLimitRepo donne accès aux plafonds des sorties de fonds et à leur consommation
+type TransferLimitRepo interface {
+	// ListFor retourne les plafonds applicables aux comptes d'un type et d'une devise du client :
+	// celui par défaut de la banque et ceux propres au client
+	ListFor(userID uint, accountType, currency string) ([]models.TransferLimit, error)
+	// List retourne les plafonds par défaut (userID nil) ou ceux propres à un client
+	List(userID *uint) ([]models.TransferLimit, error)
+	Find(userID *uint, accountType, currency, source string) (*models.TransferLimit, error)
+	FindByID(id uint) (*models.TransferLimit, error)
+	Save(limit *models.TransferLimit) error
+	Delete(limit *models.TransferLimit) error
+	CreateUsage(usage *models.TransferLimitUsage) error
+	// SumUsage retourne le cumul (unités mineures) des sorties depuis since de tous les comptes
+	// d'un type et d'une devise du client, et le nombre d'opérations, une référence comptant
+	// pour une opération
+	SumUsage(userID uint, accountType, currency string, since time.Time) (int64, int, error)
+	// FirstUsage retourne la plus ancienne de ces sorties depuis since
+	FirstUsage(userID uint, accountType, currency string, since time.Time) (*models.TransferLimitUsage, error)
+}
+
+// FraudRepo donne accès aux décisions du contrôle anti-fraude des virements
+type FraudRepo interface {
+	// Create enregistre la décision et ses règles déclenchées
+	Create(decision *models.FraudDecision) error
+	// Save enregistre la décision sans toucher à ses règles déclenchées
+	Save(decision *models.FraudDecision) error
+	FindByID(id uint) (*models.FraudDecision, error)
+	// LockByID pose un verrou SELECT ... FOR UPDATE jusqu'à la fin de la transaction
+	LockByID(id uint) (*models.FraudDecision, error)
+	ListPage(filter FraudFilter, page utils.PageRequest) ([]models.FraudDecision, error)
+}
+
+// FraudFilter restreint une liste de décisions anti-fraude ; les champs vides sont ignorés
+type FraudFilter struct {
+	UserID uint
+	// Statuses retient les décisions dont le statut est l'un de ceux listés
+	Statuses []string
+}
+
+// Store regroupe les dépôts adossés à une même connexion gorm.
+// DB reste exposé pour les opérations transverses (grand livre, idempotence).
+type Store struct {
+	DB             *gorm.DB
+	Users          UserRepo
+	Accounts       AccountRepo
+	Transactions   TransactionRepo
+	Notifications  NotificationRepo
+	OutboundEmails OutboundEmailRepo
+	Sessions       SessionRepo
+	RecoveryCodes  RecoveryCodeRepo
+	UserTokens     UserTokenRepo
+	LoginAttempts  LoginAttemptRepo
+	FXQuotes       FXQuoteRepo
+	StandingOrders StandingOrderRepo
+	Holds          HoldRepo
+	Interest       InterestRepo
+	Statements     StatementRepo
+	PaymentBatches PaymentBatchRepo
+	Beneficiaries  BeneficiaryRepo
+	TransferLimits TransferLimitRepo
+	Fraud          FraudRepo
+}
+
+// NewStore crée les dépôts gorm sur la connexion db
+func NewStore(db *gorm.DB) *Store {
+	return &Store{
+		DB:             db,
+		Users:          &gormUserRepo{db: db},
+		Accounts:       &gormAccountRepo{db: db},
+		Transactions:   &gormTransactionRepo{db: db},
+		Notifications:  &gormNotificationRepo{db: db},
+		OutboundEmails: &gormOutboundEmailRepo{db: db},
+		Sessions:       &gormSessionRepo{db: db},
+		RecoveryCodes:  &gormRecoveryCodeRepo{db: db},
+		UserTokens:     &gormUserTokenRepo{db: db},
+		LoginAttempts:  &gormLoginAttemptRepo{db: db},
+		FXQuotes:       &gormFXQuoteRepo{db: db},
+		StandingOrders: &gormStandingOrderRepo{db: db},
+		Holds:          &gormHoldRepo{db: db},
+		Interest:       &gormInterestRepo{db: db},
+		Statements:     &gormStatementRepo{db: db},
+		PaymentBatches: &gormPaymentBatchRepo{db: db},
+		Beneficiaries:  &gormBeneficiaryRepo{db: db},
+		TransferLimits: &gormTransferLimitRepo{db: db},
+		Fraud:          &gormFraudRepo{db: db},
+	}
+}
+
+// Transaction exécute fn dans une transaction de base de données ; les dépôts du
+// Store passé à fn partagent cette transaction. Une erreur retournée annule tout.
+func (s *Store) Transaction(fn func(tx *Store) error) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(NewStore(tx))
+	})
+}
+
+// paginate trie sur (created_at, id) de table, applique le curseur de page et lit un
+// élément de plus que la limite pour que utils.NewPage sache s'il reste une page
+func paginate(db *gorm.DB, table string, page utils.PageRequest) *gorm.DB {
+	createdAt, id := table+".created_at", table+".id"
+	direction, comparison := "DESC", "<"
+	if page.Ascending() {
+		direction, comparison = "ASC", ">"
+	}
+
+	if page.Cursor != nil {
+		db = db.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", createdAt, comparison, createdAt, id, comparison),
+			page.Cursor.CreatedAt, page.Cursor.CreatedAt, page.Cursor.ID,
+		)
+	}
+	return db.Order(createdAt + " " + direction).Order(id + " " + direction).Limit(page.Limit + 1)
+}
+
+// notFound convertit gorm.ErrRecordNotFound en ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
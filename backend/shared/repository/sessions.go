@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"time"
+
+	"banking-app/shared/models"
+
+	"gorm.io/gorm"
+)
+
+type gormSessionRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormSessionRepo) Create(session *models.AuthSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *gormSessionRepo) FindByID(id string) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := r.db.Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+func (r *gormSessionRepo) FindByRefreshHash(hash string) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := r.db.Where("refresh_token_hash = ?", hash).First(&session).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+func (r *gormSessionRepo) FindByPreviousHash(hash string) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := r.db.Where("previous_token_hash = ?", hash).First(&session).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+func (r *gormSessionRepo) ListActiveByUser(userID uint, now time.Time) ([]models.AuthSession, error) {
+	var sessions []models.AuthSession
+	if err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("created_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *gormSessionRepo) Rotate(id, oldHash, newHash string, now time.Time) error {
+	// La condition sur l'ancien hash empêche deux renouvellements concurrents du même token
+	result := r.db.Model(&models.AuthSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  newHash,
+			"previous_token_hash": oldHash,
+			"last_used_at":        now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormSessionRepo) Revoke(id string, userID uint, now time.Time) error {
+	result := r.db.Model(&models.AuthSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormSessionRepo) RevokeAllForUser(userID uint, now time.Time) (int64, error) {
+	result := r.db.Model(&models.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormStandingOrderRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormStandingOrderRepo) Create(order *models.StandingOrder) error {
+	return r.db.Create(order).Error
+}
+
+func (r *gormStandingOrderRepo) FindForUser(id, userID uint) (*models.StandingOrder, error) {
+	var order models.StandingOrder
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&order).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &order, nil
+}
+
+func (r *gormStandingOrderRepo) LockForUser(id, userID uint) (*models.StandingOrder, error) {
+	var order models.StandingOrder
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", id, userID).First(&order).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &order, nil
+}
+
+func (r *gormStandingOrderRepo) ListPage(userID uint, page utils.PageRequest) ([]models.StandingOrder, error) {
+	var orders []models.StandingOrder
+	err := paginate(r.db, "standing_orders", page).
+		Where("user_id = ?", userID).
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *gormStandingOrderRepo) Save(order *models.StandingOrder) error {
+	return r.db.Save(order).Error
+}
+
+func (r *gormStandingOrderRepo) ListDue(now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.StandingOrder{}).
+		Where("status = ? AND next_run_at <= ?", models.StandingOrderActive, now).
+		Order("next_run_at ASC").Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *gormStandingOrderRepo) LockDue(id uint, now time.Time) (*models.StandingOrder, error) {
+	var order models.StandingOrder
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ? AND next_run_at <= ?", id, models.StandingOrderActive, now).
+		First(&order).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &order, nil
+}
+
+func (r *gormStandingOrderRepo) CreateRun(run *models.StandingOrderRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *gormStandingOrderRepo) ListRuns(orderID uint, limit int) ([]models.StandingOrderRun, error) {
+	var runs []models.StandingOrderRun
+	err := r.db.Where("standing_order_id = ?", orderID).
+		Order("scheduled_for DESC").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"time"
+
+	"banking-app/shared/models"
+
+	"gorm.io/gorm"
+)
+
+type gormStatementRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormStatementRepo) Create(statement *models.Statement) error {
+	return r.db.Create(statement).Error
+}
+
+func (r *gormStatementRepo) Find(accountID uint, period string) (*models.Statement, error) {
+	var statement models.Statement
+	err := r.db.
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("account_id = ? AND period = ?", accountID, period).
+		First(&statement).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &statement, nil
+}
+
+func (r *gormStatementRepo) ListForAccount(accountID uint) ([]models.Statement, error) {
+	var statements []models.Statement
+	err := r.db.Where("account_id = ?", accountID).Order("period DESC").Find(&statements).Error
+	return statements, err
+}
+
+func (r *gormStatementRepo) ListMissing(period string, periodEnd time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Account{}).
+		Where("created_at < ? AND status <> ?", periodEnd, "closed").
+		Where("NOT EXISTS (SELECT 1 FROM statements WHERE statements.account_id = accounts.id AND statements.period = ?)", period).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
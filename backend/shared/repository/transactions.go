@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// orderByID trie une relation préchargée par identifiant croissant
+func orderByID(db *gorm.DB) *gorm.DB {
+	return db.Order("id ASC")
+}
+
+type gormTransactionRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormTransactionRepo) List(filter TransactionFilter, page utils.PageRequest) ([]models.Transaction, error) {
+	db := paginate(r.db, "transactions", page)
+	if filter.UserID != 0 {
+		db = db.Joins("JOIN accounts ON transactions.account_id = accounts.id").
+			Where("accounts.user_id = ?", filter.UserID).
+			Preload("Account")
+	}
+	if filter.AccountID != 0 {
+		db = db.Where("transactions.account_id = ?", filter.AccountID)
+	}
+	if filter.Type != "" {
+		db = db.Where("transactions.type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		db = db.Where("transactions.status = ?", filter.Status)
+	}
+	if filter.Currency != "" {
+		db = db.Where("transactions.currency = ?", filter.Currency)
+	}
+	if filter.Description != "" {
+		db = db.Where("LOWER(transactions.description) LIKE ?", "%"+strings.ToLower(filter.Description)+"%")
+	}
+	if filter.MinAmount != nil {
+		db = db.Where("transactions.amount_minor >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		db = db.Where("transactions.amount_minor <= ?", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		db = db.Where("transactions.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("transactions.created_at < ?", *filter.To)
+	}
+
+	var transactions []models.Transaction
+	err := db.Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *gormTransactionRepo) FindByID(id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.Preload("Account").Preload("Reversals", orderByID).First(&transaction, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &transaction, nil
+}
+
+func (r *gormTransactionRepo) FindForUser(id, userID uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.
+		Joins("JOIN accounts ON transactions.account_id = accounts.id").
+		Where("transactions.id = ? AND accounts.user_id = ?", id, userID).
+		Preload("Account").
+		Preload("Reversals", orderByID).
+		First(&transaction).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &transaction, nil
+}
+
+func (r *gormTransactionRepo) ListByIDs(ids []uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	if len(ids) == 0 {
+		return transactions, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *gormTransactionRepo) LockByJournalEntry(entryID uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("journal_entry_id = ? AND reversal_of IS NULL", entryID).
+		Order("id ASC").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *gormTransactionRepo) ListOutgoingTransfers(userID uint, since time.Time, limit int) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.
+		Joins("JOIN accounts ON transactions.account_id = accounts.id").
+		Joins("JOIN accounts AS destinations ON transactions.to_account_id = destinations.id").
+		Where("accounts.user_id = ? AND transactions.type = ? AND destinations.user_id <> ?", userID, "transfer", userID).
+		Where("transactions.reversal_of IS NULL AND transactions.created_at >= ?", since).
+		Order("transactions.created_at DESC").Order("transactions.id DESC").
+		Limit(limit).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *gormTransactionRepo) Create(transaction *models.Transaction) error {
+	return r.db.Create(transaction).Error
+}
+
+func (r *gormTransactionRepo) Save(transaction *models.Transaction) error {
+	return r.db.Omit(clause.Associations).Save(transaction).Error
+}
+
+func (r *gormTransactionRepo) ListEntryPostings(entryID uint) ([]models.Posting, error) {
+	var postings []models.Posting
+	err := r.db.Where("journal_entry_id = ?", entryID).
+		Order("id ASC").
+		Find(&postings).Error
+	return postings, err
+}
+
+func (r *gormTransactionRepo) ListPostings(accountID uint) ([]models.Posting, error) {
+	var postings []models.Posting
+	if err := r.db.Where("account_id = ?", accountID).
+		Order("id DESC").
+		Find(&postings).Error; err != nil {
+		return nil, err
+	}
+	return postings, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"time"
+
+	"banking-app/shared/models"
+
+	"gorm.io/gorm"
+)
+
+type gormTransferLimitRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormTransferLimitRepo) ListFor(userID uint, accountType, currency string) ([]models.TransferLimit, error) {
+	var limits []models.TransferLimit
+	err := r.db.Where("(user_id IS NULL OR user_id = ?) AND account_type = ? AND currency = ?", userID, accountType, currency).
+		Find(&limits).Error
+	return limits, err
+}
+
+func (r *gormTransferLimitRepo) List(userID *uint) ([]models.TransferLimit, error) {
+	var limits []models.TransferLimit
+	db := r.db.Where("user_id IS NULL")
+	if userID != nil {
+		db = r.db.Where("user_id = ?", *userID)
+	}
+	err := db.Order("account_type ASC").Order("currency ASC").Order("source ASC").Find(&limits).Error
+	return limits, err
+}
+
+func (r *gormTransferLimitRepo) Find(userID *uint, accountType, currency, source string) (*models.TransferLimit, error) {
+	var limit models.TransferLimit
+	db := r.db.Where("user_id IS NULL")
+	if userID != nil {
+		db = r.db.Where("user_id = ?", *userID)
+	}
+	if err := db.Where("account_type = ? AND currency = ? AND source = ?", accountType, currency, source).
+		First(&limit).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &limit, nil
+}
+
+func (r *gormTransferLimitRepo) FindByID(id uint) (*models.TransferLimit, error) {
+	var limit models.TransferLimit
+	if err := r.db.First(&limit, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &limit, nil
+}
+
+func (r *gormTransferLimitRepo) Save(limit *models.TransferLimit) error {
+	return r.db.Save(limit).Error
+}
+
+func (r *gormTransferLimitRepo) Delete(limit *models.TransferLimit) error {
+	return r.db.Delete(limit).Error
+}
+
+func (r *gormTransferLimitRepo) CreateUsage(usage *models.TransferLimitUsage) error {
+	return r.db.Create(usage).Error
+}
+
+// usagesInScope restreint les sorties de fonds à celles des comptes d'un type et d'une devise
+// du client
+func (r *gormTransferLimitRepo) usagesInScope(userID uint, accountType, currency string, since time.Time) *gorm.DB {
+	return r.db.Model(&models.TransferLimitUsage{}).
+		Joins("JOIN accounts ON accounts.id = transfer_limit_usages.account_id").
+		Where("accounts.user_id = ? AND accounts.account_type = ? AND transfer_limit_usages.currency = ?", userID, accountType, currency).
+		Where("transfer_limit_usages.created_at >= ?", since)
+}
+
+func (r *gormTransferLimitRepo) SumUsage(userID uint, accountType, currency string, since time.Time) (int64, int, error) {
+	var total struct {
+		Amount int64
+		Count  int
+	}
+	err := r.usagesInScope(userID, accountType, currency, since).
+		Select("COALESCE(SUM(transfer_limit_usages.amount_minor), 0) AS amount, COUNT(DISTINCT transfer_limit_usages.reference) AS count").
+		Scan(&total).Error
+	return total.Amount, total.Count, err
+}
+
+func (r *gormTransferLimitRepo) FirstUsage(userID uint, accountType, currency string, since time.Time) (*models.TransferLimitUsage, error) {
+	var usage models.TransferLimitUsage
+	if err := r.usagesInScope(userID, accountType, currency, since).
+		Order("transfer_limit_usages.created_at ASC").First(&usage).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &usage, nil
+}
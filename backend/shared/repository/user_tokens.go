@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"time"
+
+	"banking-app/shared/models"
+
+	"gorm.io/gorm"
+)
+
+type gormUserTokenRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormUserTokenRepo) Create(token *models.UserToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *gormUserTokenRepo) Consume(id string, userID uint, purpose string, now time.Time) error {
+	// Mise à jour conditionnelle : un token ne peut être consommé qu'une fois, même en concurrence
+	result := r.db.Model(&models.UserToken{}).
+		Where("id = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", id, userID, purpose, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormUserTokenRepo) InvalidateForUser(userID uint, purpose string, now time.Time) error {
+	return r.db.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error
+}
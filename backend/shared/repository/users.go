@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"strings"
+
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormUserRepo struct {
+	db *gorm.DB
+}
+
+func (r *gormUserRepo) FindByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepo) LockByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepo) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepo) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *gormUserRepo) Save(user *models.User) error {
+	return r.db.Save(user).Error
+}
+
+func (r *gormUserRepo) Search(query string, page utils.PageRequest) ([]models.User, error) {
+	var users []models.User
+	db := paginate(r.db, "users", page)
+	if query != "" {
+		pattern := "%" + strings.ToLower(query) + "%"
+		db = db.Where("LOWER(email) LIKE ? OR LOWER(first_name) LIKE ? OR LOWER(last_name) LIKE ?", pattern, pattern, pattern)
+	}
+	err := db.Find(&users).Error
+	return users, err
+}
+
+func (r *gormUserRepo) AdvanceTOTPStep(id uint, step int64) error {
+	// Mise à jour conditionnelle : deux connexions ne peuvent pas consommer le même code
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
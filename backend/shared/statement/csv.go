@@ -0,0 +1,42 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+
+	"banking-app/shared/models"
+)
+
+// dateLayout est le format des dates des relevés
+const dateLayout = "2006-01-02"
+
+// csvHeader est l'en-tête des relevés CSV ; les montants sont décimaux, signés (débit négatif)
+var csvHeader = []string{"date", "reference", "libelle", "montant", "solde", "devise"}
+
+// WriteCSV écrit le relevé au format CSV : solde d'ouverture, une ligne par opération avec
+// le solde après opération, puis solde de clôture
+func WriteCSV(w io.Writer, statement *models.Statement) error {
+	writer := csv.NewWriter(w)
+	lastDay := statement.PeriodEnd.AddDate(0, 0, -1)
+
+	rows := [][]string{
+		csvHeader,
+		{statement.PeriodStart.Format(dateLayout), "", "Solde d'ouverture", "", statement.OpeningBalance.Decimal(), statement.Currency},
+	}
+	for _, line := range statement.Lines {
+		rows = append(rows, []string{
+			line.Date.Format(dateLayout),
+			line.Reference,
+			line.Description,
+			line.Amount.Decimal(),
+			line.Balance.Decimal(),
+			statement.Currency,
+		})
+	}
+	rows = append(rows, []string{lastDay.Format(dateLayout), "", "Solde de clôture", "", statement.ClosingBalance.Decimal(), statement.Currency})
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
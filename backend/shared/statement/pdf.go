@@ -0,0 +1,270 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"banking-app/shared/models"
+	"banking-app/shared/utils"
+)
+
+// Mise en page A4 (points PDF) ; le tableau des opérations est composé en Courier, à
+// chasse fixe (0,6 em), pour aligner les montants sans table de métriques
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	marginLeft   = 50.0
+	marginTop    = 792.0
+	marginBottom = 60.0
+	tableSize    = 8.0
+	tableLeading = 11.0
+)
+
+// Largeur des colonnes du tableau, en caractères
+const (
+	colDate        = 10
+	colDescription = 38
+	colAmount      = 14
+	colBalance     = 15
+)
+
+// Polices standard PDF, disponibles dans tout lecteur sans incorporation
+const (
+	fontRegular   = "F1" // Helvetica
+	fontBold      = "F2" // Helvetica-Bold
+	fontTable     = "F3" // Courier
+	fontTableBold = "F4" // Courier-Bold
+)
+
+var pdfFonts = []struct{ name, base string }{
+	{fontRegular, "Helvetica"},
+	{fontBold, "Helvetica-Bold"},
+	{fontTable, "Courier"},
+	{fontTableBold, "Courier-Bold"},
+}
+
+// WritePDF écrit le relevé au format PDF. Le document est produit sans dépendance externe
+// et ne contient aucune date de génération : un même relevé donne toujours le même fichier.
+func WritePDF(w io.Writer, statement *models.Statement) error {
+	doc := &pdfDocument{}
+	page := doc.addPage()
+
+	y := marginTop
+	page.text(fontBold, 16, marginLeft, y, "Relevé de compte")
+	y -= 24
+	lastDay := statement.PeriodEnd.AddDate(0, 0, -1)
+	for _, line := range []string{
+		"Titulaire : " + statement.HolderName,
+		"IBAN : " + utils.FormatIBAN(statement.AccountNumber),
+		fmt.Sprintf("Période : du %s au %s", statement.PeriodStart.Format("02/01/2006"), lastDay.Format("02/01/2006")),
+		"Devise : " + statement.Currency,
+	} {
+		page.text(fontRegular, 10, marginLeft, y, line)
+		y -= 14
+	}
+
+	y -= 6
+	for _, total := range []struct {
+		label  string
+		amount models.Money
+	}{
+		{"Solde d'ouverture", statement.OpeningBalance},
+		{"Total des débits", statement.TotalDebits},
+		{"Total des crédits", statement.TotalCredits},
+		{"Solde de clôture", statement.ClosingBalance},
+	} {
+		page.text(fontTable, 9, marginLeft, y, padRight(total.label, 20)+padLeft(formatAmount(total.amount), colBalance))
+		y -= 12
+	}
+
+	y -= 12
+	header := func() {
+		page.text(fontTableBold, tableSize, marginLeft, y, tableRow("Date", "Libellé", "Débit", "Crédit", "Solde"))
+		y -= 4
+		page.line(marginLeft, y, pageWidth-marginLeft, y)
+		y -= tableLeading
+	}
+	row := func(text string) {
+		if y < marginBottom {
+			page = doc.addPage()
+			y = marginTop
+			header()
+		}
+		page.text(fontTable, tableSize, marginLeft, y, text)
+		y -= tableLeading
+	}
+
+	header()
+	row(tableRow(statement.PeriodStart.Format("02/01/2006"), "Solde d'ouverture", "", "", formatAmount(statement.OpeningBalance)))
+	for _, line := range statement.Lines {
+		debit, credit := "", ""
+		if line.Amount.IsNegative() {
+			debit = formatAmount(line.Amount.Neg())
+		} else {
+			credit = formatAmount(line.Amount)
+		}
+		row(tableRow(line.Date.Format("02/01/2006"), line.Description, debit, credit, formatAmount(line.Balance)))
+	}
+	row(tableRow(lastDay.Format("02/01/2006"), "Solde de clôture", "", "", formatAmount(statement.ClosingBalance)))
+
+	for i, page := range doc.pages {
+		page.text(fontRegular, 8, marginLeft, marginBottom-30, "Empreinte SHA-256 : "+statement.Checksum)
+		page.text(fontRegular, 8, pageWidth-marginLeft-40, marginBottom-30, fmt.Sprintf("Page %d/%d", i+1, len(doc.pages)))
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// tableRow compose une ligne du tableau des opérations
+func tableRow(date, description, debit, credit, balance string) string {
+	return padRight(date, colDate) + " " +
+		padRight(truncate(description, colDescription), colDescription) + " " +
+		padLeft(debit, colAmount) + " " +
+		padLeft(credit, colAmount) + " " +
+		padLeft(balance, colBalance)
+}
+
+// formatAmount présente un montant à la française : 1 234,56
+func formatAmount(amount models.Money) string {
+	decimal := amount.Decimal()
+	sign := ""
+	if strings.HasPrefix(decimal, "-") {
+		sign, decimal = "-", decimal[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(decimal, ".")
+
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteByte(' ')
+		}
+		grouped.WriteRune(digit)
+	}
+	if hasFraction {
+		return sign + grouped.String() + "," + fraction
+	}
+	return sign + grouped.String()
+}
+
+func truncate(value string, width int) string {
+	runes := []rune(value)
+	if len(runes) <= width {
+		return value
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+func padRight(value string, width int) string {
+	if n := len([]rune(value)); n < width {
+		return value + strings.Repeat(" ", width-n)
+	}
+	return value
+}
+
+func padLeft(value string, width int) string {
+	if n := len([]rune(value)); n < width {
+		return strings.Repeat(" ", width-n) + value
+	}
+	return value
+}
+
+// pdfDocument assemble un document PDF 1.4 de pages de texte
+type pdfDocument struct {
+	pages []*pdfPage
+}
+
+// pdfPage accumule le flux de contenu d'une page
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+func (d *pdfDocument) addPage() *pdfPage {
+	page := &pdfPage{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// text écrit value à la position (x, y), y étant mesuré depuis le bas de la page
+func (p *pdfPage) text(font string, size, x, y float64, value string) {
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(value))
+}
+
+// line trace un filet horizontal ou vertical de 0,5 point
+func (p *pdfPage) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// WriteTo sérialise le document : catalogue, arbre des pages, polices, puis chaque page
+// suivie de son contenu, et enfin la table des références croisées
+func (d *pdfDocument) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Numérotation : 1 catalogue, 2 pages, puis les polices, puis (page, contenu) par page
+	firstPage := 3 + len(pdfFonts)
+	var kids, fonts []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	for i, font := range pdfFonts {
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", font.name, 3+i))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, font := range pdfFonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.base))
+	}
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, strings.Join(fonts, " "), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+// pdfString encode value en WinAnsi (polices standard) et échappe les délimiteurs ;
+// les caractères hors de ce jeu sont remplacés par « ? »
+func pdfString(value string) string {
+	var out strings.Builder
+	for _, r := range value {
+		var b byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			b = byte(r)
+		case r >= 0x20 && r < 0x7f:
+			b = byte(r)
+		case r >= 0xa0 && r <= 0xff:
+			b = byte(r)
+		case r == '€':
+			b = 0x80
+		case r == '’':
+			b = 0x92
+		case r == '–':
+			b = 0x96
+		case r == '—':
+			b = 0x97
+		default:
+			b = '?'
+		}
+		out.WriteByte(b)
+	}
+	return out.String()
+}
@@ -0,0 +1,180 @@
+// Package statement établit les relevés mensuels des comptes à partir du grand livre et
+// les restitue aux formats CSV et PDF. Un relevé est figé à sa création : il est ensuite
+// toujours restitué à l'identique, même si le compte évolue.
+package statement
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+)
+
+// batchSize borne le nombre de relevés établis par passage
+const batchSize = 100
+
+// Erreurs d'établissement d'un relevé
+var (
+	ErrInvalidPeriod   = errors.New("période invalide, format attendu AAAA-MM")
+	ErrPeriodNotClosed = errors.New("la période n'est pas encore close")
+	ErrBeforeOpening   = errors.New("le compte n'était pas ouvert sur cette période")
+)
+
+// ParsePeriod retourne les bornes [start, end) du mois civil period (AAAA-MM, UTC)
+func ParsePeriod(period string) (start, end time.Time, err error) {
+	start, err = time.Parse(models.StatementPeriodLayout, period)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidPeriod
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// PreviousPeriod retourne le dernier mois civil clos à now
+func PreviousPeriod(now time.Time) string {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format(models.StatementPeriodLayout)
+}
+
+// Get retourne le relevé de la période, établi au besoin s'il n'a pas encore été produit
+func Get(store *repository.Store, accountID uint, period string, now time.Time) (*models.Statement, error) {
+	statement, err := store.Statements.Find(accountID, period)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return statement, err
+	}
+	if _, err := Generate(store, accountID, period, now); err != nil {
+		return nil, err
+	}
+	return store.Statements.Find(accountID, period)
+}
+
+// Generate établit le relevé d'une période close. Le compte est verrouillé le temps de
+// l'établissement : un relevé déjà établi, par exemple par une autre instance, est
+// retourné tel quel.
+func Generate(store *repository.Store, accountID uint, period string, now time.Time) (*models.Statement, error) {
+	start, end, err := ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if end.After(now) {
+		return nil, ErrPeriodNotClosed
+	}
+
+	var statement *models.Statement
+	err = store.Transaction(func(tx *repository.Store) error {
+		account, err := tx.Accounts.LockByID(accountID)
+		if err != nil {
+			return err
+		}
+		if !account.CreatedAt.Before(end) {
+			return ErrBeforeOpening
+		}
+		if statement, err = tx.Statements.Find(account.ID, period); err == nil {
+			return nil
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+
+		holder, err := tx.Users.FindByID(account.UserID)
+		if err != nil {
+			return err
+		}
+		statement, err = build(tx, account, holder, period, start, end)
+		if err != nil {
+			return err
+		}
+		return tx.Statements.Create(statement)
+	})
+	return statement, err
+}
+
+// build calcule le relevé d'un compte à partir de ses lignes d'écriture
+func build(tx *repository.Store, account *models.Account, holder *models.User, period string, start, end time.Time) (*models.Statement, error) {
+	opening, err := ledger.BalanceAt(tx.DB, account.ID, account.Currency, start)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := ledger.Movements(tx.DB, account.ID, account.Currency, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := account.Currency
+	statement := &models.Statement{
+		AccountID:      account.ID,
+		Period:         period,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		AccountNumber:  account.AccountNumber,
+		HolderName:     strings.TrimSpace(holder.FirstName + " " + holder.LastName),
+		OpeningBalance: models.NewMoney(opening, currency),
+		Currency:       currency,
+	}
+
+	balance, debits, credits := opening, int64(0), int64(0)
+	for i, movement := range movements {
+		balance += movement.Amount
+		if movement.Amount < 0 {
+			debits -= movement.Amount
+		} else {
+			credits += movement.Amount
+		}
+		statement.Lines = append(statement.Lines, models.StatementLine{
+			Position:      i + 1,
+			PostingID:     movement.PostingID,
+			TransactionID: movement.TransactionID,
+			Date:          movement.Date.UTC(),
+			Reference:     movement.Reference,
+			Description:   movement.Description,
+			Amount:        models.NewMoney(movement.Amount, currency),
+			Balance:       models.NewMoney(balance, currency),
+		})
+	}
+	statement.TotalDebits = models.NewMoney(debits, currency)
+	statement.TotalCredits = models.NewMoney(credits, currency)
+	statement.ClosingBalance = models.NewMoney(balance, currency)
+
+	var csv bytes.Buffer
+	if err := WriteCSV(&csv, statement); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(csv.Bytes())
+	statement.Checksum = hex.EncodeToString(sum[:])
+	return statement, nil
+}
+
+// GenerateDue établit les relevés du dernier mois clos à now qui manquent encore et
+// retourne leur nombre ; une erreur sur un compte n'interrompt pas les autres
+func GenerateDue(store *repository.Store, now time.Time) (int, error) {
+	period := PreviousPeriod(now)
+	_, end, err := ParsePeriod(period)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := store.Statements.ListMissing(period, end, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	generated := 0
+	for _, id := range ids {
+		if _, err := Generate(store, id, period, now); err != nil {
+			log.Printf("Erreur lors de l'établissement du relevé %s du compte %d: %v", period, id, err)
+			continue
+		}
+		generated++
+	}
+	return generated, nil
+}
+
+// FileName retourne le nom de fichier d'un relevé pour l'extension ext
+func FileName(statement *models.Statement, ext string) string {
+	return fmt.Sprintf("releve-%s-%s.%s", statement.AccountNumber, statement.Period, ext)
+}
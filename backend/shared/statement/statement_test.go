@@ -0,0 +1,179 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/testutil"
+)
+
+// post enregistre au grand livre un mouvement daté sur le compte, contre le compte externe
+func post(t *testing.T, store *repository.Store, accountID uint, amount int64, at time.Time, description string) {
+	t.Helper()
+
+	money := models.NewMoney(amount, "EUR")
+	customer := ledger.CustomerPosting(accountID, money)
+	external := ledger.SystemPosting(ledger.ExternalAccount, money.Neg())
+	customer.CreatedAt, external.CreatedAt = at, at
+	entry := models.JournalEntry{
+		Reference:   fmt.Sprintf("TEST-%d-%d", accountID, at.UnixNano()),
+		Description: description,
+		Postings:    []models.Posting{customer, external},
+	}
+	if err := ledger.Post(store.DB, &entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// openedAccount crée un compte ouvert le 20 décembre 2023 avec un solde de 100,00
+func openedAccount(t *testing.T, store *repository.Store) models.Account {
+	t.Helper()
+
+	user, _ := testutil.CreateUser(t, store)
+	account := testutil.CreateAccount(t, store, user.ID, 10000)
+	opened := time.Date(2023, time.December, 20, 10, 0, 0, 0, time.UTC)
+	store.DB.Model(&models.Account{}).Where("id = ?", account.ID).Update("created_at", opened)
+	store.DB.Model(&models.Posting{}).Where("account_id = ?", account.ID).Update("created_at", opened)
+	return account
+}
+
+func TestGenerateStatement(t *testing.T) {
+	store := testutil.NewStore(t)
+	account := openedAccount(t, store)
+	post(t, store, account.ID, 25000, time.Date(2024, time.January, 5, 9, 0, 0, 0, time.UTC), "Salaire")
+	post(t, store, account.ID, -4250, time.Date(2024, time.January, 12, 18, 30, 0, 0, time.UTC), "Courses")
+	post(t, store, account.ID, -1000, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), "Février")
+
+	now := time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC)
+	statement, err := Get(store, account.ID, "2024-01", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statement.OpeningBalance.Amount != 10000 || statement.ClosingBalance.Amount != 30750 ||
+		statement.TotalCredits.Amount != 25000 || statement.TotalDebits.Amount != 4250 {
+		t.Errorf("relevé = ouverture %s, clôture %s, crédits %s, débits %s",
+			statement.OpeningBalance, statement.ClosingBalance, statement.TotalCredits, statement.TotalDebits)
+	}
+	if len(statement.Lines) != 2 || statement.Lines[0].Balance.Amount != 35000 || statement.Lines[1].Balance.Amount != 30750 ||
+		statement.Lines[1].Description != "Courses" {
+		t.Fatalf("lignes = %+v, attendu deux opérations avec solde courant", statement.Lines)
+	}
+
+	// Le relevé est figé : une opération antidatée ne le modifie plus
+	post(t, store, account.ID, 500, time.Date(2024, time.January, 20, 0, 0, 0, 0, time.UTC), "Antidatée")
+	again, err := Get(store, account.ID, "2024-01", now)
+	if err != nil || again.ID != statement.ID || len(again.Lines) != 2 || again.Checksum != statement.Checksum {
+		t.Errorf("relevé relu = %+v (%v), attendu inchangé", again, err)
+	}
+
+	var rendered bytes.Buffer
+	if err := WriteCSV(&rendered, again); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&rendered).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 || rows[1][2] != "Solde d'ouverture" || rows[1][4] != "100.00" || rows[2][0] != "2024-01-05" ||
+		rows[3][3] != "-42.50" || rows[3][4] != "307.50" || rows[4][2] != "Solde de clôture" || rows[4][0] != "2024-01-31" {
+		t.Errorf("CSV = %v", rows)
+	}
+
+	if _, err := Get(store, account.ID, "2024-02", now); !errors.Is(err, ErrPeriodNotClosed) {
+		t.Errorf("mois en cours: erreur %v, attendu ErrPeriodNotClosed", err)
+	}
+	if _, err := Get(store, account.ID, "2023-11", now); !errors.Is(err, ErrBeforeOpening) {
+		t.Errorf("mois antérieur à l'ouverture: erreur %v, attendu ErrBeforeOpening", err)
+	}
+	if _, err := Get(store, account.ID, "janvier", now); !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("période mal formée: erreur %v, attendu ErrInvalidPeriod", err)
+	}
+}
+
+func TestGenerateDue(t *testing.T) {
+	store := testutil.NewStore(t)
+	account := openedAccount(t, store)
+	now := time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC)
+
+	if n, err := GenerateDue(store, now); err != nil || n != 1 {
+		t.Fatalf("premier passage: %d relevé(s), erreur %v, attendu 1", n, err)
+	}
+	if n, err := GenerateDue(store, now.Add(time.Hour)); err != nil || n != 0 {
+		t.Errorf("second passage: %d relevé(s), erreur %v, attendu 0", n, err)
+	}
+	statements, err := store.Statements.ListForAccount(account.ID)
+	if err != nil || len(statements) != 1 || statements[0].Period != "2024-01" || statements[0].ClosingBalance.Amount != 10000 {
+		t.Errorf("relevés = %+v (%v), attendu celui de janvier 2024", statements, err)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	statement := &models.Statement{
+		Period:         "2024-01",
+		PeriodStart:    time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:      time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		AccountNumber:  "FR7630006000011234567890189",
+		HolderName:     "Jeanne (Test) Dupont",
+		OpeningBalance: models.NewMoney(123456, "EUR"),
+		ClosingBalance: models.NewMoney(123456, "EUR"),
+		Currency:       "EUR",
+	}
+	for i := 0; i < 120; i++ {
+		statement.Lines = append(statement.Lines, models.StatementLine{
+			Date:        statement.PeriodStart,
+			Description: "Opération",
+			Amount:      models.NewMoney(0, "EUR"),
+			Balance:     models.NewMoney(123456, "EUR"),
+		})
+	}
+
+	var first, second bytes.Buffer
+	if err := WritePDF(&first, statement); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePDF(&second, statement); err != nil {
+		t.Fatal(err)
+	}
+	pdf := first.String()
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("deux rendus du même relevé diffèrent")
+	}
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatal("en-tête ou fin de fichier PDF absents")
+	}
+	if !strings.Contains(pdf, "/Count 3") {
+		t.Error("122 lignes de tableau devraient occuper 3 pages")
+	}
+	// Texte encodé en WinAnsi, parenthèses échappées, montants à la française
+	for _, text := range []string{"(Relev\xe9 de compte)", `Jeanne \(Test\) Dupont`, "1 234,56", "FR76 3000 6000 0112 3456 7890 189"} {
+		if !strings.Contains(pdf, text) {
+			t.Errorf("texte %q absent du PDF", text)
+		}
+	}
+
+	// La table des références croisées pointe sur le début de chaque objet
+	start := strings.LastIndex(pdf, "startxref\n")
+	offset, err := strconv.Atoi(strings.Fields(pdf[start+len("startxref\n"):])[0])
+	if err != nil || !strings.HasPrefix(pdf[offset:], "xref") {
+		t.Fatalf("startxref invalide: %v", err)
+	}
+	entries := strings.Split(pdf[offset:], "\n")[3:]
+	for i, entry := range entries {
+		if !strings.HasSuffix(entry, " n ") {
+			break
+		}
+		position, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !strings.HasPrefix(pdf[position:], want) {
+			t.Errorf("objet %d: l'entrée xref pointe sur %q", i+1, pdf[position:position+10])
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package testutil fournit une base SQLite jetable et des données de test
+// pour les suites httptest des services.
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"banking-app/shared/database"
+	"banking-app/shared/ledger"
+	"banking-app/shared/models"
+	"banking-app/shared/repository"
+	"banking-app/shared/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// userSequence garantit des emails uniques au sein d'une même base
+var userSequence atomic.Int64
+
+// NewStore crée une base SQLite migrée dans un répertoire temporaire du test
+func NewStore(t *testing.T) *repository.Store {
+	t.Helper()
+
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("BCRYPT_COST", "4")
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.Open(database.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("ouverture de la base de test: %v", err)
+	}
+	db = db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migration de la base de test: %v", err)
+	}
+	return repository.NewStore(db)
+}
+
+// CreateUser crée un utilisateur actif à l'email vérifié et retourne un access token de session valide
+func CreateUser(t *testing.T, store *repository.Store) (models.User, string) {
+	t.Helper()
+
+	return CreateUserWithRole(t, store, models.RoleCustomer)
+}
+
+// CreateUserWithRole crée un utilisateur vérifié ayant le rôle indiqué et retourne un access token valide
+func CreateUserWithRole(t *testing.T, store *repository.Store, role string) (models.User, string) {
+	t.Helper()
+
+	user := models.User{
+		Email:     fmt.Sprintf("user%d@example.com", userSequence.Add(1)),
+		Password:  "not-a-real-hash",
+		FirstName: "Test",
+		LastName:  "User",
+		Role:      role,
+		IsActive:  true,
+	}
+	verifiedAt := time.Now()
+	user.EmailVerifiedAt = &verifiedAt
+	if err := store.Users.Create(&user); err != nil {
+		t.Fatalf("création de l'utilisateur: %v", err)
+	}
+
+	return user, NewSessionToken(t, store, user.ID)
+}
+
+// NewSessionToken ouvre une session pour userID et retourne son access token
+func NewSessionToken(t *testing.T, store *repository.Store, userID uint) string {
+	t.Helper()
+
+	sessionID, err := utils.GenerateRandomID()
+	if err != nil {
+		t.Fatalf("génération de la session: %v", err)
+	}
+	session := models.AuthSession{
+		ID:               sessionID,
+		UserID:           userID,
+		RefreshTokenHash: utils.HashToken(sessionID),
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+	if err := store.Sessions.Create(&session); err != nil {
+		t.Fatalf("création de la session: %v", err)
+	}
+
+	user, err := store.Users.FindByID(userID)
+	if err != nil {
+		t.Fatalf("lecture de l'utilisateur: %v", err)
+	}
+	token, err := utils.GenerateAccessToken(userID, sessionID, user.Role, models.PermissionsForRole(user.Role))
+	if err != nil {
+		t.Fatalf("génération du token: %v", err)
+	}
+	return token
+}
+
+// CreateAccount crée un compte courant actif avec le solde indiqué (en centimes)
+func CreateAccount(t *testing.T, store *repository.Store, userID uint, balance int64) models.Account {
+	t.Helper()
+
+	return CreateAccountInCurrency(t, store, userID, balance, models.DefaultCurrency)
+}
+
+// CreateAccountInCurrency crée un compte courant actif dans la devise indiquée (solde en unités mineures)
+func CreateAccountInCurrency(t *testing.T, store *repository.Store, userID uint, balance int64, currency string) models.Account {
+	t.Helper()
+
+	account := models.Account{
+		UserID:      userID,
+		AccountType: "checking",
+		Balance:     models.NewMoney(balance, currency),
+		Currency:    currency,
+		Status:      "active",
+	}
+	if err := store.Accounts.CreateWithNumber(&account, utils.GenerateAccountNumber); err != nil {
+		t.Fatalf("création du compte: %v", err)
+	}
+
+	// Le solde initial est porté au grand livre pour que la réconciliation reste exacte
+	if _, err := ledger.BackfillOpeningBalances(store.DB); err != nil {
+		t.Fatalf("solde d'ouverture: %v", err)
+	}
+	return account
+}
+
+// Request exécute une requête JSON authentifiée (token vide pour une route publique)
+func Request(router http.Handler, method, path, token string, payload interface{}) *httptest.ResponseRecorder {
+	var body io.Reader
+	if payload != nil {
+		data, _ := json.Marshal(payload)
+		body = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, body)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// DecodeData décode le champ data d'une réponse APIResponse dans out
+func DecodeData(t *testing.T, rec *httptest.ResponseRecorder, out interface{}) {
+	t.Helper()
+
+	var response struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("réponse invalide: %v (%s)", err, rec.Body.String())
+	}
+	if !response.Success {
+		t.Fatalf("réponse en échec: %s", rec.Body.String())
+	}
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		t.Fatalf("données invalides: %v (%s)", err, response.Data)
+	}
+}
@@ -0,0 +1,106 @@
+// Package totp implémente les mots de passe à usage unique basés sur le temps (RFC 6238)
+// avec HMAC-SHA1, pas de 30 secondes et codes à 6 chiffres, compatibles avec les
+// applications d'authentification courantes.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period est la durée d'un pas de temps en secondes
+	Period = 30
+	// Digits est le nombre de chiffres d'un code
+	Digits = 6
+	// Skew est le nombre de pas acceptés avant et après le pas courant (dérive d'horloge)
+	Skew = 1
+
+	secretSize = 20 // 160 bits, taille recommandée par la RFC 4226
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret génère un secret aléatoire encodé en base32
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI construit l'URI otpauth:// à encoder en QR code pour l'application d'authentification
+func URI(issuer, account, secret string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(Digits))
+	values.Set("period", fmt.Sprint(Period))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+// Step retourne le pas de temps correspondant à t
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// Code calcule le code du pas step pour le secret base32
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", fmt.Errorf("secret TOTP invalide: %v", err)
+	}
+	return hotp(key, uint64(step), Digits), nil
+}
+
+// Validate vérifie code à l'instant t en tolérant Skew pas de dérive.
+// Il retourne le pas correspondant afin que l'appelant refuse un code déjà utilisé.
+func Validate(secret, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := Step(t)
+	for offset := int64(-Skew); offset <= Skew; offset++ {
+		expected, err := Code(secret, current+offset)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + offset, true
+		}
+	}
+	return 0, false
+}
+
+// hotp calcule un code HOTP (RFC 4226) pour le compteur donné
+func hotp(key []byte, counter uint64, digits int) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
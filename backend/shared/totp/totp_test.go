@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Vecteurs SHA-1 de l'annexe B de la RFC 6238 (secret ASCII "12345678901234567890", 8 chiffres)
+func TestHOTPMatchesRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+
+	for unix, want := range vectors {
+		if got := hotp(key, uint64(unix/Period), 8); got != want {
+			t.Errorf("T=%d: code = %s, attendu %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateAcceptsClockSkew(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	code, err := Code(secret, Step(now)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step, ok := Validate(secret, code, now)
+	if !ok || step != Step(now)-1 {
+		t.Errorf("Validate() = %d, %v ; attendu le pas précédent", step, ok)
+	}
+
+	old, _ := Code(secret, Step(now)-2)
+	if _, ok := Validate(secret, old, now); ok {
+		t.Error("un code vieux de deux pas ne doit pas être accepté")
+	}
+	if _, ok := Validate(secret, "abc", now); ok {
+		t.Error("un code mal formé ne doit pas être accepté")
+	}
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("longueur du secret = %d, attendu 32 caractères base32", len(secret))
+	}
+
+	uri := URI("Banking App", "jean@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Banking%20App:jean@example.com?") ||
+		!strings.Contains(uri, "secret="+secret) || !strings.Contains(uri, "issuer=Banking+App") {
+		t.Errorf("URI inattendue: %s", uri)
+	}
+}
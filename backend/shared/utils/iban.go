@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Configuration par défaut de l'émission des IBAN : établissement et guichet fictifs
+const (
+	defaultIBANCountry  = "FR"
+	defaultIBANBankCode = "9999900001"
+)
+
+// ErrInvalidIBAN est retournée pour un IBAN mal formé ou dont la clé de contrôle est fausse
+var ErrInvalidIBAN = errors.New("IBAN invalide")
+
+// ibanSpec décrit le format national d'un IBAN
+type ibanSpec struct {
+	length int
+	// nationalKey calcule la clé nationale terminant le BBAN (clé RIB), nil si le pays n'en a pas
+	nationalKey func(bban string) (string, error)
+}
+
+// ibanSpecs donne la longueur des IBAN des pays pris en charge (registre ISO 13616)
+var ibanSpecs = map[string]ibanSpec{
+	"AT": {length: 20},
+	"BE": {length: 16},
+	"CH": {length: 21},
+	"DE": {length: 22},
+	"ES": {length: 24},
+	"FR": {length: 27, nationalKey: ribKey},
+	"GB": {length: 22},
+	"IE": {length: 22},
+	"IT": {length: 27},
+	"LU": {length: 20},
+	"MC": {length: 27, nationalKey: ribKey},
+	"NL": {length: 18},
+	"PT": {length: 25},
+}
+
+// ribKeyLength est la longueur de la clé RIB française
+const ribKeyLength = 2
+
+// IBANCountry retourne le pays des IBAN émis (IBAN_COUNTRY)
+func IBANCountry() string {
+	if country := os.Getenv("IBAN_COUNTRY"); country != "" {
+		return strings.ToUpper(country)
+	}
+	return defaultIBANCountry
+}
+
+// IBANBankCode retourne l'identifiant de l'établissement en tête du BBAN (IBAN_BANK_CODE) ;
+// pour la France, code banque et code guichet (10 chiffres)
+func IBANBankCode() string {
+	if code := os.Getenv("IBAN_BANK_CODE"); code != "" {
+		return strings.ToUpper(code)
+	}
+	return defaultIBANBankCode
+}
+
+// GenerateAccountNumber génère un numéro de compte au format IBAN pour le pays et
+// l'établissement configurés. L'unicité est garantie par l'index unique de account_number.
+func GenerateAccountNumber() (string, error) {
+	return GenerateIBAN(IBANCountry(), IBANBankCode())
+}
+
+// GenerateIBAN génère un IBAN aléatoire : bankCode suivi d'un numéro de compte aléatoire,
+// de la clé nationale éventuelle et des chiffres de contrôle ISO 13616 (mod 97)
+func GenerateIBAN(country, bankCode string) (string, error) {
+	spec, ok := ibanSpecs[country]
+	if !ok {
+		return "", fmt.Errorf("pays IBAN non pris en charge: %s", country)
+	}
+	if !isAlphanumeric(bankCode) {
+		return "", fmt.Errorf("code établissement invalide: %s", bankCode)
+	}
+
+	bbanLength := spec.length - 4
+	randomLength := bbanLength - len(bankCode)
+	if spec.nationalKey != nil {
+		randomLength -= ribKeyLength
+	}
+	if randomLength < 6 {
+		return "", fmt.Errorf("code établissement trop long pour un IBAN %s: %s", country, bankCode)
+	}
+
+	digits, err := randomDigits(randomLength)
+	if err != nil {
+		return "", err
+	}
+	bban := bankCode + digits
+	if spec.nationalKey != nil {
+		key, err := spec.nationalKey(bban)
+		if err != nil {
+			return "", err
+		}
+		bban += key
+	}
+
+	return country + ibanCheckDigits(country, bban) + bban, nil
+}
+
+// NormalizeIBAN retire les espaces et met l'IBAN en majuscules (format électronique)
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// FormatIBAN présente l'IBAN par groupes de quatre caractères (format papier)
+func FormatIBAN(iban string) string {
+	iban = NormalizeIBAN(iban)
+	var groups []string
+	for len(iban) > 4 {
+		groups = append(groups, iban[:4])
+		iban = iban[4:]
+	}
+	return strings.Join(append(groups, iban), " ")
+}
+
+// ValidateIBAN vérifie le format, la longueur nationale et la clé de contrôle mod 97 d'un IBAN
+func ValidateIBAN(iban string) error {
+	iban = NormalizeIBAN(iban)
+	if len(iban) < 15 || len(iban) > 34 || !isAlphanumeric(iban) {
+		return fmt.Errorf("%w: format incorrect", ErrInvalidIBAN)
+	}
+
+	country, check := iban[:2], iban[2:4]
+	if !isLetters(country) || !isDigits(check) {
+		return fmt.Errorf("%w: format incorrect", ErrInvalidIBAN)
+	}
+	if spec, ok := ibanSpecs[country]; ok && len(iban) != spec.length {
+		return fmt.Errorf("%w: un IBAN %s compte %d caractères", ErrInvalidIBAN, country, spec.length)
+	}
+	if mod97(iban[4:]+iban[:4]) != 1 {
+		return fmt.Errorf("%w: clé de contrôle incorrecte", ErrInvalidIBAN)
+	}
+	return nil
+}
+
+// ibanCheckDigits calcule les deux chiffres de contrôle d'un IBAN (ISO 13616)
+func ibanCheckDigits(country, bban string) string {
+	return fmt.Sprintf("%02d", 98-mod97(bban+country+"00"))
+}
+
+// mod97 calcule le reste modulo 97 d'une chaîne alphanumérique, les lettres valant 10 à 35
+func mod97(value string) int {
+	remainder := 0
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A'+10)) % 97
+		}
+	}
+	return remainder
+}
+
+// ribKey calcule la clé RIB d'un BBAN français sans clé : code banque (5), code guichet (5)
+// et numéro de compte (11), tous numériques ici
+func ribKey(bban string) (string, error) {
+	if len(bban) != 21 || !isDigits(bban) {
+		return "", fmt.Errorf("BBAN français invalide: %s", bban)
+	}
+	bank, _ := strconv.ParseInt(bban[:5], 10, 64)
+	branch, _ := strconv.ParseInt(bban[5:10], 10, 64)
+	account, _ := strconv.ParseInt(bban[10:], 10, 64)
+	return fmt.Sprintf("%02d", 97-(89*bank+15*branch+3*account)%97), nil
+}
+
+// randomDigits génère n chiffres aléatoires
+func randomDigits(n int) (string, error) {
+	var digits strings.Builder
+	for i := 0; i < n; i++ {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits.WriteByte(byte('0' + digit.Int64()))
+	}
+	return digits.String(), nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func isLetters(value string) bool {
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func isAlphanumeric(value string) bool {
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return value != ""
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	valid := []string{
+		"FR14 2004 1010 0505 0001 3M02 606",
+		"GB82WEST12345698765432",
+		"de89 3704 0044 0532 0130 00",
+	}
+	for _, iban := range valid {
+		if err := ValidateIBAN(iban); err != nil {
+			t.Errorf("ValidateIBAN(%q) = %v", iban, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"FR1420041010050500013M02607", // clé de contrôle
+		"FR142004101005050001",        // longueur nationale
+		"GB82WEST1234569876543$",
+		"1282WEST12345698765432",
+		"ACC0123456789ab",
+	}
+	for _, iban := range invalid {
+		if err := ValidateIBAN(iban); !errors.Is(err, ErrInvalidIBAN) {
+			t.Errorf("ValidateIBAN(%q) = %v, attendu ErrInvalidIBAN", iban, err)
+		}
+	}
+}
+
+func TestGenerateIBAN(t *testing.T) {
+	for _, country := range []string{"FR", "DE", "BE"} {
+		iban, err := GenerateIBAN(country, "12345")
+		if country == "FR" {
+			iban, err = GenerateIBAN(country, "3000400001")
+		}
+		if err != nil {
+			t.Fatalf("GenerateIBAN(%s): %v", country, err)
+		}
+		if err := ValidateIBAN(iban); err != nil {
+			t.Errorf("IBAN généré %s invalide: %v", iban, err)
+		}
+		if len(iban) != ibanSpecs[country].length {
+			t.Errorf("longueur de %s = %d", iban, len(iban))
+		}
+	}
+
+	// La clé RIB d'un IBAN français généré est cohérente avec le BBAN
+	iban, err := GenerateIBAN("FR", "3000400001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := ribKey(iban[4:25]); key != iban[25:] {
+		t.Errorf("clé RIB de %s = %s", iban, key)
+	}
+
+	if _, err := GenerateIBAN("XX", "12345"); err == nil {
+		t.Error("un pays inconnu devrait être refusé")
+	}
+	if _, err := GenerateIBAN("BE", "1234567"); err == nil {
+		t.Error("un code établissement trop long devrait être refusé")
+	}
+}
+
+func TestFormatIBAN(t *testing.T) {
+	if got := FormatIBAN("fr1420041010050500013m02606"); got != "FR14 2004 1010 0505 0001 3M02 606" {
+		t.Errorf("FormatIBAN = %q", got)
+	}
+}
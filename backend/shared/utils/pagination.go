@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Taille des pages de résultats
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Ordres de tri des listes paginées, sur (created_at, id)
+const (
+	SortDesc = "desc"
+	SortAsc  = "asc"
+)
+
+// ErrInvalidPageRequest est retournée pour des paramètres de pagination invalides
+var ErrInvalidPageRequest = errors.New("paramètres de pagination invalides")
+
+// Cursor repère le dernier élément d'une page. Le couple (created_at, id) est unique,
+// ce qui rend la pagination stable même si des lignes sont insérées entre deux pages.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// PageRequest décrit la page demandée par le client
+type PageRequest struct {
+	Limit  int
+	Order  string
+	Cursor *Cursor
+}
+
+// Ascending indique si la page est triée du plus ancien au plus récent
+func (p PageRequest) Ascending() bool {
+	return p.Order == SortAsc
+}
+
+// PageInfo sont les métadonnées de pagination renvoyées avec chaque liste
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	Order      string `json:"order"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ParsePageRequest lit les paramètres limit, order (asc, desc) et cursor de la requête
+func ParsePageRequest(query url.Values) (PageRequest, error) {
+	page := PageRequest{Limit: DefaultPageSize, Order: SortDesc}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			return PageRequest{}, fmt.Errorf("%w: limit doit être compris entre 1 et %d", ErrInvalidPageRequest, MaxPageSize)
+		}
+		page.Limit = limit
+	}
+
+	switch order := query.Get("order"); order {
+	case "":
+	case SortAsc, SortDesc:
+		page.Order = order
+	default:
+		return PageRequest{}, fmt.Errorf("%w: order doit valoir asc ou desc", ErrInvalidPageRequest)
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := DecodeCursor(value)
+		if err != nil {
+			return PageRequest{}, err
+		}
+		page.Cursor = &cursor
+	}
+	return page, nil
+}
+
+// EncodeCursor sérialise un curseur en une chaîne opaque pour le client
+func EncodeCursor(cursor Cursor) string {
+	raw := fmt.Sprintf("%d:%d", cursor.CreatedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor relit un curseur produit par EncodeCursor
+func DecodeCursor(value string) (Cursor, error) {
+	invalid := fmt.Errorf("%w: curseur invalide", ErrInvalidPageRequest)
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return Cursor{}, invalid
+	}
+	createdAt, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+	parsedID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+	return Cursor{CreatedAt: time.Unix(0, createdAt), ID: uint(parsedID)}, nil
+}
+
+// NewPage tronque items, lus avec une limite de page.Limit+1, à la taille de la page
+// et calcule les métadonnées ; key retourne le curseur d'un élément
+func NewPage[T any](items []T, page PageRequest, key func(T) Cursor) ([]T, PageInfo) {
+	info := PageInfo{Limit: page.Limit, Order: page.Order}
+	if items == nil {
+		items = []T{}
+	}
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+		info.HasMore = true
+		info.NextCursor = EncodeCursor(key(items[len(items)-1]))
+	}
+	return items, info
+}
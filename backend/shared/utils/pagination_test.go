@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{CreatedAt: time.Date(2024, 3, 1, 10, 30, 0, 123000000, time.UTC), ID: 42}
+
+	decoded, err := DecodeCursor(EncodeCursor(cursor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("curseur = %+v, attendu %+v", decoded, cursor)
+	}
+
+	for _, value := range []string{"", "invalide", EncodeCursor(cursor)[:4]} {
+		if _, err := DecodeCursor(value); err == nil {
+			t.Errorf("DecodeCursor(%q) devrait échouer", value)
+		}
+	}
+}
+
+func TestParsePageRequest(t *testing.T) {
+	page, err := ParsePageRequest(url.Values{})
+	if err != nil || page.Limit != DefaultPageSize || page.Order != SortDesc || page.Cursor != nil {
+		t.Errorf("page par défaut = %+v, %v", page, err)
+	}
+
+	page, err = ParsePageRequest(url.Values{"limit": {"5"}, "order": {"asc"}})
+	if err != nil || page.Limit != 5 || !page.Ascending() {
+		t.Errorf("page = %+v, %v", page, err)
+	}
+
+	for _, query := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"101"}},
+		{"order": {"random"}},
+		{"cursor": {"!!"}},
+	} {
+		if _, err := ParsePageRequest(query); err == nil {
+			t.Errorf("ParsePageRequest(%v) devrait échouer", query)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	key := func(id uint) Cursor { return Cursor{ID: id} }
+	page := PageRequest{Limit: 2, Order: SortDesc}
+
+	items, info := NewPage([]uint{5, 4, 3}, page, key)
+	if len(items) != 2 || !info.HasMore || info.NextCursor != EncodeCursor(Cursor{ID: 4}) {
+		t.Errorf("page = %v, %+v", items, info)
+	}
+
+	items, info = NewPage([]uint(nil), page, key)
+	if items == nil || len(items) != 0 || info.HasMore || info.NextCursor != "" {
+		t.Errorf("page vide = %v, %+v", items, info)
+	}
+}
@@ -2,17 +2,34 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// defaultBcryptCost est le coût bcrypt par défaut ; un coût plus élevé rend chaque
+// tentative de connexion plus coûteuse en CPU pour le serveur
+const defaultBcryptCost = 12
+
+// bcryptCost lit le coût bcrypt depuis BCRYPT_COST
+func bcryptCost() int {
+	cost, err := strconv.Atoi(os.Getenv("BCRYPT_COST"))
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return defaultBcryptCost
+	}
+	return cost
+}
+
 // HashPassword hache un mot de passe
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost())
 	return string(bytes), err
 }
 
@@ -22,47 +39,160 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateJWT génère un token JWT pour un utilisateur
-func GenerateJWT(userID uint) (string, error) {
+// dummyHashes conserve, par coût bcrypt, le hash factice de SimulatePasswordCheck
+var dummyHashes sync.Map
+
+// dummyHash retourne un hash factice au coût cost, calculé une fois par coût
+func dummyHash(cost int) []byte {
+	if hash, ok := dummyHashes.Load(cost); ok {
+		return hash.([]byte)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("mot de passe factice"), cost)
+	if err != nil {
+		return nil
+	}
+	dummyHashes.Store(cost, hash)
+	return hash
+}
+
+// SimulatePasswordCheck compare password à un hash factice au coût courant, pour qu'une
+// connexion sur un email inconnu dure autant qu'une connexion avec un mauvais mot de passe
+func SimulatePasswordCheck(password string) {
+	bcrypt.CompareHashAndPassword(dummyHash(bcryptCost()), []byte(password))
+}
+
+// Durées de validité par défaut des tokens (JWT_EXPIRY et REFRESH_TOKEN_EXPIRY)
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// AccessTokenTTL retourne la durée de validité d'un access token (JWT_EXPIRY)
+func AccessTokenTTL() time.Duration {
+	return durationFromEnv("JWT_EXPIRY", defaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL retourne la durée de vie maximale d'une session (REFRESH_TOKEN_EXPIRY)
+func RefreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_EXPIRY", defaultRefreshTokenTTL)
+}
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// GenerateAccessToken génère un JWT de courte durée rattaché à la session sessionID,
+// portant le rôle de l'utilisateur et les permissions qu'il accorde
+func GenerateAccessToken(userID uint, sessionID, role string, permissions []string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 jours
-		"iat":     time.Now().Unix(),
+		"user_id":     userID,
+		"sid":         sessionID,
+		"role":        role,
+		"permissions": permissions,
+		"exp":         time.Now().Add(AccessTokenTTL()).Unix(),
+		"iat":         time.Now().Unix(),
 	})
 
 	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
 	return tokenString, err
 }
 
-// GenerateTransactionReference génère une référence unique pour une transaction
-func GenerateTransactionReference() (string, error) {
+// ChallengeTokenTTL est la durée laissée pour saisir le code de double authentification
+const ChallengeTokenTTL = 5 * time.Minute
+
+// challengePurpose distingue les tokens de challenge des access tokens
+const challengePurpose = "2fa_challenge"
+
+// ErrInvalidToken est retournée pour un token à usage restreint invalide, expiré ou d'un autre usage
+var ErrInvalidToken = errors.New("token invalide ou expiré")
+
+// GeneratePurposeToken génère un JWT signé réservé à un usage (purpose) et identifié par tokenID.
+// Sans identifiant de session, il est refusé par AuthMiddleware.
+func GeneratePurposeToken(userID uint, purpose, tokenID string, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"purpose": purpose,
+		"exp":     time.Now().Add(ttl).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+	if tokenID != "" {
+		claims["jti"] = tokenID
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+// ParsePurposeToken vérifie la signature, l'expiration et l'usage d'un token et
+// retourne l'utilisateur et l'identifiant du token
+func ParsePurposeToken(tokenString, purpose string) (uint, string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, "", ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != purpose {
+		return 0, "", ErrInvalidToken
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, "", ErrInvalidToken
+	}
+	tokenID, _ := claims["jti"].(string)
+	return uint(userID), tokenID, nil
+}
+
+// GenerateChallengeToken génère le token intermédiaire d'une connexion en deux étapes
+func GenerateChallengeToken(userID uint) (string, error) {
+	return GeneratePurposeToken(userID, challengePurpose, "", ChallengeTokenTTL)
+}
+
+// ParseChallengeToken vérifie un token de challenge et retourne l'utilisateur concerné
+func ParseChallengeToken(tokenString string) (uint, error) {
+	userID, _, err := ParsePurposeToken(tokenString, challengePurpose)
+	return userID, err
+}
+
+// GenerateRandomID génère un identifiant aléatoire (sessions, tokens à usage unique)
+func GenerateRandomID() (string, error) {
 	bytes := make([]byte, 16)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
-	return "TXN-" + hex.EncodeToString(bytes), nil
+	return hex.EncodeToString(bytes), nil
 }
 
-// GenerateAccountNumber génère un numéro de compte unique
-func GenerateAccountNumber(accountType string) (string, error) {
-	bytes := make([]byte, 8)
+// GenerateRefreshToken génère un refresh token opaque
+func GenerateRefreshToken() (string, error) {
+	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
+	return hex.EncodeToString(bytes), nil
+}
 
-	var prefix string
-	switch accountType {
-	case "checking":
-		prefix = "ACC"
-	case "savings":
-		prefix = "SAV"
-	case "credit":
-		prefix = "CRD"
-	default:
-		prefix = "ACC"
-	}
+// HashToken retourne l'empreinte SHA-256 d'un token, seule valeur conservée en base
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
 
-	return prefix + hex.EncodeToString(bytes)[:12], nil
+// GenerateTransactionReference génère une référence unique pour une transaction
+func GenerateTransactionReference() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "TXN-" + hex.EncodeToString(bytes), nil
 }
 
 // ValidateAccountType valide le type de compte
@@ -78,7 +208,7 @@ func ValidateAccountType(accountType string) bool {
 
 // ValidateTransactionType valide le type de transaction
 func ValidateTransactionType(transactionType string) bool {
-	validTypes := []string{"debit", "credit", "transfer"}
+	validTypes := []string{"debit", "credit", "transfer", "reversal"}
 	for _, validType := range validTypes {
 		if transactionType == validType {
 			return true
@@ -86,3 +216,14 @@ func ValidateTransactionType(transactionType string) bool {
 	}
 	return false
 }
+
+// ValidateTransactionStatus valide le statut de transaction
+func ValidateTransactionStatus(status string) bool {
+	validStatuses := []string{"pending", "completed", "failed", "cancelled"}
+	for _, validStatus := range validStatuses {
+		if status == validStatus {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestDummyHashUsesConfiguredCost vérifie que le hash factice comparé pour un email inconnu
+// a le coût des vrais mots de passe, condition d'un temps de réponse identique
+func TestDummyHashUsesConfiguredCost(t *testing.T) {
+	for _, cost := range []string{"4", "5"} {
+		t.Setenv("BCRYPT_COST", cost)
+		real, err := HashPassword("secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := bcrypt.Cost([]byte(real))
+		if got, err := bcrypt.Cost(dummyHash(bcryptCost())); err != nil || got != want {
+			t.Errorf("BCRYPT_COST=%s: coût du hash factice = %d (%v), attendu %d", cost, got, err, want)
+		}
+	}
+	SimulatePasswordCheck("secret")
+}